@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/danielmiessler/fabric/internal/tools/filescan"
 )
 
 // FileItem represents a file in the project
@@ -31,11 +33,18 @@ type ProjectData struct {
 	} `json:"report"`
 }
 
-// ScanDirectory scans a directory and returns a JSON representation of its structure
+// ScanDirectory scans a directory and returns a JSON representation of its structure.
+// Files ignored by .gitignore or by a pattern of ignoreList, in the same syntax, and
+// binary files are left out.
 func ScanDirectory(rootDir string, maxDepth int, instructions string, ignoreList []string) ([]byte, error) {
+	result, err := filescan.Scan([]string{rootDir}, nil, filescan.Options{MaxDepth: maxDepth, Ignore: ignoreList})
+	if err != nil {
+		return nil, err
+	}
+
 	// Count totals for report
-	dirCount := 1
-	fileCount := 0
+	dirCount := result.Directories
+	fileCount := len(result.Files)
 
 	// Create root directory item
 	rootItem := FileItem{
@@ -44,68 +53,13 @@ func ScanDirectory(rootDir string, maxDepth int, instructions string, ignoreList
 		Contents: []FileItem{},
 	}
 
-	// Walk through the directory
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+	// Add each file to its parent directory
+	for _, file := range result.Files {
+		relPath, err := filepath.Rel(rootDir, file.Path)
 		if err != nil {
-			return err
-		}
-
-		// Skip .git directory
-		if strings.Contains(path, ".git") {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Check if path matches any ignore pattern
-		relPath, err := filepath.Rel(rootDir, path)
-		if err != nil {
-			return err
-		}
-
-		for _, pattern := range ignoreList {
-			if strings.Contains(relPath, pattern) {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+			return nil, err
 		}
-
-		if relPath == "." {
-			return nil
-		}
-
-		depth := len(strings.Split(relPath, string(filepath.Separator)))
-		if depth > maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Create directory structure
-		if info.IsDir() {
-			dirCount++
-		} else {
-			fileCount++
-
-			// Read file content
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return fmt.Errorf("error reading file %s: %v", path, err)
-			}
-
-			// Add file to appropriate parent directory
-			addFileToDirectory(&rootItem, relPath, string(content), rootDir)
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
+		addFileToDirectory(&rootItem, relPath, file.Content, rootDir)
 	}
 
 	// Create final data structure
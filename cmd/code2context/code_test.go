@@ -98,3 +98,27 @@ func TestScanDirectory(t *testing.T) {
 	instr := result[2].(map[string]any)
 	assert.Equal(t, "Test instructions", instr["details"])
 }
+
+func TestScanDirectorySkipsIgnoredAndBinaryFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "debug.log"), []byte("log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "app.bin"), []byte{0, 1, 2}, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "vendor"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "vendor", "lib.go"), []byte("package lib\n"), 0644))
+
+	jsonData, err := ScanDirectory(tmpDir, 3, "Test instructions", []string{"vendor"})
+	require.NoError(t, err)
+
+	var result []any
+	require.NoError(t, json.Unmarshal(jsonData, &result))
+
+	// Only .gitignore and main.go are left
+	report := result[1].(map[string]any)
+	assert.Equal(t, float64(2), report["files"])
+	assert.Equal(t, float64(1), report["directories"])
+	assert.NotContains(t, string(jsonData), "debug.log")
+	assert.NotContains(t, string(jsonData), "app.bin")
+}
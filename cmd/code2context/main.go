@@ -11,7 +11,7 @@ import (
 func main() {
 	// Command line flags
 	maxDepth := flag.Int("depth", 3, "Maximum directory depth to scan")
-	ignorePatterns := flag.String("ignore", ".git,node_modules,vendor", "Comma-separated patterns to ignore")
+	ignorePatterns := flag.String("ignore", ".git,node_modules,vendor", "Comma-separated patterns to ignore, in .gitignore syntax")
 	outputFile := flag.String("out", "", "Output file (default: stdout)")
 	flag.Usage = printUsage
 	flag.Parse()
@@ -12,7 +12,10 @@ import (
 func main() {
 	err := cli.Cli(version)
 	if err != nil && !flags.WroteHelp(err) {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
+		// Errors of --output-format json and jsonl were printed to stdout already
+		if !cli.IsReported(err) {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -17,7 +17,7 @@ _fabric() {
    fi
 
   # Define all possible options/flags
-  local opts="--pattern -p --variable -v --context -C --session --attachment -a --setup -S --temperature -t --topp -T --stream -s --presencepenalty -P --raw -r --frequencypenalty -F --listpatterns -l --readpattern --listmodels -L --listcontexts -x --listsessions -X --updatepatterns -U --copy -c --model -m --vendor -V --modelContextLength --output -o --output-session --latest -n --changeDefaultModel -d --youtube -y --playlist --transcript --transcript-with-timestamps --visual --visual-sensitivity --visual-fps --comments --metadata --yt-dlp-args --spotify --language -g --scrape_url -u --scrape_question -q --seed -e --thinking --wipecontext -w --wipesession -W --printcontext --printsession --readability --input-has-vars --no-variable-replacement --dry-run --serve --serveOllama --address --api-key --config --search --search-location --image-file --image-size --image-quality --image-compression --image-background --suppress-think --think-start-tag --think-end-tag --disable-responses-api --transcribe-file --transcribe-model --split-media-file --voice --list-gemini-voices --list-transcription-models --notification --notification-command --show-metadata --debug --version --listextensions --addextension --rmextension --strategy --liststrategies --listvendors --shell-complete-list --help -h"
+  local opts="--pattern -p --variable -v --context -C --session --attachment -a --input-file --input-glob --input-max-tokens --input-max-file-size --setup -S --non-interactive --temperature -t --topp -T --stream -s --presencepenalty -P --raw -r --frequencypenalty -F --listpatterns -l --readpattern --listmodels -L --capabilities --listcontexts -x --listsessions -X --updatepatterns -U --copy -c --model -m --vendor -V --compare --judge --compare-layout --interactive --modelContextLength --output -o --output-session --output-format --latest -n --changeDefaultModel -d --migrate-secrets --youtube -y --playlist --transcript --transcript-with-timestamps --visual --visual-sensitivity --visual-fps --comments --metadata --yt-dlp-args --spotify --language -g --scrape_url -u --scrape_question -q --seed -e --thinking --wipecontext -w --wipesession -W --printcontext --printsession --readability --input-has-vars --no-variable-replacement --dry-run --serve --serveOllama --address --api-key --config --profile --search --search-location --search-query --image-file --image-size --image-quality --image-compression --image-background --suppress-think --think-start-tag --think-end-tag --disable-responses-api --transcribe-file --transcribe-model --transcribe-format --split-media-file --voice --list-gemini-voices --list-transcription-models --notification --notification-command --show-metadata --no-prompt-cache --chunk --chunk-size --chunk-overlap --merge-pattern --apply-changes --yes --force-changes --rollback --batch-submit --batch-status --batch-fetch --batch --batch-workers --batch-output --watch --watch-debounce --watch-diff --debug --version --listextensions --addextension --rmextension --strategy --liststrategies --listvendors --doctor --doctor-json --shell-complete-list --help -h"
 
   # Helper function for dynamic completions
   _fabric_get_list() {
@@ -26,10 +26,14 @@ _fabric() {
 
   # Handle completions based on the previous word
   case "${prev}" in
-  -p | --pattern | --readpattern)
+  -p | --pattern | --readpattern | --merge-pattern)
     COMPREPLY=($(compgen -W "$(_fabric_get_list --listpatterns)" -- "${cur}"))
     return 0
     ;;
+  --rollback)
+    COMPREPLY=($(compgen -W "latest" -- "${cur}"))
+    return 0
+    ;;
   -C | --context)
     COMPREPLY=($(compgen -W "$(_fabric_get_list --listcontexts)" -- "${cur}"))
     return 0
@@ -87,7 +91,7 @@ _fabric() {
     return 0
     ;;
   # Options requiring file/directory paths
-  -a | --attachment | -o | --output | --config | --addextension | --image-file | --transcribe-file)
+  -a | --attachment | -o | --output | --config | --addextension | --image-file | --transcribe-file | --watch | --input-file)
     _filedir
     return 0
     ;;
@@ -104,8 +108,24 @@ _fabric() {
     COMPREPLY=($(compgen -W "opaque transparent" -- "$cur"))
     return 0
     ;;
+  --compare-layout)
+    COMPREPLY=($(compgen -W "sequential side-by-side" -- "$cur"))
+    return 0
+    ;;
+  --migrate-secrets)
+    COMPREPLY=($(compgen -W "passphrase keyfile keyring" -- "$cur"))
+    return 0
+    ;;
+  --output-format)
+    COMPREPLY=($(compgen -W "text json jsonl" -- "$cur"))
+    return 0
+    ;;
+  --transcribe-format)
+    COMPREPLY=($(compgen -W "text srt vtt json" -- "$cur"))
+    return 0
+    ;;
   # Options requiring simple arguments (no specific completion logic here)
-  -v | --variable | -t | --temperature | -T | --topp | -P | --presencepenalty | -F | --frequencypenalty | --modelContextLength | -n | --latest | -y | --youtube | --visual-sensitivity | --visual-fps | --yt-dlp-args | -g | --language | -u | --scrape_url | -q | --scrape_question | -e | --seed | --address | --api-key | --search-location | --image-compression | --think-start-tag | --think-end-tag | --notification-command)
+  -v | --variable | -t | --temperature | -T | --topp | -P | --presencepenalty | -F | --frequencypenalty | --compare | --judge | --modelContextLength | -n | --latest | -y | --youtube | --visual-sensitivity | --visual-fps | --yt-dlp-args | -g | --language | -u | --scrape_url | -q | --scrape_question | -e | --seed | --address | --api-key | --search-location | --search-query | --image-compression | --think-start-tag | --think-end-tag | --notification-command | --input-glob | --input-max-tokens | --input-max-file-size)
     # No specific completion suggestions, user types the value
     return 0
     ;;
@@ -64,18 +64,25 @@ function __fabric_register_completions
         # Options that take a value from a dynamic list
         complete -c $cmd -s p -l pattern -x -d "Choose a pattern from the available patterns" -a "(__fabric_get_patterns)"
         complete -c $cmd -l readpattern -x -d "Print the contents of the named pattern to the terminal" -a "(__fabric_get_patterns)"
-        complete -c $cmd -s C -l context -x -d "Choose a context from the available contexts" -a "(__fabric_get_contexts)"
+        complete -c $cmd -l merge-pattern -x -d "Pattern merging the partial results of --chunk" -a "(__fabric_get_patterns)"
+        complete -c $cmd -s C -l context -x -d "Choose a context by name, file path or URL; repeat to stack contexts" -a "(__fabric_get_contexts)"
         complete -c $cmd -l session -x -d "Choose a session from the available sessions" -a "(__fabric_get_sessions)"
         complete -c $cmd -s m -l model -x -d "Choose model" -a "(__fabric_get_models)"
         complete -c $cmd -s V -l vendor -x -d "Specify vendor for the selected model (e.g., -V \"LM Studio\" -m openai/gpt-oss-20b)" -a "(__fabric_get_vendors)"
+        complete -c $cmd -l compare -x -d "Run the request through several models and compare the answers (vendor|model)"
+        complete -c $cmd -l judge -x -d "Have this vendor|model rank the --compare answers"
+        complete -c $cmd -l compare-layout -x -d "Show --compare answers sequential or side-by-side" -a "sequential side-by-side"
+        complete -c $cmd -l migrate-secrets -x -d "Move keys, tokens and secrets from .env to the encrypted secret store" -a "passphrase keyfile keyring"
         complete -c $cmd -s w -l wipecontext -x -d "Wipe context" -a "(__fabric_get_contexts)"
         complete -c $cmd -s W -l wipesession -x -d "Wipe session" -a "(__fabric_get_sessions)"
         complete -c $cmd -l printcontext -x -d "Print context" -a "(__fabric_get_contexts)"
         complete -c $cmd -l printsession -x -d "Print session" -a "(__fabric_get_sessions)"
         complete -c $cmd -l rmextension -x -d "Remove a registered extension by name" -a "(__fabric_get_extensions)"
         complete -c $cmd -l strategy -x -d "Choose a strategy from the available strategies" -a "(__fabric_get_strategies)"
-        complete -c $cmd -l voice -x -d "TTS voice name for supported models (e.g., Kore, Charon, Puck)" -a "(__fabric_get_gemini_voices)"
+        complete -c $cmd -l voice -x -d "TTS voice name for supported models (e.g., Kore, Charon, Puck for Gemini; alloy, nova, onyx for OpenAI)" -a "(__fabric_get_gemini_voices)"
         complete -c $cmd -l transcribe-model -x -d "Model to use for transcription (separate from chat model)" -a "(__fabric_get_transcription_models)"
+        complete -c $cmd -l output-format -x -d "Print the result as text, a json object, or jsonl stream events (default: text)" -a "text json jsonl"
+        complete -c $cmd -l transcribe-format -x -d "Transcription output format: text, srt, vtt, json (default: text)" -a "text srt vtt json"
 
         # Options that take a value from a fixed list
         complete -c $cmd -l thinking -x -d "Set reasoning/thinking level" -a "off low medium high"
@@ -85,12 +92,17 @@ function __fabric_register_completions
         complete -c $cmd -l debug -x -d "Set debug level (0=off, 1=basic, 2=detailed, 3=trace, 4=wire)" -a "0 1 2 3 4"
 
         # Options that take a file path
-        complete -c $cmd -s a -l attachment -r -d "Attachment path or URL (e.g. for OpenAI image recognition messages)"
+        complete -c $cmd -s a -l attachment -r -d "Attachment path or URL: image, audio, PDF, DOCX, HTML, CSV or text file"
+        complete -c $cmd -l input-file -r -d "Text file or directory to add to the input, each file under a header with its path; repeat for more"
+        complete -c $cmd -l input-glob -x -d "Glob pattern of text files to add to the input, where ** matches any number of directories; repeat for more"
+        complete -c $cmd -l input-max-tokens -x -d "Token budget of the --input-file and --input-glob files, 0 for no limit"
+        complete -c $cmd -l input-max-file-size -x -d "Size in bytes above which --input-file and --input-glob files are skipped, 0 for no limit"
         complete -c $cmd -s o -l output -r -d "Output to file"
         complete -c $cmd -l config -r -d "Path to YAML config file" -a "(__fish_complete_suffix .yaml .yml)"
         complete -c $cmd -l addextension -r -d "Register a new extension from config file path" -a "(__fish_complete_suffix .yaml .yml)"
-        complete -c $cmd -l image-file -r -d "Save generated image to specified file path (e.g., 'output.png')" -a "(__fish_complete_suffix .png .webp .jpeg .jpg)"
+        complete -c $cmd -l image-file -r -d "Save generated image to specified file path (e.g., 'output.png'); image attachments are edited" -a "(__fish_complete_suffix .png .webp .jpeg .jpg)"
         complete -c $cmd -l transcribe-file -r -d "Audio or video file to transcribe" -a "(__fish_complete_suffix .mp3 .mp4 .mpeg .mpga .m4a .wav .webm)"
+        complete -c $cmd -l batch-submit -r -d "Submit a JSONL file of inputs as a provider batch (OpenAI, Anthropic)" -a "(__fish_complete_suffix .jsonl)"
 
         # Options that take a value the user types
         complete -c $cmd -s v -l variable -x -d "Values for pattern variables, e.g. -v=#role:expert -v=#points:30"
@@ -98,6 +110,7 @@ function __fabric_register_completions
         complete -c $cmd -s T -l topp -x -d "Set top P (default: 0.9)"
         complete -c $cmd -s P -l presencepenalty -x -d "Set presence penalty (default: 0.0)"
         complete -c $cmd -s F -l frequencypenalty -x -d "Set frequency penalty (default: 0.0)"
+        complete -c $cmd -l profile -x -d "Apply a named profile from the config file"
         complete -c $cmd -l modelContextLength -x -d "Model context length (only affects ollama)"
         complete -c $cmd -s n -l latest -x -d "Number of latest patterns to list (default: 0)"
         complete -c $cmd -s y -l youtube -x -d "YouTube video or play list URL to grab transcript, comments from it"
@@ -107,11 +120,22 @@ function __fabric_register_completions
         complete -c $cmd -l spotify -x -d "Spotify podcast or episode URL to grab metadata from and send to chat"
         complete -c $cmd -s g -l language -x -d "Specify the Language Code for the chat, e.g. -g=en -g=zh"
         complete -c $cmd -s u -l scrape_url -x -d "Scrape website URL to markdown using Jina AI"
+        complete -c $cmd -l batch-status -x -d "Show the status of a submitted provider batch"
+        complete -c $cmd -l batch-fetch -x -d "Fetch the results of a finished provider batch as JSONL keyed by input ID"
+        complete -c $cmd -l chunk-size -x -d "Chunk size in tokens for --chunk"
+        complete -c $cmd -l chunk-overlap -x -d "Tokens repeated from the end of each chunk at the start of the next"
+        complete -c $cmd -l rollback -x -d "Undo applied file changes, given the snapshot ID they printed or latest" -a "latest"
+        complete -c $cmd -l batch -r -d "Run the pattern over every file of a directory or glob, or every line of a JSONL file"
+        complete -c $cmd -l batch-workers -x -d "Number of --batch items processed at the same time"
+        complete -c $cmd -l batch-output -r -d "Output path of each --batch item, where {{name}} is the item name"
+        complete -c $cmd -l watch -r -d "Run the pattern over a file, and again whenever the file changes"
+        complete -c $cmd -l watch-debounce -x -d "Milliseconds the --watch file must stay unchanged before the pattern runs again"
         complete -c $cmd -s q -l scrape_question -x -d "Search question using Jina AI"
         complete -c $cmd -s e -l seed -x -d "Seed to be used for LMM generation"
         complete -c $cmd -l address -x -d "The address to bind the REST API (default: :8080)"
         complete -c $cmd -l api-key -x -d "API key used to secure server routes"
         complete -c $cmd -l search-location -x -d "Set location for web search results (e.g., 'America/Los_Angeles')"
+        complete -c $cmd -l search-query -x -d "Query for the web search backend (defaults to the input)"
         complete -c $cmd -l image-compression -x -d "Compression level 0-100 for JPEG/WebP formats (default: not set)"
         complete -c $cmd -l think-start-tag -x -d "Start tag for thinking sections (default: <think>)"
         complete -c $cmd -l think-end-tag -x -d "End tag for thinking sections (default: </think>)"
@@ -119,14 +143,17 @@ function __fabric_register_completions
 
         # Boolean flags (no arguments)
         complete -c $cmd -s S -l setup -d "Run setup for all reconfigurable parts of fabric"
+        complete -c $cmd -l non-interactive -d "With --setup, configure --vendor and --model from environment variables without asking questions"
         complete -c $cmd -s s -l stream -d "Stream"
         complete -c $cmd -s r -l raw -d "Use the defaults of the model without sending chat options. Only affects OpenAI-compatible providers. Anthropic models always use smart parameter selection to comply with model-specific requirements."
         complete -c $cmd -s l -l listpatterns -d "List all patterns"
         complete -c $cmd -s L -l listmodels -d "List all available models"
+        complete -c $cmd -l capabilities -d "Show model capabilities with --listmodels"
         complete -c $cmd -s x -l listcontexts -d "List all contexts"
         complete -c $cmd -s X -l listsessions -d "List all sessions"
         complete -c $cmd -s U -l updatepatterns -d "Update patterns"
         complete -c $cmd -s c -l copy -d "Copy to clipboard"
+        complete -c $cmd -l interactive -d "Chat interactively in the terminal, with slash commands"
         complete -c $cmd -l output-session -d "Output the entire session to the output file"
         complete -c $cmd -s d -l changeDefaultModel -d "Change default model"
         complete -c $cmd -l playlist -d "Prefer playlist over video if both ids are present in the URL"
@@ -139,21 +166,29 @@ function __fabric_register_completions
         complete -c $cmd -l input-has-vars -d "Apply variables to user input"
         complete -c $cmd -l no-variable-replacement -d "Disable pattern variable replacement"
         complete -c $cmd -l dry-run -d "Show what would be sent to the model without actually sending it"
-        complete -c $cmd -l search -d "Enable web search tool for supported models (Anthropic, OpenAI, Gemini, Grok)"
+        complete -c $cmd -l search -d "Enable web search: native for supported models, otherwise the configured web search backend"
         complete -c $cmd -l serve -d "Serve the Fabric Rest API"
         complete -c $cmd -l serveOllama -d "Serve the Fabric Rest API with ollama endpoints"
         complete -c $cmd -l version -d "Print current version"
         complete -c $cmd -l listextensions -d "List all registered extensions"
         complete -c $cmd -l liststrategies -d "List all strategies"
         complete -c $cmd -l listvendors -d "List all vendors"
+        complete -c $cmd -l doctor -d "Check the configuration and connectivity of vendors and tools"
+        complete -c $cmd -l doctor-json -d "Print the --doctor report as JSON"
         complete -c $cmd -l list-gemini-voices -d "List all available Gemini TTS voices"
         complete -c $cmd -l list-transcription-models -d "List all available transcription models"
         complete -c $cmd -l shell-complete-list -d "Output raw list without headers/formatting (for shell completion)"
-        complete -c $cmd -l suppress-think -d "Suppress text enclosed in thinking tags"
+        complete -c $cmd -l suppress-think -d "Hide model reasoning, including text enclosed in thinking tags"
         complete -c $cmd -l disable-responses-api -d "Disable OpenAI Responses API (default: false)"
         complete -c $cmd -l split-media-file -d "Split audio/video files larger than 25MB using ffmpeg"
         complete -c $cmd -l notification -d "Send desktop notification when command completes"
         complete -c $cmd -l show-metadata -d "Print metadata (input/output tokens) to stderr"
+        complete -c $cmd -l no-prompt-cache -d "Disable automatic prompt caching of patterns, contexts and session history (Anthropic)"
+        complete -c $cmd -l chunk -d "Split inputs larger than the model's context window and merge the partial results"
+        complete -c $cmd -l apply-changes -d "Offer the file changes the answer proposes to be applied"
+        complete -c $cmd -l yes -d "Apply proposed file changes without asking for confirmation"
+        complete -c $cmd -l force-changes -d "Apply file changes to files with uncommitted changes or outside the project"
+        complete -c $cmd -l watch-diff -d "Send only the changes since the last run of --watch, continuing the same session"
         complete -c $cmd -s h -l help -d "Show this help message"
 end
 
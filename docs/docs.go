@@ -215,6 +215,86 @@ const docTemplate = `{
                 }
             }
         },
+        "/transcribe": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Transcribes an uploaded file as plain text, SRT or WebVTT subtitles, or JSON with timed segments. Speaker labels are included for diarization models.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transcription"
+                ],
+                "summary": "Transcribe an audio or video file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Audio or video file (mp3, mp4, mpeg, mpga, m4a, wav, webm)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transcription model, e.g. whisper-1 or gpt-4o-transcribe-diarize",
+                        "name": "model",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Output format: text, srt, vtt, json (default: text)",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vendor to transcribe with (default: OpenAI)",
+                        "name": "vendor",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Split files larger than 25MB using ffmpeg",
+                        "name": "split",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/restapi.TranscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/youtube/transcript": {
             "post": {
                 "security": [
@@ -274,6 +354,23 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "chat.Citation": {
+            "type": "object",
+            "properties": {
+                "snippet": {
+                    "type": "string"
+                },
+                "span": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "domain.ThinkingLevel": {
             "type": "string",
             "enum": [
@@ -289,6 +386,23 @@ const docTemplate = `{
                 "ThinkingHigh"
             ]
         },
+        "domain.TranscriptSegment": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "number"
+                },
+                "speaker": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "number"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
         "domain.UsageMetadata": {
             "type": "object",
             "properties": {
@@ -472,6 +586,12 @@ const docTemplate = `{
         "restapi.StreamResponse": {
             "type": "object",
             "properties": {
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/chat.Citation"
+                    }
+                },
                 "content": {
                     "type": "string"
                 },
@@ -480,7 +600,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "type": {
-                    "description": "\"content\", \"usage\", \"error\", \"complete\"",
+                    "description": "\"content\", \"reasoning\", \"citation\", \"usage\", \"error\", \"complete\"",
                     "type": "string"
                 },
                 "usage": {
@@ -488,6 +608,36 @@ const docTemplate = `{
                 }
             }
         },
+        "restapi.TranscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "type": "number"
+                },
+                "format": {
+                    "description": "Requested output format",
+                    "type": "string",
+                    "example": "srt"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "output": {
+                    "description": "Transcript in the requested format",
+                    "type": "string",
+                    "example": "Hello there."
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.TranscriptSegment"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
         "restapi.YouTubeRequest": {
             "type": "object",
             "required": [
@@ -3,6 +3,7 @@ package chat
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/danielmiessler/fabric/internal/i18n"
 )
@@ -21,18 +22,46 @@ var ErrContentFieldsMisused = errors.New(i18n.T("chat_error_content_fields_misus
 type ChatMessagePartType string
 
 const (
-	ChatMessagePartTypeText     ChatMessagePartType = "text"
-	ChatMessagePartTypeImageURL ChatMessagePartType = "image_url"
+	ChatMessagePartTypeText       ChatMessagePartType = "text"
+	ChatMessagePartTypeImageURL   ChatMessagePartType = "image_url"
+	ChatMessagePartTypeFile       ChatMessagePartType = "file"
+	ChatMessagePartTypeInputAudio ChatMessagePartType = "input_audio"
 )
 
 type ChatMessageImageURL struct {
 	URL string `json:"url,omitempty"`
 }
 
+// ChatMessageFile is a document attached to a message, either inline as base64
+// Data or by URL.
+type ChatMessageFile struct {
+	Filename string `json:"filename,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Data     string `json:"file_data,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// ChatMessageInputAudio is base64 encoded audio in Format (wav, mp3, flac, ...).
+type ChatMessageInputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
 type ChatMessagePart struct {
-	Type     ChatMessagePartType  `json:"type,omitempty"`
-	Text     string               `json:"text,omitempty"`
-	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
+	Type       ChatMessagePartType    `json:"type,omitempty"`
+	Text       string                 `json:"text,omitempty"`
+	ImageURL   *ChatMessageImageURL   `json:"image_url,omitempty"`
+	File       *ChatMessageFile       `json:"file,omitempty"`
+	InputAudio *ChatMessageInputAudio `json:"input_audio,omitempty"`
+}
+
+// Citation is a source a response cites, normalized across vendors. Span is the
+// part of the answer the source supports and Snippet the quoted source text, when known.
+type Citation struct {
+	URL     string `json:"url"`
+	Title   string `json:"title,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+	Span    string `json:"span,omitempty"`
 }
 
 type FunctionCall struct {
@@ -63,6 +92,22 @@ type ChatCompletionMessage struct {
 	FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
 	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
 	ToolCallID       string            `json:"tool_call_id,omitempty"`
+	Citations        []Citation        `json:"citations,omitempty"`
+}
+
+// TextContent returns Content followed by the text parts of MultiContent, for vendors
+// that only accept plain text messages.
+func (m ChatCompletionMessage) TextContent() string {
+	texts := make([]string, 0, len(m.MultiContent)+1)
+	if strings.TrimSpace(m.Content) != "" {
+		texts = append(texts, m.Content)
+	}
+	for _, part := range m.MultiContent {
+		if part.Type == ChatMessagePartTypeText && strings.TrimSpace(part.Text) != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
 }
 
 func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
@@ -80,6 +125,7 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 			FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
 			ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
 			ToolCallID       string            `json:"tool_call_id,omitempty"`
+			Citations        []Citation        `json:"citations,omitempty"`
 		}(m)
 		return json.Marshal(msg)
 	}
@@ -94,6 +140,7 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 		FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
 		ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
 		ToolCallID       string            `json:"tool_call_id,omitempty"`
+		Citations        []Citation        `json:"citations,omitempty"`
 	}(m)
 	return json.Marshal(msg)
 }
@@ -109,6 +156,7 @@ func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
 		FunctionCall     *FunctionCall `json:"function_call,omitempty"`
 		ToolCalls        []ToolCall    `json:"tool_calls,omitempty"`
 		ToolCallID       string        `json:"tool_call_id,omitempty"`
+		Citations        []Citation    `json:"citations,omitempty"`
 	}{}
 
 	if err := json.Unmarshal(bs, &msg); err == nil {
@@ -125,6 +173,7 @@ func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
 		FunctionCall     *FunctionCall     `json:"function_call,omitempty"`
 		ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
 		ToolCallID       string            `json:"tool_call_id,omitempty"`
+		Citations        []Citation        `json:"citations,omitempty"`
 	}{}
 	if err := json.Unmarshal(bs, &multiMsg); err != nil {
 		return err
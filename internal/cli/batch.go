@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+)
+
+// batchNameVariable is replaced by the item name in the --batch-output template.
+const batchNameVariable = "{{name}}"
+
+// batchItem is one input of a --batch run.
+type batchItem struct {
+	name      string
+	input     string
+	variables map[string]string
+}
+
+// batchResult tells how a --batch item went.
+type batchResult struct {
+	name     string
+	output   string
+	skipped  bool
+	err      error
+	duration time.Duration
+}
+
+// handleBatch runs the pattern over every --batch item with --batch-workers items at a
+// time, sharing one chatter. Each answer is written to the --batch-output path of its
+// item, and items whose output already exists are skipped, so an interrupted run can be
+// started again. A failed item does not stop the others; the run fails at the end.
+func handleBatch(flags *Flags, registry *core.PluginRegistry) (err error) {
+	if strings.TrimSpace(flags.Message) != "" {
+		err = errors.New(i18n.T("batch_run_message_not_supported"))
+		return
+	}
+	if flags.BatchWorkers < 1 {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_run_invalid_workers"), flags.BatchWorkers))
+		return
+	}
+	if !strings.Contains(flags.BatchOutput, batchNameVariable) {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_run_output_needs_name"), batchNameVariable))
+		return
+	}
+
+	var items []batchItem
+	if items, err = collectBatchItems(flags.Batch); err != nil {
+		return
+	}
+	if len(items) == 0 {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_run_no_items"), flags.Batch))
+		return
+	}
+
+	var chatter *core.Chatter
+	if chatter, err = registry.GetChatter(flags.Model, flags.ModelContextLength, flags.Vendor, false, flags.DryRun); err != nil {
+		return
+	}
+	var chatOptions *domain.ChatOptions
+	if chatOptions, err = flags.BuildChatOptions(); err != nil {
+		return
+	}
+	chatOptions.Quiet = true
+
+	// Ctrl-C stops the run; items that were not finished are picked up by the next run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results := make([]batchResult, len(items))
+	indexes := make(chan int)
+	var mu sync.Mutex
+	finished := 0
+	var wg sync.WaitGroup
+	for range min(flags.BatchWorkers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := runBatchItem(ctx, flags, registry, chatter, chatOptions, items[i])
+				mu.Lock()
+				finished++
+				results[i] = result
+				printBatchResult(finished, len(items), result)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var done, skipped, failed []string
+	for i, result := range results {
+		switch {
+		case result.skipped:
+			skipped = append(skipped, result.name)
+		case result.err != nil:
+			failed = append(failed, fmt.Sprintf("%s: %v", result.name, result.err))
+		case result.output != "":
+			done = append(done, result.name)
+		default:
+			// never started because the run was interrupted
+			failed = append(failed, fmt.Sprintf("%s: %v", items[i].name, context.Canceled))
+		}
+	}
+	summary := fmt.Sprintf(i18n.T("batch_run_summary"), len(done), len(skipped), len(failed))
+	fmt.Println(summary)
+	for _, failure := range failed {
+		fmt.Printf("  %s\n", failure)
+	}
+
+	if chatOptions.Notification {
+		if notifyErr := sendNotification(chatOptions, flags.Pattern, summary); notifyErr != nil {
+			debuglog.Log("Failed to send notification: %v\n", notifyErr)
+		}
+	}
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_run_items_failed"), len(failed), len(items)))
+	}
+	return
+}
+
+// runBatchItem sends one item with the pattern, context, strategy and options of the
+// flags, and writes the answer to the item's output path.
+func runBatchItem(ctx context.Context, flags *Flags, registry *core.PluginRegistry, chatter *core.Chatter,
+	chatOptions *domain.ChatOptions, item batchItem) (ret batchResult) {
+
+	ret.name = item.name
+	output := strings.ReplaceAll(flags.BatchOutput, batchNameVariable, item.name)
+	if _, err := os.Stat(output); err == nil {
+		ret.skipped = true
+		return
+	}
+	if ret.err = ctx.Err(); ret.err != nil {
+		return
+	}
+	start := time.Now()
+
+	itemFlags := *flags
+	itemFlags.Message = item.input
+	itemFlags.Session = ""
+	itemFlags.PatternVariables = maps.Clone(flags.PatternVariables)
+	if len(item.variables) > 0 {
+		if itemFlags.PatternVariables == nil {
+			itemFlags.PatternVariables = make(map[string]string, len(item.variables))
+		}
+		maps.Copy(itemFlags.PatternVariables, item.variables)
+	}
+
+	var chatReq *domain.ChatRequest
+	if chatReq, ret.err = itemFlags.BuildChatRequest(""); ret.err != nil {
+		return
+	}
+	if chatReq.Language == "" {
+		chatReq.Language = registry.Language.DefaultLanguage.Value
+	}
+	if ret.err = chatter.ValidateRequest(chatReq, chatOptions); ret.err != nil {
+		return
+	}
+
+	// Send fills in the options, so every item gets its own copy
+	options := *chatOptions
+	session, err := chatter.Send(ctx, chatReq, &options)
+	if err != nil {
+		ret.err = err
+		return
+	}
+	result := session.GetLastMessage().TextContent()
+	if footnotes := domain.FormatCitationFootnotes(session.GetLastMessage().Citations); footnotes != "" {
+		result += "\n\n" + footnotes
+	}
+
+	if ret.err = writeBatchOutput(output, result); ret.err == nil {
+		ret.output = output
+	}
+	ret.duration = time.Since(start)
+	return
+}
+
+// writeBatchOutput writes the answer through a temporary file, so that an interrupted
+// write does not leave an output that the next run would take as done.
+func writeBatchOutput(path string, content string) (err error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return
+		}
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	temp := path + ".tmp"
+	if err = os.WriteFile(temp, []byte(content), 0o644); err != nil {
+		return
+	}
+	if err = os.Rename(temp, path); err != nil {
+		os.Remove(temp)
+	}
+	return
+}
+
+func printBatchResult(finished int, total int, result batchResult) {
+	prefix := fmt.Sprintf("[%d/%d] %s: ", finished, total, result.name)
+	switch {
+	case result.skipped:
+		fmt.Println(prefix + i18n.T("batch_run_item_skipped"))
+	case result.err != nil:
+		fmt.Println(prefix + fmt.Sprintf(i18n.T("batch_run_item_failed"), result.err))
+	default:
+		fmt.Println(prefix + fmt.Sprintf(i18n.T("batch_run_item_done"), result.output, result.duration.Round(100*time.Millisecond)))
+	}
+}
+
+// collectBatchItems reads the items of a --batch source: the lines of a .jsonl file,
+// named by their id, the files of a directory and its subdirectories, named by their
+// path relative to it, or the files matching a glob pattern, named by their base name.
+// File extensions are left out of the names.
+func collectBatchItems(source string) (ret []batchItem, err error) {
+	info, statErr := os.Stat(source)
+	switch {
+	case statErr == nil && !info.IsDir() && strings.EqualFold(filepath.Ext(source), ".jsonl"):
+		var lines []batchInputLine
+		if lines, err = readBatchInputFile(source); err != nil {
+			return
+		}
+		for _, line := range lines {
+			// IDs name output files, so they must not reach outside the output directory
+			name := strings.NewReplacer("/", "_", `\`, "_").Replace(line.ID)
+			ret = append(ret, batchItem{name: name, input: line.Input, variables: line.Variables})
+		}
+	case statErr == nil && info.IsDir():
+		err = filepath.WalkDir(source, func(path string, entry fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if path != source && strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			relative, relErr := filepath.Rel(source, path)
+			if relErr != nil {
+				return relErr
+			}
+			item, readErr := readBatchFile(path, filepath.ToSlash(strings.TrimSuffix(relative, filepath.Ext(relative))))
+			if readErr != nil {
+				return readErr
+			}
+			ret = append(ret, item)
+			return nil
+		})
+	default:
+		var matches []string
+		if matches, err = filepath.Glob(source); err != nil {
+			return
+		}
+		for _, path := range matches {
+			if info, err = os.Stat(path); err != nil {
+				return
+			}
+			if !info.Mode().IsRegular() {
+				continue
+			}
+			base := filepath.Base(path)
+			var item batchItem
+			if item, err = readBatchFile(path, strings.TrimSuffix(base, filepath.Ext(base))); err != nil {
+				return
+			}
+			ret = append(ret, item)
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	// Two items with the same name would write the same output
+	names := make([]string, len(ret))
+	for i, item := range ret {
+		names[i] = item.name
+	}
+	slices.Sort(names)
+	for i := 1; i < len(names); i++ {
+		if names[i] == names[i-1] {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_run_duplicate_name"), names[i]))
+			return
+		}
+	}
+	return
+}
+
+func readBatchFile(path string, name string) (ret batchItem, err error) {
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+	ret = batchItem{name: name, input: string(content)}
+	return
+}
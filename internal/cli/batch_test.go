@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBatchItems(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":          "first",
+		"sub/b.md":       "second",
+		".hidden/c.txt":  "hidden",
+		"sub/.notes.txt": "hidden too",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		items, err := collectBatchItems(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []batchItem{{name: "a", input: "first"}, {name: "sub/b", input: "second"}}, items)
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		items, err := collectBatchItems(filepath.Join(dir, "*", "*.md"))
+		require.NoError(t, err)
+		assert.Equal(t, []batchItem{{name: "b", input: "second"}}, items)
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "inputs.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(`{"id":"tickets/42","input":"one"}`+"\n"+`{"input":"two","variables":{"lang":"de"}}`+"\n"), 0o644))
+		items, err := collectBatchItems(path)
+		require.NoError(t, err)
+		assert.Equal(t, []batchItem{
+			{name: "tickets_42", input: "one"},
+			{name: "2", input: "two", variables: map[string]string{"lang": "de"}},
+		}, items)
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "inputs.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(`{"id":"a/1","input":"one"}`+"\n"+`{"id":"a_1","input":"two"}`+"\n"), 0o644))
+		_, err := collectBatchItems(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteBatchOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "nested", "item.md")
+
+	require.NoError(t, writeBatchOutput(path, "answer"))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "answer\n", string(content))
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err), "the temporary file should be renamed")
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+// changeReview shows the file changes an answer proposes, asks which to apply unless
+// --yes is given, and applies them after taking a snapshot to roll them back with.
+type changeReview struct {
+	db      *fsdb.Db
+	pattern string
+	yes     bool
+	force   bool
+	out     *os.File
+
+	// answers reads the replies to the confirmations, opened on first use
+	answers *bufio.Reader
+}
+
+func newChangeReview(flags *Flags, db *fsdb.Db) *changeReview {
+	return &changeReview{db: db, pattern: flags.Pattern, yes: flags.Yes, force: flags.ForceChanges, out: os.Stderr}
+}
+
+// apply is the domain.ChatOptions.ChangeApplier of the command line.
+func (o *changeReview) apply(changes []domain.FileChange) (err error) {
+	var root string
+	if root, err = projectRoot(); err != nil {
+		return
+	}
+	if err = o.check(root, changes); err != nil {
+		return
+	}
+
+	var accepted []domain.FileChange
+	var paths []string
+	all := o.yes
+	for _, change := range changes {
+		var before, after string
+		if before, after, err = change.Contents(root); err != nil {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("changes_preview_failed"), change.Path, err))
+		}
+		o.preview(change, before, after)
+
+		if !all {
+			var answer string
+			if answer, err = o.confirm(change.Path); err != nil {
+				return
+			}
+			if answer == "q" {
+				break
+			}
+			if answer == "a" {
+				all = true
+			} else if answer != "y" {
+				continue
+			}
+		}
+		accepted = append(accepted, change)
+		paths = append(paths, change.Paths()...)
+	}
+	if len(accepted) == 0 {
+		fmt.Fprintln(o.out, i18n.T("changes_none_applied"))
+		return
+	}
+
+	var snapshot *fsdb.Snapshot
+	if snapshot, err = fsdb.NewSnapshot(root, paths); err != nil {
+		return
+	}
+	snapshot.Pattern = o.pattern
+	if err = o.db.Snapshots.SaveSnapshot(snapshot); err != nil {
+		return
+	}
+	if err = domain.ApplyFileChanges(root, accepted); err != nil {
+		return fmt.Errorf("%s", fmt.Sprintf(i18n.T("changes_partially_applied"), err, snapshot.ID))
+	}
+	fmt.Fprintln(o.out, i18n.T("chatter_info_file_changes_applied_successfully"))
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("changes_rollback_hint"), snapshot.ID))
+	fmt.Fprintf(o.out, "%s\n\n", i18n.T("chatter_help_review_changes_with_git_diff"))
+	return
+}
+
+// check refuses changes to files outside the project or with uncommitted changes,
+// unless --force-changes is given, before any change is shown.
+func (o *changeReview) check(root string, changes []domain.FileChange) (err error) {
+	if o.force {
+		return
+	}
+	uncommitted := uncommittedFiles(root)
+	for _, change := range changes {
+		for _, path := range change.Paths() {
+			if _, err = domain.ResolveChangePath(root, path); err != nil {
+				return fmt.Errorf("%s", fmt.Sprintf(i18n.T("changes_refused"), err))
+			}
+			if uncommitted[filepath.ToSlash(filepath.Clean(path))] {
+				return fmt.Errorf("%s", fmt.Sprintf(i18n.T("changes_refused_uncommitted"), path))
+			}
+		}
+	}
+	return
+}
+
+// preview prints the change as a colored unified diff.
+func (o *changeReview) preview(change domain.FileChange, before, after string) {
+	if change.Operation == domain.FileOperationRename {
+		fmt.Fprintf(o.out, "\n%s\n", util.Cyan(o.out, fmt.Sprintf("%s %s -> %s", change.Operation, change.Path, change.NewPath)))
+		return
+	}
+	fmt.Fprintf(o.out, "\n%s\n", util.Cyan(o.out, change.Operation+" "+change.Path))
+	diff := domain.UnifiedDiff(change.Path, before, after)
+	if diff == "" {
+		fmt.Fprintln(o.out, i18n.T("changes_preview_unchanged"))
+		return
+	}
+	for line := range strings.SplitSeq(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Fprintln(o.out, line)
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprintln(o.out, util.Green(o.out, line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintln(o.out, util.Red(o.out, line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprintln(o.out, util.Cyan(o.out, line))
+		default:
+			fmt.Fprintln(o.out, line)
+		}
+	}
+}
+
+// confirm asks whether to apply the change to path and returns y, n, a or q. The
+// question is asked on the terminal, since stdin usually carries the input.
+func (o *changeReview) confirm(path string) (ret string, err error) {
+	if o.answers == nil {
+		var terminal io.Reader
+		if terminal, err = openTerminal(); err != nil {
+			return "", errors.New(i18n.T("changes_confirmation_needs_terminal"))
+		}
+		o.answers = bufio.NewReader(terminal)
+	}
+	for {
+		fmt.Fprintf(o.out, i18n.T("changes_confirm"), path)
+		var line string
+		line, err = o.answers.ReadString('\n')
+		if line == "" && err != nil {
+			return "q", nil
+		}
+		err = nil
+		switch answer := strings.ToLower(strings.TrimSpace(line)); answer {
+		case "y", "yes":
+			return "y", nil
+		case "", "n", "no":
+			return "n", nil
+		case "a", "all":
+			return "a", nil
+		case "q", "quit":
+			return "q", nil
+		}
+	}
+}
+
+// openTerminal opens the terminal the user types in, even when stdin is piped.
+func openTerminal() (*os.File, error) {
+	if util.IsTerminal(os.Stdin) {
+		return os.Stdin, nil
+	}
+	if runtime.GOOS == "windows" {
+		return os.Open("CONIN$")
+	}
+	return os.Open("/dev/tty")
+}
+
+// projectRoot returns the root of the git repository of the current directory, or the
+// current directory outside a repository.
+func projectRoot() (ret string, err error) {
+	if output, gitErr := exec.Command("git", "rev-parse", "--show-toplevel").Output(); gitErr == nil {
+		if ret = strings.TrimSpace(string(output)); ret != "" {
+			return
+		}
+	}
+	if ret, err = os.Getwd(); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("changes_working_directory_failed"), err))
+	}
+	return
+}
+
+// uncommittedFiles returns the files of the repository at root that have uncommitted
+// changes or are untracked, by their slash-separated paths from the root. Outside a
+// repository, or without git, it returns none.
+func uncommittedFiles(root string) (ret map[string]bool) {
+	ret = map[string]bool{}
+	output, err := exec.Command("git", "-C", root, "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return
+	}
+	entries := bytes.Split(output, []byte{0})
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if len(entry) < 4 {
+			continue
+		}
+		ret[entry[3:]] = true
+		// A rename is followed by the path it was renamed from
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+			if i < len(entries) {
+				ret[string(entries[i])] = true
+			}
+		}
+	}
+	return
+}
+
+// rollbackChanges restores the files a snapshot recorded before file changes were applied.
+func rollbackChanges(db *fsdb.Db, id string) (err error) {
+	var snapshot *fsdb.Snapshot
+	if snapshot, err = db.Snapshots.Get(id); err != nil {
+		return
+	}
+	if err = snapshot.Restore(); err != nil {
+		return
+	}
+	fmt.Printf("%s\n", fmt.Sprintf(i18n.T("changes_rolled_back"), len(snapshot.Files), snapshot.ID, snapshot.Root))
+	return
+}
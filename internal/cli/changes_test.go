@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeReviewCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "clean.txt"), []byte("clean"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dirty.txt"), []byte("dirty"), 0644))
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dirty.txt"), []byte("changed"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "untracked.txt"), []byte("new"), 0644))
+
+	uncommitted := uncommittedFiles(root)
+	assert.Equal(t, map[string]bool{"dirty.txt": true, "sub/untracked.txt": true}, uncommitted)
+
+	review := &changeReview{}
+	assert.NoError(t, review.check(root, []domain.FileChange{
+		{Operation: domain.FileOperationUpdate, Path: "clean.txt"},
+		{Operation: domain.FileOperationCreate, Path: "sub/new.txt"},
+	}))
+	assert.Error(t, review.check(root, []domain.FileChange{{Operation: domain.FileOperationUpdate, Path: "dirty.txt"}}))
+	assert.Error(t, review.check(root, []domain.FileChange{
+		{Operation: domain.FileOperationRename, Path: "clean.txt", NewPath: "sub/untracked.txt"},
+	}))
+
+	review.force = true
+	assert.NoError(t, review.check(root, []domain.FileChange{{Operation: domain.FileOperationUpdate, Path: "dirty.txt"}}))
+}
+
+func TestChangeReviewApplyWithYes(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("one\n"), 0644))
+
+	out, err := os.CreateTemp(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer out.Close()
+	db := fsdb.NewDb(t.TempDir())
+	require.NoError(t, os.MkdirAll(db.Snapshots.Dir, 0755))
+	review := &changeReview{db: db, yes: true, force: true, out: out}
+
+	require.NoError(t, review.apply([]domain.FileChange{
+		{Operation: domain.FileOperationUpdate, Path: "a.txt", Content: "two\n"},
+		{Operation: domain.FileOperationCreate, Path: "b.txt", Content: "new\n"},
+	}))
+	content, err := os.ReadFile(filepath.Join(root, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "two\n", string(content))
+
+	require.NoError(t, rollbackChanges(db, "latest"))
+	content, err = os.ReadFile(filepath.Join(root, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "one\n", string(content))
+	assert.NoFileExists(t, filepath.Join(root, "b.txt"))
+}
@@ -13,38 +13,55 @@ import (
 	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
 	"github.com/danielmiessler/fabric/internal/tools/notifications"
+	"github.com/danielmiessler/fabric/internal/util"
 )
 
+// applyPatternModel picks the pattern-specific model from its environment variable when no
+// model was given. The variable holds vendor|model, a model or a model alias.
+func (o *Flags) applyPatternModel() {
+	if o.Pattern == "" || o.Model != "" {
+		return
+	}
+	envVar := "FABRIC_MODEL_" + strings.ToUpper(strings.ReplaceAll(o.Pattern, "-", "_"))
+	if modelSpec := os.Getenv(envVar); modelSpec != "" {
+		parts := strings.SplitN(modelSpec, "|", 2)
+		if len(parts) == 2 {
+			o.Vendor = parts[0]
+			o.Model = parts[1]
+		} else {
+			o.Model = modelSpec
+			o.resolveModelAlias(o.cliFlags)
+		}
+	}
+}
+
 // handleChatProcessing handles the main chat processing logic
 func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, messageTools string) (err error) {
 	if messageTools != "" {
 		currentFlags.AppendMessage(messageTools)
 	}
-	// Check for pattern-specific model via environment variable
-	if currentFlags.Pattern != "" && currentFlags.Model == "" {
-		envVar := "FABRIC_MODEL_" + strings.ToUpper(strings.ReplaceAll(currentFlags.Pattern, "-", "_"))
-		if modelSpec := os.Getenv(envVar); modelSpec != "" {
-			parts := strings.SplitN(modelSpec, "|", 2)
-			if len(parts) == 2 {
-				currentFlags.Vendor = parts[0]
-				currentFlags.Model = parts[1]
-			} else {
-				currentFlags.Model = modelSpec
-			}
-		}
+	currentFlags.applyPatternModel()
+
+	// Structured output is made from the stream updates, which carry the token usage
+	var output *jsonOutput
+	if currentFlags.structuredOutput() {
+		output = newJSONOutput(currentFlags.OutputFormat, os.Stdout)
 	}
 
 	var chatter *core.Chatter
 	if chatter, err = registry.GetChatter(currentFlags.Model, currentFlags.ModelContextLength,
-		currentFlags.Vendor, currentFlags.Stream, currentFlags.DryRun); err != nil {
+		currentFlags.Vendor, currentFlags.Stream || output != nil, currentFlags.DryRun); err != nil {
+		err = domain.WithCode(domain.ErrorCodeModelUnavailable, err)
 		return
 	}
 
 	var session *fsdb.Session
 	var chatReq *domain.ChatRequest
 	if chatReq, err = currentFlags.BuildChatRequest(strings.Join(os.Args[1:], " ")); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidRequest, err)
 		return
 	}
 
@@ -53,20 +70,28 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 	}
 	var chatOptions *domain.ChatOptions
 	if chatOptions, err = currentFlags.BuildChatOptions(); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidArguments, err)
+		return
+	}
+	chatOptions.ChangeApplier = newChangeReview(currentFlags, registry.Db).apply
+
+	// Reject options and attachments the selected model is known not to support
+	if err = chatter.ValidateRequest(chatReq, chatOptions); err != nil {
+		err = domain.WithCode(domain.ErrorCodeUnsupported, err)
 		return
 	}
 
 	// Check if user is requesting audio output or using a TTS model
 	isAudioOutput := currentFlags.Output != "" && IsAudioFormat(currentFlags.Output)
-	isTTSModel := isTTSModel(currentFlags.Model)
+	isTTSModel := chatter.Capabilities().AudioOutput.IsSupported()
 
 	if isTTSModel && !isAudioOutput {
-		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("tts_model_requires_audio_output"), currentFlags.Model))
+		err = domain.WithCode(domain.ErrorCodeUnsupported, fmt.Errorf("%s", fmt.Sprintf(i18n.T("tts_model_requires_audio_output"), currentFlags.Model)))
 		return
 	}
 
 	if isAudioOutput && !isTTSModel {
-		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("audio_output_file_specified_but_not_tts_model"), currentFlags.Output, currentFlags.Model))
+		err = domain.WithCode(domain.ErrorCodeUnsupported, fmt.Errorf("%s", fmt.Sprintf(i18n.T("audio_output_file_specified_but_not_tts_model"), currentFlags.Output, currentFlags.Model)))
 		return
 	}
 
@@ -78,7 +103,7 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 			outputFile += ".wav"
 		}
 		if _, err = os.Stat(outputFile); err == nil {
-			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("file_already_exists_choose_different"), outputFile))
+			err = domain.WithCode(domain.ErrorCodeOutput, fmt.Errorf("%s", fmt.Sprintf(i18n.T("file_already_exists_choose_different"), outputFile)))
 			return
 		}
 	}
@@ -86,22 +111,50 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 	// Set audio options in chat config
 	chatOptions.AudioOutput = isAudioOutput
 	if isAudioOutput {
-		chatOptions.AudioFormat = "wav" // Default to WAV format
+		// The output file extension selects the audio format
+		if chatOptions.AudioFormat = ai.SpeechFormat(currentFlags.Output); chatOptions.AudioFormat == "" {
+			err = domain.WithCode(domain.ErrorCodeUnsupported, fmt.Errorf("%s", fmt.Sprintf(i18n.T("speech_format_not_supported"), filepath.Ext(currentFlags.Output))))
+			return
+		}
 	}
 
-	if session, err = chatter.Send(context.Background(), chatReq, chatOptions); err != nil {
+	if output != nil {
+		output.start(chatOptions)
+	}
+	session, err = chatter.Send(context.Background(), chatReq, chatOptions)
+	if output != nil {
+		output.stop(chatOptions)
+	}
+	if err != nil {
 		return
 	}
 
-	result := session.GetLastMessage().Content
+	result := session.GetLastMessage().TextContent()
 
-	if !currentFlags.Stream || currentFlags.SuppressThink {
-		// For TTS models with audio output, show a user-friendly message instead of raw data
-		if isTTSModel && isAudioOutput && strings.HasPrefix(result, "FABRIC_AUDIO_DATA:") {
-			fmt.Printf(i18n.T("tts_audio_generated_successfully"), currentFlags.Output)
-		} else {
-			// print the result if it was not streamed already or suppress-think disabled streaming output
-			fmt.Println(result)
+	// Sources cited by the answer are shown, copied and written as footnotes
+	footnotes := domain.FormatCitationFootnotes(session.GetLastMessage().Citations)
+	if footnotes != "" {
+		result += "\n\n" + footnotes
+	}
+
+	// Structured output is printed at the end, once the output file is written
+	if output == nil {
+		// Reasoning was already printed while streaming
+		if reasoning := session.GetLastMessage().ReasoningContent; reasoning != "" && !currentFlags.Stream && !currentFlags.SuppressThink {
+			fmt.Printf("%s\n\n", util.Dim(os.Stdout, reasoning))
+		}
+
+		if !currentFlags.Stream || currentFlags.SuppressThink {
+			// For TTS models with audio output, show a user-friendly message instead of raw data
+			if isTTSModel && isAudioOutput && strings.HasPrefix(result, ai.AudioDataPrefix) {
+				fmt.Printf(i18n.T("tts_audio_generated_successfully"), currentFlags.Output)
+			} else {
+				// print the result if it was not streamed already or suppress-think disabled streaming output
+				fmt.Println(result)
+			}
+		} else if footnotes != "" {
+			// The answer was streamed already, so only the footnotes are left to print
+			fmt.Printf("\n%s\n", footnotes)
 		}
 	}
 
@@ -121,9 +174,9 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 			// For TTS models, we need to handle audio output differently
 			if isTTSModel && isAudioOutput {
 				// Check if result contains actual audio data
-				if strings.HasPrefix(result, "FABRIC_AUDIO_DATA:") {
+				if strings.HasPrefix(result, ai.AudioDataPrefix) {
 					// Extract the binary audio data
-					audioData := result[len("FABRIC_AUDIO_DATA:"):]
+					audioData := strings.TrimPrefix(result, ai.AudioDataPrefix)
 					err = CreateAudioOutputFile([]byte(audioData), currentFlags.Output)
 				} else {
 					// Fallback for any error messages or unexpected responses
@@ -133,6 +186,10 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 				err = CreateOutputFile(result, currentFlags.Output)
 			}
 		}
+		if err != nil {
+			err = domain.WithCode(domain.ErrorCodeOutput, err)
+			return
+		}
 	}
 
 	// Send notification if requested
@@ -143,6 +200,10 @@ func handleChatProcessing(currentFlags *Flags, registry *core.PluginRegistry, me
 		}
 	}
 
+	if output != nil {
+		err = output.writeResult(output.result(session.GetLastMessage(), chatter.VendorName(), chatter.Model(),
+			chatReq.PatternName, chatReq.SessionName, currentFlags.warnings))
+	}
 	return
 }
 
@@ -192,11 +253,3 @@ func sendNotification(options *domain.ChatOptions, patternName, result string) e
 
 	return notificationManager.Send(title, message)
 }
-
-// isTTSModel checks if the model is a text-to-speech model
-func isTTSModel(modelName string) bool {
-	lowerModel := strings.ToLower(modelName)
-	return strings.Contains(lowerModel, "tts") ||
-		strings.Contains(lowerModel, "preview-tts") ||
-		strings.Contains(lowerModel, "text-to-speech")
-}
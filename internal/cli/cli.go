@@ -2,30 +2,55 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
 	"github.com/danielmiessler/fabric/internal/tools/converter"
 	"github.com/danielmiessler/fabric/internal/tools/youtube"
+	"github.com/jessevdk/go-flags"
 )
 
 // Cli Controls the cli. It takes in the flags and runs the appropriate functions
 func Cli(version string) (err error) {
 	var currentFlags *Flags
+
+	// Errors are printed in the requested output format, with a code scripts can rely on
+	outputFormat := parseOutputFormat(os.Args[1:])
+	defer func() {
+		if err != nil && !flags.WroteHelp(err) && !IsReported(err) {
+			err = writeJSONError(os.Stdout, outputFormat, err)
+		}
+	}()
+
 	if currentFlags, err = Init(); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidArguments, err)
 		return
 	}
+	outputFormat = currentFlags.OutputFormat
 
 	// initialize internationalization using requested language
 	if _, err = i18n.Init(currentFlags.Language); err != nil {
 		return
 	}
 
-	if currentFlags.Setup {
+	if err = currentFlags.validateOutputFormat(); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidArguments, err)
+		return
+	}
+
+	if currentFlags.NonInteractive && !currentFlags.Setup {
+		err = domain.WithCode(domain.ErrorCodeInvalidArguments, errors.New(i18n.T("non_interactive_requires_setup")))
+		return
+	}
+
+	if currentFlags.Setup || currentFlags.configCommand {
 		if err = ensureEnvFile(); err != nil {
 			return
 		}
@@ -38,15 +63,24 @@ func Cli(version string) (err error) {
 
 	// Initialize database and registry
 	var registry, err2 = initializeFabric()
+	if currentFlags.Doctor || currentFlags.DoctorJSON {
+		return handleDoctor(currentFlags, registry, err2)
+	}
+	if currentFlags.configCommand {
+		if registry == nil {
+			return domain.WithCode(domain.ErrorCodeNotConfigured, err2)
+		}
+		return handleConfigCommand(currentFlags, registry)
+	}
 	if err2 != nil {
+		// Scripts reading structured output cannot answer the setup questions
+		if registry == nil || (currentFlags.structuredOutput() && !currentFlags.Setup) {
+			return domain.WithCode(domain.ErrorCodeNotConfigured, err2)
+		}
 		if !currentFlags.Setup {
 			debuglog.Log("%s\n", err2.Error())
 			currentFlags.Setup = true
 		}
-		// Return early if registry is nil to prevent panics in subsequent handlers
-		if registry == nil {
-			return err2
-		}
 	}
 
 	// Configure OpenAI Responses API setting based on CLI flag
@@ -80,19 +114,47 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	// Handle provider batch commands
+	if handled, err = handleProviderBatchCommands(currentFlags, registry); err != nil || handled {
+		return
+	}
+
+	// Add the files of --input-file and --input-glob to the message
+	if err = currentFlags.appendInputFiles(); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidArguments, err)
+		return
+	}
+
+	// Run the pattern over many inputs
+	if currentFlags.Batch != "" {
+		err = handleBatch(currentFlags, registry)
+		return
+	}
+
+	// Run the pattern again whenever the watched file changes
+	if currentFlags.Watch != "" {
+		err = handleWatch(currentFlags, registry)
+		return
+	}
+
 	// Handle transcription if specified
 	if currentFlags.TranscribeFile != "" {
 		var transcriptionMessage string
 		if transcriptionMessage, err = handleTranscription(currentFlags, registry); err != nil {
 			return
 		}
+		// Subtitles and JSON transcripts are the result unless there is something to chat about
+		if transcribeFormat(currentFlags) != domain.TranscriptFormatText && !currentFlags.IsChatRequest() {
+			err = currentFlags.WriteOutput(transcriptionMessage)
+			return
+		}
 		currentFlags.Message = AppendMessage(currentFlags.Message, transcriptionMessage)
 	}
 
 	// Process HTML readability if needed
 	if currentFlags.HtmlReadability {
 		if msg, cleanErr := converter.HtmlReadability(currentFlags.Message); cleanErr != nil {
-			fmt.Println(i18n.T("html_readability_error"), cleanErr)
+			currentFlags.warn(fmt.Sprintf("%s %v", i18n.T("html_readability_error"), cleanErr))
 		} else {
 			currentFlags.Message = msg
 		}
@@ -104,11 +166,23 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	// Chat interactively, starting with the message built so far
+	if currentFlags.Interactive {
+		err = handleInteractive(currentFlags, registry, messageTools)
+		return
+	}
+
 	// Return early for non-chat tool operations
 	if messageTools != "" && !currentFlags.IsChatRequest() {
 		return nil
 	}
 
+	// Compare the answers of several models instead of chatting with one
+	if len(currentFlags.Compare) > 0 {
+		err = handleCompare(currentFlags, registry, messageTools)
+		return
+	}
+
 	// Handle chat processing
 	err = handleChatProcessing(currentFlags, registry, messageTools)
 	return
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// Layouts of the --compare results in the terminal.
+const (
+	compareLayoutSequential = "sequential"
+	compareLayoutSideBySide = "side-by-side"
+)
+
+// compareColumnGap separates the columns of the side-by-side layout.
+const compareColumnGap = " │ "
+
+// modelSpec is a model to run, with the vendor that serves it when one was named.
+type modelSpec struct {
+	vendor string
+	model  string
+}
+
+// parseModelSpecs splits --compare entries, which may be repeated or comma-separated,
+// into vendor|model pairs. Entries without a vendor may be model aliases.
+func (o *Flags) parseModelSpecs(entries []string) (ret []modelSpec) {
+	for _, entry := range entries {
+		for spec := range strings.SplitSeq(entry, ",") {
+			if spec = strings.TrimSpace(spec); spec != "" {
+				ret = append(ret, o.parseModelSpec(spec))
+			}
+		}
+	}
+	return
+}
+
+// parseModelSpec parses one vendor|model entry, resolving model aliases.
+func (o *Flags) parseModelSpec(spec string) modelSpec {
+	if vendor, model, found := strings.Cut(spec, "|"); found {
+		return modelSpec{vendor: strings.TrimSpace(vendor), model: strings.TrimSpace(model)}
+	}
+	if alias, ok := o.Aliases[spec]; ok {
+		return modelSpec{vendor: alias.Vendor, model: alias.Model}
+	}
+	return modelSpec{model: spec}
+}
+
+// handleCompare runs the request through every --compare model concurrently, optionally
+// has the --judge model rank the answers, prints them and writes the report to --output
+// as JSON or Markdown, chosen by the file extension.
+func handleCompare(flags *Flags, registry *core.PluginRegistry, messageTools string) (err error) {
+	if messageTools != "" {
+		flags.AppendMessage(messageTools)
+	}
+	layout := strings.ToLower(flags.CompareLayout)
+	if layout != compareLayoutSequential && layout != compareLayoutSideBySide {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("compare_invalid_layout"), flags.CompareLayout))
+		return
+	}
+	specs := flags.parseModelSpecs(flags.Compare)
+	if len(specs) < 2 {
+		err = errors.New(i18n.T("compare_needs_two_models"))
+		return
+	}
+	if flags.Output != "" && IsAudioFormat(flags.Output) {
+		err = errors.New(i18n.T("compare_audio_output_not_supported"))
+		return
+	}
+
+	var chatReq *domain.ChatRequest
+	if chatReq, err = flags.BuildChatRequest(strings.Join(os.Args[1:], " ")); err != nil {
+		return
+	}
+	if chatReq.Language == "" {
+		chatReq.Language = registry.Language.DefaultLanguage.Value
+	}
+	var chatOptions *domain.ChatOptions
+	if chatOptions, err = flags.BuildChatOptions(); err != nil {
+		return
+	}
+
+	chatters := make([]*core.Chatter, len(specs))
+	for i, spec := range specs {
+		if chatters[i], err = registry.GetChatter(spec.model, flags.ModelContextLength, spec.vendor, true, flags.DryRun); err != nil {
+			return
+		}
+		if err = chatters[i].ValidateRequest(chatReq, chatOptions); err != nil {
+			return
+		}
+	}
+
+	var judge *core.Chatter
+	if flags.Judge != "" {
+		spec := flags.parseModelSpec(flags.Judge)
+		if judge, err = registry.GetChatter(spec.model, flags.ModelContextLength, spec.vendor, true, flags.DryRun); err != nil {
+			return
+		}
+	}
+
+	ctx := context.Background()
+	var report *core.CompareReport
+	if report, err = core.Compare(ctx, chatters, chatReq, chatOptions); err != nil {
+		return
+	}
+	if judge != nil {
+		// The judge only gets the options that shape its answer, not those of the task
+		judgeOptions := &domain.ChatOptions{
+			Temperature:   chatOptions.Temperature,
+			TopP:          chatOptions.TopP,
+			SuppressThink: true,
+			ThinkStartTag: chatOptions.ThinkStartTag,
+			ThinkEndTag:   chatOptions.ThinkEndTag,
+			NoPromptCache: chatOptions.NoPromptCache,
+		}
+		report.Judge = judge.Judge(ctx, report, judgeOptions)
+	}
+
+	printCompareReport(report, layout)
+
+	if flags.Output != "" {
+		content := report.Markdown()
+		if strings.EqualFold(filepath.Ext(flags.Output), ".json") {
+			var data []byte
+			if data, err = json.MarshalIndent(report, "", "  "); err != nil {
+				return
+			}
+			content = string(data)
+		}
+		if err = CreateOutputFile(content, flags.Output); err != nil {
+			return
+		}
+	}
+
+	if len(report.Succeeded()) == 0 {
+		err = errors.New(i18n.T("compare_all_models_failed"))
+	}
+	return
+}
+
+// printCompareReport prints every answer, labeled with its model and statistics, and
+// the judge's ranking.
+func printCompareReport(report *core.CompareReport, layout string) {
+	if layout == compareLayoutSideBySide {
+		printSideBySide(report)
+	} else {
+		for i := range report.Results {
+			result := &report.Results[i]
+			fmt.Printf("=== %s ===\n", compareHeading(result))
+			if result.Error != "" {
+				fmt.Printf("%s\n\n", fmt.Sprintf(i18n.T("compare_report_error"), result.Error))
+			} else {
+				fmt.Printf("%s\n\n", strings.TrimSpace(result.Output))
+			}
+		}
+	}
+
+	if judge := report.Judge; judge != nil {
+		fmt.Printf("=== %s ===\n", fmt.Sprintf(i18n.T("compare_report_judge"), judge.Vendor+"|"+judge.Model))
+		if judge.Error != "" {
+			fmt.Println(judge.Error)
+		}
+		for _, ranking := range judge.Ranking {
+			fmt.Printf("%d. %s|%s (%g/10): %s\n", ranking.Rank, ranking.Vendor, ranking.Model, ranking.Score, ranking.Reason)
+		}
+		if judge.Summary != "" {
+			fmt.Printf("\n%s\n", judge.Summary)
+		}
+	}
+}
+
+// compareHeading labels a result with its latency, token usage and cost.
+func compareHeading(result *core.CompareResult) string {
+	stats := []string{core.FormatLatency(result.LatencyMs)}
+	if result.Usage != nil {
+		stats = append(stats, fmt.Sprintf(i18n.T("compare_tokens"), result.Usage.InputTokens, result.Usage.OutputTokens))
+	}
+	if result.CostUSD != nil {
+		stats = append(stats, core.FormatCost(result.CostUSD))
+	}
+	return fmt.Sprintf("%s (%s)", result.Label(), strings.Join(stats, ", "))
+}
+
+// printSideBySide prints the answers in columns that share the terminal width, taken
+// from the COLUMNS environment variable.
+func printSideBySide(report *core.CompareReport) {
+	width := 160
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		width = columns
+	}
+	count := len(report.Results)
+	columnWidth := max((width-(count-1)*len([]rune(compareColumnGap)))/count, 20)
+
+	columns := make([][]string, count)
+	rows := 0
+	for i := range report.Results {
+		result := &report.Results[i]
+		text := strings.TrimSpace(result.Output)
+		if result.Error != "" {
+			text = fmt.Sprintf(i18n.T("compare_report_error"), result.Error)
+		}
+		lines := wrapText(compareHeading(result), columnWidth)
+		lines = append(lines, strings.Repeat("─", columnWidth))
+		lines = append(lines, wrapText(text, columnWidth)...)
+		columns[i] = lines
+		rows = max(rows, len(lines))
+	}
+
+	for row := range rows {
+		cells := make([]string, count)
+		for i, lines := range columns {
+			cell := ""
+			if row < len(lines) {
+				cell = lines[row]
+			}
+			cells[i] = cell + strings.Repeat(" ", columnWidth-len([]rune(cell)))
+		}
+		fmt.Println(strings.TrimRight(strings.Join(cells, compareColumnGap), " "))
+	}
+	fmt.Println()
+}
+
+// wrapText breaks text into lines of at most width runes, at spaces where possible.
+func wrapText(text string, width int) (ret []string) {
+	for paragraph := range strings.SplitSeq(text, "\n") {
+		line := []rune{}
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			for len(runes) > width {
+				if len(line) > 0 {
+					ret = append(ret, string(line))
+					line = line[:0]
+				}
+				ret = append(ret, string(runes[:width]))
+				runes = runes[width:]
+			}
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				ret = append(ret, string(line))
+				line = line[:0]
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, runes...)
+		}
+		ret = append(ret, string(line))
+	}
+	return
+}
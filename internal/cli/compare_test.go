@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModelSpecs(t *testing.T) {
+	flags := &Flags{Aliases: ModelAliases{"fast": {Vendor: "Groq", Model: "llama-3.1-8b-instant"}}}
+
+	specs := flags.parseModelSpecs([]string{"OpenAI|gpt-4o, Anthropic|claude-sonnet-4-5", "fast", " gemini-2.5-pro ", ""})
+	require.Len(t, specs, 4)
+	assert.Equal(t, modelSpec{vendor: "OpenAI", model: "gpt-4o"}, specs[0])
+	assert.Equal(t, modelSpec{vendor: "Anthropic", model: "claude-sonnet-4-5"}, specs[1])
+	assert.Equal(t, modelSpec{vendor: "Groq", model: "llama-3.1-8b-instant"}, specs[2], "aliases are resolved")
+	assert.Equal(t, modelSpec{model: "gemini-2.5-pro"}, specs[3])
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("the quick brown fox\n\nsupercalifragilistic", 10)
+	assert.Equal(t, []string{"the quick", "brown fox", "", "supercalif", "ragilistic"}, lines)
+}
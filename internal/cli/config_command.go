@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// handleConfigCommand runs fabric config, which sets, gets, lists and unsets the settings
+// of vendors and tools without the interactive setup:
+//
+//	fabric config set <PLUGIN>.<SETTING> [VALUE]   (the value is read from stdin when left out)
+//	fabric config get <PLUGIN>.<SETTING>
+//	fabric config list [PLUGIN]
+//	fabric config unset <PLUGIN>.<SETTING>
+func handleConfigCommand(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	args := currentFlags.configArgs
+	if len(args) == 0 {
+		return configUsageError()
+	}
+	command, args := args[0], args[1:]
+
+	var setting core.ConfigSetting
+	switch {
+	case command == "set" && (len(args) == 1 || len(args) == 2):
+		var value string
+		if value, err = configValue(args); err != nil {
+			return
+		}
+		if value == "" {
+			return domain.WithCode(domain.ErrorCodeInvalidArguments, errors.New(i18n.T("config_empty_value")))
+		}
+		if setting, err = registry.SetConfig(args[0], value); err != nil {
+			return
+		}
+		fmt.Printf("%s\n", fmt.Sprintf(i18n.T("config_set_done"), setting.Key, setting.EnvVariable))
+	case command == "get" && len(args) == 1:
+		if setting, err = registry.FindConfigSetting(args[0]); err != nil {
+			return
+		}
+		value := setting.CurrentValue()
+		if value == "" {
+			return domain.WithCode(domain.ErrorCodeNotConfigured, fmt.Errorf("%s", fmt.Sprintf(i18n.T("config_not_set"), setting.Key)))
+		}
+		fmt.Println(value)
+	case command == "list" && len(args) <= 1:
+		settings := registry.ConfigSettings()
+		all := len(args) == 1
+		if all {
+			if settings = filterConfigSettings(settings, args[0]); len(settings) == 0 {
+				return domain.WithCode(domain.ErrorCodeInvalidArguments, fmt.Errorf("%s", fmt.Sprintf(i18n.T("config_unknown_plugin"), args[0])))
+			}
+		}
+		writeConfigSettings(os.Stdout, settings, all, fsdb.MaskSecret)
+	case command == "unset" && len(args) == 1:
+		if setting, err = registry.SetConfig(args[0], ""); err != nil {
+			return
+		}
+		fmt.Printf("%s\n", fmt.Sprintf(i18n.T("config_unset_done"), setting.Key, setting.EnvVariable))
+	default:
+		return configUsageError()
+	}
+	return
+}
+
+// configUsageError tells how fabric config is used.
+func configUsageError() error {
+	return domain.WithCode(domain.ErrorCodeInvalidArguments, errors.New(i18n.T("config_usage")))
+}
+
+// configValue returns the value given to fabric config set, or reads it from stdin, so
+// that secrets need not appear in the shell history.
+func configValue(args []string) (ret string, err error) {
+	if len(args) == 2 {
+		return strings.TrimSpace(args[1]), nil
+	}
+	if info, statErr := os.Stdin.Stat(); statErr != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return "", domain.WithCode(domain.ErrorCodeInvalidArguments, errors.New(i18n.T("config_value_missing")))
+	}
+	if ret, err = readStdin(); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(ret)
+	return
+}
+
+// filterConfigSettings returns the settings of the plugin named by name or by the first
+// part of the keys of its settings.
+func filterConfigSettings(settings []core.ConfigSetting, name string) (ret []core.ConfigSetting) {
+	prefix := plugins.BuildEnvVariable(name) + "."
+	for _, setting := range settings {
+		if strings.EqualFold(setting.Plugin, name) || strings.HasPrefix(setting.Key, prefix) {
+			ret = append(ret, setting)
+		}
+	}
+	return
+}
+
+// writeConfigSettings writes the settings and their values, masking sensitive values.
+// Unless all is set, only the settings the user set are written, leaving out defaults.
+func writeConfigSettings(w io.Writer, settings []core.ConfigSetting, all bool, mask func(value string) string) {
+	if !all {
+		settings = slices.DeleteFunc(slices.Clone(settings), func(setting core.ConfigSetting) bool { return !setting.IsSet() })
+	}
+	width := 0
+	for _, setting := range settings {
+		width = max(width, len(setting.Key))
+	}
+	for _, setting := range settings {
+		value := setting.CurrentValue()
+		switch {
+		case value == "" && setting.Required:
+			value = i18n.T("config_value_required_not_set")
+		case value == "":
+			value = i18n.T("config_value_not_set")
+		case setting.Sensitive && setting.IsSet():
+			value = mask(value)
+		}
+		fmt.Fprintf(w, "%-*s  %s\n", width, setting.Key, value)
+	}
+}
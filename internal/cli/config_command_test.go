@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteConfigSettings(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "sk-123456789")
+	t.Setenv("TEST_API_BASE_URL", "")
+	t.Setenv("OTHER_MODEL", "")
+	settings := []core.ConfigSetting{
+		{Setting: &plugins.Setting{EnvVariable: "TEST_API_KEY", Required: true, Sensitive: true}, Key: "TEST.API_KEY", Plugin: "Test"},
+		{Setting: &plugins.Setting{EnvVariable: "TEST_API_BASE_URL", Value: "https://example.com"}, Key: "TEST.API_BASE_URL", Plugin: "Test"},
+		{Setting: &plugins.Setting{EnvVariable: "OTHER_MODEL", Required: true}, Key: "OTHER.MODEL", Plugin: "Other"},
+	}
+	mask := func(string) string { return "********6789" }
+
+	// Only what the user set is listed, with secrets masked
+	var out bytes.Buffer
+	writeConfigSettings(&out, settings, false, mask)
+	assert.Equal(t, "TEST.API_KEY  ********6789\n", out.String())
+
+	// The settings of a plugin are listed with their defaults and what is missing
+	out.Reset()
+	writeConfigSettings(&out, filterConfigSettings(settings, "test"), true, mask)
+	assert.Equal(t, "TEST.API_KEY       ********6789\nTEST.API_BASE_URL  https://example.com\n", out.String())
+
+	out.Reset()
+	writeConfigSettings(&out, filterConfigSettings(settings, "Other"), true, mask)
+	assert.Equal(t, "OTHER.MODEL  (required, not set)\n", out.String())
+
+	assert.Empty(t, filterConfigSettings(settings, "nope"))
+}
@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/i18n"
 )
 
 // handleConfigurationCommands handles configuration-related commands
@@ -24,5 +28,17 @@ func handleConfigurationCommands(currentFlags *Flags, registry *core.PluginRegis
 		return true, err
 	}
 
+	if currentFlags.MigrateSecrets != "" {
+		var moved []string
+		if moved, err = registry.MigrateSecrets(strings.ToLower(currentFlags.MigrateSecrets)); err != nil {
+			return true, err
+		}
+		fmt.Printf("%s\n", fmt.Sprintf(i18n.T("secrets_migrated"), len(moved), registry.Db.SecretsFilePath))
+		for _, name := range moved {
+			fmt.Printf("  %s\n", name)
+		}
+		return true, nil
+	}
+
 	return false, nil
 }
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// doctorSymbols mark the status of each check in the text report
+var doctorSymbols = map[core.DoctorStatus]string{
+	core.DoctorPass: "✓",
+	core.DoctorWarn: "⚠",
+	core.DoctorFail: "✗",
+}
+
+// handleDoctor runs the diagnostics and prints the report as text or JSON. When Fabric
+// failed to initialize, that error is reported along with the checks that need no
+// configuration. It returns an error when any check failed.
+func handleDoctor(flags *Flags, registry *core.PluginRegistry, initErr error) (err error) {
+	report := &core.DoctorReport{}
+	if initErr != nil {
+		report.Add(core.DoctorCategoryConfig, "fabric", core.DoctorFail, initErr.Error())
+	}
+	if registry != nil {
+		registry.Doctor(context.Background(), report)
+	} else {
+		core.CheckBinaries(report)
+	}
+
+	if flags.DoctorJSON || flags.structuredOutput() {
+		var data []byte
+		if data, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Println(i18n.T("doctor_header"))
+		for _, check := range report.Checks {
+			fmt.Printf("  %s [%s] %s: %s\n", doctorSymbols[check.Status], check.Category, check.Name, check.Message)
+		}
+		fmt.Printf("\n%s\n", fmt.Sprintf(i18n.T("doctor_summary"), report.Passed, report.Warned, report.Failed))
+	}
+
+	if report.Failed > 0 {
+		err = domain.WithCode(domain.ErrorCodeChecksFailed, errors.New(i18n.T("doctor_checks_failed")))
+		if flags.structuredOutput() {
+			// The report tells which checks failed
+			err = &reportedError{err}
+		}
+	}
+	return
+}
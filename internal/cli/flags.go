@@ -28,10 +28,15 @@ import (
 type Flags struct {
 	Pattern                         string               `short:"p" long:"pattern" yaml:"pattern" description:"Choose a pattern from the available patterns" default:""`
 	PatternVariables                map[string]string    `short:"v" long:"variable" description:"Values for pattern variables, e.g. -v=#role:expert -v=#points:30"`
-	Context                         string               `short:"C" long:"context" description:"Choose a context from the available contexts" default:""`
+	Context                         []string             `short:"C" long:"context" description:"Choose a context by name, file path or URL; repeat to stack several contexts in order"`
 	Session                         string               `long:"session" description:"Choose a session from the available sessions"`
-	Attachments                     []string             `short:"a" long:"attachment" description:"Attachment path or URL (e.g. for OpenAI image recognition messages)"`
+	Attachments                     []string             `short:"a" long:"attachment" description:"Attachment path or URL: image, audio, PDF, DOCX, HTML, CSV or text file"`
+	InputFile                       []string             `long:"input-file" description:"Text file or directory to add to the input, each file under a header with its path; repeat for more"`
+	InputGlob                       []string             `long:"input-glob" description:"Glob pattern of text files to add to the input, where ** matches any number of directories; repeat for more"`
+	InputMaxTokens                  int                  `long:"input-max-tokens" yaml:"inputMaxTokens" description:"Token budget of the --input-file and --input-glob files, 0 for no limit" default:"100000"`
+	InputMaxFileSize                int64                `long:"input-max-file-size" yaml:"inputMaxFileSize" description:"Size in bytes above which --input-file and --input-glob files are skipped, 0 for no limit" default:"1048576"`
 	Setup                           bool                 `short:"S" long:"setup" description:"Run setup for all reconfigurable parts of fabric"`
+	NonInteractive                  bool                 `long:"non-interactive" description:"With --setup, configure the vendor given by --vendor and the model given by --model from environment variables, without asking questions"`
 	Temperature                     float64              `short:"t" long:"temperature" yaml:"temperature" description:"Set temperature" default:"0.7"`
 	TopP                            float64              `short:"T" long:"topp" yaml:"topp" description:"Set top P" default:"0.9"`
 	Stream                          bool                 `short:"s" long:"stream" yaml:"stream" description:"Stream"`
@@ -41,6 +46,7 @@ type Flags struct {
 	ListPatterns                    bool                 `short:"l" long:"listpatterns" description:"List all patterns"`
 	ReadPattern                     string               `long:"readpattern" description:"Print the contents of the named pattern to the terminal"`
 	ListAllModels                   bool                 `short:"L" long:"listmodels" description:"List all available models"`
+	ShowModelCapabilities           bool                 `long:"capabilities" description:"Show model capabilities (context window, vision, thinking, search, ...) with --listmodels"`
 	ListAllContexts                 bool                 `short:"x" long:"listcontexts" description:"List all contexts"`
 	ListAllSessions                 bool                 `short:"X" long:"listsessions" description:"List all sessions"`
 	UpdatePatterns                  bool                 `short:"U" long:"updatepatterns" description:"Update patterns"`
@@ -48,11 +54,17 @@ type Flags struct {
 	Copy                            bool                 `short:"c" long:"copy" description:"Copy to clipboard"`
 	Model                           string               `short:"m" long:"model" yaml:"model" description:"Choose model"`
 	Vendor                          string               `short:"V" long:"vendor" yaml:"vendor" description:"Specify vendor for the selected model (e.g., -V \"LM Studio\" -m openai/gpt-oss-20b)"`
+	Compare                         []string             `long:"compare" description:"Run the request through several models concurrently and compare the answers; takes vendor|model entries, repeated or comma-separated"`
+	Judge                           string               `long:"judge" description:"Have this vendor|model rank the --compare answers"`
+	CompareLayout                   string               `long:"compare-layout" description:"Show --compare answers sequential or side-by-side" default:"sequential"`
+	Interactive                     bool                 `long:"interactive" description:"Chat interactively in the terminal, with slash commands and the conversation kept in a session"`
 	ModelContextLength              int                  `long:"modelContextLength" yaml:"modelContextLength" description:"Model context length (only affects ollama)"`
 	Output                          string               `short:"o" long:"output" description:"Output to file" default:""`
 	OutputSession                   bool                 `long:"output-session" description:"Output the entire session (also a temporary one) to the output file"`
+	OutputFormat                    string               `long:"output-format" description:"Print the result as text, a json object, or jsonl stream events (default: text)"`
 	LatestPatterns                  string               `short:"n" long:"latest" description:"Number of latest patterns to list" default:"0"`
 	ChangeDefaultModel              bool                 `short:"d" long:"changeDefaultModel" description:"Change default model"`
+	MigrateSecrets                  string               `long:"migrate-secrets" description:"Move keys, tokens and secrets from .env to the encrypted secret store, unlocked by passphrase, keyfile or keyring"`
 	YouTube                         string               `short:"y" long:"youtube" description:"YouTube video or play list \"URL\" to grab transcript, comments from it and send to chat or print it put to the console and store it in the output file"`
 	YouTubePlaylist                 bool                 `long:"playlist" description:"Prefer playlist over video if both ids are present in the URL"`
 	YouTubeTranscript               bool                 `long:"transcript" description:"Grab transcript from YouTube video and send to chat (it is used per default)."`
@@ -81,6 +93,9 @@ type Flags struct {
 	ServeAddress                    string               `long:"address" description:"The address to bind the REST API" default:":8080"`
 	ServeAPIKey                     string               `long:"api-key" description:"API key used to secure server routes" default:""`
 	Config                          string               `long:"config" description:"Path to YAML config file"`
+	Profile                         string               `long:"profile" yaml:"profile" description:"Apply a named profile from the config file (vendor, model, temperature, top_p, thinking, strategy, language)"`
+	Profiles                        map[string]Profile   `yaml:"profiles"`
+	Aliases                         ModelAliases         `yaml:"aliases"`
 	Version                         bool                 `long:"version" description:"Print current version"`
 	ListExtensions                  bool                 `long:"listextensions" description:"List all registered extensions"`
 	AddExtension                    string               `long:"addextension" description:"Register a new extension from config file path"`
@@ -88,29 +103,61 @@ type Flags struct {
 	Strategy                        string               `long:"strategy" description:"Choose a strategy from the available strategies" default:""`
 	ListStrategies                  bool                 `long:"liststrategies" description:"List all strategies"`
 	ListVendors                     bool                 `long:"listvendors" description:"List all vendors"`
+	Doctor                          bool                 `long:"doctor" description:"Check the configuration and connectivity of vendors and tools and report problems"`
+	DoctorJSON                      bool                 `long:"doctor-json" description:"Print the --doctor report as JSON"`
 	ShellCompleteOutput             bool                 `long:"shell-complete-list" description:"Output raw list without headers/formatting (for shell completion)"`
-	Search                          bool                 `long:"search" description:"Enable web search tool for supported models (Anthropic, OpenAI, Gemini, Grok)"`
+	Search                          bool                 `long:"search" description:"Enable web search: native for supported models, otherwise the configured web search backend"`
 	SearchLocation                  string               `long:"search-location" description:"Set location for web search results (e.g., 'America/Los_Angeles')"`
-	ImageFile                       string               `long:"image-file" description:"Save generated image to specified file path (e.g., 'output.png')"`
+	SearchQuery                     string               `long:"search-query" description:"Query for the web search backend (defaults to the input)"`
+	ImageFile                       string               `long:"image-file" description:"Save generated image to specified file path (e.g., 'output.png'); image attachments are edited"`
 	ImageSize                       string               `long:"image-size" description:"Image dimensions: 1024x1024, 1536x1024, 1024x1536, auto (default: auto)"`
 	ImageQuality                    string               `long:"image-quality" description:"Image quality: low, medium, high, auto (default: auto)"`
 	ImageCompression                int                  `long:"image-compression" description:"Compression level 0-100 for JPEG/WebP formats (default: not set)"`
 	ImageBackground                 string               `long:"image-background" description:"Background type: opaque, transparent (default: opaque, only for PNG/WebP)"`
-	SuppressThink                   bool                 `long:"suppress-think" yaml:"suppressThink" description:"Suppress text enclosed in thinking tags"`
+	SuppressThink                   bool                 `long:"suppress-think" yaml:"suppressThink" description:"Hide model reasoning, including text enclosed in thinking tags"`
 	ThinkStartTag                   string               `long:"think-start-tag" yaml:"thinkStartTag" description:"Start tag for thinking sections" default:"<think>"`
 	ThinkEndTag                     string               `long:"think-end-tag" yaml:"thinkEndTag" description:"End tag for thinking sections" default:"</think>"`
 	DisableResponsesAPI             bool                 `long:"disable-responses-api" yaml:"disableResponsesAPI" description:"Disable OpenAI Responses API (default: false)"`
 	TranscribeFile                  string               `long:"transcribe-file" yaml:"transcribeFile" description:"Audio or video file to transcribe"`
 	TranscribeModel                 string               `long:"transcribe-model" yaml:"transcribeModel" description:"Model to use for transcription (separate from chat model)"`
+	TranscribeFormat                string               `long:"transcribe-format" yaml:"transcribeFormat" description:"Transcription output format: text, srt, vtt, json (default: text)"`
 	SplitMediaFile                  bool                 `long:"split-media-file" yaml:"splitMediaFile" description:"Split audio/video files larger than 25MB using ffmpeg"`
-	Voice                           string               `long:"voice" yaml:"voice" description:"TTS voice name for supported models (e.g., Kore, Charon, Puck)" default:"Kore"`
+	Voice                           string               `long:"voice" yaml:"voice" description:"TTS voice name for supported models (e.g., Kore, Charon, Puck for Gemini; alloy, nova, onyx for OpenAI)"`
 	ListGeminiVoices                bool                 `long:"list-gemini-voices" description:"List all available Gemini TTS voices"`
 	ListTranscriptionModels         bool                 `long:"list-transcription-models" description:"List all available transcription models"`
 	Notification                    bool                 `long:"notification" yaml:"notification" description:"Send desktop notification when command completes"`
 	NotificationCommand             string               `long:"notification-command" yaml:"notificationCommand" description:"Custom command to run for notifications (overrides built-in notifications)"`
 	Thinking                        domain.ThinkingLevel `long:"thinking" yaml:"thinking" description:"Set reasoning/thinking level (e.g., off, low, medium, high, or numeric tokens for Anthropic or Google Gemini)"`
 	ShowMetadata                    bool                 `long:"show-metadata" description:"Print metadata (input/output tokens) to stderr"`
+	NoPromptCache                   bool                 `long:"no-prompt-cache" yaml:"noPromptCache" description:"Disable automatic prompt caching of patterns, contexts and session history (Anthropic)"`
+	Chunk                           bool                 `long:"chunk" yaml:"chunk" description:"Split inputs larger than the model's context window, run the pattern over each chunk and merge the partial results"`
+	ChunkSize                       int                  `long:"chunk-size" yaml:"chunkSize" description:"Chunk size in tokens for --chunk (default: derived from the model's context window)"`
+	ChunkOverlap                    int                  `long:"chunk-overlap" yaml:"chunkOverlap" description:"Tokens repeated from the end of each chunk at the start of the next" default:"200"`
+	MergePattern                    string               `long:"merge-pattern" yaml:"mergePattern" description:"Pattern merging the partial results of --chunk (default: the pattern itself)"`
+	ApplyChanges                    bool                 `long:"apply-changes" description:"Offer the file changes the answer proposes to be applied, as patterns with apply_changes in their front matter do"`
+	Yes                             bool                 `long:"yes" description:"Apply proposed file changes without asking for confirmation"`
+	ForceChanges                    bool                 `long:"force-changes" description:"Apply file changes to files with uncommitted changes or outside the project"`
+	Rollback                        string               `long:"rollback" description:"Undo applied file changes, given the snapshot ID they printed or latest"`
+	BatchSubmit                     string               `long:"batch-submit" description:"Submit a JSONL file of inputs ({\"id\", \"input\", \"variables\"} per line) as a provider batch (OpenAI, Anthropic)"`
+	BatchStatus                     string               `long:"batch-status" description:"Show the status of a submitted provider batch"`
+	BatchFetch                      string               `long:"batch-fetch" description:"Fetch the results of a finished provider batch as JSONL keyed by input ID"`
+	Batch                           string               `long:"batch" description:"Run the pattern over every file of a directory or glob, or every line of a JSONL file, writing one output per item"`
+	BatchWorkers                    int                  `long:"batch-workers" description:"Number of --batch items processed at the same time" default:"4"`
+	BatchOutput                     string               `long:"batch-output" description:"Output path of each --batch item, where {{name}} is the item name" default:"{{name}}.md"`
+	Watch                           string               `long:"watch" description:"Run the pattern over a file, and again whenever the file changes"`
+	WatchDebounce                   int                  `long:"watch-debounce" yaml:"watchDebounce" description:"Milliseconds the --watch file must stay unchanged before the pattern runs again" default:"1000"`
+	WatchDiff                       bool                 `long:"watch-diff" yaml:"watchDiff" description:"Send only the changes since the last run of --watch, continuing the same session"`
 	Debug                           int                  `long:"debug" description:"Set debug level (0=off, 1=basic, 2=detailed, 3=trace, 4=wire)" default:"0"`
+
+	// warnings collects what warn reports for the JSON result
+	warnings []string
+
+	// configArgs are the arguments of fabric config, and configCommand tells whether it runs
+	configArgs    []string
+	configCommand bool
+
+	// cliFlags are the long names of the flags given on the command line
+	cliFlags map[string]bool
 }
 
 // Init Initialize flags. returns a Flags struct and an error
@@ -120,10 +167,20 @@ func Init() (ret *Flags, err error) {
 	usedFlags := make(map[string]bool)
 	yamlArgsScan := os.Args[1:]
 
+	// Track which flags were set on CLI by long name, for profiles and aliases
+	cliFlags := make(map[string]bool)
+
 	// Create mapping from flag names (both short and long) to yaml tag names
 	flagToYamlTag := make(map[string]string)
+	flagToLongTag := make(map[string]string)
 	t := reflect.TypeFor[Flags]()
 	for field := range t.Fields() {
+		if longTag := field.Tag.Get("long"); longTag != "" {
+			flagToLongTag[longTag] = longTag
+			if shortTag := field.Tag.Get("short"); shortTag != "" {
+				flagToLongTag[shortTag] = longTag
+			}
+		}
 		yamlTag := field.Tag.Get("yaml")
 		if yamlTag != "" {
 			longTag := field.Tag.Get("long")
@@ -144,6 +201,9 @@ func Init() (ret *Flags, err error) {
 		flag := extractFlag(arg)
 
 		if flag != "" {
+			if longTag, exists := flagToLongTag[flag]; exists {
+				cliFlags[longTag] = true
+			}
 			if yamlTag, exists := flagToYamlTag[flag]; exists {
 				usedFlags[yamlTag] = true
 				debuglog.Debug(debuglog.Detailed, "CLI flag used: %s (yaml: %s)\n", flag, yamlTag)
@@ -220,6 +280,20 @@ func Init() (ret *Flags, err error) {
 		}
 	}
 
+	// Profiles override the flat config, explicit CLI flags override profiles
+	if err = ret.applyProfile(cliFlags); err != nil {
+		return
+	}
+	ret.cliFlags = cliFlags
+	ret.resolveModelAlias(cliFlags)
+
+	// fabric config manages the settings, and reads a value to set from stdin itself
+	if len(args) > 0 && args[0] == "config" && len(os.Args) > 1 && os.Args[1] == "config" {
+		ret.configArgs = args[1:]
+		ret.configCommand = true
+		return
+	}
+
 	// Handle stdin and messages
 	info, _ := os.Stdin.Stat()
 	pipedToStdin := (info.Mode() & os.ModeCharDevice) == 0
@@ -229,7 +303,9 @@ func Init() (ret *Flags, err error) {
 		ret.Message = AppendMessage(ret.Message, strings.Join(args, " "))
 	}
 
-	if pipedToStdin {
+	// Interactive mode reads its messages and commands from stdin itself, and
+	// batch and watch runs read their inputs from files
+	if pipedToStdin && !ret.Interactive && ret.Batch == "" && ret.Watch == "" {
 		var pipedMessage string
 		if pipedMessage, err = readStdin(); err != nil {
 			return
@@ -463,6 +539,7 @@ func (o *Flags) BuildChatOptions() (ret *domain.ChatOptions, err error) {
 		ModelContextLength:  o.ModelContextLength,
 		Search:              o.Search,
 		SearchLocation:      o.SearchLocation,
+		SearchQuery:         o.SearchQuery,
 		ImageFile:           o.ImageFile,
 		ImageSize:           o.ImageSize,
 		ImageQuality:        o.ImageQuality,
@@ -475,13 +552,19 @@ func (o *Flags) BuildChatOptions() (ret *domain.ChatOptions, err error) {
 		Notification:        o.Notification || o.NotificationCommand != "",
 		NotificationCommand: o.NotificationCommand,
 		ShowMetadata:        o.ShowMetadata,
+		NoPromptCache:       o.NoPromptCache,
+		Chunk:               o.Chunk,
+		ChunkSize:           o.ChunkSize,
+		ChunkOverlap:        o.ChunkOverlap,
+		MergePattern:        o.MergePattern,
+		ApplyChanges:        o.ApplyChanges,
 	}
 	return
 }
 
 func (o *Flags) BuildChatRequest(Meta string) (ret *domain.ChatRequest, err error) {
 	ret = &domain.ChatRequest{
-		ContextName:           o.Context,
+		ContextNames:          o.Context,
 		SessionName:           o.Session,
 		PatternName:           o.Pattern,
 		StrategyName:          o.Strategy,
@@ -509,25 +592,11 @@ func (o *Flags) BuildChatRequest(Meta string) (ret *domain.ChatRequest, err erro
 			if attachment, err = domain.NewAttachment(attachmentValue); err != nil {
 				return
 			}
-			url := attachment.URL
-			if url == nil {
-				var base64Image string
-				if base64Image, err = attachment.Base64Content(); err != nil {
-					return
-				}
-				var mimeType string
-				if mimeType, err = attachment.ResolveType(); err != nil {
-					return
-				}
-				dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image)
-				url = &dataURL
+			var part chat.ChatMessagePart
+			if part, err = attachment.MessagePart(); err != nil {
+				return
 			}
-			message.MultiContent = append(message.MultiContent, chat.ChatMessagePart{
-				Type: chat.ChatMessagePartTypeImageURL,
-				ImageURL: &chat.ChatMessageImageURL{
-					URL: *url,
-				},
-			})
+			message.MultiContent = append(message.MultiContent, part)
 		}
 	} else if o.Message != "" {
 		message = &chat.ChatCompletionMessage{
@@ -551,7 +620,7 @@ func (o *Flags) AppendMessage(message string) {
 }
 
 func (o *Flags) IsChatRequest() (ret bool) {
-	ret = o.Message != "" || len(o.Attachments) > 0 || o.Context != "" || o.Session != "" || o.Pattern != ""
+	ret = o.Message != "" || len(o.Attachments) > 0 || len(o.Context) > 0 || o.Session != "" || o.Pattern != ""
 	return
 }
 
@@ -16,10 +16,15 @@ import (
 var flagDescriptionMap = map[string]string{
 	"pattern":                    "choose_pattern_from_available",
 	"variable":                   "pattern_variables_help",
-	"context":                    "choose_context_from_available",
+	"context":                    "context_help",
 	"session":                    "choose_session_from_available",
 	"attachment":                 "attachment_path_or_url_help",
+	"input-file":                 "input_file_help",
+	"input-glob":                 "input_glob_help",
+	"input-max-tokens":           "input_max_tokens_help",
+	"input-max-file-size":        "input_max_file_size_help",
 	"setup":                      "run_setup_for_reconfigurable_parts",
+	"non-interactive":            "non_interactive_setup_help",
 	"temperature":                "set_temperature",
 	"topp":                       "set_top_p",
 	"stream":                     "stream_help",
@@ -29,17 +34,24 @@ var flagDescriptionMap = map[string]string{
 	"listpatterns":               "list_all_patterns",
 	"readpattern":                "print_pattern_contents",
 	"listmodels":                 "list_all_available_models",
+	"capabilities":               "show_model_capabilities",
 	"listcontexts":               "list_all_contexts",
 	"listsessions":               "list_all_sessions",
 	"updatepatterns":             "update_patterns",
 	"copy":                       "copy_to_clipboard",
 	"model":                      "choose_model",
 	"vendor":                     "specify_vendor_for_model",
+	"compare":                    "compare_models_help",
+	"judge":                      "compare_judge_help",
+	"compare-layout":             "compare_layout_help",
+	"interactive":                "interactive_mode_help",
 	"modelContextLength":         "model_context_length_ollama",
 	"output":                     "output_to_file",
 	"output-session":             "output_entire_session",
+	"output-format":              "output_format_help",
 	"latest":                     "number_of_latest_patterns",
 	"changeDefaultModel":         "change_default_model",
+	"migrate-secrets":            "migrate_secrets_help",
 	"youtube":                    "youtube_url_help",
 	"playlist":                   "prefer_playlist_over_video",
 	"transcript":                 "grab_transcript_from_youtube",
@@ -68,6 +80,7 @@ var flagDescriptionMap = map[string]string{
 	"address":                    "address_to_bind_rest_api",
 	"api-key":                    "api_key_secure_server_routes",
 	"config":                     "path_to_yaml_config",
+	"profile":                    "apply_named_profile",
 	"version":                    "print_current_version",
 	"listextensions":             "list_all_registered_extensions",
 	"addextension":               "register_new_extension",
@@ -75,9 +88,12 @@ var flagDescriptionMap = map[string]string{
 	"strategy":                   "choose_strategy_from_available",
 	"liststrategies":             "list_all_strategies",
 	"listvendors":                "list_all_vendors",
+	"doctor":                     "doctor_help",
+	"doctor-json":                "doctor_json_help",
 	"shell-complete-list":        "output_raw_list_shell_completion",
 	"search":                     "enable_web_search_tool",
 	"search-location":            "set_location_web_search",
+	"search-query":               "web_search_query",
 	"image-file":                 "save_generated_image_to_file",
 	"image-size":                 "image_dimensions_help",
 	"image-quality":              "image_quality_help",
@@ -89,6 +105,7 @@ var flagDescriptionMap = map[string]string{
 	"disable-responses-api":      "disable_openai_responses_api",
 	"transcribe-file":            "audio_video_file_transcribe",
 	"transcribe-model":           "model_for_transcription",
+	"transcribe-format":          "transcription_output_format",
 	"split-media-file":           "split_media_files_ffmpeg",
 	"voice":                      "tts_voice_name",
 	"list-gemini-voices":         "list_gemini_tts_voices",
@@ -97,6 +114,24 @@ var flagDescriptionMap = map[string]string{
 	"notification-command":       "custom_notification_command",
 	"thinking":                   "set_reasoning_thinking_level",
 	"show-metadata":              "print_metadata_to_stderr",
+	"no-prompt-cache":            "disable_prompt_caching",
+	"chunk":                      "chunk_help",
+	"chunk-size":                 "chunk_size_help",
+	"chunk-overlap":              "chunk_overlap_help",
+	"merge-pattern":              "merge_pattern_help",
+	"apply-changes":              "apply_changes_help",
+	"yes":                        "apply_changes_yes_help",
+	"force-changes":              "force_changes_help",
+	"rollback":                   "rollback_help",
+	"batch-submit":               "submit_provider_batch",
+	"batch-status":               "show_provider_batch_status",
+	"batch-fetch":                "fetch_provider_batch_results",
+	"batch":                      "batch_run_help",
+	"batch-workers":              "batch_workers_help",
+	"batch-output":               "batch_output_help",
+	"watch":                      "watch_help",
+	"watch-debounce":             "watch_debounce_help",
+	"watch-diff":                 "watch_diff_help",
 	"debug":                      "set_debug_level",
 }
 
@@ -117,7 +152,8 @@ func NewTranslatedHelpWriter(parser *flags.Parser, writer io.Writer) *Translated
 // WriteHelp writes the help output with translated flag descriptions
 func (h *TranslatedHelpWriter) WriteHelp() {
 	fmt.Fprintf(h.writer, "%s\n", i18n.T("usage_header"))
-	fmt.Fprintf(h.writer, "  %s %s\n\n", h.parser.Name, i18n.T("options_placeholder"))
+	fmt.Fprintf(h.writer, "  %s %s\n", h.parser.Name, i18n.T("options_placeholder"))
+	fmt.Fprintf(h.writer, "  %s %s\n\n", h.parser.Name, i18n.T("config_command_placeholder"))
 
 	fmt.Fprintf(h.writer, "%s\n", i18n.T("application_options_header"))
 	h.writeAllFlags()
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/tools/filescan"
+)
+
+// inputFilesListed is how many left out files the truncation report names.
+const inputFilesListed = 10
+
+// inputFilesReport tells what of the input files did not fit the token budget.
+type inputFilesReport struct {
+	full      int      // Files included in full
+	tokens    int      // Estimated tokens of everything included
+	truncated string   // The file cut short to fill the budget, if any
+	omitted   []string // Files left out
+}
+
+// appendInputFiles adds the text files of --input-file and --input-glob to the message,
+// each under a header with its path, within the --input-max-tokens budget. Skipped,
+// truncated and left out files are reported as warnings.
+func (o *Flags) appendInputFiles() (err error) {
+	if len(o.InputFile) == 0 && len(o.InputGlob) == 0 {
+		return
+	}
+	var mode string
+	switch {
+	case o.Batch != "":
+		mode = "--batch"
+	case o.Watch != "":
+		mode = "--watch"
+	}
+	if mode != "" {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("input_files_not_supported_with"), mode))
+		return
+	}
+	if o.InputMaxTokens < 0 {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("input_files_invalid_max_tokens"), o.InputMaxTokens))
+		return
+	}
+
+	var result *filescan.Result
+	if result, err = filescan.Scan(o.InputFile, o.InputGlob, filescan.Options{MaxFileSize: o.InputMaxFileSize}); err != nil {
+		return
+	}
+	for _, skipped := range result.Skipped {
+		if skipped.Reason == filescan.SkipTooLarge {
+			o.warn(fmt.Sprintf(i18n.T("input_files_skipped_too_large"), skipped.Path, skipped.Size, o.InputMaxFileSize))
+		} else {
+			o.warn(fmt.Sprintf(i18n.T("input_files_skipped_binary"), skipped.Path))
+		}
+	}
+	if len(result.Files) == 0 {
+		err = errors.New(i18n.T("input_files_none_read"))
+		return
+	}
+
+	text, report := joinInputFiles(result.Files, o.InputMaxTokens)
+	if report.truncated != "" || len(report.omitted) > 0 {
+		o.warn(fmt.Sprintf(i18n.T("input_files_over_budget"), o.InputMaxTokens, report.full, len(result.Files), report.tokens))
+		if report.truncated != "" {
+			o.warn(fmt.Sprintf(i18n.T("input_files_truncated"), report.truncated))
+		}
+		if len(report.omitted) > 0 {
+			listed := strings.Join(report.omitted[:min(len(report.omitted), inputFilesListed)], ", ")
+			if more := len(report.omitted) - inputFilesListed; more > 0 {
+				listed += fmt.Sprintf(i18n.T("input_files_omitted_more"), more)
+			}
+			o.warn(fmt.Sprintf(i18n.T("input_files_omitted"), len(report.omitted), listed))
+		}
+	}
+	o.Message = AppendMessage(o.Message, text)
+	return
+}
+
+// joinInputFiles wraps the files in headers with their paths and joins them, in order,
+// until the estimated tokens reach budget. The file that does not fit is cut short at a
+// line end to fill the budget, and the files after it are left out. A budget of 0 takes
+// all files.
+func joinInputFiles(files []filescan.File, budget int) (ret string, report inputFilesReport) {
+	var blocks []string
+	for i, file := range files {
+		block := inputFileBlock(file.Path, file.Content, false)
+		tokens := ai.EstimateTokens(block)
+		if budget == 0 || report.tokens+tokens <= budget {
+			blocks = append(blocks, block)
+			report.tokens += tokens
+			report.full++
+			continue
+		}
+
+		remaining := budget - report.tokens - ai.EstimateTokens(inputFileBlock(file.Path, "", true))
+		if content := cutAtLineEnd(file.Content, remaining*4); content != "" {
+			block = inputFileBlock(file.Path, content, true)
+			blocks = append(blocks, block)
+			report.tokens += ai.EstimateTokens(block)
+			report.truncated = file.Path
+			i++
+		}
+		for _, omitted := range files[i:] {
+			report.omitted = append(report.omitted, omitted.Path)
+		}
+		break
+	}
+	ret = strings.Join(blocks, "\n")
+	return
+}
+
+// inputFileBlock puts content between a header with its path and a closing line.
+func inputFileBlock(path string, content string, truncated bool) string {
+	var b strings.Builder
+	if truncated {
+		fmt.Fprintf(&b, "<file path=%q truncated=\"true\">\n", path)
+	} else {
+		fmt.Fprintf(&b, "<file path=%q>\n", path)
+	}
+	b.WriteString(content)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("</file>\n")
+	return b.String()
+}
+
+// cutAtLineEnd returns the lines of content that fit in size bytes, or nothing when not
+// even the first line fits.
+func cutAtLineEnd(content string, size int) string {
+	if size <= 0 {
+		return ""
+	}
+	if len(content) <= size {
+		return content
+	}
+	return content[:strings.LastIndex(content[:size], "\n")+1]
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/tools/filescan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinInputFiles(t *testing.T) {
+	files := []filescan.File{
+		{Path: "a.txt", Content: "first file\n"},
+		{Path: "b.txt", Content: strings.Repeat("line of text\n", 20)},
+		{Path: "c.txt", Content: "third file"},
+	}
+
+	text, report := joinInputFiles(files, 0)
+	assert.True(t, strings.HasPrefix(text, "<file path=\"a.txt\">\nfirst file\n</file>\n\n<file path=\"b.txt\">\n"))
+	assert.Contains(t, text, "<file path=\"c.txt\">\nthird file\n</file>\n")
+	assert.Equal(t, 3, report.full)
+	assert.Empty(t, report.truncated)
+	assert.Empty(t, report.omitted)
+
+	// The second file is cut at a line end to fill the budget, and the third left out
+	text, report = joinInputFiles(files, 40)
+	assert.Equal(t, 1, report.full)
+	assert.Equal(t, "b.txt", report.truncated)
+	assert.Equal(t, []string{"c.txt"}, report.omitted)
+	assert.Contains(t, text, "<file path=\"b.txt\" truncated=\"true\">\nline of text\n")
+	assert.NotContains(t, text, "c.txt")
+	assert.LessOrEqual(t, report.tokens, 40)
+
+	// A file that does not fit at all is left out with the rest
+	_, report = joinInputFiles(files, 10)
+	assert.Equal(t, 1, report.full)
+	assert.Empty(t, report.truncated)
+	assert.Equal(t, []string{"b.txt", "c.txt"}, report.omitted)
+}
+
+func TestAppendInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("# Notes\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.bin"), []byte{0, 1, 2}, 0644))
+
+	flags := &Flags{Message: "Summarize these", InputFile: []string{dir}, InputMaxTokens: 1000, OutputFormat: outputFormatJSON}
+	require.NoError(t, flags.appendInputFiles())
+	assert.True(t, strings.HasPrefix(flags.Message, "Summarize these\n<file path="))
+	assert.Contains(t, flags.Message, "# Notes\n</file>\n")
+	require.Len(t, flags.warnings, 1)
+	assert.Contains(t, flags.warnings[0], "data.bin")
+
+	// Only a binary file matches
+	flags = &Flags{InputGlob: []string{filepath.Join(dir, "*.bin")}, OutputFormat: outputFormatJSON}
+	assert.Error(t, flags.appendInputFiles())
+
+	flags = &Flags{InputFile: []string{dir}, Watch: "input.md"}
+	assert.Error(t, flags.appendInputFiles())
+}
@@ -0,0 +1,551 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// Prompts of the interactive mode, for the first and the following lines of a message.
+const (
+	interactivePrompt             = "> "
+	interactiveContinuationPrompt = "... "
+)
+
+// interactiveBlockDelimiter starts and ends a multi-line message.
+const interactiveBlockDelimiter = `"""`
+
+// interactiveHistoryFile keeps the messages entered in interactive mode, one per line,
+// in the fabric config directory.
+const (
+	interactiveHistoryFile  = "interactive_history"
+	interactiveHistoryLimit = 1000
+	interactiveHistoryShown = 20
+)
+
+// interactiveTurn is what is needed to send a message again with /retry.
+type interactiveTurn struct {
+	text        string
+	pattern     string
+	context     []string
+	strategy    string
+	attachments []string
+}
+
+// interactiveChat is a chat session in the terminal. The conversation is kept in a named
+// session, saved after every answer. Patterns, contexts, strategies and attachments
+// chosen with slash commands apply to the next message only.
+type interactiveChat struct {
+	flags    *Flags
+	registry *core.PluginRegistry
+	chatter  *core.Chatter
+	options  *domain.ChatOptions
+	in       *bufio.Reader
+	out      io.Writer
+
+	sessionName string
+	next        interactiveTurn
+	last        *interactiveTurn
+	// turns holds the length of the session before each answered message, for /undo
+	turns   []int
+	history []string
+
+	lastUsage  *domain.UsageMetadata
+	totalUsage domain.UsageMetadata
+	totalCost  float64
+	costKnown  bool
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// handleInteractive runs the interactive chat until the user quits. The message given
+// on the command line, if any, is sent first with the chosen pattern, context and strategy.
+func handleInteractive(flags *Flags, registry *core.PluginRegistry, messageTools string) (err error) {
+	if messageTools != "" {
+		flags.AppendMessage(messageTools)
+	}
+
+	o := &interactiveChat{
+		flags:       flags,
+		registry:    registry,
+		in:          bufio.NewReader(os.Stdin),
+		out:         os.Stdout,
+		sessionName: flags.Session,
+		next: interactiveTurn{
+			pattern:     flags.Pattern,
+			context:     flags.Context,
+			strategy:    flags.Strategy,
+			attachments: flags.Attachments,
+		},
+	}
+	if o.sessionName == "" {
+		o.sessionName = "interactive-" + time.Now().Format("20060102-150405")
+	}
+	if o.chatter, err = registry.GetChatter(flags.Model, flags.ModelContextLength, flags.Vendor, true, flags.DryRun); err != nil {
+		return
+	}
+	if o.options, err = flags.BuildChatOptions(); err != nil {
+		return
+	}
+	// Confirmations of file changes are read from the same input as the messages
+	review := newChangeReview(flags, registry.Db)
+	review.answers = o.in
+	o.options.ChangeApplier = review.apply
+	o.loadHistory()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer func() {
+		signal.Stop(interrupts)
+		close(interrupts)
+	}()
+	go func() {
+		for range interrupts {
+			// Ctrl-C stops the answer being generated; at the prompt it only reminds how to quit
+			if !o.cancelTurn() {
+				fmt.Fprintf(o.out, "\n%s\n%s", i18n.T("interactive_interrupt_hint"), interactivePrompt)
+			}
+		}
+	}()
+
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_welcome"), o.model(), o.sessionName))
+	if message := strings.TrimSpace(flags.Message); message != "" {
+		o.send(message)
+	}
+	o.run()
+
+	if o.registry.Db.Sessions.Exists(o.sessionName) {
+		fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_session_saved"), o.sessionName, o.sessionName))
+	}
+	return
+}
+
+// run reads messages and commands until /exit or the end of the input.
+func (o *interactiveChat) run() {
+	for {
+		fmt.Fprint(o.out, interactivePrompt)
+		input, err := readInteractiveInput(o.in, func() { fmt.Fprint(o.out, interactiveContinuationPrompt) })
+		if err != nil {
+			if input = strings.TrimSpace(input); input == "" {
+				fmt.Fprintln(o.out)
+				return
+			}
+		}
+		if input = strings.TrimSpace(input); input == "" {
+			continue
+		}
+
+		if entry, isRecall := o.recall(input); isRecall {
+			if entry == "" {
+				continue
+			}
+			fmt.Fprintf(o.out, "%s%s\n", interactivePrompt, entry)
+			input = entry
+		}
+		o.addHistory(input)
+
+		if strings.HasPrefix(input, "/") {
+			if quit := o.command(input); quit {
+				return
+			}
+		} else {
+			o.send(input)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readInteractiveInput reads one message. A line ending with a backslash continues on
+// the next line, and lines between """ delimiters are read as one message.
+// continuation is called before every further line is read.
+func readInteractiveInput(reader *bufio.Reader, continuation func()) (ret string, err error) {
+	var lines []string
+	inBlock := false
+	for {
+		var line string
+		line, err = reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case !inBlock && len(lines) == 0 && strings.TrimSpace(line) == interactiveBlockDelimiter:
+			inBlock = true
+		case inBlock && strings.TrimSpace(line) == interactiveBlockDelimiter:
+			return strings.Join(lines, "\n"), err
+		case inBlock:
+			lines = append(lines, line)
+		case strings.HasSuffix(line, `\`):
+			lines = append(lines, strings.TrimSuffix(line, `\`))
+		default:
+			lines = append(lines, line)
+			return strings.Join(lines, "\n"), err
+		}
+		if err != nil {
+			return strings.Join(lines, "\n"), err
+		}
+		continuation()
+	}
+}
+
+// command runs a slash command and tells whether to quit.
+func (o *interactiveChat) command(input string) (quit bool) {
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(name) {
+	case "/exit", "/quit":
+		return true
+	case "/help":
+		fmt.Fprintln(o.out, i18n.T("interactive_commands_help"))
+	case "/pattern":
+		o.setNext(&o.next.pattern, arg, o.registry.Db.Patterns.StorageEntity, "interactive_pattern_set", "interactive_pattern_cleared")
+	case "/context":
+		o.setContexts(arg)
+	case "/strategy":
+		o.setNext(&o.next.strategy, arg, nil, "interactive_strategy_set", "interactive_strategy_cleared")
+	case "/model":
+		o.setModel(arg)
+	case "/attach":
+		o.attach(arg)
+	case "/save":
+		o.save(arg)
+	case "/undo":
+		if o.undo() {
+			fmt.Fprintln(o.out, i18n.T("interactive_undone"))
+		}
+	case "/retry":
+		if o.last == nil {
+			fmt.Fprintln(o.out, i18n.T("interactive_nothing_to_retry"))
+		} else if turn := *o.last; o.undo() {
+			o.next = turn
+			o.send(turn.text)
+		}
+	case "/usage":
+		o.printUsage()
+	case "/history":
+		o.printHistory()
+	default:
+		fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_unknown_command"), name))
+	}
+	return false
+}
+
+// setNext sets a pattern or strategy for the next message, checking that it
+// exists when entity is given. An empty name clears it.
+func (o *interactiveChat) setNext(target *string, name string, entity *fsdb.StorageEntity, setKey, clearedKey string) {
+	if name == "" {
+		*target = ""
+		fmt.Fprintln(o.out, i18n.T(clearedKey))
+		return
+	}
+	if entity != nil && !entity.Exists(name) {
+		fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_not_found"), entity.Label, name))
+		return
+	}
+	*target = name
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T(setKey), name))
+}
+
+// setContexts sets the contexts for the next message, stacked in the order given.
+// Contexts given by file path or URL are loaded when the message is sent.
+func (o *interactiveChat) setContexts(arg string) {
+	names := strings.Fields(arg)
+	if len(names) == 0 {
+		o.next.context = nil
+		fmt.Fprintln(o.out, i18n.T("interactive_context_cleared"))
+		return
+	}
+	for _, name := range names {
+		if !fsdb.IsExternalSource(name) && !o.registry.Db.Contexts.Exists(name) {
+			fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_not_found"), o.registry.Db.Contexts.Label, name))
+			return
+		}
+	}
+	o.next.context = names
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_context_set"), strings.Join(names, ", ")))
+}
+
+// setModel switches the model for the following messages. It takes a model, an alias or
+// vendor|model.
+func (o *interactiveChat) setModel(arg string) {
+	if arg == "" {
+		fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_model_current"), o.model()))
+		return
+	}
+	spec := o.flags.parseModelSpec(arg)
+	chatter, err := o.registry.GetChatter(spec.model, o.flags.ModelContextLength, spec.vendor, true, o.flags.DryRun)
+	if err != nil {
+		fmt.Fprintf(o.out, "%s\n", err)
+		return
+	}
+	o.chatter = chatter
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_model_set"), o.model()))
+}
+
+// attach adds a file or URL to the next message.
+func (o *interactiveChat) attach(arg string) {
+	if arg == "" {
+		fmt.Fprintln(o.out, i18n.T("interactive_attach_usage"))
+		return
+	}
+	if _, err := domain.NewAttachment(arg); err != nil {
+		fmt.Fprintf(o.out, "%s\n", err)
+		return
+	}
+	o.next.attachments = append(o.next.attachments, arg)
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_attached"), arg))
+}
+
+// save copies the conversation to the named session and continues in it. Without a
+// name, it tells where the conversation is saved.
+func (o *interactiveChat) save(name string) {
+	if name != "" && name != o.sessionName {
+		sessions := o.registry.Db.Sessions
+		if sessions.Exists(o.sessionName) {
+			session, err := sessions.Get(o.sessionName)
+			if err == nil {
+				session.Name = name
+				err = sessions.SaveSession(session)
+			}
+			if err != nil {
+				fmt.Fprintf(o.out, "%s\n", err)
+				return
+			}
+		}
+		o.sessionName = name
+	}
+	fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_session_saved"), o.sessionName, o.sessionName))
+}
+
+// undo removes the last message and its answer from the session. Messages answered
+// before interactive mode was started are removed back to the last user message.
+func (o *interactiveChat) undo() bool {
+	sessions := o.registry.Db.Sessions
+	if !sessions.Exists(o.sessionName) {
+		fmt.Fprintln(o.out, i18n.T("interactive_nothing_to_undo"))
+		return false
+	}
+	session, err := sessions.Get(o.sessionName)
+	if err != nil {
+		fmt.Fprintf(o.out, "%s\n", err)
+		return false
+	}
+
+	length := lastTurnStart(session.Messages, o.turns)
+	if length < 0 {
+		fmt.Fprintln(o.out, i18n.T("interactive_nothing_to_undo"))
+		return false
+	}
+	if len(o.turns) > 0 {
+		o.turns = o.turns[:len(o.turns)-1]
+	}
+	session.Messages = session.Messages[:length]
+	if err = sessions.SaveSession(session); err != nil {
+		fmt.Fprintf(o.out, "%s\n", err)
+		return false
+	}
+	return true
+}
+
+// lastTurnStart returns the length of the session before its last turn: the recorded one
+// if any, else the index of the last user message. It returns -1 when there is no turn.
+func lastTurnStart(messages []*chat.ChatCompletionMessage, turns []int) int {
+	if len(turns) > 0 && turns[len(turns)-1] <= len(messages) {
+		return turns[len(turns)-1]
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == chat.ChatMessageRoleUser {
+			return i
+		}
+	}
+	return -1
+}
+
+// send sends a message with the options chosen for it, streams the answer and records
+// its usage. Errors are printed, so the conversation can go on.
+func (o *interactiveChat) send(text string) {
+	turn := o.next
+	turn.text = text
+	o.next = interactiveTurn{}
+
+	flags := *o.flags
+	flags.Message = text
+	flags.Session = o.sessionName
+	flags.Pattern, flags.Context, flags.Strategy, flags.Attachments = turn.pattern, turn.context, turn.strategy, turn.attachments
+	request, err := flags.BuildChatRequest("")
+	if err == nil {
+		if request.Language == "" {
+			request.Language = o.registry.Language.DefaultLanguage.Value
+		}
+		err = o.chatter.ValidateRequest(request, o.options)
+	}
+	if err != nil {
+		fmt.Fprintf(o.out, "%s\n", err)
+		return
+	}
+
+	length := 0
+	if o.registry.Db.Sessions.Exists(o.sessionName) {
+		if session, getErr := o.registry.Db.Sessions.Get(o.sessionName); getErr == nil {
+			length = len(session.Messages)
+		}
+	}
+
+	options := *o.options
+	updates := make(chan domain.StreamUpdate)
+	options.UpdateChan = updates
+	var usage *domain.UsageMetadata
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for update := range updates {
+			if update.Type == domain.StreamTypeUsage && update.Usage != nil {
+				usage = update.Usage
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.mu.Lock()
+	o.cancel = cancel
+	o.mu.Unlock()
+
+	session, err := o.chatter.Send(ctx, request, &options)
+
+	o.mu.Lock()
+	o.cancel = nil
+	o.mu.Unlock()
+	cancel()
+	close(updates)
+	<-drained
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(o.out, "\n%s\n", i18n.T("interactive_canceled"))
+		} else {
+			fmt.Fprintf(o.out, "%s\n", err)
+		}
+		return
+	}
+
+	if footnotes := domain.FormatCitationFootnotes(session.GetLastMessage().Citations); footnotes != "" {
+		fmt.Fprintf(o.out, "\n%s\n", footnotes)
+	}
+	o.turns = append(o.turns, length)
+	o.last = &turn
+	o.recordUsage(usage)
+}
+
+// cancelTurn cancels the answer being generated, and tells whether there was one.
+func (o *interactiveChat) cancelTurn() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cancel == nil {
+		return false
+	}
+	o.cancel()
+	return true
+}
+
+func (o *interactiveChat) recordUsage(usage *domain.UsageMetadata) {
+	o.lastUsage = usage
+	if usage == nil {
+		return
+	}
+	o.totalUsage.InputTokens += usage.InputTokens
+	o.totalUsage.OutputTokens += usage.OutputTokens
+	o.totalUsage.TotalTokens += usage.TotalTokens
+	if cost, known := o.chatter.Capabilities().EstimateCost(usage); known {
+		o.totalCost += cost
+		o.costKnown = true
+	}
+}
+
+func (o *interactiveChat) printUsage() {
+	if o.lastUsage == nil && o.totalUsage.TotalTokens == 0 {
+		fmt.Fprintln(o.out, i18n.T("interactive_no_usage"))
+		return
+	}
+	if o.lastUsage != nil {
+		fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_usage_last"), o.lastUsage.InputTokens, o.lastUsage.OutputTokens))
+	}
+	total := fmt.Sprintf(i18n.T("interactive_usage_total"), o.totalUsage.InputTokens, o.totalUsage.OutputTokens)
+	if o.costKnown {
+		total += ", " + core.FormatCost(&o.totalCost)
+	}
+	fmt.Fprintln(o.out, total)
+}
+
+func (o *interactiveChat) model() string {
+	return o.chatter.Label()
+}
+
+// recall resolves !N to the Nth entry of the history. Unknown entries are reported and
+// returned empty.
+func (o *interactiveChat) recall(input string) (entry string, isRecall bool) {
+	if !strings.HasPrefix(input, "!") {
+		return "", false
+	}
+	number, err := strconv.Atoi(input[1:])
+	if err != nil {
+		return "", false
+	}
+	if number < 1 || number > len(o.history) {
+		fmt.Fprintf(o.out, "%s\n", fmt.Sprintf(i18n.T("interactive_history_not_found"), number))
+		return "", true
+	}
+	return o.history[number-1], true
+}
+
+func (o *interactiveChat) printHistory() {
+	start := max(len(o.history)-interactiveHistoryShown, 0)
+	for i := start; i < len(o.history); i++ {
+		fmt.Fprintf(o.out, "%5d  %s\n", i+1, strings.ReplaceAll(o.history[i], "\n", " ⏎ "))
+	}
+}
+
+// loadHistory reads the history of earlier interactive sessions. Multi-line messages are
+// stored with escaped newlines.
+func (o *interactiveChat) loadHistory() {
+	data, err := os.ReadFile(o.registry.Db.FilePath(interactiveHistoryFile))
+	if err != nil {
+		return
+	}
+	for line := range strings.SplitSeq(strings.TrimRight(string(data), "\n"), "\n") {
+		if line != "" {
+			o.history = append(o.history, strings.ReplaceAll(line, `\n`, "\n"))
+		}
+	}
+}
+
+// addHistory records an entry, keeping the history file to its last entries.
+func (o *interactiveChat) addHistory(entry string) {
+	if len(o.history) > 0 && o.history[len(o.history)-1] == entry {
+		return
+	}
+	o.history = append(o.history, entry)
+	if len(o.history) > interactiveHistoryLimit {
+		o.history = o.history[len(o.history)-interactiveHistoryLimit:]
+	}
+
+	var builder strings.Builder
+	for _, item := range o.history {
+		builder.WriteString(strings.ReplaceAll(item, "\n", `\n`) + "\n")
+	}
+	_ = os.WriteFile(o.registry.Db.FilePath(interactiveHistoryFile), []byte(builder.String()), 0600)
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadInteractiveInput(t *testing.T) {
+	input := "hello\nfirst \\\nsecond\n\"\"\"\nline one\n\n  line three\n\"\"\"\nlast"
+	reader := bufio.NewReader(strings.NewReader(input))
+	continuations := 0
+	next := func() string {
+		ret, err := readInteractiveInput(reader, func() { continuations++ })
+		if err != nil {
+			require.ErrorIs(t, err, io.EOF)
+		}
+		return ret
+	}
+
+	assert.Equal(t, "hello", next())
+	assert.Equal(t, "first \nsecond", next())
+	assert.Equal(t, "line one\n\n  line three", next())
+	assert.Equal(t, "last", next())
+	assert.Equal(t, 5, continuations)
+
+	_, err := readInteractiveInput(reader, func() {})
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestLastTurnStart(t *testing.T) {
+	messages := []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleSystem, Content: "pattern"},
+		{Role: chat.ChatMessageRoleUser, Content: "question"},
+		{Role: chat.ChatMessageRoleAssistant, Content: "answer"},
+		{Role: chat.ChatMessageRoleSystem, Content: "context"},
+		{Role: chat.ChatMessageRoleUser, Content: "follow-up"},
+		{Role: chat.ChatMessageRoleAssistant, Content: "answer"},
+	}
+
+	// A recorded turn starts at its system message
+	assert.Equal(t, 3, lastTurnStart(messages, []int{0, 3}))
+	// Without a record, for a resumed session, the turn starts at the last user message
+	assert.Equal(t, 4, lastTurnStart(messages, nil))
+	// A stale record past the end of the session is ignored
+	assert.Equal(t, 4, lastTurnStart(messages, []int{10}))
+	assert.Equal(t, -1, lastTurnStart(nil, nil))
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+// Formats of --output-format
+const (
+	outputFormatText  = "text"
+	outputFormatJSON  = "json"
+	outputFormatJSONL = "jsonl"
+)
+
+// Types of the events of --output-format jsonl that are not stream updates
+const (
+	jsonEventComplete = "complete"
+	jsonEventError    = "error"
+)
+
+// jsonResult is the result of a chat, printed by --output-format json and by the
+// complete event of --output-format jsonl.
+type jsonResult struct {
+	Response  string                `json:"response"`
+	Reasoning string                `json:"reasoning,omitempty"`
+	Citations []chat.Citation       `json:"citations,omitempty"`
+	Vendor    string                `json:"vendor"`
+	Model     string                `json:"model"`
+	Pattern   string                `json:"pattern,omitempty"`
+	Session   string                `json:"session,omitempty"`
+	Usage     *domain.UsageMetadata `json:"usage,omitempty"`
+	Timings   jsonTimings           `json:"timings"`
+	Warnings  []string              `json:"warnings,omitempty"`
+}
+
+type jsonTimings struct {
+	StartedAt    time.Time `json:"started_at"`
+	FirstTokenMs *int64    `json:"first_token_ms,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+}
+
+type jsonError struct {
+	Code    domain.ErrorCode `json:"code"`
+	Message string           `json:"message"`
+}
+
+// jsonEvent is the last line of --output-format jsonl, after the stream updates.
+type jsonEvent struct {
+	Type   string      `json:"type"`
+	Result *jsonResult `json:"result,omitempty"`
+	Error  *jsonError  `json:"error,omitempty"`
+}
+
+// reportedError is an error already printed as JSON, which must not be printed again.
+type reportedError struct {
+	error
+}
+
+func (o *reportedError) Unwrap() error {
+	return o.error
+}
+
+// IsReported tells whether err was already printed by --output-format.
+func IsReported(err error) bool {
+	var reported *reportedError
+	return errors.As(err, &reported)
+}
+
+// parseOutputFormat finds --output-format in args, so that errors can be printed in
+// that format even when the flags cannot be parsed.
+func parseOutputFormat(args []string) string {
+	for i, arg := range args {
+		if arg == "--output-format" && i+1 < len(args) {
+			return args[i+1]
+		} else if after, ok := strings.CutPrefix(arg, "--output-format="); ok {
+			return after
+		}
+	}
+	return ""
+}
+
+// validateOutputFormat rejects unknown formats, and structured output for the modes
+// that print more than one answer or read from the terminal.
+func (o *Flags) validateOutputFormat() (err error) {
+	switch o.OutputFormat {
+	case "", outputFormatText:
+		return
+	case outputFormatJSON, outputFormatJSONL:
+	default:
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("output_format_invalid"), o.OutputFormat))
+		return
+	}
+	var mode string
+	switch {
+	case o.Interactive:
+		mode = "--interactive"
+	case len(o.Compare) > 0:
+		mode = "--compare"
+	case o.Batch != "":
+		mode = "--batch"
+	case o.Watch != "":
+		mode = "--watch"
+	}
+	if mode != "" {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("output_format_not_supported_with"), o.OutputFormat, mode))
+	}
+	return
+}
+
+// structuredOutput tells whether the chat is printed as JSON or JSONL.
+func (o *Flags) structuredOutput() bool {
+	return o.OutputFormat == outputFormatJSON || o.OutputFormat == outputFormatJSONL
+}
+
+// warn reports a problem that does not stop the command: on stderr, or in the
+// warnings of the JSON result.
+func (o *Flags) warn(message string) {
+	if o.structuredOutput() {
+		o.warnings = append(o.warnings, message)
+		return
+	}
+	fmt.Fprintln(os.Stderr, message)
+}
+
+// jsonOutput follows a chat for --output-format: it takes the stream updates of the
+// chatter, writing them as events in JSONL mode, and keeps what the result needs.
+type jsonOutput struct {
+	format string
+	out    io.Writer
+
+	mu         sync.Mutex
+	started    time.Time
+	firstToken time.Time
+	usage      *domain.UsageMetadata
+	warnings   []string
+	writeErr   error
+
+	updates chan domain.StreamUpdate
+	done    chan struct{}
+}
+
+func newJSONOutput(format string, out io.Writer) *jsonOutput {
+	return &jsonOutput{format: format, out: out}
+}
+
+// start makes the chat quiet and sends its updates to the output until stop.
+func (o *jsonOutput) start(opts *domain.ChatOptions) {
+	o.started = time.Now()
+	o.updates = make(chan domain.StreamUpdate)
+	o.done = make(chan struct{})
+	opts.Quiet = true
+	opts.UpdateChan = o.updates
+	go func() {
+		defer close(o.done)
+		for update := range o.updates {
+			o.update(update)
+		}
+	}()
+}
+
+// stop waits for the updates of the chat to be handled.
+func (o *jsonOutput) stop(opts *domain.ChatOptions) {
+	close(o.updates)
+	<-o.done
+	opts.UpdateChan = nil
+}
+
+func (o *jsonOutput) update(update domain.StreamUpdate) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch update.Type {
+	case domain.StreamTypeContent, domain.StreamTypeReasoning:
+		if o.firstToken.IsZero() && update.Content != "" {
+			o.firstToken = time.Now()
+		}
+	case domain.StreamTypeUsage:
+		if update.Usage != nil {
+			o.usage = update.Usage
+		}
+	case domain.StreamTypeWarning:
+		o.warnings = append(o.warnings, update.Content)
+	case domain.StreamTypeError:
+		// Send returns the error too, and it is printed as the last event
+		return
+	}
+	if o.format == outputFormatJSONL && o.writeErr == nil {
+		o.writeErr = writeJSONLine(o.out, update)
+	}
+}
+
+// result makes the result of the chat from its last answer.
+func (o *jsonOutput) result(answer *chat.ChatCompletionMessage, vendor, model, pattern, session string, warnings []string) *jsonResult {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ret := &jsonResult{
+		Response:  answer.TextContent(),
+		Reasoning: answer.ReasoningContent,
+		Citations: answer.Citations,
+		Vendor:    vendor,
+		Model:     model,
+		Pattern:   pattern,
+		Session:   session,
+		Usage:     o.usage,
+		Timings: jsonTimings{
+			StartedAt:  o.started,
+			DurationMs: time.Since(o.started).Milliseconds(),
+		},
+		Warnings: append(append([]string{}, warnings...), o.warnings...),
+	}
+	// Synthesized speech is only written to the output file
+	if strings.HasPrefix(ret.Response, ai.AudioDataPrefix) {
+		ret.Response = ""
+	}
+	if !o.firstToken.IsZero() {
+		firstToken := o.firstToken.Sub(o.started).Milliseconds()
+		ret.Timings.FirstTokenMs = &firstToken
+	}
+	return ret
+}
+
+// writeResult prints the result as a JSON object, or as the complete event of JSONL.
+func (o *jsonOutput) writeResult(result *jsonResult) (err error) {
+	if o.writeErr != nil {
+		return o.writeErr
+	}
+	if o.format == outputFormatJSONL {
+		return writeJSONLine(o.out, jsonEvent{Type: jsonEventComplete, Result: result})
+	}
+	return writeJSON(o.out, result)
+}
+
+// writeJSONError prints err with its code in the given format, and marks it as
+// reported. Errors of the text format are left to the caller.
+func writeJSONError(out io.Writer, format string, err error) error {
+	payload := &jsonError{Code: domain.CodeOf(err), Message: err.Error()}
+	var writeErr error
+	switch format {
+	case outputFormatJSON:
+		writeErr = writeJSON(out, struct {
+			Error *jsonError `json:"error"`
+		}{payload})
+	case outputFormatJSONL:
+		writeErr = writeJSONLine(out, jsonEvent{Type: jsonEventError, Error: payload})
+	default:
+		return err
+	}
+	if writeErr != nil {
+		return err
+	}
+	return &reportedError{err}
+}
+
+func writeJSON(out io.Writer, value any) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(value, "", "  "); err != nil {
+		return
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return
+}
+
+func writeJSONLine(out io.Writer, value any) (err error) {
+	var data []byte
+	if data, err = json.Marshal(value); err != nil {
+		return
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	assert.Equal(t, "json", parseOutputFormat([]string{"-p", "summarize", "--output-format", "json"}))
+	assert.Equal(t, "jsonl", parseOutputFormat([]string{"--output-format=jsonl", "hi"}))
+	assert.Equal(t, "", parseOutputFormat([]string{"--output", "out.md"}))
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	assert.NoError(t, (&Flags{}).validateOutputFormat())
+	assert.NoError(t, (&Flags{OutputFormat: "jsonl"}).validateOutputFormat())
+	assert.Error(t, (&Flags{OutputFormat: "xml"}).validateOutputFormat())
+	assert.Error(t, (&Flags{OutputFormat: "json", Interactive: true}).validateOutputFormat())
+	assert.Error(t, (&Flags{OutputFormat: "json", Compare: []string{"a", "b"}}).validateOutputFormat())
+	assert.Error(t, (&Flags{OutputFormat: "json", Batch: "inputs/"}).validateOutputFormat())
+}
+
+func TestJSONOutput_JSONL(t *testing.T) {
+	var out bytes.Buffer
+	output := newJSONOutput(outputFormatJSONL, &out)
+	opts := &domain.ChatOptions{}
+	output.start(opts)
+	assert.True(t, opts.Quiet)
+	opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeContent, Content: "Hello"}
+	opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeWarning, Content: "careful"}
+	opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeUsage, Usage: &domain.UsageMetadata{InputTokens: 3, OutputTokens: 1, TotalTokens: 4}}
+	opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeError, Content: "returned by Send"}
+	output.stop(opts)
+
+	answer := &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleAssistant, Content: "Hello"}
+	require.NoError(t, output.writeResult(output.result(answer, "OpenAI", "gpt-4o", "summarize", "", []string{"readability"})))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4, "the error update is left to the error event")
+	assert.JSONEq(t, `{"type":"content","content":"Hello"}`, lines[0])
+	assert.JSONEq(t, `{"type":"warning","content":"careful"}`, lines[1])
+
+	var complete struct {
+		Type   string     `json:"type"`
+		Result jsonResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[3]), &complete))
+	assert.Equal(t, "complete", complete.Type)
+	assert.Equal(t, "Hello", complete.Result.Response)
+	assert.Equal(t, "gpt-4o", complete.Result.Model)
+	assert.Equal(t, 4, complete.Result.Usage.TotalTokens)
+	assert.Equal(t, []string{"readability", "careful"}, complete.Result.Warnings)
+	assert.NotNil(t, complete.Result.Timings.FirstTokenMs)
+}
+
+func TestWriteJSONError(t *testing.T) {
+	cause := domain.WithCode(domain.ErrorCodeVendor, errors.New("rate limited"))
+
+	var out bytes.Buffer
+	err := writeJSONError(&out, outputFormatJSON, cause)
+	assert.True(t, IsReported(err))
+	assert.JSONEq(t, `{"error":{"code":"vendor_error","message":"rate limited"}}`, out.String())
+
+	out.Reset()
+	err = writeJSONError(&out, outputFormatJSONL, cause)
+	assert.True(t, IsReported(err))
+	assert.JSONEq(t, `{"type":"error","error":{"code":"vendor_error","message":"rate limited"}}`, out.String())
+
+	out.Reset()
+	err = writeJSONError(&out, outputFormatText, cause)
+	assert.False(t, IsReported(err), "text errors are printed by the caller")
+	assert.Empty(t, out.String())
+}
@@ -73,6 +73,8 @@ func handleListingCommands(currentFlags *Flags, fabricDb *fsdb.Db, registry *cor
 
 		if currentFlags.ShellCompleteOutput {
 			models.Print(true)
+		} else if currentFlags.ShowModelCapabilities {
+			models.PrintWithCapabilities(registry.Defaults.Vendor.Value, registry.Defaults.Model.Value)
 		} else {
 			models.PrintWithVendor(false, registry.Defaults.Vendor.Value, registry.Defaults.Model.Value)
 		}
@@ -119,6 +121,7 @@ func listTranscriptionModels(shellComplete bool) {
 		string(openai.AudioModelWhisper1),
 		string(openai.AudioModelGPT4oMiniTranscribe),
 		string(openai.AudioModelGPT4oTranscribe),
+		"gpt-4o-transcribe-diarize",
 	}
 
 	if shellComplete {
@@ -27,5 +27,10 @@ func handleManagementCommands(currentFlags *Flags, fabricDb *fsdb.Db) (handled b
 		return true, err
 	}
 
+	if currentFlags.Rollback != "" {
+		err = rollbackChanges(fabricDb, currentFlags.Rollback)
+		return true, err
+	}
+
 	return false, nil
 }
@@ -63,9 +63,10 @@ func CreateAudioOutputFile(audioData []byte, fileName string) (err error) {
 	return
 }
 
-// IsAudioFormat checks if the filename suggests an audio format
+// IsAudioFormat checks if the filename suggests an audio format that speech output can
+// produce, see ai.SpeechFormat
 func IsAudioFormat(fileName string) bool {
 	ext := strings.ToLower(filepath.Ext(fileName))
-	audioExts := []string{".wav", ".mp3", ".m4a", ".aac", ".ogg", ".flac"}
+	audioExts := []string{".wav", ".mp3", ".aac", ".ogg", ".opus", ".flac"}
 	return slices.Contains(audioExts, ext)
 }
@@ -3,6 +3,8 @@ package cli
 import (
 	"os"
 	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 )
 
 func TestCopyToClipboard(t *testing.T) {
@@ -55,3 +57,11 @@ func TestCreateOutputFileMessageWithTrailingNewline(t *testing.T) {
 		t.Fatalf("expected file contents %q, got %q", message, data)
 	}
 }
+
+func TestIsAudioFormatMatchesSpeechFormats(t *testing.T) {
+	for _, fileName := range []string{"a.wav", "a.MP3", "a.ogg", "a.opus", "a.aac", "a.flac", "a.m4a", "a.txt"} {
+		if IsAudioFormat(fileName) != (ai.SpeechFormat(fileName) != "") {
+			t.Errorf("IsAudioFormat(%q) = %v, but ai.SpeechFormat() = %q", fileName, IsAudioFormat(fileName), ai.SpeechFormat(fileName))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ModelAlias maps a short name (e.g. "fast", "local") to a vendor and model.
+// In YAML it is written either as "Vendor|model" or as a mapping with vendor and model keys.
+type ModelAlias struct {
+	Vendor string `yaml:"vendor"`
+	Model  string `yaml:"model"`
+}
+
+// UnmarshalYAML accepts both the "Vendor|model" shorthand and the mapping form.
+func (o *ModelAlias) UnmarshalYAML(value *yaml.Node) (err error) {
+	if value.Kind == yaml.ScalarNode {
+		var spec string
+		if err = value.Decode(&spec); err != nil {
+			return
+		}
+		if vendor, model, found := strings.Cut(spec, "|"); found {
+			o.Vendor, o.Model = strings.TrimSpace(vendor), strings.TrimSpace(model)
+		} else {
+			o.Model = strings.TrimSpace(spec)
+		}
+	} else {
+		type plainAlias ModelAlias
+		if err = value.Decode((*plainAlias)(o)); err != nil {
+			return
+		}
+	}
+
+	if o.Model == "" {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("invalid_model_alias"), value.Line))
+	}
+	return
+}
+
+// ModelAliases maps alias names to their vendor and model.
+type ModelAliases map[string]ModelAlias
+
+// Profile bundles chat settings selectable with --profile.
+// Pointer fields distinguish "not set" from explicit zero values.
+type Profile struct {
+	Vendor      string               `yaml:"vendor"`
+	Model       string               `yaml:"model"`
+	Temperature *float64             `yaml:"temperature"`
+	TopP        *float64             `yaml:"topp"`
+	Thinking    domain.ThinkingLevel `yaml:"thinking"`
+	Strategy    string               `yaml:"strategy"`
+	Language    string               `yaml:"language"`
+}
+
+// applyProfile copies the selected profile's settings onto flags that were not
+// given explicitly on the command line. cliFlags holds the long names of those flags.
+func (o *Flags) applyProfile(cliFlags map[string]bool) (err error) {
+	if o.Profile == "" {
+		return
+	}
+
+	profile, ok := o.Profiles[o.Profile]
+	if !ok {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("profile_not_found"), o.Profile, o.Config))
+		return
+	}
+	debuglog.Debug(debuglog.Detailed, "Applying profile %s: %+v\n", o.Profile, profile)
+
+	setString := func(flag string, target *string, value string) {
+		if value != "" && !cliFlags[flag] {
+			*target = value
+		}
+	}
+	setString("vendor", &o.Vendor, profile.Vendor)
+	setString("model", &o.Model, profile.Model)
+	setString("strategy", &o.Strategy, profile.Strategy)
+	setString("language", &o.Language, profile.Language)
+
+	if profile.Temperature != nil && !cliFlags["temperature"] {
+		o.Temperature = *profile.Temperature
+	}
+	if profile.TopP != nil && !cliFlags["topp"] {
+		o.TopP = *profile.TopP
+	}
+	if profile.Thinking != "" && !cliFlags["thinking"] {
+		o.Thinking = profile.Thinking
+	}
+	return
+}
+
+// resolveModelAlias replaces an aliased model name with the aliased vendor and model.
+// A vendor given explicitly on the command line is kept.
+func (o *Flags) resolveModelAlias(cliFlags map[string]bool) {
+	alias, ok := o.Aliases[o.Model]
+	if !ok {
+		return
+	}
+	debuglog.Debug(debuglog.Detailed, "Resolved model alias %s to %s|%s\n", o.Model, alias.Vendor, alias.Model)
+
+	o.Model = alias.Model
+	if alias.Vendor != "" && !cliFlags["vendor"] {
+		o.Vendor = alias.Vendor
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesConfig = `
+temperature: 0.5
+aliases:
+  fast: "Groq|llama-3.1-8b-instant"
+  local:
+    vendor: LM Studio
+    model: openai/gpt-oss-20b
+profiles:
+  research:
+    model: fast
+    temperature: 0.2
+    topp: 0.3
+    thinking: high
+    strategy: cot
+    language: de
+`
+
+func writeProfilesConfig(t *testing.T, content string) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+	return configPath
+}
+
+func initWithArgs(t *testing.T, args ...string) (*Flags, error) {
+	t.Helper()
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = append([]string{"cmd"}, args...)
+	return Init()
+}
+
+func TestInitWithProfile(t *testing.T) {
+	configPath := writeProfilesConfig(t, profilesConfig)
+
+	flags, err := initWithArgs(t, "--config", configPath, "--profile", "research")
+	require.NoError(t, err)
+	assert.Equal(t, "Groq", flags.Vendor)
+	assert.Equal(t, "llama-3.1-8b-instant", flags.Model)
+	assert.Equal(t, 0.2, flags.Temperature)
+	assert.Equal(t, 0.3, flags.TopP)
+	assert.Equal(t, domain.ThinkingHigh, flags.Thinking)
+	assert.Equal(t, "cot", flags.Strategy)
+	assert.Equal(t, "de", flags.Language)
+}
+
+func TestInitExplicitFlagsOverrideProfile(t *testing.T) {
+	configPath := writeProfilesConfig(t, profilesConfig)
+
+	flags, err := initWithArgs(t, "--config", configPath, "--profile", "research",
+		"-t", "0.9", "-g", "fr", "--strategy", "tot", "-V", "OpenRouter")
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, flags.Temperature)
+	assert.Equal(t, "fr", flags.Language)
+	assert.Equal(t, "tot", flags.Strategy)
+	assert.Equal(t, "OpenRouter", flags.Vendor)
+	assert.Equal(t, "llama-3.1-8b-instant", flags.Model)
+	assert.Equal(t, 0.3, flags.TopP)
+}
+
+func TestInitResolvesModelAlias(t *testing.T) {
+	configPath := writeProfilesConfig(t, profilesConfig)
+
+	flags, err := initWithArgs(t, "--config", configPath, "-m", "local")
+	require.NoError(t, err)
+	assert.Equal(t, "LM Studio", flags.Vendor)
+	assert.Equal(t, "openai/gpt-oss-20b", flags.Model)
+	assert.Equal(t, 0.5, flags.Temperature)
+}
+
+func TestInitUnknownProfile(t *testing.T) {
+	configPath := writeProfilesConfig(t, profilesConfig)
+
+	_, err := initWithArgs(t, "--config", configPath, "--profile", "missing")
+	assert.Error(t, err)
+}
+
+func TestInitInvalidModelAlias(t *testing.T) {
+	configPath := writeProfilesConfig(t, "aliases:\n  broken: \"Groq|\"\n")
+
+	_, err := initWithArgs(t, "--config", configPath)
+	assert.Error(t, err)
+}
+
+func TestPatternModelAliasKeepsExplicitVendor(t *testing.T) {
+	configPath := writeProfilesConfig(t, profilesConfig)
+	t.Setenv("FABRIC_MODEL_SUMMARIZE", "local")
+
+	flags, err := initWithArgs(t, "--config", configPath, "-p", "summarize", "-V", "Ollama")
+	require.NoError(t, err)
+	flags.applyPatternModel()
+	assert.Equal(t, "Ollama", flags.Vendor)
+	assert.Equal(t, "openai/gpt-oss-20b", flags.Model)
+
+	flags, err = initWithArgs(t, "--config", configPath, "-p", "summarize")
+	require.NoError(t, err)
+	flags.applyPatternModel()
+	assert.Equal(t, "LM Studio", flags.Vendor)
+	assert.Equal(t, "openai/gpt-oss-20b", flags.Model)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// batchInputLine is one line of a --batch-submit input file.
+type batchInputLine struct {
+	ID        string            `json:"id"`
+	Input     string            `json:"input"`
+	Variables map[string]string `json:"variables"`
+}
+
+// handleProviderBatchCommands handles --batch-submit, --batch-status and --batch-fetch.
+// Returns (handled, error) where handled indicates if a command was processed and should exit
+func handleProviderBatchCommands(currentFlags *Flags, registry *core.PluginRegistry) (handled bool, err error) {
+	ctx := context.Background()
+
+	if currentFlags.BatchSubmit != "" {
+		var batch *fsdb.Batch
+		if batch, err = submitProviderBatch(ctx, currentFlags, registry); err != nil {
+			return true, err
+		}
+		fmt.Printf(i18n.T("batch_submitted"), batch.ID, len(batch.RequestIDs), batch.Vendor, batch.Model, batch.ID)
+		return true, nil
+	}
+
+	if currentFlags.BatchStatus != "" {
+		var status *ai.BatchStatus
+		if status, err = registry.GetBatchStatus(ctx, currentFlags.BatchStatus); err != nil {
+			return true, err
+		}
+		fmt.Printf(i18n.T("batch_status"), status.ID, status.Status, status.Completed, status.Total, status.Failed)
+		if status.Done {
+			fmt.Printf(i18n.T("batch_ready_to_fetch"), status.ID)
+		}
+		return true, nil
+	}
+
+	if currentFlags.BatchFetch != "" {
+		var results []ai.BatchResult
+		if results, err = registry.FetchBatchResults(ctx, currentFlags.BatchFetch); err != nil {
+			return true, err
+		}
+		var output strings.Builder
+		if err = writeBatchResults(&output, results); err != nil {
+			return true, err
+		}
+		if currentFlags.Output != "" {
+			err = CreateOutputFile(output.String(), currentFlags.Output)
+		} else {
+			fmt.Print(output.String())
+		}
+		return true, err
+	}
+
+	return false, nil
+}
+
+// submitProviderBatch builds one chat request per input line, using the pattern, context,
+// strategy and chat options of the current flags, and submits them as one provider batch.
+func submitProviderBatch(ctx context.Context, currentFlags *Flags, registry *core.PluginRegistry) (batch *fsdb.Batch, err error) {
+	var lines []batchInputLine
+	if lines, err = readBatchInputFile(currentFlags.BatchSubmit); err != nil {
+		return
+	}
+
+	var chatter *core.Chatter
+	if chatter, err = registry.GetChatter(currentFlags.Model, currentFlags.ModelContextLength,
+		currentFlags.Vendor, false, false); err != nil {
+		return
+	}
+
+	var chatOptions *domain.ChatOptions
+	if chatOptions, err = currentFlags.BuildChatOptions(); err != nil {
+		return
+	}
+
+	inputs := make([]core.BatchInput, 0, len(lines))
+	for _, line := range lines {
+		lineFlags := *currentFlags
+		lineFlags.Message = line.Input
+		lineFlags.Session = ""
+		lineFlags.PatternVariables = maps.Clone(currentFlags.PatternVariables)
+		if len(line.Variables) > 0 {
+			if lineFlags.PatternVariables == nil {
+				lineFlags.PatternVariables = make(map[string]string, len(line.Variables))
+			}
+			maps.Copy(lineFlags.PatternVariables, line.Variables)
+		}
+
+		var chatReq *domain.ChatRequest
+		if chatReq, err = lineFlags.BuildChatRequest(""); err != nil {
+			return
+		}
+		if chatReq.Language == "" {
+			chatReq.Language = registry.Language.DefaultLanguage.Value
+		}
+		if err = chatter.ValidateRequest(chatReq, chatOptions); err != nil {
+			return
+		}
+		inputs = append(inputs, core.BatchInput{ID: line.ID, Request: chatReq})
+	}
+
+	batch, err = chatter.SubmitBatch(ctx, inputs, chatOptions)
+	return
+}
+
+// readBatchInputFile parses a JSONL input file. Lines without an id are keyed by their line number.
+func readBatchInputFile(path string) (ret []batchInputLine, err error) {
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var line batchInputLine
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_invalid_input_line"), path, lineNumber, err))
+			return
+		}
+		if line.ID == "" {
+			line.ID = strconv.Itoa(lineNumber)
+		}
+		ret = append(ret, line)
+	}
+	err = scanner.Err()
+	return
+}
+
+// writeBatchResults writes one JSON object per result.
+func writeBatchResults(w io.Writer, results []ai.BatchResult) (err error) {
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err = encoder.Encode(result); err != nil {
+			return
+		}
+	}
+	return
+}
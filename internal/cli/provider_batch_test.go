@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBatchInputFile(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "inputs.jsonl")
+	content := `{"id":"doc-1","input":"first document"}
+
+{"input":"second document","variables":{"lang":"de"}}
+`
+	require.NoError(t, os.WriteFile(inputPath, []byte(content), 0o644))
+
+	lines, err := readBatchInputFile(inputPath)
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "doc-1", lines[0].ID)
+	assert.Equal(t, "3", lines[1].ID, "lines without id are keyed by line number")
+	assert.Equal(t, map[string]string{"lang": "de"}, lines[1].Variables)
+}
+
+func TestReadBatchInputFileInvalidLine(t *testing.T) {
+	inputPath := filepath.Join(t.TempDir(), "inputs.jsonl")
+	require.NoError(t, os.WriteFile(inputPath, []byte("not json\n"), 0o644))
+
+	_, err := readBatchInputFile(inputPath)
+	assert.Error(t, err)
+}
+
+func TestWriteBatchResults(t *testing.T) {
+	var output strings.Builder
+	require.NoError(t, writeBatchResults(&output, []ai.BatchResult{{ID: "a", Content: "ok"}, {ID: "b", Error: "failed"}}))
+	assert.Equal(t, "{\"id\":\"a\",\"content\":\"ok\"}\n{\"id\":\"b\",\"error\":\"failed\"}\n", output.String())
+}
@@ -10,7 +10,11 @@ import (
 func handleSetupAndServerCommands(currentFlags *Flags, registry *core.PluginRegistry, version string) (handled bool, err error) {
 	// if the setup flag is set, run the setup function
 	if currentFlags.Setup {
-		err = registry.Setup()
+		if currentFlags.NonInteractive {
+			err = registry.SetupNonInteractive(currentFlags.Vendor, currentFlags.Model)
+		} else {
+			err = registry.Setup()
+		}
 		return true, err
 	}
 
@@ -4,26 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 )
 
-type transcriber interface {
-	TranscribeFile(ctx context.Context, filePath, model string, split bool) (string, error)
-}
-
 func handleTranscription(flags *Flags, registry *core.PluginRegistry) (message string, err error) {
 	vendorName := flags.Vendor
 	if vendorName == "" {
 		vendorName = "OpenAI"
 	}
 
+	format := transcribeFormat(flags)
+	if !slices.Contains(domain.TranscriptFormats, format) {
+		return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("transcribe_format_not_supported"), format, strings.Join(domain.TranscriptFormats, ", ")))
+	}
+
 	vendor := registry.VendorManager.FindByName(vendorName)
 	if vendor == nil {
 		return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("vendor_not_configured"), vendorName))
 	}
-	tr, ok := vendor.(transcriber)
+	tr, ok := vendor.(ai.Transcriber)
 	if !ok {
 		return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("vendor_no_transcription_support"), vendorName))
 	}
@@ -31,8 +36,22 @@ func handleTranscription(flags *Flags, registry *core.PluginRegistry) (message s
 	if model == "" {
 		return "", errors.New(i18n.T("transcription_model_required"))
 	}
-	if message, err = tr.TranscribeFile(context.Background(), flags.TranscribeFile, model, flags.SplitMediaFile); err != nil {
+	opts := &domain.TranscriptionOptions{
+		Model:      model,
+		Split:      flags.SplitMediaFile,
+		Timestamps: format != domain.TranscriptFormatText,
+	}
+	var transcript *domain.Transcript
+	if transcript, err = tr.Transcribe(context.Background(), flags.TranscribeFile, opts); err != nil {
 		return
 	}
-	return
+	return transcript.Format(format)
+}
+
+// transcribeFormat returns the requested transcript format, text when none is given.
+func transcribeFormat(flags *Flags) string {
+	if flags.TranscribeFormat == "" {
+		return domain.TranscriptFormatText
+	}
+	return strings.ToLower(flags.TranscribeFormat)
 }
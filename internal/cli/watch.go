@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/core"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+// watchPollInterval is how often the --watch file is checked for changes. Polling works
+// the same on every platform and file system, and editors that replace the file when
+// saving are seen as well.
+const watchPollInterval = 250 * time.Millisecond
+
+// handleWatch runs the pattern over the --watch file, and again whenever the file has
+// changed and then stayed unchanged for --watch-debounce. Every run is a normal chat that
+// writes --output, sharing the registry of the first one. With --watch-diff, the runs
+// continue one session and send the unified diff since the last successful run.
+func handleWatch(flags *Flags, registry *core.PluginRegistry) (err error) {
+	if strings.TrimSpace(flags.Message) != "" {
+		err = errors.New(i18n.T("watch_message_not_supported"))
+		return
+	}
+	if flags.WatchDebounce < 0 {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("watch_invalid_debounce"), flags.WatchDebounce))
+		return
+	}
+	if _, err = os.Stat(flags.Watch); err != nil {
+		return
+	}
+	if flags.WatchDiff && flags.Session == "" {
+		flags.Session = "watch-" + time.Now().Format("20060102-150405")
+	}
+
+	// Ctrl-C stops watching once the current run is done; a second one stops at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	name := filepath.Base(flags.Watch)
+	fmt.Fprintln(os.Stderr, util.Dim(os.Stderr, fmt.Sprintf(i18n.T("watch_started"), flags.Watch)))
+
+	// sent is the content of the last successful run, which the session already has
+	var sent string
+	var synced, started bool
+	err = watchFile(ctx, flags.Watch, watchPollInterval, time.Duration(flags.WatchDebounce)*time.Millisecond, func(content string) {
+		if started {
+			fmt.Fprintln(os.Stderr, util.Dim(os.Stderr, fmt.Sprintf(i18n.T("watch_changed"), flags.Watch)))
+		}
+		started = true
+		message := content
+		if flags.WatchDiff && synced {
+			message = fmt.Sprintf(i18n.T("watch_diff_message"), name, domain.UnifiedDiff(name, sent, content))
+		}
+
+		runFlags := *flags
+		runFlags.Message = message
+		if flags.Output != "" {
+			runFlags.Output = watchOutputPath(flags.Output)
+			os.Remove(runFlags.Output)
+		}
+		runErr := handleChatProcessing(&runFlags, registry, "")
+		if flags.Output != "" {
+			if runErr == nil {
+				runErr = os.Rename(runFlags.Output, flags.Output)
+			}
+			if runErr != nil {
+				os.Remove(runFlags.Output)
+			}
+		}
+		if runErr != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("watch_run_failed")+"\n", runErr)
+			return
+		}
+		sent, synced = content, true
+	})
+	return
+}
+
+// watchOutputPath is where a run of --watch writes its output before it replaces the
+// output of the previous run, which the output file would otherwise refuse to overwrite.
+// A failed run leaves the previous output alone. The extension is kept, since it
+// selects the audio format of speech output.
+func watchOutputPath(output string) string {
+	ext := filepath.Ext(output)
+	return filepath.Join(filepath.Dir(output), "."+strings.TrimSuffix(filepath.Base(output), ext)+".watch"+ext)
+}
+
+// fileStamp tells whether a file changed since it was last looked at.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime int64
+}
+
+func statFile(path string) (ret fileStamp) {
+	if info, err := os.Stat(path); err == nil {
+		ret = fileStamp{exists: true, size: info.Size(), modTime: info.ModTime().UnixNano()}
+	}
+	return
+}
+
+// watchFile calls run with the content of the file at path, and again whenever the file
+// changed and then stayed unchanged for debounce, until ctx is done. Changes that leave
+// the content as it was, and a file that is missing for a while, are ignored.
+func watchFile(ctx context.Context, path string, poll time.Duration, debounce time.Duration, run func(content string)) (err error) {
+	stamp := statFile(path)
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+	last := string(data)
+	run(last)
+
+	var changedAt time.Time
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if current := statFile(path); current != stamp {
+			stamp = current
+			changedAt = time.Now()
+			continue
+		}
+		if changedAt.IsZero() || time.Since(changedAt) < debounce || !stamp.exists {
+			continue
+		}
+		changedAt = time.Time{}
+		if data, readErr := os.ReadFile(path); readErr == nil && string(data) != last {
+			last = string(data)
+			run(last)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchFile(ctx, path, 5*time.Millisecond, 200*time.Millisecond, func(content string) {
+			runs <- content
+		})
+	}()
+
+	next := func() string {
+		select {
+		case content := <-runs:
+			return content
+		case <-time.After(2 * time.Second):
+			t.Fatal("the watched file did not run")
+			return ""
+		}
+	}
+	assert.Equal(t, "first", next())
+
+	// Quick successive saves run once, after the file stays unchanged
+	require.NoError(t, os.WriteFile(path, []byte("second draft"), 0644))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	assert.Equal(t, "second", next())
+
+	// Saving the same content again does not run
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	time.Sleep(400 * time.Millisecond)
+	assert.Empty(t, runs)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWatchFileMissing(t *testing.T) {
+	err := watchFile(context.Background(), filepath.Join(t.TempDir(), "missing.md"), time.Millisecond, 0, func(string) {
+		t.Error("run must not be called for a missing file")
+	})
+	assert.Error(t, err)
+}
+
+func TestWatchOutputPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("out", ".summary.watch.md"), watchOutputPath(filepath.Join("out", "summary.md")))
+	assert.Equal(t, ".speech.watch.mp3", watchOutputPath("speech.mp3"))
+	assert.Equal(t, ".summary.watch", watchOutputPath("summary"))
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"encoding/base64"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/tools/converter"
+)
+
+// prepareAttachments returns the vendor messages with their file parts resolved for the
+// model: PDFs stay files with inline data when the model reads documents natively, and
+// every other file is replaced by its locally extracted text. Messages are copied before
+// they are changed, so the session keeps the original attachments.
+func (o *Chatter) prepareAttachments(messages []*chat.ChatCompletionMessage) (ret []*chat.ChatCompletionMessage, err error) {
+	native := o.Capabilities().DocumentInput.IsSupported()
+
+	ret = make([]*chat.ChatCompletionMessage, 0, len(messages))
+	for _, message := range messages {
+		if !hasFileParts(message) {
+			ret = append(ret, message)
+			continue
+		}
+
+		converted := *message
+		converted.MultiContent = make([]chat.ChatMessagePart, 0, len(message.MultiContent))
+		for _, part := range message.MultiContent {
+			if part.Type == chat.ChatMessagePartTypeFile && part.File != nil {
+				if part, err = resolveFilePart(part.File, native); err != nil {
+					return
+				}
+			}
+			converted.MultiContent = append(converted.MultiContent, part)
+		}
+		ret = append(ret, &converted)
+	}
+	return
+}
+
+func hasFileParts(message *chat.ChatCompletionMessage) bool {
+	for _, part := range message.MultiContent {
+		if part.Type == chat.ChatMessagePartTypeFile && part.File != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveFilePart(file *chat.ChatMessageFile, native bool) (ret chat.ChatMessagePart, err error) {
+	keep := native && file.MimeType == converter.MimeTypePDF
+	if keep && file.Data != "" {
+		ret = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeFile, File: file}
+		return
+	}
+
+	var content []byte
+	if content, err = fileContent(file); err != nil {
+		return
+	}
+	if keep {
+		inline := *file
+		inline.Data = base64.StdEncoding.EncodeToString(content)
+		ret = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeFile, File: &inline}
+		return
+	}
+
+	var text string
+	if text, err = converter.DocumentText(file.MimeType, content); err != nil {
+		return
+	}
+	ret = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeText, Text: domain.FormatAttachmentText(file.Filename, text)}
+	return
+}
+
+func fileContent(file *chat.ChatMessageFile) (ret []byte, err error) {
+	if file.Data != "" {
+		return base64.StdEncoding.DecodeString(file.Data)
+	}
+	attachment := &domain.Attachment{URL: &file.URL}
+	return attachment.ContentBytes()
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+func TestChatter_Send_ExtractsDocumentText(t *testing.T) {
+	var sent *chat.ChatCompletionMessage
+	vendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		sent = messages[len(messages)-1]
+		return "ok", nil
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model"}
+
+	csv := base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n"))
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{
+		Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "sum the columns"},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{Filename: "data.csv", MimeType: "text/csv", Data: csv}},
+		},
+	}}
+	session, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if len(sent.MultiContent) != 2 || sent.MultiContent[1].Type != chat.ChatMessagePartTypeText {
+		t.Fatalf("Expected the CSV to be sent as text, got %+v", sent.MultiContent)
+	}
+	if want := "[Attachment: data.csv]\n| a | b |\n| --- | --- |\n| 1 | 2 |"; sent.MultiContent[1].Text != want {
+		t.Errorf("Expected %q, got %q", want, sent.MultiContent[1].Text)
+	}
+	if stored := session.Messages[0].MultiContent[1]; stored.Type != chat.ChatMessagePartTypeFile {
+		t.Errorf("Expected the session to keep the file part, got %+v", stored)
+	}
+}
+
+func TestChatter_Send_KeepsPDFForDocumentModels(t *testing.T) {
+	ai.Capabilities.AddOverrides(ai.CapabilityRule{Vendor: "mock", Model: "test-pdf-model",
+		ModelCapabilities: ai.ModelCapabilities{DocumentInput: ai.SupportYes}})
+
+	var sent *chat.ChatCompletionMessage
+	vendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		sent = messages[len(messages)-1]
+		return "ok", nil
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-pdf-model"}
+
+	file := &chat.ChatMessageFile{Filename: "report.pdf", MimeType: "application/pdf", Data: "JVBERi0="}
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{
+		Role:         chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{{Type: chat.ChatMessagePartTypeFile, File: file}},
+	}}
+	if _, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-pdf-model"}); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if sent.MultiContent[0].Type != chat.ChatMessagePartTypeFile || sent.MultiContent[0].File.Data != file.Data {
+		t.Errorf("Expected the PDF to be sent as a file, got %+v", sent.MultiContent[0])
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// BatchInput is a single chat request of a provider batch, keyed by the caller's input ID.
+type BatchInput struct {
+	ID      string
+	Request *domain.ChatRequest
+}
+
+// SubmitBatch builds every input through BuildSession, submits them as one provider
+// batch and stores the batch state so it can be checked and fetched later.
+func (o *Chatter) SubmitBatch(ctx context.Context, inputs []BatchInput, opts *domain.ChatOptions) (batch *fsdb.Batch, err error) {
+	batchVendor, ok := o.vendor.(ai.BatchVendor)
+	if !ok {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("vendor_no_batch_support"), o.vendor.GetName()))
+		return
+	}
+	if len(inputs) == 0 {
+		err = errors.New(i18n.T("batch_no_inputs"))
+		return
+	}
+
+	if o.vendor.NeedsRawMode(o.model) {
+		opts.Raw = true
+	}
+	opts.Model = o.model
+	if opts.ModelContextLength == 0 {
+		opts.ModelContextLength = o.modelContextLength
+	}
+
+	requests := make([]ai.BatchRequest, 0, len(inputs))
+	requestIDs := make([]string, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		if seen[input.ID] {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_duplicate_input_id"), input.ID))
+			return
+		}
+		seen[input.ID] = true
+
+		var session *fsdb.Session
+		if session, err = o.BuildSession(input.Request, opts.Raw); err != nil {
+			return
+		}
+		if err = o.runPreRequestHooks(ctx, input.Request, opts, session); err != nil {
+			return
+		}
+		var messages []*chat.ChatCompletionMessage
+		if messages = session.GetVendorMessages(); len(messages) == 0 {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_input_no_messages"), input.ID))
+			return
+		}
+		requests = append(requests, ai.BatchRequest{ID: input.ID, Messages: messages})
+		requestIDs = append(requestIDs, input.ID)
+	}
+
+	var batchID string
+	if batchID, err = batchVendor.SubmitBatch(ctx, requests, opts); err != nil {
+		return
+	}
+
+	batch = &fsdb.Batch{
+		ID:         batchID,
+		Vendor:     o.vendor.GetName(),
+		Model:      o.model,
+		Pattern:    inputs[0].Request.PatternName,
+		RequestIDs: requestIDs,
+		CreatedAt:  time.Now(),
+	}
+	err = o.db.Batches.SaveBatch(batch)
+	return
+}
+
+// GetBatchStatus asks the vendor of a stored batch for its progress and records the latest status.
+func (o *PluginRegistry) GetBatchStatus(ctx context.Context, batchID string) (status *ai.BatchStatus, err error) {
+	var batch *fsdb.Batch
+	var batchVendor ai.BatchVendor
+	if batch, batchVendor, err = o.getBatchVendor(batchID); err != nil {
+		return
+	}
+	if status, err = batchVendor.GetBatchStatus(ctx, batchID); err != nil {
+		return
+	}
+	batch.Status = status.Status
+	err = o.Db.Batches.SaveBatch(batch)
+	return
+}
+
+// FetchBatchResults downloads the results of a finished batch, ordered like the submitted inputs.
+// Inputs the provider returned no result for are reported as errors.
+func (o *PluginRegistry) FetchBatchResults(ctx context.Context, batchID string) (results []ai.BatchResult, err error) {
+	var batch *fsdb.Batch
+	var batchVendor ai.BatchVendor
+	if batch, batchVendor, err = o.getBatchVendor(batchID); err != nil {
+		return
+	}
+	var fetched []ai.BatchResult
+	if fetched, err = batchVendor.FetchBatchResults(ctx, batchID); err != nil {
+		return
+	}
+
+	byID := make(map[string]ai.BatchResult, len(fetched))
+	for _, result := range fetched {
+		byID[result.ID] = result
+	}
+	for _, id := range batch.RequestIDs {
+		result, ok := byID[id]
+		if !ok {
+			result = ai.BatchResult{ID: id, Error: i18n.T("batch_result_missing_response")}
+		}
+		results = append(results, result)
+		delete(byID, id)
+	}
+	for _, result := range fetched {
+		if _, ok := byID[result.ID]; ok {
+			results = append(results, result)
+		}
+	}
+	return
+}
+
+func (o *PluginRegistry) getBatchVendor(batchID string) (batch *fsdb.Batch, batchVendor ai.BatchVendor, err error) {
+	if batch, err = o.Db.Batches.Get(batchID); err != nil {
+		return
+	}
+	vendor := o.VendorManager.FindByName(batch.Vendor)
+	if vendor == nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("vendor_not_configured"), batch.Vendor))
+		return
+	}
+	var ok bool
+	if batchVendor, ok = vendor.(ai.BatchVendor); !ok {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("vendor_no_batch_support"), batch.Vendor))
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// batchMockVendor records submitted batches and returns canned results
+type batchMockVendor struct {
+	mockVendor
+	submitted []ai.BatchRequest
+	results   []ai.BatchResult
+}
+
+func (m *batchMockVendor) SubmitBatch(_ context.Context, requests []ai.BatchRequest, _ *domain.ChatOptions) (string, error) {
+	m.submitted = requests
+	return "batch_123", nil
+}
+
+func (m *batchMockVendor) GetBatchStatus(_ context.Context, batchID string) (*ai.BatchStatus, error) {
+	return &ai.BatchStatus{ID: batchID, Status: "completed", Total: 2, Completed: 2, Done: true}, nil
+}
+
+func (m *batchMockVendor) FetchBatchResults(context.Context, string) ([]ai.BatchResult, error) {
+	return m.results, nil
+}
+
+func TestBatchSubmitStatusAndFetch(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	if err := db.Batches.Configure(); err != nil {
+		t.Fatalf("failed to configure batches: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(db.Patterns.Dir, "summarize"), 0o755); err != nil {
+		t.Fatalf("failed to create pattern directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(db.Patterns.Dir, "summarize", "system.md"), []byte("SUMMARIZE"), 0o644); err != nil {
+		t.Fatalf("failed to write pattern: %v", err)
+	}
+
+	vendor := &batchMockVendor{results: []ai.BatchResult{
+		{ID: "b", Content: "second"},
+		{ID: "a", Content: "first"},
+	}}
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+
+	inputs := []BatchInput{
+		{ID: "a", Request: &domain.ChatRequest{PatternName: "summarize", Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "one"}}},
+		{ID: "b", Request: &domain.ChatRequest{PatternName: "summarize", Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "two"}}},
+		{ID: "c", Request: &domain.ChatRequest{PatternName: "summarize", Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "three"}}},
+	}
+	batch, err := chatter.SubmitBatch(context.Background(), inputs, &domain.ChatOptions{})
+	if err != nil {
+		t.Fatalf("SubmitBatch() error = %v", err)
+	}
+	if batch.ID != "batch_123" || batch.Vendor != "mock" || batch.Pattern != "summarize" {
+		t.Errorf("unexpected batch record %+v", batch)
+	}
+	if len(vendor.submitted) != 3 || vendor.submitted[0].ID != "a" {
+		t.Fatalf("unexpected submitted requests %+v", vendor.submitted)
+	}
+	if messages := vendor.submitted[1].Messages; len(messages) != 1 || !strings.Contains(messages[0].Content, "SUMMARIZE") || !strings.Contains(messages[0].Content, "two") {
+		t.Errorf("request not built through BuildSession: %+v", messages)
+	}
+
+	vm := ai.NewVendorsManager()
+	vm.AddVendors(vendor)
+	registry := &PluginRegistry{Db: db, VendorManager: vm}
+
+	status, err := registry.GetBatchStatus(context.Background(), "batch_123")
+	if err != nil {
+		t.Fatalf("GetBatchStatus() error = %v", err)
+	}
+	if !status.Done {
+		t.Errorf("expected batch to be done, got %+v", status)
+	}
+	if stored, _ := db.Batches.Get("batch_123"); stored.Status != "completed" {
+		t.Errorf("status not persisted, got %q", stored.Status)
+	}
+
+	results, err := registry.FetchBatchResults(context.Background(), "batch_123")
+	if err != nil {
+		t.Fatalf("FetchBatchResults() error = %v", err)
+	}
+	if len(results) != 3 || results[0].Content != "first" || results[1].Content != "second" {
+		t.Fatalf("results not ordered by input, got %+v", results)
+	}
+	if results[2].ID != "c" || results[2].Error == "" {
+		t.Errorf("missing result should be reported as error, got %+v", results[2])
+	}
+}
+
+func TestBatchSubmitRejectsDuplicateIDs(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	chatter := &Chatter{db: db, vendor: &batchMockVendor{}, model: "test-model"}
+
+	inputs := []BatchInput{
+		{ID: "a", Request: &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "one"}}},
+		{ID: "a", Request: &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "two"}}},
+	}
+	if _, err := chatter.SubmitBatch(context.Background(), inputs, &domain.ChatOptions{}); err == nil {
+		t.Error("expected error for duplicate input IDs")
+	}
+}
+
+func TestBatchSubmitRequiresBatchVendor(t *testing.T) {
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: &mockVendor{}, model: "test-model"}
+	inputs := []BatchInput{{ID: "a", Request: &domain.ChatRequest{}}}
+	if _, err := chatter.SubmitBatch(context.Background(), inputs, &domain.ChatOptions{}); err == nil {
+		t.Error("expected error for vendor without batch support")
+	}
+}
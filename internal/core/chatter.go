@@ -16,6 +16,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
 	"github.com/danielmiessler/fabric/internal/plugins/strategy"
 	"github.com/danielmiessler/fabric/internal/plugins/template"
+	"github.com/danielmiessler/fabric/internal/util"
 )
 
 type Chatter struct {
@@ -27,6 +28,39 @@ type Chatter struct {
 	model              string
 	modelContextLength int
 	vendor             ai.Vendor
+	webSearch          webSearcher
+	hooks              *Hooks
+}
+
+// Label names the chatter's vendor and model as vendor|model.
+func (o *Chatter) Label() string {
+	return o.vendor.GetName() + "|" + o.model
+}
+
+// VendorName names the chatter's vendor.
+func (o *Chatter) VendorName() string {
+	return o.vendor.GetName()
+}
+
+// Model names the chatter's model, as normalized for the vendor.
+func (o *Chatter) Model() string {
+	return o.model
+}
+
+// Capabilities returns what is known about the chatter's vendor and model.
+func (o *Chatter) Capabilities() ai.ModelCapabilities {
+	return ai.LookupCapabilities(o.vendor.GetName(), o.model)
+}
+
+// ValidateRequest rejects options and attachments the selected model is known not to support.
+func (o *Chatter) ValidateRequest(request *domain.ChatRequest, opts *domain.ChatOptions) error {
+	if o.usesWebSearchBackend(opts) {
+		// The web search backend serves --search, so the model needs no native search
+		backendOpts := *opts
+		backendOpts.Search = false
+		opts = &backendOpts
+	}
+	return o.Capabilities().Validate(o.model, request, opts)
 }
 
 // recordFirstStreamError sends err to errChan if the channel is empty; subsequent errors are discarded.
@@ -43,6 +77,30 @@ func recordFirstStreamError(errChan chan error, err error) {
 	}
 }
 
+// notify tells the user about a problem that does not fail the request: as a warning
+// update when the caller follows the updates, and on stderr unless the request is quiet.
+func (o *Chatter) notify(opts *domain.ChatOptions, message string) {
+	if opts.UpdateChan != nil {
+		opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeWarning, Content: message}
+	}
+	if !opts.Quiet {
+		fmt.Fprintln(os.Stderr, message)
+	}
+}
+
+// appliesChanges tells whether the answer may propose file changes: when the request asks
+// for them, or its pattern declares apply_changes in its front matter.
+func (o *Chatter) appliesChanges(request *domain.ChatRequest, opts *domain.ChatOptions) bool {
+	if opts.ApplyChanges {
+		return true
+	}
+	if request.PatternName == "" {
+		return false
+	}
+	frontMatter, err := o.db.Patterns.GetFrontMatter(request.PatternName)
+	return err == nil && frontMatter.ApplyChanges
+}
+
 // joinPromptSections trims each part, drops empty ones, and joins the rest with newline separators.
 func joinPromptSections(parts ...string) string {
 	sections := make([]string, 0, len(parts))
@@ -56,18 +114,63 @@ func joinPromptSections(parts ...string) string {
 	return strings.Join(sections, "\n")
 }
 
-// Send processes a chat request and applies file changes for create_coding_feature pattern
+// Send sends the request and appends the answer to the session. With opts.Chunk, an
+// input too large for the model's context window is processed in chunks, see sendChunked.
 func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions) (session *fsdb.Session, err error) {
+	if opts.Chunk {
+		var chunks []string
+		var budget int
+		if chunks, budget, err = o.splitInput(request, opts); err != nil {
+			err = domain.WithCode(domain.ErrorCodeInvalidRequest, err)
+			return
+		}
+		if len(chunks) > 1 {
+			return o.sendChunked(ctx, request, opts, chunks, budget)
+		}
+	}
+	return o.send(ctx, request, opts)
+}
+
+func (o *Chatter) send(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions) (session *fsdb.Session, err error) {
 	// Use o.model (normalized) for NeedsRawMode check instead of opts.Model
 	// This ensures case-insensitive model names work correctly (e.g., "GPT-5" → "gpt-5")
 	if o.vendor.NeedsRawMode(o.model) {
 		opts.Raw = true
 	}
 	if session, err = o.BuildSession(request, opts.Raw); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidRequest, err)
+		return
+	}
+
+	var citations []chat.Citation
+	if o.usesWebSearchBackend(opts) {
+		if citations, err = o.addWebSearchResults(ctx, session, request, opts); err != nil {
+			err = domain.WithCode(domain.ErrorCodeVendor, err)
+			return
+		}
+		// The results are in the request now; the caller's options keep searching for
+		// its next requests
+		searchedOpts := *opts
+		searchedOpts.Search = false
+		opts = &searchedOpts
+	}
+
+	if err = o.runPreRequestHooks(ctx, request, opts, session); err != nil {
 		return
 	}
 
-	vendorMessages := session.GetVendorMessages()
+	var vendorMessages []*chat.ChatCompletionMessage
+	if vendorMessages, err = o.prepareAttachments(session.GetVendorMessages()); err != nil {
+		err = domain.WithCode(domain.ErrorCodeInvalidRequest, err)
+		return
+	}
+	imageGenerator, generatesImages := o.imageGenerator(opts)
+	synthesizer, speaks := o.speechSynthesizer(opts)
+	// Answers are shown once the post_response hooks, which may change or refuse them, ran
+	hold := o.hooks.Has(HookEventPostResponse) && !speaks
+	if !generatesImages {
+		vendorMessages = ai.WithoutGeneratedImages(vendorMessages)
+	}
 
 	if debuglog.GetLevel() >= debuglog.Wire {
 		debuglog.Debug(debuglog.Wire, "FABRIC->LLM request messages (%d)\n", len(vendorMessages))
@@ -85,7 +188,7 @@ func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *d
 				return
 			}
 		}
-		err = errors.New(i18n.T("chatter_error_no_messages_provided"))
+		err = domain.WithCode(domain.ErrorCodeInvalidRequest, errors.New(i18n.T("chatter_error_no_messages_provided")))
 		return
 	}
 
@@ -97,17 +200,47 @@ func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *d
 		opts.ModelContextLength = o.modelContextLength
 	}
 
+	// Wait for the client-side rate limits of the vendor and model, shared with other requests
+	estimatedTokens := 0
+	for _, msg := range vendorMessages {
+		estimatedTokens += ai.EstimateTokens(msg.TextContent())
+	}
+	if err = ai.RateLimits.Wait(ctx, o.vendor.GetName(), o.model, estimatedTokens); err != nil {
+		return
+	}
+
 	message := ""
+	reasoning := ""
+	var images []chat.ChatMessagePart
+	var usage *domain.UsageMetadata
 
-	if o.Stream {
+	if generatesImages {
+		if message, images, err = o.generateImages(ctx, imageGenerator, vendorMessages, opts); err != nil {
+			err = domain.WithCode(domain.ErrorCodeVendor, err)
+			return
+		}
+		if opts.UpdateChan != nil && !hold {
+			opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeContent, Content: message}
+		}
+		if o.Stream && !opts.SuppressThink && !opts.Quiet && !hold {
+			// Nothing was streamed, so the text is printed as a streamed answer would be
+			fmt.Println(message)
+		}
+	} else if speaks {
+		if message, err = o.synthesizeSpeech(ctx, synthesizer, vendorMessages, opts); err != nil {
+			err = domain.WithCode(domain.ErrorCodeVendor, err)
+			return
+		}
+	} else if o.Stream {
 		responseChan := make(chan domain.StreamUpdate)
 		errChan := make(chan error, 1)
 		done := make(chan struct{})
 		printedStream := false
+		printedReasoning := false
 
 		go func() {
 			defer close(done)
-			if streamErr := o.vendor.SendStream(ctx, session.GetVendorMessages(), opts, responseChan); streamErr != nil {
+			if streamErr := o.vendor.SendStream(ctx, vendorMessages, opts, responseChan); streamErr != nil {
 				recordFirstStreamError(errChan, streamErr)
 			}
 		}()
@@ -119,17 +252,35 @@ func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *d
 					debuglog.Debug(debuglog.Wire, "LLM->FABRIC stream usage input=%d output=%d total=%d\n", update.Usage.InputTokens, update.Usage.OutputTokens, update.Usage.TotalTokens)
 				}
 			}
-			if opts.UpdateChan != nil {
+			held := hold && (update.Type == domain.StreamTypeContent || update.Type == domain.StreamTypeReasoning)
+			if opts.UpdateChan != nil && !held {
 				opts.UpdateChan <- update
 			}
 			switch update.Type {
 			case domain.StreamTypeContent:
 				message += update.Content
-				if !opts.SuppressThink && !opts.Quiet {
+				if !opts.SuppressThink && !opts.Quiet && !hold {
+					if printedReasoning {
+						// Separate the answer from the reasoning printed before it
+						fmt.Print("\n\n")
+						printedReasoning = false
+					}
 					fmt.Print(update.Content)
 					printedStream = true
 				}
+			case domain.StreamTypeReasoning:
+				reasoning += update.Content
+				if !opts.SuppressThink && !opts.Quiet && !hold && update.Content != "" {
+					fmt.Print(util.Dim(os.Stdout, update.Content))
+					printedReasoning = true
+					printedStream = true
+				}
+			case domain.StreamTypeCitation:
+				citations = domain.AppendCitations(citations, update.Citations...)
 			case domain.StreamTypeUsage:
+				if update.Usage != nil {
+					usage = update.Usage
+				}
 				if opts.ShowMetadata && update.Usage != nil && !opts.Quiet {
 					fmt.Fprintf(
 						os.Stderr,
@@ -141,6 +292,17 @@ func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *d
 							update.Usage.TotalTokens,
 						),
 					)
+					if update.Usage.CacheReadTokens > 0 || update.Usage.CacheWriteTokens > 0 {
+						fmt.Fprintf(
+							os.Stderr,
+							"%s\n",
+							fmt.Sprintf(
+								i18n.T("chatter_log_stream_cache_metadata"),
+								update.Usage.CacheReadTokens,
+								update.Usage.CacheWriteTokens,
+							),
+						)
+					}
 				}
 			case domain.StreamTypeError:
 				if !opts.Quiet {
@@ -161,14 +323,25 @@ func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *d
 		select {
 		case streamErr := <-errChan:
 			if streamErr != nil {
-				err = streamErr
+				err = domain.WithCode(domain.ErrorCodeVendor, streamErr)
 				return
 			}
 		default:
 			// No errors, continue
 		}
+	} else if citationSender, ok := o.vendor.(ai.CitationSender); ok {
+		var sent []chat.Citation
+		if message, sent, err = citationSender.SendWithCitations(ctx, vendorMessages, opts); err != nil {
+			err = domain.WithCode(domain.ErrorCodeVendor, err)
+			return
+		}
+		citations = domain.AppendCitations(citations, sent...)
+		if debuglog.GetLevel() >= debuglog.Wire {
+			debuglog.Debug(debuglog.Wire, "LLM->FABRIC response content=%q citations=%d\n", message, len(citations))
+		}
 	} else {
-		if message, err = o.vendor.Send(ctx, session.GetVendorMessages(), opts); err != nil {
+		if message, err = o.vendor.Send(ctx, vendorMessages, opts); err != nil {
+			err = domain.WithCode(domain.ErrorCodeVendor, err)
 			return
 		}
 		if debuglog.GetLevel() >= debuglog.Wire {
@@ -176,38 +349,62 @@ func (o *Chatter) Send(ctx context.Context, request *domain.ChatRequest, opts *d
 		}
 	}
 
-	if opts.SuppressThink && !o.DryRun {
-		message = domain.StripThinkBlocks(message, opts.ThinkStartTag, opts.ThinkEndTag)
+	// Correct the token budget with the reported usage, or the estimated size of the answer
+	actualTokens := estimatedTokens + ai.EstimateTokens(message)
+	if usage != nil && usage.TotalTokens > 0 {
+		actualTokens = usage.TotalTokens
+	}
+	ai.RateLimits.Record(o.vendor.GetName(), o.model, estimatedTokens, actualTokens)
+
+	// Move reasoning that vendors left inline in think tags out of the answer
+	if !o.DryRun && !speaks {
+		if thinking, content := domain.SplitThinkBlocks(message, opts.ThinkStartTag, opts.ThinkEndTag); content != "" {
+			message = content
+			reasoning += thinking
+		}
 	}
 
 	if message == "" {
 		session = nil
-		err = errors.New(i18n.T("chatter_error_empty_response"))
+		err = domain.WithCode(domain.ErrorCodeEmptyResponse, errors.New(i18n.T("chatter_error_empty_response")))
 		return
 	}
 
-	// Process file changes for create_coding_feature pattern
-	if request.PatternName == "create_coding_feature" {
+	if hold {
+		if message, err = o.runPostResponseHooks(ctx, request, opts, session, message); err != nil {
+			session = nil
+			return
+		}
+		o.releaseHeldAnswer(opts, message, reasoning, o.Stream || generatesImages)
+	}
+
+	// Hand the file changes the answer proposes to the caller, which reviews and applies them
+	if o.appliesChanges(request, opts) {
 		summary, fileChanges, parseErr := domain.ParseFileChanges(message)
 		if parseErr != nil {
-			fmt.Printf("%s\n", fmt.Sprintf(i18n.T("chatter_warning_parse_file_changes_failed"), parseErr))
+			o.notify(opts, fmt.Sprintf(i18n.T("chatter_warning_parse_file_changes_failed"), parseErr))
 		} else if len(fileChanges) > 0 {
-			projectRoot, err := os.Getwd()
-			if err != nil {
-				fmt.Printf("%s\n", fmt.Sprintf(i18n.T("chatter_warning_get_current_directory_failed"), err))
-			} else {
-				if applyErr := domain.ApplyFileChanges(projectRoot, fileChanges); applyErr != nil {
-					fmt.Printf("%s\n", fmt.Sprintf(i18n.T("chatter_warning_apply_file_changes_failed"), applyErr))
-				} else {
-					fmt.Println(i18n.T("chatter_info_file_changes_applied_successfully"))
-					fmt.Printf("%s\n\n", i18n.T("chatter_help_review_changes_with_git_diff"))
-				}
+			if opts.ChangeApplier == nil {
+				o.notify(opts, fmt.Sprintf(i18n.T("chatter_warning_file_changes_not_applied"), len(fileChanges)))
+			} else if applyErr := opts.ChangeApplier(fileChanges); applyErr != nil {
+				o.notify(opts, fmt.Sprintf(i18n.T("chatter_warning_apply_file_changes_failed"), applyErr))
 			}
 		}
 		message = summary
 	}
 
-	session.Append(&chat.ChatCompletionMessage{Role: chat.ChatMessageRoleAssistant, Content: message})
+	answer := &chat.ChatCompletionMessage{
+		Role:             chat.ChatMessageRoleAssistant,
+		Content:          message,
+		ReasoningContent: strings.TrimSpace(reasoning),
+		Citations:        citations,
+	}
+	if len(images) > 0 {
+		// Generated images are kept as attachments of the answer, next to its text
+		answer.Content = ""
+		answer.MultiContent = append([]chat.ChatMessagePart{{Type: chat.ChatMessagePartTypeText, Text: message}}, images...)
+	}
+	session.Append(answer)
 
 	if session.Name != "" {
 		err = o.db.Sessions.SaveSession(session)
@@ -231,17 +428,6 @@ func (o *Chatter) BuildSession(request *domain.ChatRequest, raw bool) (session *
 		session.Append(&chat.ChatCompletionMessage{Role: domain.ChatMessageRoleMeta, Content: request.Meta})
 	}
 
-	// if a context name is provided, retrieve it from the database
-	var contextContent string
-	if request.ContextName != "" {
-		var ctx *fsdb.Context
-		if ctx, err = o.db.Contexts.Get(request.ContextName); err != nil {
-			err = fmt.Errorf(i18n.T("chatter_error_find_context"), request.ContextName, err)
-			return
-		}
-		contextContent = ctx.Content
-	}
-
 	// Process template variables in message content
 	// Double curly braces {{variable}} indicate template substitution
 	// Ensure we have a message before processing
@@ -260,6 +446,21 @@ func (o *Chatter) BuildSession(request *domain.ChatRequest, raw bool) (session *
 		}
 	}
 
+	// Contexts are stacked in the order given, with their variables substituted like those of patterns
+	var sections []string
+	for _, name := range request.ContextNames {
+		var ctx *fsdb.Context
+		if request.NoVariableReplacement {
+			ctx, err = o.db.Contexts.GetFromSource(name)
+		} else {
+			ctx, err = o.db.Contexts.GetApplyVariables(name, request.PatternVariables, request.Message.Content)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(i18n.T("chatter_error_find_context"), name, err)
+		}
+		sections = append(sections, ctx.Content)
+	}
+
 	var patternContent string
 	inputUsed := false
 	if request.PatternName != "" {
@@ -277,7 +478,7 @@ func (o *Chatter) BuildSession(request *domain.ChatRequest, raw bool) (session *
 		inputUsed = true
 	}
 
-	systemMessage := joinPromptSections(contextContent, patternContent)
+	systemMessage := joinPromptSections(append(sections, patternContent)...)
 
 	if request.StrategyName != "" {
 		strategy, err := strategy.LoadStrategy(request.StrategyName)
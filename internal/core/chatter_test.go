@@ -229,7 +229,7 @@ func TestChatter_BuildSession_SeparatesSystemSections(t *testing.T) {
 
 	chatter := &Chatter{db: db}
 	request := &domain.ChatRequest{
-		ContextName:  "test-context",
+		ContextNames: []string{"test-context"},
 		PatternName:  "test-pattern",
 		StrategyName: "test-strategy",
 		Message: &chat.ChatCompletionMessage{
@@ -263,6 +263,52 @@ func TestChatter_BuildSession_SeparatesSystemSections(t *testing.T) {
 	}
 }
 
+func TestChatter_BuildSession_StacksTemplatedContexts(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	if err := os.MkdirAll(db.Contexts.Dir, 0o755); err != nil {
+		t.Fatalf("failed to create context directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(db.Contexts.Dir, "audience"), []byte("Audience: {{audience}}"), 0o644); err != nil {
+		t.Fatalf("failed to write context: %v", err)
+	}
+	stylePath := filepath.Join(t.TempDir(), "style.md")
+	if err := os.WriteFile(stylePath, []byte("Style: terse"), 0o644); err != nil {
+		t.Fatalf("failed to write context file: %v", err)
+	}
+
+	chatter := &Chatter{db: db}
+	newRequest := func() *domain.ChatRequest {
+		return &domain.ChatRequest{
+			ContextNames:     []string{stylePath, "audience"},
+			PatternVariables: map[string]string{"audience": "engineers"},
+			Message:          &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "input"},
+		}
+	}
+
+	session, err := chatter.BuildSession(newRequest(), false)
+	if err != nil {
+		t.Fatalf("BuildSession returned error: %v", err)
+	}
+	if got := session.GetVendorMessages()[0].Content; got != "Style: terse\nAudience: engineers" {
+		t.Fatalf("expected stacked contexts with variables applied, got %q", got)
+	}
+
+	request := newRequest()
+	request.NoVariableReplacement = true
+	if session, err = chatter.BuildSession(request, false); err != nil {
+		t.Fatalf("BuildSession returned error: %v", err)
+	}
+	if got := session.GetVendorMessages()[0].Content; got != "Style: terse\nAudience: {{audience}}" {
+		t.Fatalf("expected contexts without variable replacement, got %q", got)
+	}
+
+	request = newRequest()
+	request.ContextNames = append(request.ContextNames, "missing")
+	if _, err = chatter.BuildSession(request, false); err == nil {
+		t.Fatal("expected an error for a missing context")
+	}
+}
+
 func TestChatter_Send_StreamingErrorPropagation(t *testing.T) {
 	// Create a temporary database for testing
 	tempDir := t.TempDir()
@@ -519,3 +565,171 @@ func TestChatter_Send_StreamingMetadataPropagation(t *testing.T) {
 		t.Error("Expected to receive a usage metadata update, but didn't")
 	}
 }
+
+func TestChatter_Send_StreamingReasoningStoredSeparately(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+
+	mockVendor := &mockVendor{
+		streamChunks: []domain.StreamUpdate{
+			{Type: domain.StreamTypeReasoning, Content: "Let me think"},
+			{Type: domain.StreamTypeReasoning, Content: " about it."},
+			{Type: domain.StreamTypeContent, Content: "The answer."},
+		},
+	}
+	chatter := &Chatter{db: db, Stream: true, vendor: mockVendor, model: "test-model"}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "question"}}
+	session, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model", Quiet: true})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	last := session.GetLastMessage()
+	if last.Content != "The answer." {
+		t.Errorf("Expected content %q, got %q", "The answer.", last.Content)
+	}
+	if last.ReasoningContent != "Let me think about it." {
+		t.Errorf("Expected reasoning %q, got %q", "Let me think about it.", last.ReasoningContent)
+	}
+}
+
+func TestChatter_Send_InlineThinkTagsMovedToReasoning(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+
+	mockVendor := &mockVendor{
+		sendFunc: func(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+			return "<think>hidden steps</think>\n\nvisible answer", nil
+		},
+	}
+	chatter := &Chatter{db: db, vendor: mockVendor, model: "test-model"}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "question"}}
+	session, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	last := session.GetLastMessage()
+	if last.Content != "visible answer" || last.ReasoningContent != "hidden steps" {
+		t.Errorf("Unexpected message content %q reasoning %q", last.Content, last.ReasoningContent)
+	}
+}
+
+// citationMockVendor returns canned citations from SendWithCitations
+type citationMockVendor struct {
+	mockVendor
+	citations []chat.Citation
+}
+
+func (m *citationMockVendor) SendWithCitations(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, []chat.Citation, error) {
+	return "cited answer", m.citations, nil
+}
+
+func TestChatter_Send_StreamingCitationsStoredInSession(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	citation := chat.Citation{URL: "https://example.com", Title: "Example", Span: "The answer."}
+
+	mockVendor := &mockVendor{
+		streamChunks: []domain.StreamUpdate{
+			{Type: domain.StreamTypeContent, Content: "The answer."},
+			{Type: domain.StreamTypeCitation, Citations: []chat.Citation{citation}},
+			{Type: domain.StreamTypeCitation, Citations: []chat.Citation{citation}},
+		},
+	}
+	chatter := &Chatter{db: db, Stream: true, vendor: mockVendor, model: "test-model"}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "question"}}
+	session, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model", Quiet: true})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	last := session.GetLastMessage()
+	if last.Content != "The answer." {
+		t.Errorf("Expected citations to stay out of the content, got %q", last.Content)
+	}
+	if len(last.Citations) != 1 || last.Citations[0] != citation {
+		t.Errorf("Expected one stored citation, got %+v", last.Citations)
+	}
+}
+
+func TestChatter_Send_UsesCitationSender(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	vendor := &citationMockVendor{citations: []chat.Citation{{URL: "https://example.com"}}}
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "question"}}
+	session, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	last := session.GetLastMessage()
+	if last.Content != "cited answer" || len(last.Citations) != 1 || last.Citations[0].URL != "https://example.com" {
+		t.Errorf("Unexpected message %+v", last)
+	}
+}
+
+func TestChatter_Send_HandsFileChangesToApplier(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	for name, content := range map[string]string{
+		"coder":  "---\napply_changes: true\n---\nWrite code.",
+		"writer": "Write prose.",
+	} {
+		if err := os.MkdirAll(filepath.Join(db.Patterns.Dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(db.Patterns.Dir, name, "system.md"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	answer := "Summary.\n" + domain.FileChangesMarker + "\n[{\"operation\": \"create\", \"path\": \"a.txt\", \"content\": \"A\"}]"
+	vendor := &mockVendor{sendFunc: func(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+		return answer, nil
+	}}
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+
+	send := func(pattern string, opts *domain.ChatOptions) *fsdb.Session {
+		request := &domain.ChatRequest{
+			PatternName: pattern,
+			Message:     &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "add a"},
+		}
+		session, err := chatter.Send(context.Background(), request, opts)
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		return session
+	}
+
+	var applied []domain.FileChange
+	applier := func(changes []domain.FileChange) error {
+		applied = changes
+		return nil
+	}
+	session := send("coder", &domain.ChatOptions{Quiet: true, ChangeApplier: applier})
+	if len(applied) != 1 || applied[0].Path != "a.txt" {
+		t.Errorf("expected the applier to get the change to a.txt, got %+v", applied)
+	}
+	if got := session.GetLastMessage().Content; got != "Summary.\n" {
+		t.Errorf("expected the summary as answer, got %q", got)
+	}
+
+	// Patterns without apply_changes keep the answer as it is
+	applied = nil
+	session = send("writer", &domain.ChatOptions{Quiet: true, ChangeApplier: applier})
+	if applied != nil || session.GetLastMessage().Content != answer {
+		t.Errorf("expected no changes for a pattern without apply_changes, got %+v", applied)
+	}
+
+	// Without an applier, the changes are reported as not applied
+	updates := make(chan domain.StreamUpdate, 10)
+	send("writer", &domain.ChatOptions{Quiet: true, ApplyChanges: true, UpdateChan: updates})
+	close(updates)
+	var warned bool
+	for update := range updates {
+		warned = warned || update.Type == domain.StreamTypeWarning
+	}
+	if !warned {
+		t.Error("expected a warning for file changes without an applier")
+	}
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// chunkWorkers is how many chunks of an input are sent at the same time.
+const chunkWorkers = 4
+
+// chunkCharsPerToken converts token budgets to text lengths, like ai.EstimateTokens.
+const chunkCharsPerToken = 4
+
+// minChunkTokens keeps chunks useful when the prompt leaves little room in the context window.
+const minChunkTokens = 512
+
+// chunkPartHeader introduces each partial result in the input of the reduce step.
+const chunkPartHeader = "## Part %d of %d\n\n"
+
+// chunkSeparators are the boundaries inputs are split on, from the most natural to the
+// least: paragraphs and subtitle cues, lines such as log entries, sentences and words.
+var chunkSeparators = []string{"\n\n", "\n", ". ", " "}
+
+// splitInput splits the input of a pattern into chunks that fit the budget, and returns
+// no chunks when it fits as it is. The budget is opts.ChunkSize, or what the model's
+// context window leaves after the prompt and room for the answer.
+func (o *Chatter) splitInput(request *domain.ChatRequest, opts *domain.ChatOptions) (ret []string, budget int, err error) {
+	// Only a pattern can be applied to parts of the input; attachments, generated files
+	// and code changes need the whole request
+	if request.PatternName == "" || request.Message == nil || len(request.Message.MultiContent) > 0 ||
+		opts.ImageFile != "" || opts.AudioOutput || o.appliesChanges(request, opts) {
+		return
+	}
+	if budget = opts.ChunkSize; budget <= 0 {
+		if budget, err = o.chunkBudget(request, opts); err != nil || budget == 0 {
+			return
+		}
+	}
+	if ai.EstimateTokens(request.Message.Content) <= budget {
+		return
+	}
+	ret = splitText(request.Message.Content, budget*chunkCharsPerToken, opts.ChunkOverlap*chunkCharsPerToken)
+	return
+}
+
+// chunkBudget returns how many input tokens a request can take, or 0 when the context
+// window of the model is unknown.
+func (o *Chatter) chunkBudget(request *domain.ChatRequest, opts *domain.ChatOptions) (ret int, err error) {
+	capabilities := o.Capabilities()
+	window := opts.ModelContextLength
+	if window == 0 {
+		window = o.modelContextLength
+	}
+	if window == 0 {
+		window = int(capabilities.ContextWindow)
+	}
+	if window == 0 {
+		debuglog.Debug(debuglog.Basic, "Context window of %s unknown, the input is not chunked\n", o.Label())
+		return
+	}
+
+	reserve := opts.MaxTokens
+	if reserve == 0 {
+		reserve = window / 4
+		if capabilities.MaxOutputTokens > 0 {
+			reserve = min(reserve, int(capabilities.MaxOutputTokens))
+		}
+	}
+
+	// The pattern, context and strategy are sent with every chunk
+	promptRequest := *request
+	promptRequest.SessionName = ""
+	promptRequest.Message = &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser}
+	var session *fsdb.Session
+	if session, err = o.BuildSession(&promptRequest, opts.Raw); err != nil {
+		return
+	}
+	prompt := 0
+	for _, message := range session.GetVendorMessages() {
+		prompt += ai.EstimateTokens(message.TextContent())
+	}
+	ret = max(window-reserve-prompt, minChunkTokens)
+	return
+}
+
+// sendChunked runs the pattern over every chunk, chunkWorkers at a time, then sends the
+// partial results as the input of the reduce step, which uses opts.MergePattern or the
+// pattern itself. The chunks are sent quietly and outside the session; only the reduce
+// step is streamed and kept in the session. When the partial results are still too
+// large, the reduce step is chunked in turn, as long as that makes the input shrink.
+func (o *Chatter) sendChunked(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions,
+	chunks []string, budget int) (session *fsdb.Session, err error) {
+
+	input := request.Message.Content
+	if !opts.Quiet {
+		fmt.Fprintf(os.Stderr, "%s\n", fmt.Sprintf(i18n.T("chunking_input"), ai.EstimateTokens(input), budget, len(chunks)))
+	}
+
+	mapCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	partials := make([]string, len(chunks))
+	workers := make(chan struct{}, chunkWorkers)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			if mapCtx.Err() != nil {
+				return
+			}
+			partial, chunkErr := o.sendChunk(mapCtx, request, opts, chunk)
+			mu.Lock()
+			defer mu.Unlock()
+			if chunkErr != nil {
+				if err == nil {
+					err = domain.WithCode(domain.CodeOf(chunkErr), fmt.Errorf("%s", fmt.Sprintf(i18n.T("chunk_failed"), i+1, len(chunks), chunkErr)))
+					cancel()
+				}
+				return
+			}
+			partials[i] = partial
+		}()
+	}
+	wg.Wait()
+	if err != nil {
+		return
+	}
+
+	var combined strings.Builder
+	for i, partial := range partials {
+		if i > 0 {
+			combined.WriteString("\n\n")
+		}
+		fmt.Fprintf(&combined, chunkPartHeader, i+1, len(partials))
+		combined.WriteString(strings.TrimSpace(partial))
+	}
+
+	reduce := *request
+	if opts.MergePattern != "" {
+		reduce.PatternName = opts.MergePattern
+	}
+	reduce.Message = &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: combined.String()}
+	if combined.Len() < len(input) {
+		return o.Send(ctx, &reduce, opts)
+	}
+	return o.send(ctx, &reduce, opts)
+}
+
+// sendChunk runs the pattern over one chunk and returns the partial result.
+func (o *Chatter) sendChunk(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions, chunk string) (ret string, err error) {
+	chunkRequest := *request
+	chunkRequest.SessionName = ""
+	chunkRequest.Message = &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: chunk}
+
+	// Web search is left to the reduce step, which sees the whole task
+	chunkOpts := *opts
+	chunkOpts.Quiet = true
+	chunkOpts.ShowMetadata = false
+	chunkOpts.Search = false
+	chunkOpts.UpdateChan = nil
+
+	var session *fsdb.Session
+	if session, err = o.send(ctx, &chunkRequest, &chunkOpts); err != nil {
+		return
+	}
+	ret = session.GetLastMessage().TextContent()
+	return
+}
+
+// splitText splits text into chunks of at most size bytes on the most natural boundaries
+// available. Every chunk but the first starts with up to overlap bytes of the end of the
+// previous one, so that passages cut at a boundary keep their context.
+func splitText(text string, size int, overlap int) (ret []string) {
+	overlap = min(max(overlap, 0), size/4)
+	var chunk strings.Builder
+	for _, piece := range splitPieces(text, size-overlap, 0) {
+		if chunk.Len() > 0 && chunk.Len()+len(piece) > size {
+			previous := chunk.String()
+			ret = append(ret, previous)
+			chunk.Reset()
+			chunk.WriteString(overlapTail(previous, overlap))
+		}
+		chunk.WriteString(piece)
+	}
+	if chunk.Len() > 0 {
+		ret = append(ret, chunk.String())
+	}
+	return
+}
+
+// splitPieces cuts text after each separator of the given level into pieces of at most
+// size bytes, cutting longer pieces on the next separators, and at size bytes as a last
+// resort. The pieces joined together give back the text.
+func splitPieces(text string, size int, level int) (ret []string) {
+	if len(text) <= size {
+		return []string{text}
+	}
+	if level == len(chunkSeparators) {
+		for len(text) > size {
+			cut := size
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				cut = size
+			}
+			ret = append(ret, text[:cut])
+			text = text[cut:]
+		}
+		if text != "" {
+			ret = append(ret, text)
+		}
+		return
+	}
+	for _, part := range strings.SplitAfter(text, chunkSeparators[level]) {
+		if part != "" {
+			ret = append(ret, splitPieces(part, size, level+1)...)
+		}
+	}
+	return
+}
+
+// overlapTail returns the end of text to repeat at the start of the next chunk: at most
+// overlap bytes, starting after the most natural boundary that keeps at least half of them.
+func overlapTail(text string, overlap int) string {
+	if overlap <= 0 || len(text) <= overlap {
+		return ""
+	}
+	tail := text[len(text)-overlap:]
+	for _, separator := range chunkSeparators {
+		if i := strings.Index(tail, separator); i >= 0 && len(tail)-i-len(separator) >= overlap/2 {
+			return tail[i+len(separator):]
+		}
+	}
+	for i := range len(tail) {
+		if utf8.RuneStart(tail[i]) {
+			return tail[i:]
+		}
+	}
+	return ""
+}
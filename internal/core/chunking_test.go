@@ -0,0 +1,166 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+func TestSplitText(t *testing.T) {
+	var paragraphs []string
+	for i := range 20 {
+		paragraphs = append(paragraphs, fmt.Sprintf("Paragraph %d. %s", i, strings.Repeat("word ", 15)))
+	}
+	text := strings.Join(paragraphs, "\n\n")
+
+	chunks := splitText(text, 400, 0)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	if joined := strings.Join(chunks, ""); joined != text {
+		t.Error("chunks without overlap must join back into the text")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 400 {
+			t.Errorf("chunk %d has %d bytes, over the size of 400", i, len(chunk))
+		}
+		if !strings.HasPrefix(chunk, "Paragraph ") {
+			t.Errorf("chunk %d does not start at a paragraph: %q", i, chunk[:20])
+		}
+	}
+
+	overlapping := splitText(text, 400, 80)
+	for i := 1; i < len(overlapping); i++ {
+		previous := overlapping[i-1]
+		tail := previous[len(previous)-20:]
+		if !strings.Contains(overlapping[i], tail) {
+			t.Errorf("chunk %d does not repeat the end of chunk %d", i, i-1)
+		}
+		if len(overlapping[i]) > 400 {
+			t.Errorf("chunk %d has %d bytes, over the size of 400", i, len(overlapping[i]))
+		}
+	}
+}
+
+func TestSplitTextLinesAndRunes(t *testing.T) {
+	logs := strings.Repeat("2025-01-01T12:00:00Z INFO request served\n", 30)
+	for i, chunk := range splitText(logs, 200, 0) {
+		if !strings.HasSuffix(chunk, "\n") {
+			t.Errorf("log chunk %d is cut inside a line: %q", i, chunk)
+		}
+	}
+
+	// Text without any separator is cut between runes
+	runes := strings.Repeat("日本語", 50)
+	chunks := splitText(runes, 100, 0)
+	for i, chunk := range chunks {
+		if !utf8.ValidString(chunk) {
+			t.Errorf("chunk %d cuts a rune", i)
+		}
+	}
+	if strings.Join(chunks, "") != runes {
+		t.Error("chunks must join back into the text")
+	}
+}
+
+func TestChatter_Send_Chunked(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	for name, content := range map[string]string{"summarize": "Summarize:", "merge": "Merge the summaries:"} {
+		if err := os.MkdirAll(filepath.Join(db.Patterns.Dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(db.Patterns.Dir, name, "system.md"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(db.Sessions.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var prompts []string
+	vendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var prompt strings.Builder
+		for _, message := range messages {
+			prompt.WriteString(message.Content)
+		}
+		prompts = append(prompts, prompt.String())
+		if strings.HasPrefix(prompt.String(), "Merge") {
+			return "merged", nil
+		}
+		return "partial", nil
+	}}
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+
+	input := strings.TrimSpace(strings.Repeat("A paragraph of the transcript.\n\n", 30))
+	request := &domain.ChatRequest{
+		PatternName: "summarize",
+		SessionName: "chunked",
+		Message:     &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: input},
+	}
+	opts := &domain.ChatOptions{Chunk: true, ChunkSize: 100, MergePattern: "merge", Quiet: true}
+
+	session, err := chatter.Send(context.Background(), request, opts)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := session.GetLastMessage().Content; got != "merged" {
+		t.Errorf("answer = %q, want the result of the merge pattern", got)
+	}
+
+	// 30 paragraphs of 32 bytes make 3 chunks of at most 400 bytes
+	if len(prompts) != 4 {
+		t.Fatalf("expected 3 chunks and a reduce step, got %d requests", len(prompts))
+	}
+	reduce := prompts[3]
+	if !strings.Contains(reduce, "## Part 1 of 3\n\npartial") || !strings.Contains(reduce, "## Part 3 of 3\n\npartial") {
+		t.Errorf("unexpected reduce input: %q", reduce)
+	}
+
+	// Only the reduce step is kept in the session
+	saved, err := db.Sessions.Get("chunked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Messages) != 2 {
+		t.Errorf("expected the reduce request and its answer in the session, got %d messages", len(saved.Messages))
+	}
+}
+
+func TestChatter_Send_NotChunkedWhenInputFits(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	if err := os.MkdirAll(filepath.Join(db.Patterns.Dir, "summarize"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(db.Patterns.Dir, "summarize", "system.md"), []byte("Summarize:"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	vendor := &mockVendor{sendFunc: func(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+		calls++
+		return "answer", nil
+	}}
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+
+	request := &domain.ChatRequest{
+		PatternName: "summarize",
+		Message:     &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "short input"},
+	}
+	if _, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Chunk: true, ChunkSize: 100}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single request, got %d", calls)
+	}
+}
@@ -0,0 +1,316 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// CompareResult is the answer of one model in a comparison.
+type CompareResult struct {
+	Vendor    string                `json:"vendor"`
+	Model     string                `json:"model"`
+	Output    string                `json:"output,omitempty"`
+	Error     string                `json:"error,omitempty"`
+	LatencyMs int64                 `json:"latency_ms"`
+	Usage     *domain.UsageMetadata `json:"usage,omitempty"`
+	// CostUSD is estimated from the model's list prices; it is nil when they are unknown.
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+}
+
+// Label names the result as vendor|model, the form --compare accepts.
+func (o *CompareResult) Label() string {
+	return o.Vendor + "|" + o.Model
+}
+
+// CompareRanking is the place the judge gave one result.
+type CompareRanking struct {
+	Rank   int     `json:"rank"`
+	Vendor string  `json:"vendor"`
+	Model  string  `json:"model"`
+	Score  float64 `json:"score,omitempty"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// CompareJudgement is the ranking of the results by a judge model.
+type CompareJudgement struct {
+	Vendor  string           `json:"vendor"`
+	Model   string           `json:"model"`
+	Ranking []CompareRanking `json:"ranking,omitempty"`
+	Summary string           `json:"summary,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// CompareReport holds the results of running one request through several models.
+type CompareReport struct {
+	Pattern string            `json:"pattern,omitempty"`
+	Input   string            `json:"input,omitempty"`
+	Results []CompareResult   `json:"results"`
+	Judge   *CompareJudgement `json:"judge,omitempty"`
+
+	// task is the prompt the models answered, shown to the judge
+	task string
+}
+
+// Succeeded returns the results that have an answer.
+func (o *CompareReport) Succeeded() (ret []*CompareResult) {
+	for i := range o.Results {
+		if o.Results[i].Error == "" {
+			ret = append(ret, &o.Results[i])
+		}
+	}
+	return
+}
+
+// Compare sends the request to every chatter concurrently and collects their answers,
+// latency, token usage and estimated cost. Failures are recorded per result, so one
+// model failing does not stop the others. Sessions are not supported, since every
+// model would append to the same one.
+func Compare(ctx context.Context, chatters []*Chatter, request *domain.ChatRequest, opts *domain.ChatOptions) (ret *CompareReport, err error) {
+	if request.SessionName != "" {
+		err = errors.New(i18n.T("compare_session_not_supported"))
+		return
+	}
+	if len(chatters) > 0 && chatters[0].appliesChanges(request, opts) {
+		err = errors.New(i18n.T("compare_apply_changes_not_supported"))
+		return
+	}
+
+	ret = &CompareReport{Pattern: request.PatternName, Results: make([]CompareResult, len(chatters))}
+	if request.Message != nil {
+		ret.Input = request.Message.Content
+	}
+	sessions := make([]*fsdb.Session, len(chatters))
+	var wg sync.WaitGroup
+	for i, chatter := range chatters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret.Results[i], sessions[i] = chatter.compareSend(ctx, request, opts)
+		}()
+	}
+	wg.Wait()
+
+	for _, session := range sessions {
+		if session != nil {
+			ret.task = compareTask(session)
+			break
+		}
+	}
+	return
+}
+
+// compareSend sends a copy of the request quietly and records how the chatter did.
+// Streaming is used to receive token usage from the vendors that report it.
+func (o *Chatter) compareSend(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions) (ret CompareResult, session *fsdb.Session) {
+	ret.Vendor, ret.Model = o.vendor.GetName(), o.model
+
+	// Send fills in the request and options, so every model gets its own copy
+	requestCopy := *request
+	if request.Message != nil {
+		message := *request.Message
+		requestCopy.Message = &message
+	}
+	optsCopy := *opts
+	optsCopy.Quiet = true
+	updates := make(chan domain.StreamUpdate)
+	optsCopy.UpdateChan = updates
+
+	var usage *domain.UsageMetadata
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for update := range updates {
+			if update.Type == domain.StreamTypeUsage && update.Usage != nil {
+				usage = update.Usage
+			}
+		}
+	}()
+
+	start := time.Now()
+	session, err := o.Send(ctx, &requestCopy, &optsCopy)
+	ret.LatencyMs = time.Since(start).Milliseconds()
+	close(updates)
+	<-drained
+
+	if err != nil {
+		ret.Error = err.Error()
+		session = nil
+		return
+	}
+	ret.Output = session.GetLastMessage().TextContent()
+	ret.Usage = usage
+	if cost, known := o.Capabilities().EstimateCost(usage); known {
+		ret.CostUSD = &cost
+	}
+	return
+}
+
+// compareTask returns the text of the messages sent to the models, without the answer.
+func compareTask(session *fsdb.Session) string {
+	var parts []string
+	for _, message := range session.Messages[:len(session.Messages)-1] {
+		if message.Role == domain.ChatMessageRoleMeta {
+			continue
+		}
+		if text := strings.TrimSpace(message.TextContent()); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// compareJudgePrompt asks the judge for a ranking in the JSON shape parse reads.
+const compareJudgePrompt = `You are judging how well several AI models completed the same task.
+
+Rank the responses from best to worst by how accurately, completely and clearly they complete the task.
+Reply with only a JSON object in this format:
+{"ranking": [{"response": <response number>, "score": <score from 1 to 10>, "reason": "<one sentence>"}], "summary": "<two sentences comparing the responses>"}
+
+# TASK
+
+%s
+
+# RESPONSES
+%s`
+
+// Judge asks the chatter to rank the successful results of the report. A judge that
+// fails or replies in an unexpected format is recorded in the judgement.
+func (o *Chatter) Judge(ctx context.Context, report *CompareReport, opts *domain.ChatOptions) *CompareJudgement {
+	ret := &CompareJudgement{Vendor: o.vendor.GetName(), Model: o.model}
+	results := report.Succeeded()
+	if len(results) < 2 {
+		ret.Error = i18n.T("compare_judge_needs_two_results")
+		return ret
+	}
+
+	var responses strings.Builder
+	for i, result := range results {
+		fmt.Fprintf(&responses, "\n## RESPONSE %d\n\n%s\n", i+1, result.Output)
+	}
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{
+		Role:    chat.ChatMessageRoleUser,
+		Content: fmt.Sprintf(compareJudgePrompt, report.task, responses.String()),
+	}}
+	optsCopy := *opts
+	optsCopy.Quiet = true
+
+	session, err := o.Send(ctx, request, &optsCopy)
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+	if err = ret.parse(session.GetLastMessage().TextContent(), results); err != nil {
+		ret.Error = err.Error()
+	}
+	return ret
+}
+
+// parse reads the judge's JSON reply, ignoring text around it, and maps the response
+// numbers back to the results.
+func (o *CompareJudgement) parse(reply string, results []*CompareResult) (err error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return errors.New(i18n.T("compare_judge_invalid_reply"))
+	}
+	var parsed struct {
+		Ranking []struct {
+			Response int     `json:"response"`
+			Score    float64 `json:"score"`
+			Reason   string  `json:"reason"`
+		} `json:"ranking"`
+		Summary string `json:"summary"`
+	}
+	if err = json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return fmt.Errorf("%s", fmt.Sprintf(i18n.T("compare_judge_invalid_reply_with_error"), err))
+	}
+
+	seen := make(map[int]bool)
+	for _, entry := range parsed.Ranking {
+		if entry.Response < 1 || entry.Response > len(results) || seen[entry.Response] {
+			continue
+		}
+		seen[entry.Response] = true
+		result := results[entry.Response-1]
+		o.Ranking = append(o.Ranking, CompareRanking{
+			Rank:   len(o.Ranking) + 1,
+			Vendor: result.Vendor,
+			Model:  result.Model,
+			Score:  entry.Score,
+			Reason: entry.Reason,
+		})
+	}
+	if len(o.Ranking) == 0 {
+		return errors.New(i18n.T("compare_judge_invalid_reply"))
+	}
+	o.Summary = parsed.Summary
+	return
+}
+
+// Markdown renders the report with a summary table, the judge's ranking and every answer.
+func (o *CompareReport) Markdown() string {
+	var builder strings.Builder
+	builder.WriteString("# " + i18n.T("compare_report_title") + "\n\n")
+	if o.Pattern != "" {
+		fmt.Fprintf(&builder, "%s: %s\n\n", i18n.T("compare_report_pattern"), o.Pattern)
+	}
+
+	fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s |\n|---|---|---|---|---|\n", i18n.T("compare_report_model"),
+		i18n.T("compare_report_latency"), i18n.T("compare_report_input_tokens"),
+		i18n.T("compare_report_output_tokens"), i18n.T("compare_report_cost"))
+	for i := range o.Results {
+		result := &o.Results[i]
+		input, output := "-", "-"
+		if result.Usage != nil {
+			input, output = fmt.Sprint(result.Usage.InputTokens), fmt.Sprint(result.Usage.OutputTokens)
+		}
+		fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s |\n", strings.ReplaceAll(result.Label(), "|", `\|`), FormatLatency(result.LatencyMs),
+			input, output, FormatCost(result.CostUSD))
+	}
+
+	if o.Judge != nil {
+		fmt.Fprintf(&builder, "\n## %s\n\n", fmt.Sprintf(i18n.T("compare_report_judge"), o.Judge.Vendor+"|"+o.Judge.Model))
+		if o.Judge.Error != "" {
+			builder.WriteString(o.Judge.Error + "\n")
+		}
+		for _, ranking := range o.Judge.Ranking {
+			fmt.Fprintf(&builder, "%d. **%s|%s** (%g/10): %s\n", ranking.Rank, ranking.Vendor, ranking.Model, ranking.Score, ranking.Reason)
+		}
+		if o.Judge.Summary != "" {
+			builder.WriteString("\n" + o.Judge.Summary + "\n")
+		}
+	}
+
+	for i := range o.Results {
+		result := &o.Results[i]
+		fmt.Fprintf(&builder, "\n## %s\n\n", result.Label())
+		if result.Error != "" {
+			fmt.Fprintf(&builder, "%s\n", fmt.Sprintf(i18n.T("compare_report_error"), result.Error))
+		} else {
+			builder.WriteString(strings.TrimSpace(result.Output) + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// FormatLatency formats milliseconds as seconds with one decimal.
+func FormatLatency(ms int64) string {
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
+// FormatCost formats an estimated cost in USD, or "-" when it is unknown.
+func FormatCost(cost *float64) string {
+	if cost == nil {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", *cost)
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+func compareChatter(t *testing.T, model string, vendor *mockVendor) *Chatter {
+	return &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: model, Stream: true}
+}
+
+func TestCompare(t *testing.T) {
+	answering := &mockVendor{streamChunks: []domain.StreamUpdate{
+		{Type: domain.StreamTypeContent, Content: "first answer"},
+		{Type: domain.StreamTypeUsage, Usage: &domain.UsageMetadata{InputTokens: 1000, OutputTokens: 500, TotalTokens: 1500}},
+	}}
+	unpriced := &mockVendor{streamChunks: []domain.StreamUpdate{{Type: domain.StreamTypeContent, Content: "second answer"}}}
+	failing := &mockVendor{sendStreamError: errors.New("rate limited")}
+
+	chatters := []*Chatter{
+		compareChatter(t, "gpt-4o", answering),
+		compareChatter(t, "local-model", unpriced),
+		compareChatter(t, "broken-model", failing),
+	}
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "Summarize this"}}
+
+	report, err := Compare(context.Background(), chatters, request, &domain.ChatOptions{})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(report.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(report.Results))
+	}
+
+	first := report.Results[0]
+	if first.Output != "first answer" || first.Label() != "mock|gpt-4o" {
+		t.Errorf("unexpected first result: %+v", first)
+	}
+	if first.Usage == nil || first.Usage.OutputTokens != 500 {
+		t.Errorf("expected usage from the stream, got %+v", first.Usage)
+	}
+	if first.CostUSD == nil || *first.CostUSD != 0.0075 {
+		t.Errorf("expected cost 0.0075 from gpt-4o prices, got %v", first.CostUSD)
+	}
+	if second := report.Results[1]; second.Output != "second answer" || second.Usage != nil || second.CostUSD != nil {
+		t.Errorf("unexpected second result: %+v", second)
+	}
+	if third := report.Results[2]; third.Error != "rate limited" || third.Output != "" {
+		t.Errorf("expected the failure to be recorded, got %+v", third)
+	}
+	if len(report.Succeeded()) != 2 {
+		t.Errorf("expected 2 successful results, got %d", len(report.Succeeded()))
+	}
+	if report.task != "Summarize this" {
+		t.Errorf("task = %q, want the user message", report.task)
+	}
+	if request.Message.Content != "Summarize this" {
+		t.Errorf("the caller's request must not be changed, got %q", request.Message.Content)
+	}
+
+	markdown := report.Markdown()
+	for _, want := range []string{`| mock\|gpt-4o | `, "$0.0075", "## mock|local-model", "second answer", "rate limited"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() is missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestCompareRejectsSessions(t *testing.T) {
+	request := &domain.ChatRequest{SessionName: "notes", Message: &chat.ChatCompletionMessage{Content: "hi"}}
+	if _, err := Compare(context.Background(), nil, request, &domain.ChatOptions{}); err == nil {
+		t.Error("expected an error when comparing with a session")
+	}
+}
+
+func TestChatterJudge(t *testing.T) {
+	report := &CompareReport{
+		Results: []CompareResult{
+			{Vendor: "A", Model: "one", Output: "short"},
+			{Vendor: "B", Model: "two", Error: "failed"},
+			{Vendor: "C", Model: "three", Output: "thorough"},
+		},
+		task: "Summarize this",
+	}
+
+	var prompt string
+	judgeVendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		prompt = messages[len(messages)-1].Content
+		return "Here you go:\n```json\n" +
+			`{"ranking": [{"response": 2, "score": 9, "reason": "complete"}, {"response": 1, "score": 5, "reason": "too short"}, {"response": 7}], "summary": "Two is better."}` +
+			"\n```", nil
+	}}
+	judge := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: judgeVendor, model: "judge-model"}
+
+	judgement := judge.Judge(context.Background(), report, &domain.ChatOptions{})
+	if judgement.Error != "" {
+		t.Fatalf("unexpected judge error: %s", judgement.Error)
+	}
+	if !strings.Contains(prompt, "## RESPONSE 2\n\nthorough") || strings.Contains(prompt, "failed") {
+		t.Errorf("judge prompt should number only the successful answers:\n%s", prompt)
+	}
+	if len(judgement.Ranking) != 2 {
+		t.Fatalf("expected 2 rankings, got %+v", judgement.Ranking)
+	}
+	if top := judgement.Ranking[0]; top.Rank != 1 || top.Model != "three" || top.Score != 9 {
+		t.Errorf("unexpected top ranking: %+v", top)
+	}
+	if judgement.Ranking[1].Model != "one" || judgement.Summary != "Two is better." {
+		t.Errorf("unexpected judgement: %+v", judgement)
+	}
+}
+
+func TestChatterJudgeInvalidReply(t *testing.T) {
+	report := &CompareReport{Results: []CompareResult{{Vendor: "A", Model: "one", Output: "x"}, {Vendor: "B", Model: "two", Output: "y"}}}
+	judgeVendor := &mockVendor{sendFunc: func(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error) {
+		return "Both are fine.", nil
+	}}
+	judge := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: judgeVendor, model: "judge-model"}
+
+	if judgement := judge.Judge(context.Background(), report, &domain.ChatOptions{}); judgement.Error == "" {
+		t.Error("expected an error for a reply without a ranking")
+	}
+}
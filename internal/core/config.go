@@ -0,0 +1,202 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins"
+)
+
+// ConfigSetting is a setting of a vendor or tool plugin, addressed by key as
+// <PLUGIN>.<SETTING>, such as OPENAI.API_KEY for the OPENAI_API_KEY variable.
+type ConfigSetting struct {
+	*plugins.Setting
+	Key    string
+	Plugin string
+}
+
+// CurrentValue returns the value of the setting in the environment, where plugins read
+// it from, or else the default of the plugin.
+func (o ConfigSetting) CurrentValue() string {
+	if value := os.Getenv(o.EnvVariable); value != "" {
+		return value
+	}
+	return o.Value
+}
+
+// IsSet tells whether the user set the setting, as opposed to a default of the plugin.
+func (o ConfigSetting) IsSet() bool {
+	return os.Getenv(o.EnvVariable) != ""
+}
+
+// envNamePrefixProvider is implemented by plugins built on plugins.PluginBase.
+type envNamePrefixProvider interface {
+	GetEnvNamePrefix() string
+}
+
+// configPlugins returns every plugin with settings saved in the .env file, vendors first.
+func (o *PluginRegistry) configPlugins() (ret []plugins.Plugin) {
+	for _, vendor := range o.VendorsAll.Vendors {
+		ret = append(ret, vendor)
+	}
+	return append(ret, o.Defaults, o.PatternsLoader, o.CustomPatterns, o.Strategies, o.YouTube, o.Jina,
+		o.Spotify, o.WebSearch, o.Language)
+}
+
+// ConfigSettings returns the settings of every plugin, in the order of the plugins.
+func (o *PluginRegistry) ConfigSettings() (ret []ConfigSetting) {
+	for _, plugin := range o.configPlugins() {
+		var prefix string
+		if provider, ok := plugin.(envNamePrefixProvider); ok {
+			prefix = provider.GetEnvNamePrefix()
+		}
+		for _, setting := range pluginSettings(plugin) {
+			ret = append(ret, ConfigSetting{Setting: setting, Key: configKey(prefix, setting.EnvVariable), Plugin: plugin.GetName()})
+		}
+	}
+	return
+}
+
+// configKey builds the key of a setting from the prefix of its plugin and its variable.
+func configKey(prefix string, envVariable string) string {
+	if prefix == "" || !strings.HasPrefix(envVariable, prefix) {
+		return envVariable
+	}
+	return strings.TrimSuffix(prefix, "_") + "." + envVariable[len(prefix):]
+}
+
+// FindConfigSetting finds a setting by its key, in any case and with spaces or dashes
+// for underscores, or by the name of its environment variable.
+func (o *PluginRegistry) FindConfigSetting(key string) (ret ConfigSetting, err error) {
+	name := plugins.BuildEnvVariable(key)
+	if plugin, setting, found := strings.Cut(key, "."); found {
+		name = plugins.BuildEnvVariable(plugin) + "." + plugins.BuildEnvVariable(setting)
+	}
+	for _, setting := range o.ConfigSettings() {
+		if setting.Key == name || setting.EnvVariable == name {
+			return setting, nil
+		}
+	}
+	err = domain.WithCode(domain.ErrorCodeInvalidArguments, fmt.Errorf("%s", fmt.Sprintf(i18n.T("config_unknown_setting"), key)))
+	return
+}
+
+// SetConfig validates value for the setting of key and saves it, in the secret store for
+// sensitive settings when it is enabled. An empty value removes the setting.
+func (o *PluginRegistry) SetConfig(key string, value string) (ret ConfigSetting, err error) {
+	if ret, err = o.FindConfigSetting(key); err != nil {
+		return
+	}
+	value = strings.TrimSpace(value)
+	if ret.Type == plugins.SettingTypeBool && value != "" {
+		var enabled bool
+		if enabled, err = plugins.ParseBool(value); err != nil {
+			err = domain.WithCode(domain.ErrorCodeInvalidArguments, err)
+			return
+		}
+		value = fmt.Sprintf("%v", enabled)
+	}
+	if err = o.Db.SetEnvValue(ret.EnvVariable, value, ret.Sensitive); err != nil {
+		return
+	}
+	ret.Value = value
+	if value == "" {
+		err = os.Unsetenv(ret.EnvVariable)
+	} else {
+		err = os.Setenv(ret.EnvVariable, value)
+	}
+	return
+}
+
+// SetupNonInteractive sets up Fabric without asking questions: it configures the vendor
+// and the default model from the environment, checks the tools the environment sets up,
+// downloads patterns and strategies when they are missing and saves the configuration.
+// Every missing or invalid setting is reported at once.
+func (o *PluginRegistry) SetupNonInteractive(vendorName string, model string) (err error) {
+	if vendorName == "" {
+		vendorName = o.Defaults.Vendor.Value
+	}
+	if vendorName == "" {
+		return domain.WithCode(domain.ErrorCodeInvalidArguments, fmt.Errorf("%s", i18n.T("setup_non_interactive_vendor_required")))
+	}
+	vendor := o.VendorsAll.FindByName(vendorName)
+	if vendor == nil {
+		return domain.WithCode(domain.ErrorCodeInvalidArguments, fmt.Errorf("%s", fmt.Sprintf(i18n.T("setup_non_interactive_unknown_vendor"), vendorName)))
+	}
+
+	var problems []string
+	if configureErr := vendor.Configure(); configureErr != nil || !vendor.IsConfigured() {
+		problems = append(problems, setupProblem(vendor.GetName(), pluginSettings(vendor), configureErr))
+	} else if o.VendorManager.FindByName(vendor.GetName()) == nil {
+		o.VendorManager.AddVendors(vendor)
+	}
+
+	if model == "" {
+		model = o.Defaults.Model.Value
+	}
+	if model == "" {
+		problems = append(problems, fmt.Sprintf(i18n.T("setup_non_interactive_model_required"), o.Defaults.Model.EnvVariable))
+	} else {
+		o.Defaults.Vendor.Value, o.Defaults.Model.Value = vendor.GetName(), model
+	}
+
+	// Optional tools are checked only when the environment sets them up
+	for _, tool := range []plugins.Plugin{o.CustomPatterns, o.YouTube, o.Jina, o.Spotify, o.WebSearch, o.Language} {
+		settings := pluginSettings(tool)
+		if !hasUserSettings(settings) {
+			continue
+		}
+		if configureErr := tool.Configure(); configureErr != nil || !tool.IsConfigured() {
+			problems = append(problems, setupProblem(tool.GetName(), settings, configureErr))
+		}
+	}
+	if len(problems) > 0 {
+		return domain.WithCode(domain.ErrorCodeNotConfigured,
+			fmt.Errorf("%s", fmt.Sprintf(i18n.T("setup_non_interactive_failed"), strings.Join(problems, "\n  "))))
+	}
+
+	if !o.PatternsLoader.IsConfigured() {
+		if err = o.PatternsLoader.PopulateDB(); err != nil {
+			return fmt.Errorf(i18n.T("setup_failed_download_patterns"), err)
+		}
+	}
+	if !o.Strategies.IsConfigured() {
+		if err = o.Strategies.PopulateDB(); err != nil {
+			return fmt.Errorf(i18n.T("setup_failed_download_strategies"), err)
+		}
+		// Load the downloaded strategies so that the validation sees them
+		if err = o.Strategies.Configure(); err != nil {
+			return
+		}
+	}
+	if err = o.SaveEnvFile(); err != nil {
+		return
+	}
+
+	o.validateSetup()
+	return
+}
+
+// setupProblem tells why a plugin could not be configured: the required settings it
+// misses, or else the error of its Configure. Plugins that need one of several optional
+// settings, such as an API key or a login, are reported with those they miss.
+func setupProblem(name string, settings plugins.Settings, configureErr error) string {
+	var invalid, undefined []string
+	for _, setting := range settings {
+		if !setting.IsValid() {
+			invalid = append(invalid, setting.EnvVariable)
+		} else if !setting.IsDefined() {
+			undefined = append(undefined, setting.EnvVariable)
+		}
+	}
+	switch {
+	case len(invalid) > 0:
+		return fmt.Sprintf("%s: %s", name, fmt.Sprintf(i18n.T("doctor_settings_missing"), strings.Join(invalid, ", ")))
+	case configureErr != nil:
+		return fmt.Sprintf("%s: %s", name, configureErr)
+	}
+	return fmt.Sprintf("%s: %s", name, fmt.Sprintf(i18n.T("doctor_settings_missing"), strings.Join(undefined, ", ")))
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+func newConfigTestRegistry(t *testing.T) *PluginRegistry {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range []string{"OPENAI_API_KEY", "ANTHROPIC_API_KEY", "DEFAULT_VENDOR", "DEFAULT_MODEL", "JINA_AI_API_KEY"} {
+		t.Setenv(name, "")
+	}
+	registry, err := NewPluginRegistry(fsdb.NewDb(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewPluginRegistry() error = %v", err)
+	}
+	return registry
+}
+
+func TestPluginRegistry_FindConfigSetting(t *testing.T) {
+	registry := newConfigTestRegistry(t)
+
+	for _, key := range []string{"OpenAI.api_key", "OPENAI.API_KEY", "openai.api-key", "OPENAI_API_KEY"} {
+		setting, err := registry.FindConfigSetting(key)
+		if err != nil {
+			t.Errorf("FindConfigSetting(%q) error = %v", key, err)
+			continue
+		}
+		if setting.EnvVariable != "OPENAI_API_KEY" || setting.Key != "OPENAI.API_KEY" || setting.Plugin != "OpenAI" {
+			t.Errorf("FindConfigSetting(%q) = %s (%s) of %s", key, setting.Key, setting.EnvVariable, setting.Plugin)
+		}
+	}
+	if setting, err := registry.FindConfigSetting("LM Studio.api_url"); err != nil || setting.EnvVariable != "LM_STUDIO_API_URL" {
+		t.Errorf("FindConfigSetting() of a vendor with a space = %v, %v", setting.EnvVariable, err)
+	}
+
+	_, err := registry.FindConfigSetting("openai.nope")
+	if err == nil || domain.CodeOf(err) != domain.ErrorCodeInvalidArguments {
+		t.Errorf("FindConfigSetting() of an unknown setting error = %v, want invalid_arguments", err)
+	}
+}
+
+func TestPluginRegistry_SetConfig(t *testing.T) {
+	registry := newConfigTestRegistry(t)
+
+	if _, err := registry.SetConfig("openai.api_key", "sk-test"); err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
+	}
+	if _, err := registry.SetConfig("default.model", "gpt-4o"); err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
+	}
+	content, _ := os.ReadFile(registry.Db.EnvFilePath)
+	if !strings.Contains(string(content), "OPENAI_API_KEY=sk-test\n") || !strings.Contains(string(content), "DEFAULT_MODEL=gpt-4o\n") {
+		t.Errorf(".env = %q, want both settings", content)
+	}
+	setting, _ := registry.FindConfigSetting("openai.api_key")
+	if !setting.IsSet() || setting.CurrentValue() != "sk-test" {
+		t.Errorf("the set value %q is not in the environment", setting.CurrentValue())
+	}
+
+	if _, err := registry.SetConfig("openai.api_key", ""); err != nil {
+		t.Fatalf("SetConfig() to unset error = %v", err)
+	}
+	if content, _ = os.ReadFile(registry.Db.EnvFilePath); string(content) != "DEFAULT_MODEL=gpt-4o\n" {
+		t.Errorf(".env after unsetting = %q", content)
+	}
+	if os.Getenv("OPENAI_API_KEY") != "" {
+		t.Error("the unset value is still in the environment")
+	}
+}
+
+func TestPluginRegistry_SetupNonInteractive_ReportsMissingSettings(t *testing.T) {
+	registry := newConfigTestRegistry(t)
+
+	err := registry.SetupNonInteractive("", "")
+	if domain.CodeOf(err) != domain.ErrorCodeInvalidArguments {
+		t.Errorf("SetupNonInteractive() without a vendor error = %v, want invalid_arguments", err)
+	}
+	err = registry.SetupNonInteractive("Nope", "")
+	if domain.CodeOf(err) != domain.ErrorCodeInvalidArguments {
+		t.Errorf("SetupNonInteractive() of an unknown vendor error = %v, want invalid_arguments", err)
+	}
+
+	// Every problem is reported at once, before anything is downloaded or saved
+	err = registry.SetupNonInteractive("openai", "")
+	if domain.CodeOf(err) != domain.ErrorCodeNotConfigured {
+		t.Fatalf("SetupNonInteractive() error = %v, want not_configured", err)
+	}
+	for _, want := range []string{"OPENAI_API_KEY", "DEFAULT_MODEL"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %s", err, want)
+		}
+	}
+	if content, _ := os.ReadFile(registry.Db.EnvFilePath); len(content) != 0 {
+		t.Errorf(".env = %q, want nothing saved", content)
+	}
+}
@@ -0,0 +1,347 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+// DoctorStatus is the outcome of a single diagnostic check.
+type DoctorStatus string
+
+const (
+	DoctorPass DoctorStatus = "pass"
+	DoctorWarn DoctorStatus = "warn"
+	DoctorFail DoctorStatus = "fail"
+)
+
+// Diagnostic check categories.
+const (
+	DoctorCategoryVendor     = "vendor"
+	DoctorCategoryTool       = "tool"
+	DoctorCategoryBinary     = "binary"
+	DoctorCategoryPatterns   = "patterns"
+	DoctorCategoryExtensions = "extensions"
+	DoctorCategoryConfig     = "config"
+)
+
+// doctorListModelsTimeout bounds the connectivity check of each vendor.
+const doctorListModelsTimeout = 20 * time.Second
+
+// DoctorCheck is one line of the diagnostic report. Messages never contain secret values.
+type DoctorCheck struct {
+	Category string       `json:"category"`
+	Name     string       `json:"name"`
+	Status   DoctorStatus `json:"status"`
+	Message  string       `json:"message"`
+}
+
+// DoctorReport collects the checks of a diagnostic run.
+type DoctorReport struct {
+	Checks []DoctorCheck `json:"checks"`
+	Passed int           `json:"passed"`
+	Warned int           `json:"warned"`
+	Failed int           `json:"failed"`
+}
+
+// Add records a check and updates the totals.
+func (o *DoctorReport) Add(category, name string, status DoctorStatus, message string) {
+	o.Checks = append(o.Checks, DoctorCheck{Category: category, Name: name, Status: status, Message: message})
+	switch status {
+	case DoctorPass:
+		o.Passed++
+	case DoctorWarn:
+		o.Warned++
+	case DoctorFail:
+		o.Failed++
+	}
+}
+
+// doctorBinaries lists the external programs Fabric calls and what needs them.
+var doctorBinaries = []struct {
+	name    string
+	feature string
+}{
+	{"yt-dlp", "doctor_binary_feature_ytdlp"},
+	{"ffmpeg", "doctor_binary_feature_ffmpeg"},
+	{"tesseract", "doctor_binary_feature_tesseract"},
+}
+
+// CheckBinaries reports whether the external programs used by optional features are
+// installed. They need no configuration, so they are checked even when setup is broken.
+func CheckBinaries(report *DoctorReport) {
+	for _, binary := range doctorBinaries {
+		if path, err := exec.LookPath(binary.name); err == nil {
+			report.Add(DoctorCategoryBinary, binary.name, DoctorPass, path)
+		} else {
+			report.Add(DoctorCategoryBinary, binary.name, DoctorWarn,
+				fmt.Sprintf(i18n.T("doctor_binary_missing"), i18n.T(binary.feature)))
+		}
+	}
+}
+
+// credentialPrefixes are the prefixes of well-known API keys. Keys without them still
+// work with proxies and compatible servers, so a mismatch is only a warning.
+var credentialPrefixes = map[string]string{
+	"OPENAI_API_KEY":     "sk-",
+	"ANTHROPIC_API_KEY":  "sk-ant-",
+	"GEMINI_API_KEY":     "AIza",
+	"GROQ_API_KEY":       "gsk_",
+	"OPENROUTER_API_KEY": "sk-or-",
+	"PERPLEXITY_API_KEY": "pplx-",
+}
+
+// settingsProvider is implemented by plugins built on plugins.PluginBase.
+type settingsProvider interface {
+	GetSettings() plugins.Settings
+}
+
+// Doctor checks the configuration of every vendor and tool plugin, lists the models of
+// each configured vendor to test connectivity and authentication, and checks the
+// external programs, the patterns directory and the extension registry.
+func (o *PluginRegistry) Doctor(ctx context.Context, report *DoctorReport) {
+	vendorModels := o.doctorVendors(ctx, report)
+	o.doctorDefaults(report, vendorModels)
+
+	tools := []plugins.Plugin{o.PatternsLoader, o.CustomPatterns, o.Strategies, o.YouTube, o.Jina,
+		o.Spotify, o.WebSearch, o.Language}
+	for _, tool := range tools {
+		o.doctorTool(report, tool)
+	}
+
+	CheckBinaries(report)
+	o.doctorPatterns(report)
+	o.doctorExtensions(report)
+}
+
+// doctorVendors checks every vendor that is configured or has settings, and returns the
+// models of the vendors that could list them.
+func (o *PluginRegistry) doctorVendors(ctx context.Context, report *DoctorReport) (ret map[string][]string) {
+	type listing struct {
+		models []string
+		err    error
+	}
+	var checked []ai.Vendor
+	for _, vendor := range o.VendorsAll.Vendors {
+		if o.VendorManager.FindByName(vendor.GetName()) != nil {
+			checked = append(checked, vendor)
+			continue
+		}
+		// Vendors the user never set up are not reported
+		if settings := pluginSettings(vendor); hasUserSettings(settings) {
+			message := missingSettingsMessage(settings)
+			if err := vendor.Configure(); err != nil {
+				message = err.Error()
+			}
+			report.Add(DoctorCategoryVendor, vendor.GetName(), DoctorFail, message)
+		}
+	}
+
+	listings := make([]listing, len(checked))
+	var wg sync.WaitGroup
+	for i, vendor := range checked {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listCtx, cancel := context.WithTimeout(ctx, doctorListModelsTimeout)
+			defer cancel()
+			listings[i].models, listings[i].err = vendor.ListModels(listCtx)
+		}()
+	}
+	wg.Wait()
+
+	ret = make(map[string][]string, len(checked))
+	for i, vendor := range checked {
+		name := vendor.GetName()
+		doctorSettingFormats(report, DoctorCategoryVendor, name, pluginSettings(vendor))
+		switch result := listings[i]; {
+		case result.err != nil:
+			report.Add(DoctorCategoryVendor, name, DoctorFail, fmt.Sprintf(i18n.T("doctor_vendor_list_models_failed"), result.err))
+		case len(result.models) == 0:
+			report.Add(DoctorCategoryVendor, name, DoctorWarn, i18n.T("doctor_vendor_no_models"))
+		default:
+			ret[name] = result.models
+			report.Add(DoctorCategoryVendor, name, DoctorPass, fmt.Sprintf(i18n.T("doctor_vendor_models_available"), len(result.models)))
+		}
+	}
+	if len(checked) == 0 {
+		report.Add(DoctorCategoryVendor, "-", DoctorFail, i18n.T("doctor_no_vendors"))
+	}
+	return
+}
+
+// doctorDefaults checks that the default model is set and served by the default vendor.
+func (o *PluginRegistry) doctorDefaults(report *DoctorReport, vendorModels map[string][]string) {
+	name := o.Defaults.GetName()
+	if !o.Defaults.IsConfigured() || o.Defaults.Model.Value == "" {
+		report.Add(DoctorCategoryConfig, name, DoctorFail, i18n.T("doctor_defaults_missing"))
+		return
+	}
+	vendor, model := o.Defaults.Vendor.Value, o.Defaults.Model.Value
+	var models []string
+	for vendorName, names := range vendorModels {
+		if strings.EqualFold(vendorName, vendor) {
+			models = names
+		}
+	}
+	switch {
+	case o.VendorManager.FindByName(vendor) == nil:
+		report.Add(DoctorCategoryConfig, name, DoctorFail, fmt.Sprintf(i18n.T("doctor_default_vendor_not_configured"), vendor))
+	case models != nil && !slices.ContainsFunc(models, func(m string) bool { return strings.EqualFold(m, model) }):
+		report.Add(DoctorCategoryConfig, name, DoctorWarn, fmt.Sprintf(i18n.T("doctor_default_model_not_listed"), model, vendor))
+	default:
+		report.Add(DoctorCategoryConfig, name, DoctorPass, fmt.Sprintf("%s/%s", vendor, model))
+	}
+}
+
+// doctorTool checks the settings of a tool plugin. Tools are optional, so those the user
+// has not set up are not reported.
+func (o *PluginRegistry) doctorTool(report *DoctorReport, tool plugins.Plugin) {
+	settings := pluginSettings(tool)
+	if !hasUserSettings(settings) {
+		return
+	}
+	name := tool.GetName()
+	if !settings.IsConfigured() {
+		report.Add(DoctorCategoryTool, name, DoctorFail, missingSettingsMessage(settings))
+		return
+	}
+	if !doctorSettingFormats(report, DoctorCategoryTool, name, settings) {
+		report.Add(DoctorCategoryTool, name, DoctorPass, i18n.T("doctor_settings_valid"))
+	}
+}
+
+// doctorPatterns checks that the patterns directory holds patterns, and that a custom
+// patterns directory, when set, exists.
+func (o *PluginRegistry) doctorPatterns(report *DoctorReport) {
+	dir := o.Db.Patterns.Dir
+	if count, err := countPatterns(dir, o.Db.Patterns.SystemPatternFile); err != nil {
+		report.Add(DoctorCategoryPatterns, dir, DoctorFail, fmt.Sprintf(i18n.T("doctor_patterns_dir_unreadable"), err))
+	} else if count == 0 {
+		report.Add(DoctorCategoryPatterns, dir, DoctorFail, i18n.T("doctor_patterns_missing"))
+	} else {
+		report.Add(DoctorCategoryPatterns, dir, DoctorPass, fmt.Sprintf(i18n.T("doctor_patterns_found"), count))
+	}
+
+	if custom := o.Db.Patterns.CustomPatternsDir; custom != "" {
+		if info, err := os.Stat(custom); err != nil || !info.IsDir() {
+			report.Add(DoctorCategoryPatterns, custom, DoctorWarn, i18n.T("doctor_custom_patterns_dir_missing"))
+		} else {
+			report.Add(DoctorCategoryPatterns, custom, DoctorPass, i18n.T("doctor_custom_patterns_dir_found"))
+		}
+	}
+}
+
+// doctorExtensions verifies every registered template extension.
+func (o *PluginRegistry) doctorExtensions(report *DoctorReport) {
+	results, err := o.TemplateExtensions.VerifyExtensions()
+	if err != nil {
+		report.Add(DoctorCategoryExtensions, "-", DoctorFail, err.Error())
+		return
+	}
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if results[name] != nil {
+			report.Add(DoctorCategoryExtensions, name, DoctorFail, results[name].Error())
+		} else {
+			report.Add(DoctorCategoryExtensions, name, DoctorPass, i18n.T("doctor_extension_verified"))
+		}
+	}
+}
+
+// countPatterns counts the pattern directories in dir, those holding a system prompt file.
+func countPatterns(dir, systemFile string) (ret int, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(dir); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, statErr := os.Stat(filepath.Join(dir, entry.Name(), systemFile)); statErr == nil {
+			ret++
+		}
+	}
+	return
+}
+
+// pluginSettings returns the settings of plugins built on plugins.PluginBase.
+func pluginSettings(plugin any) plugins.Settings {
+	if provider, ok := plugin.(settingsProvider); ok {
+		return provider.GetSettings()
+	}
+	return nil
+}
+
+// hasUserSettings tells whether the user set any of the settings, as opposed to values
+// plugins fill in by default.
+func hasUserSettings(settings plugins.Settings) bool {
+	return slices.ContainsFunc(settings, func(setting *plugins.Setting) bool {
+		return os.Getenv(setting.EnvVariable) != ""
+	})
+}
+
+// missingSettingsMessage names the required settings that have no valid value.
+func missingSettingsMessage(settings plugins.Settings) string {
+	var missing []string
+	for _, setting := range settings {
+		if !setting.IsValid() {
+			missing = append(missing, setting.EnvVariable)
+		}
+	}
+	return fmt.Sprintf(i18n.T("doctor_settings_missing"), strings.Join(missing, ", "))
+}
+
+// doctorSettingFormats reports credentials and URLs that are malformed. It returns
+// whether any problem was reported. Values are never included in the messages.
+func doctorSettingFormats(report *DoctorReport, category, name string, settings plugins.Settings) (found bool) {
+	for _, setting := range settings {
+		if !setting.IsDefined() {
+			continue
+		}
+		status, message := checkSettingFormat(setting)
+		if status != DoctorPass {
+			report.Add(category, name, status, message)
+			found = true
+		}
+	}
+	return
+}
+
+// checkSettingFormat validates the format of a credential or URL setting.
+func checkSettingFormat(setting *plugins.Setting) (DoctorStatus, string) {
+	name, value := setting.EnvVariable, setting.Value
+	switch {
+	case strings.HasSuffix(name, "_URL"):
+		if parsed, err := url.Parse(value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return DoctorFail, fmt.Sprintf(i18n.T("doctor_setting_invalid_url"), name)
+		}
+	case plugins.IsSensitiveEnvVariable(name):
+		if strings.TrimSpace(value) != value || strings.ContainsAny(value, " \t\r\n") {
+			return DoctorFail, fmt.Sprintf(i18n.T("doctor_credential_whitespace"), name)
+		}
+		if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+			return DoctorFail, fmt.Sprintf(i18n.T("doctor_credential_quoted"), name)
+		}
+		if prefix, ok := credentialPrefixes[name]; ok && !strings.HasPrefix(value, prefix) {
+			return DoctorWarn, fmt.Sprintf(i18n.T("doctor_credential_unexpected_prefix"), name, prefix)
+		}
+	}
+	return DoctorPass, ""
+}
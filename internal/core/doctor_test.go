@@ -0,0 +1,147 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/tools"
+)
+
+func TestDoctorReportAdd(t *testing.T) {
+	report := &DoctorReport{}
+	report.Add(DoctorCategoryVendor, "A", DoctorPass, "ok")
+	report.Add(DoctorCategoryVendor, "B", DoctorWarn, "hmm")
+	report.Add(DoctorCategoryTool, "C", DoctorFail, "broken")
+	report.Add(DoctorCategoryTool, "D", DoctorFail, "broken")
+
+	if len(report.Checks) != 4 {
+		t.Fatalf("expected 4 checks, got %d", len(report.Checks))
+	}
+	if report.Passed != 1 || report.Warned != 1 || report.Failed != 2 {
+		t.Errorf("unexpected totals: passed=%d warned=%d failed=%d", report.Passed, report.Warned, report.Failed)
+	}
+}
+
+func TestCheckSettingFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		value  string
+		status DoctorStatus
+	}{
+		{"valid url", "OLLAMA_API_URL", "http://localhost:11434", DoctorPass},
+		{"url without scheme", "OLLAMA_API_URL", "localhost:11434", DoctorFail},
+		{"url with other scheme", "LM_STUDIO_API_BASE_URL", "ftp://example.com", DoctorFail},
+		{"valid key", "OPENAI_API_KEY", "sk-abc123", DoctorPass},
+		{"key with trailing newline", "OPENAI_API_KEY", "sk-abc123\n", DoctorFail},
+		{"key with inner space", "GROQ_API_KEY", "gsk_abc 123", DoctorFail},
+		{"quoted key", "ANTHROPIC_API_KEY", `"sk-ant-abc"`, DoctorFail},
+		{"unexpected prefix", "ANTHROPIC_API_KEY", "abc123", DoctorWarn},
+		{"key without known prefix", "MISTRAL_API_KEY", "abc123", DoctorPass},
+		{"token", "GITHUB_TOKEN", " ghp_abc", DoctorFail},
+		{"other setting", "DEFAULT_MODEL", "gpt 4o", DoctorPass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting := &plugins.Setting{EnvVariable: tt.env, Value: tt.value}
+			status, message := checkSettingFormat(setting)
+			if status != tt.status {
+				t.Errorf("checkSettingFormat() status = %s, want %s (%s)", status, tt.status, message)
+			}
+			if status != DoctorPass && strings.Contains(message, tt.value) {
+				t.Errorf("message %q must not contain the setting value", message)
+			}
+		})
+	}
+}
+
+func TestCountPatterns(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"summarize", "extract_wisdom"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "system.md"), []byte("# IDENTITY"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directories without a system prompt and plain files are not patterns
+	if err := os.MkdirAll(filepath.Join(dir, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := countPatterns(dir, "system.md")
+	if err != nil {
+		t.Fatalf("countPatterns() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("countPatterns() = %d, want 2", count)
+	}
+
+	if _, err = countPatterns(filepath.Join(dir, "missing"), "system.md"); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestHasUserSettings(t *testing.T) {
+	settings := plugins.Settings{
+		{EnvVariable: "FABRIC_DOCTOR_TEST_URL", Value: "https://example.com"},
+		{EnvVariable: "FABRIC_DOCTOR_TEST_KEY"},
+	}
+	if hasUserSettings(settings) {
+		t.Error("default values must not count as user settings")
+	}
+
+	t.Setenv("FABRIC_DOCTOR_TEST_KEY", "secret")
+	if !hasUserSettings(settings) {
+		t.Error("expected settings from the environment to count as user settings")
+	}
+}
+
+func TestDoctorDefaults(t *testing.T) {
+	vm := ai.NewVendorsManager()
+	vm.AddVendors(&testVendor{name: "VendorA", models: []string{"model-a"}})
+
+	tests := []struct {
+		name   string
+		vendor string
+		model  string
+		models map[string][]string
+		status DoctorStatus
+	}{
+		{"listed model", "VendorA", "model-a", map[string][]string{"VendorA": {"model-a"}}, DoctorPass},
+		{"unlisted model", "VendorA", "model-b", map[string][]string{"VendorA": {"model-a"}}, DoctorWarn},
+		{"vendor without listing", "VendorA", "model-b", map[string][]string{}, DoctorPass},
+		{"unconfigured vendor", "VendorB", "model-a", map[string][]string{}, DoctorFail},
+		{"no default model", "VendorA", "", map[string][]string{}, DoctorFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaults := &tools.Defaults{
+				PluginBase:         &plugins.PluginBase{Name: "Default AI Vendor and Model"},
+				Vendor:             &plugins.Setting{Value: tt.vendor},
+				Model:              &plugins.SetupQuestion{Setting: &plugins.Setting{Value: tt.model}},
+				ModelContextLength: &plugins.SetupQuestion{Setting: &plugins.Setting{Value: "0"}},
+			}
+			registry := &PluginRegistry{VendorManager: vm, Defaults: defaults}
+
+			report := &DoctorReport{}
+			registry.doctorDefaults(report, tt.models)
+			if len(report.Checks) != 1 {
+				t.Fatalf("expected 1 check, got %d", len(report.Checks))
+			}
+			if report.Checks[0].Status != tt.status {
+				t.Errorf("status = %s, want %s (%s)", report.Checks[0].Status, tt.status, report.Checks[0].Message)
+			}
+		})
+	}
+}
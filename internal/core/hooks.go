@@ -0,0 +1,351 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// Events hooks run on.
+const (
+	HookEventPreRequest   = "pre_request"
+	HookEventPostResponse = "post_response"
+)
+
+// Failure policies of hooks: a hook that fails, times out or replies with invalid JSON
+// either fails the request or is reported and skipped.
+const (
+	HookOnFailureFail   = "fail"
+	HookOnFailureIgnore = "ignore"
+)
+
+// defaultHookTimeout bounds hooks that do not set their own timeout.
+const defaultHookTimeout = 30 * time.Second
+
+// hookWaitDelay is how long the output of a hook is waited for after it was stopped,
+// in case processes it started keep its output open.
+const hookWaitDelay = time.Second
+
+// Hook is a command run before every request is sent to a model or after every answer
+// is received. It reads a HookDocument on stdin and may write a changed one on stdout.
+type Hook struct {
+	Name      string        `yaml:"name"`
+	Event     string        `yaml:"event"`
+	Command   string        `yaml:"command"`
+	Timeout   time.Duration `yaml:"timeout,omitempty"`
+	OnFailure string        `yaml:"on_failure,omitempty"`
+}
+
+// HookDocument is what hooks read and write. A hook that prints nothing leaves the
+// document as it is; fields left out of its reply are kept. Setting Veto refuses the
+// request or the answer, whatever the failure policy.
+type HookDocument struct {
+	Event    string                        `json:"event"`
+	Vendor   string                        `json:"vendor"`
+	Model    string                        `json:"model"`
+	DryRun   bool                          `json:"dry_run,omitempty"`
+	Request  *domain.ChatRequest           `json:"request,omitempty"`
+	Options  *domain.ChatOptions           `json:"options,omitempty"`
+	Messages []*chat.ChatCompletionMessage `json:"messages,omitempty"`
+	Response *string                       `json:"response,omitempty"`
+	Veto     bool                          `json:"veto,omitempty"`
+	Reason   string                        `json:"reason,omitempty"`
+}
+
+// hooksFile is the YAML document users can put in the fabric config directory.
+type hooksFile struct {
+	Hooks []Hook `yaml:"hooks"`
+}
+
+// Hooks holds the hooks of every request, run in the order they are configured.
+type Hooks struct {
+	hooks []Hook
+}
+
+// NewHooks creates an empty set of hooks.
+func NewHooks() *Hooks {
+	return &Hooks{}
+}
+
+// Add appends hooks after checking them.
+func (o *Hooks) Add(hooks ...Hook) (err error) {
+	for i := range hooks {
+		hook := &hooks[i]
+		if hook.Name == "" {
+			hook.Name = hook.Command
+		}
+		switch {
+		case strings.TrimSpace(hook.Command) == "":
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_missing_command"), hook.Name))
+		case hook.Event != HookEventPreRequest && hook.Event != HookEventPostResponse:
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_invalid_event"), hook.Name, hook.Event))
+		case hook.OnFailure != "" && hook.OnFailure != HookOnFailureFail && hook.OnFailure != HookOnFailureIgnore:
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_invalid_on_failure"), hook.Name, hook.OnFailure))
+		case hook.Timeout < 0:
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_invalid_timeout"), hook.Name, hook.Timeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+	o.hooks = append(o.hooks, hooks...)
+	return
+}
+
+// LoadFile reads hooks from a YAML file. A missing file is not an error.
+func (o *Hooks) LoadFile(filePath string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(filePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	var file hooksFile
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_parse_failed"), filePath, err))
+	}
+	if err = o.Add(file.Hooks...); err != nil {
+		return fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_parse_failed"), filePath, err))
+	}
+	return
+}
+
+// Has tells whether hooks run on event.
+func (o *Hooks) Has(event string) bool {
+	if o == nil {
+		return false
+	}
+	for _, hook := range o.hooks {
+		if hook.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run passes doc through the hooks of its event in turn, each receiving what the one
+// before it replied. Failures of hooks that are ignored are given to warn.
+func (o *Hooks) Run(ctx context.Context, doc *HookDocument, warn func(message string)) (err error) {
+	if o == nil {
+		return
+	}
+	for _, hook := range o.hooks {
+		if hook.Event != doc.Event {
+			continue
+		}
+		var reply *HookDocument
+		if reply, err = hook.run(ctx, doc); err != nil {
+			if ctx.Err() != nil {
+				return domain.WithCode(domain.ErrorCodeCanceled, ctx.Err())
+			}
+			if hook.OnFailure == HookOnFailureIgnore {
+				warn(fmt.Sprintf(i18n.T("hooks_failure_ignored"), err))
+				err = nil
+				continue
+			}
+			return domain.WithCode(domain.ErrorCodeHookFailed, err)
+		}
+		if reply == nil {
+			continue
+		}
+		if reply.Veto {
+			reason := reply.Reason
+			if reason == "" {
+				reason = i18n.T("hooks_no_reason")
+			}
+			return domain.WithCode(domain.ErrorCodeHookVetoed, fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_vetoed"), hook.Name, doc.Event, reason)))
+		}
+		if reply.Request != nil {
+			doc.Request = reply.Request
+		}
+		if reply.Options != nil {
+			doc.Options = reply.Options
+		}
+		if reply.Messages != nil {
+			doc.Messages = reply.Messages
+		}
+		if reply.Response != nil {
+			doc.Response = reply.Response
+		}
+	}
+	return
+}
+
+// run runs the hook with doc on its stdin and returns its reply, or nil when it printed
+// nothing.
+func (o *Hook) run(ctx context.Context, doc *HookDocument) (ret *HookDocument, err error) {
+	var input []byte
+	if input, err = json.Marshal(doc); err != nil {
+		return
+	}
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = defaultHookTimeout
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(hookCtx, "cmd", "/C", o.Command)
+	} else {
+		cmd = exec.CommandContext(hookCtx, "sh", "-c", o.Command)
+	}
+	var output bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	cmd.WaitDelay = hookWaitDelay
+
+	debuglog.Debug(debuglog.Detailed, "Running %s hook %s\n", doc.Event, o.Name)
+	if err = cmd.Run(); err != nil {
+		if errors.Is(hookCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_timed_out"), o.Name, timeout))
+		} else {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_command_failed"), o.Name, err))
+		}
+		return
+	}
+	if len(bytes.TrimSpace(output.Bytes())) == 0 {
+		return
+	}
+	if ret, err = decodeHookReply(output.Bytes(), doc); err != nil {
+		ret = nil
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("hooks_invalid_reply"), o.Name, err))
+	}
+	return
+}
+
+// decodeHookReply decodes the reply of a hook. The request and options it contains are
+// decoded over copies of those of doc, so that a reply only needs the fields it changes.
+func decodeHookReply(data []byte, doc *HookDocument) (ret *HookDocument, err error) {
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+	ret = &HookDocument{}
+	if _, ok := fields["request"]; ok && doc.Request != nil {
+		if ret.Request, err = cloneHookValue(doc.Request); err != nil {
+			return
+		}
+	}
+	if _, ok := fields["options"]; ok && doc.Options != nil {
+		if ret.Options, err = cloneHookValue(doc.Options); err != nil {
+			return
+		}
+	}
+	err = json.Unmarshal(data, ret)
+	return
+}
+
+// cloneHookValue copies value through JSON, which hooks see it as, so that decoding a
+// reply over the copy leaves value and what it refers to alone.
+func cloneHookValue[T any](value *T) (ret *T, err error) {
+	var data []byte
+	if data, err = json.Marshal(value); err != nil {
+		return
+	}
+	ret = new(T)
+	err = json.Unmarshal(data, ret)
+	return
+}
+
+// hookDocument describes the request of the chatter to hooks.
+func (o *Chatter) hookDocument(event string, request *domain.ChatRequest, opts *domain.ChatOptions, messages []*chat.ChatCompletionMessage) *HookDocument {
+	return &HookDocument{
+		Event:    event,
+		Vendor:   o.vendor.GetName(),
+		Model:    o.model,
+		DryRun:   o.DryRun,
+		Request:  request,
+		Options:  opts,
+		Messages: messages,
+	}
+}
+
+// runPreRequestHooks passes the request, its options and the messages of the session
+// through the pre_request hooks, and keeps what they changed. The attachments of the
+// messages are prepared first, so that hooks see the text extracted from documents as
+// the model would. The model of the chatter and the callbacks of the options cannot be
+// changed.
+func (o *Chatter) runPreRequestHooks(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions, session *fsdb.Session) (err error) {
+	if !o.hooks.Has(HookEventPreRequest) {
+		return
+	}
+	var messages []*chat.ChatCompletionMessage
+	if messages, err = o.prepareAttachments(session.Messages); err != nil {
+		return domain.WithCode(domain.ErrorCodeInvalidRequest, err)
+	}
+	doc := o.hookDocument(HookEventPreRequest, request, opts, messages)
+	if err = o.hooks.Run(ctx, doc, func(message string) { o.notify(opts, message) }); err != nil {
+		return
+	}
+	if doc.Request != request {
+		*request = *doc.Request
+	}
+	if doc.Options != opts {
+		model, applier, updates := opts.Model, opts.ChangeApplier, opts.UpdateChan
+		*opts = *doc.Options
+		opts.Model, opts.ChangeApplier, opts.UpdateChan = model, applier, updates
+	}
+	// A new session, since the session keeps the messages it sends
+	*session = fsdb.Session{Name: session.Name, Messages: doc.Messages}
+	return
+}
+
+// runPostResponseHooks passes the answer to the post_response hooks, with the request,
+// options and session messages it answers, and returns the answer as they changed it.
+func (o *Chatter) runPostResponseHooks(ctx context.Context, request *domain.ChatRequest, opts *domain.ChatOptions,
+	session *fsdb.Session, message string) (ret string, err error) {
+
+	ret = message
+	if !o.hooks.Has(HookEventPostResponse) {
+		return
+	}
+	doc := o.hookDocument(HookEventPostResponse, request, opts, session.Messages)
+	doc.Response = &message
+	if err = o.hooks.Run(ctx, doc, func(warning string) { o.notify(opts, warning) }); err != nil {
+		return
+	}
+	ret = *doc.Response
+	return
+}
+
+// releaseHeldAnswer shows the answer that was held back for the post_response hooks the
+// way it would have been streamed.
+func (o *Chatter) releaseHeldAnswer(opts *domain.ChatOptions, message string, reasoning string, streamed bool) {
+	if !streamed {
+		return
+	}
+	if opts.UpdateChan != nil {
+		if reasoning != "" {
+			opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeReasoning, Content: reasoning}
+		}
+		opts.UpdateChan <- domain.StreamUpdate{Type: domain.StreamTypeContent, Content: message}
+	}
+	if o.Stream && !opts.SuppressThink && !opts.Quiet {
+		if reasoning != "" {
+			fmt.Printf("%s\n\n", util.Dim(os.Stdout, reasoning))
+		}
+		fmt.Print(message)
+		if !strings.HasSuffix(message, "\n") {
+			fmt.Println()
+		}
+	}
+}
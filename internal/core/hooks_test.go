@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+func TestHooks_LoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hooks.yaml")
+	content := `hooks:
+  - name: redact
+    event: pre_request
+    command: ./redact
+    timeout: 2s
+  - event: post_response
+    command: ./audit
+    on_failure: ignore
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks := NewHooks()
+	if err := hooks.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(hooks.hooks) != 2 {
+		t.Fatalf("expected 2 hooks, got %d", len(hooks.hooks))
+	}
+	if hooks.hooks[0].Timeout != 2*time.Second {
+		t.Errorf("timeout = %v, want 2s", hooks.hooks[0].Timeout)
+	}
+	if hooks.hooks[1].Name != "./audit" {
+		t.Errorf("name of unnamed hook = %q, want its command", hooks.hooks[1].Name)
+	}
+	if !hooks.Has(HookEventPreRequest) || !hooks.Has(HookEventPostResponse) {
+		t.Error("Has() = false for a configured event")
+	}
+
+	if err := NewHooks().LoadFile(filepath.Join(dir, "missing.yaml")); err != nil {
+		t.Errorf("LoadFile() of a missing file error = %v", err)
+	}
+
+	for _, hook := range []Hook{
+		{Event: HookEventPreRequest},
+		{Event: "before", Command: "true"},
+		{Event: HookEventPreRequest, Command: "true", OnFailure: "retry"},
+		{Event: HookEventPreRequest, Command: "true", Timeout: -time.Second},
+	} {
+		if err := NewHooks().Add(hook); err == nil {
+			t.Errorf("Add(%+v) succeeded", hook)
+		}
+	}
+}
+
+func TestChatter_Send_RunsHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are shell scripts")
+	}
+
+	var sent []*chat.ChatCompletionMessage
+	vendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, error) {
+		sent = messages
+		return "the secret answer", nil
+	}}
+	send := func(hooks ...Hook) (*fsdb.Session, error) {
+		t.Helper()
+		registered := NewHooks()
+		if err := registered.Add(hooks...); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model", hooks: registered}
+		request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "my password is hunter2"}}
+		return chatter.Send(context.Background(), request, &domain.ChatOptions{Quiet: true})
+	}
+
+	// A pre_request hook replaces the messages, a post_response hook the answer
+	auditLog := filepath.Join(t.TempDir(), "audit.json")
+	session, err := send(
+		Hook{Event: HookEventPreRequest, Command: `sed 's/hunter2/[redacted]/g'`},
+		Hook{Event: HookEventPostResponse, Command: "cat > " + auditLog},
+		Hook{Event: HookEventPostResponse, Command: `cat > /dev/null; echo '{"response": "the [redacted] answer"}'`},
+	)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(sent) != 1 || sent[0].Content != "my password is [redacted]" {
+		t.Errorf("vendor got %+v, want the redacted message", sent[0])
+	}
+	if got := session.GetLastMessage().Content; got != "the [redacted] answer" {
+		t.Errorf("answer = %q, want the one of the hook", got)
+	}
+	audit, _ := os.ReadFile(auditLog)
+	for _, want := range []string{`"event":"post_response"`, `"vendor":"mock"`, `"response":"the secret answer"`, `[redacted]`} {
+		if !strings.Contains(string(audit), want) {
+			t.Errorf("post_response document %s does not contain %s", audit, want)
+		}
+	}
+
+	// A veto stops the request whatever the failure policy
+	sent = nil
+	_, err = send(Hook{Event: HookEventPreRequest, Command: `cat > /dev/null; echo '{"veto": true, "reason": "secrets"}'`, OnFailure: HookOnFailureIgnore})
+	if domain.CodeOf(err) != domain.ErrorCodeHookVetoed || !strings.Contains(err.Error(), "secrets") {
+		t.Errorf("Send() error = %v, want a veto", err)
+	}
+	if sent != nil {
+		t.Error("vetoed request was sent")
+	}
+
+	// Failures fail the request, unless they are ignored
+	if _, err = send(Hook{Event: HookEventPostResponse, Command: "exit 3"}); domain.CodeOf(err) != domain.ErrorCodeHookFailed {
+		t.Errorf("Send() error = %v, want a hook failure", err)
+	}
+	if _, err = send(Hook{Event: HookEventPreRequest, Command: "echo not json"}); domain.CodeOf(err) != domain.ErrorCodeHookFailed {
+		t.Errorf("Send() error = %v, want a hook failure", err)
+	}
+	if _, err = send(Hook{Event: HookEventPreRequest, Command: "sleep 5", Timeout: 100 * time.Millisecond}); domain.CodeOf(err) != domain.ErrorCodeHookFailed {
+		t.Errorf("Send() error = %v, want a hook timeout", err)
+	}
+	if _, err = send(Hook{Event: HookEventPreRequest, Command: "exit 3", OnFailure: HookOnFailureIgnore}); err != nil {
+		t.Errorf("Send() error = %v with an ignored hook failure", err)
+	}
+}
+
+func TestChatter_Send_PreRequestHooksSeeAttachmentsAndChangeSomeOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are shell scripts")
+	}
+
+	hooks := NewHooks()
+	if err := hooks.Add(
+		Hook{Event: HookEventPreRequest, Command: `sed 's/hunter2/[redacted]/g'`},
+		Hook{Event: HookEventPreRequest, Command: `cat > /dev/null; echo '{"options": {"Temperature": 0.2}}'`},
+	); err != nil {
+		t.Fatal(err)
+	}
+	var sent *chat.ChatCompletionMessage
+	var sentOpts domain.ChatOptions
+	vendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+		sent, sentOpts = messages[len(messages)-1], *opts
+		return "ok", nil
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model", hooks: hooks}
+
+	// The text extracted from an attachment is what the hooks see
+	csv := base64.StdEncoding.EncodeToString([]byte("user,password\nalice,hunter2\n"))
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{
+		Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "check the passwords"},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{Filename: "users.csv", MimeType: "text/csv", Data: csv}},
+		},
+	}}
+	opts := &domain.ChatOptions{Temperature: 0.7, TopP: 0.9, MaxTokens: 100, Quiet: true}
+	if _, err := chatter.Send(context.Background(), request, opts); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(sent.MultiContent) != 2 || strings.Contains(sent.MultiContent[1].Text, "hunter2") ||
+		!strings.Contains(sent.MultiContent[1].Text, "[redacted]") {
+		t.Errorf("vendor got %+v, want the redacted attachment text", sent.MultiContent)
+	}
+
+	// A reply with some options changes those and keeps the others
+	if sentOpts.Temperature != 0.2 || sentOpts.TopP != 0.9 || sentOpts.MaxTokens != 100 {
+		t.Errorf("vendor got temperature %v, top_p %v and max tokens %v, want 0.2, 0.9 and 100",
+			sentOpts.Temperature, sentOpts.TopP, sentOpts.MaxTokens)
+	}
+}
+
+func TestChatter_Send_HoldsStreamForPostResponseHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are shell scripts")
+	}
+
+	hooks := NewHooks()
+	if err := hooks.Add(Hook{Event: HookEventPostResponse, Command: `cat > /dev/null; echo '{"response": "clean"}'`}); err != nil {
+		t.Fatal(err)
+	}
+	vendor := &mockVendor{streamChunks: []domain.StreamUpdate{
+		{Type: domain.StreamTypeContent, Content: "dirty "},
+		{Type: domain.StreamTypeContent, Content: "words"},
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model", Stream: true, hooks: hooks}
+
+	updates := make(chan domain.StreamUpdate, 10)
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "hi"}}
+	if _, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Quiet: true, UpdateChan: updates}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	close(updates)
+
+	var content []string
+	for update := range updates {
+		if update.Type == domain.StreamTypeContent {
+			content = append(content, update.Content)
+		}
+	}
+	if len(content) != 1 || content[0] != "clean" {
+		t.Errorf("streamed content = %q, want only the answer of the hook", content)
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+// imageGenerator returns the vendor as an image generator when the request asks for an image.
+func (o *Chatter) imageGenerator(opts *domain.ChatOptions) (ret ai.ImageGenerator, ok bool) {
+	if opts.ImageFile == "" {
+		return
+	}
+	ret, ok = o.vendor.(ai.ImageGenerator)
+	return
+}
+
+// generateImages asks the vendor for images, saves them to --image-file and returns the text
+// to record with them: the model's own text or, when it gave none, where the images were
+// saved. The returned parts hold the images so the session keeps them as attachments.
+func (o *Chatter) generateImages(ctx context.Context, generator ai.ImageGenerator, messages []*chat.ChatCompletionMessage,
+	opts *domain.ChatOptions) (message string, parts []chat.ChatMessagePart, err error) {
+	if messages, err = inlineImages(messages); err != nil {
+		return
+	}
+
+	var images []ai.ImageData
+	if message, images, err = generator.GenerateImages(ctx, messages, opts); err != nil {
+		return
+	}
+	message = strings.TrimSpace(message)
+	if len(images) == 0 {
+		err = errors.New(joinPromptSections(fmt.Sprintf(i18n.T("image_generation_no_images"), opts.Model), message))
+		return
+	}
+
+	var saved []string
+	if saved, err = ai.SaveImages(images, opts.ImageFile); err != nil {
+		return
+	}
+	notices := make([]string, 0, len(saved))
+	for _, name := range saved {
+		notices = append(notices, fmt.Sprintf(i18n.T("image_saved_to"), name))
+	}
+	if message == "" {
+		message = strings.Join(notices, "\n")
+	} else if !opts.Quiet {
+		fmt.Println(strings.Join(notices, "\n"))
+	}
+
+	parts = make([]chat.ChatMessagePart, 0, len(images))
+	for _, image := range images {
+		parts = append(parts, chat.ChatMessagePart{Type: chat.ChatMessagePartTypeImageURL,
+			ImageURL: &chat.ChatMessageImageURL{URL: image.DataURL()}})
+	}
+	return
+}
+
+// inlineImages returns the messages with remote image URLs replaced by data URLs, so vendors
+// can use every image of the conversation as a reference. Changed messages are copied.
+func inlineImages(messages []*chat.ChatCompletionMessage) (ret []*chat.ChatCompletionMessage, err error) {
+	ret = make([]*chat.ChatCompletionMessage, 0, len(messages))
+	for _, message := range messages {
+		if !hasRemoteImages(message) {
+			ret = append(ret, message)
+			continue
+		}
+
+		converted := *message
+		converted.MultiContent = make([]chat.ChatMessagePart, 0, len(message.MultiContent))
+		for _, part := range message.MultiContent {
+			if isRemoteImage(part) {
+				var content []byte
+				if content, err = (&domain.Attachment{URL: &part.ImageURL.URL}).ContentBytes(); err != nil {
+					return
+				}
+				image := ai.ImageData{Data: content, MimeType: http.DetectContentType(content)}
+				part = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeImageURL,
+					ImageURL: &chat.ChatMessageImageURL{URL: image.DataURL()}}
+			}
+			converted.MultiContent = append(converted.MultiContent, part)
+		}
+		ret = append(ret, &converted)
+	}
+	return
+}
+
+func hasRemoteImages(message *chat.ChatCompletionMessage) bool {
+	for _, part := range message.MultiContent {
+		if isRemoteImage(part) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRemoteImage(part chat.ChatMessagePart) bool {
+	return part.Type == chat.ChatMessagePartTypeImageURL && part.ImageURL != nil &&
+		(strings.HasPrefix(part.ImageURL.URL, "http://") || strings.HasPrefix(part.ImageURL.URL, "https://"))
+}
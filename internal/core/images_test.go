@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// imageMockVendor returns canned images from GenerateImages and records what it was sent
+type imageMockVendor struct {
+	mockVendor
+	images   []ai.ImageData
+	text     string
+	received []*chat.ChatCompletionMessage
+}
+
+func (m *imageMockVendor) GenerateImages(_ context.Context, messages []*chat.ChatCompletionMessage, _ *domain.ChatOptions) (string, []ai.ImageData, error) {
+	m.received = messages
+	return m.text, m.images, nil
+}
+
+func TestChatter_Send_GeneratesImages(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	if err := os.MkdirAll(db.Sessions.Dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	vendor := &imageMockVendor{images: []ai.ImageData{
+		{Data: []byte("first"), MimeType: "image/webp"},
+		{Data: []byte("second"), MimeType: "image/webp"},
+	}}
+	chatter := &Chatter{db: db, vendor: vendor, model: "test-model"}
+
+	imageFile := filepath.Join(t.TempDir(), "out", "cat.webp")
+	reference := "data:image/png;base64,cmVm"
+	request := &domain.ChatRequest{SessionName: "images", Message: &chat.ChatCompletionMessage{
+		Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "make it a cat"},
+			{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: reference}},
+		},
+	}}
+	session, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model", ImageFile: imageFile, Quiet: true})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	for name, want := range map[string]string{imageFile: "first", ai.ImageFileName(imageFile, 1): "second"} {
+		if data, readErr := os.ReadFile(name); readErr != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", name, want, data, readErr)
+		}
+	}
+	if last := vendor.received[len(vendor.received)-1]; last.MultiContent[1].ImageURL.URL != reference {
+		t.Errorf("Expected the reference image to reach the vendor, got %+v", last.MultiContent)
+	}
+
+	last := session.GetLastMessage()
+	if last.Content != "" || len(last.MultiContent) != 3 {
+		t.Fatalf("Expected the text and both images as parts, got %+v", last)
+	}
+	if last.MultiContent[1].ImageURL.URL != vendor.images[0].DataURL() {
+		t.Errorf("Expected the first image to be recorded, got %q", last.MultiContent[1].ImageURL.URL)
+	}
+	if !strings.Contains(last.TextContent(), imageFile) {
+		t.Errorf("Expected the text to name the saved file, got %q", last.TextContent())
+	}
+
+	// The images recorded in the session are not sent to chat models
+	saved, err := db.Sessions.Get("images")
+	if err != nil {
+		t.Fatalf("Expected the session to be saved, got: %v", err)
+	}
+	messages := ai.WithoutGeneratedImages(saved.GetVendorMessages())
+	if answer := messages[len(messages)-1]; len(answer.MultiContent) != 0 || answer.Content == "" {
+		t.Errorf("Expected the generated images to be reduced to text, got %+v", answer)
+	}
+}
+
+func TestChatter_Send_ImageGenerationWithoutImagesFails(t *testing.T) {
+	vendor := &imageMockVendor{text: "I cannot draw that."}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model"}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "draw"}}
+	imageFile := filepath.Join(t.TempDir(), "out.png")
+	if _, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model", ImageFile: imageFile}); err == nil {
+		t.Fatal("Expected an error when no image is returned")
+	}
+	if _, err := os.Stat(imageFile); !os.IsNotExist(err) {
+		t.Errorf("Expected no image file, got %v", err)
+	}
+}
@@ -43,6 +43,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/tools/jina"
 	"github.com/danielmiessler/fabric/internal/tools/lang"
 	"github.com/danielmiessler/fabric/internal/tools/spotify"
+	"github.com/danielmiessler/fabric/internal/tools/websearch"
 	"github.com/danielmiessler/fabric/internal/tools/youtube"
 	"github.com/danielmiessler/fabric/internal/util"
 )
@@ -58,7 +59,9 @@ func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry, err error) {
 		Language:       lang.NewLanguage(),
 		Jina:           jina.NewClient(),
 		Spotify:        spotify.NewSpotify(),
+		WebSearch:      websearch.NewClient(),
 		Strategies:     strategy.NewStrategiesManager(),
+		Hooks:          NewHooks(),
 	}
 
 	var homedir string
@@ -67,6 +70,17 @@ func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry, err error) {
 	}
 	ret.TemplateExtensions = template.NewExtensionManager(filepath.Join(homedir, ".config/fabric"))
 
+	// User overrides extend or correct the built-in model capability table
+	if err = ai.Capabilities.LoadOverrides(filepath.Join(homedir, ".config/fabric", "capabilities.yaml")); err != nil {
+		return
+	}
+	if err = ai.RateLimits.LoadRules(filepath.Join(homedir, ".config/fabric", "ratelimits.yaml")); err != nil {
+		return
+	}
+	if err = ret.Hooks.LoadFile(filepath.Join(homedir, ".config/fabric", "hooks.yaml")); err != nil {
+		return
+	}
+
 	ret.Defaults = tools.NeeDefaults(ret.GetModels)
 
 	// Create a vendors slice to hold all vendors (order doesn't matter initially)
@@ -132,8 +146,10 @@ type PluginRegistry struct {
 	Language           *lang.Language
 	Jina               *jina.Client
 	Spotify            *spotify.Spotify
+	WebSearch          *websearch.Client
 	TemplateExtensions *template.ExtensionManager
 	Strategies         *strategy.StrategiesManager
+	Hooks              *Hooks
 }
 
 func (o *PluginRegistry) SaveEnvFile() (err error) {
@@ -152,12 +168,36 @@ func (o *PluginRegistry) SaveEnvFile() (err error) {
 	o.YouTube.SetupFillEnvFileContent(&envFileContent)
 	o.Jina.SetupFillEnvFileContent(&envFileContent)
 	o.Spotify.SetupFillEnvFileContent(&envFileContent)
+	o.WebSearch.SetupFillEnvFileContent(&envFileContent)
 	o.Language.SetupFillEnvFileContent(&envFileContent)
 
-	err = o.Db.SaveEnv(envFileContent.String())
+	err = o.Db.SaveEnvSecrets(envFileContent.String(), o.sensitiveSettings())
 	return
 }
 
+// sensitiveSettings returns the environment variables of the settings of every plugin
+// that are marked sensitive, which the secret store keeps instead of the .env file.
+func (o *PluginRegistry) sensitiveSettings() (ret map[string]bool) {
+	ret = map[string]bool{}
+	for _, plugin := range o.configPlugins() {
+		for _, setting := range pluginSettings(plugin) {
+			if setting.Sensitive {
+				ret[setting.EnvVariable] = true
+			}
+		}
+	}
+	return
+}
+
+// MigrateSecrets moves the keys, tokens and secrets of the .env file to the encrypted
+// secret store, creating it with the given unlock method, and returns the moved names.
+func (o *PluginRegistry) MigrateSecrets(unlock string) (ret []string, err error) {
+	sensitive := o.sensitiveSettings()
+	return o.Db.MigrateSecrets(unlock, os.Getenv(fsdb.SecretsKeyFileEnv), func(name string) bool {
+		return sensitive[name] || plugins.IsSensitiveEnvVariable(name)
+	})
+}
+
 func (o *PluginRegistry) Setup() (err error) {
 	// Check if this is a first-time setup
 	isFirstRun := o.isFirstTimeSetup()
@@ -325,7 +365,7 @@ func (o *PluginRegistry) runInteractiveSetup() (err error) {
 	groupsPlugins.AddGroupItems(i18n.T("setup_required_tools"), o.Defaults, o.PatternsLoader, o.Strategies)
 
 	// Add optional tools
-	groupsPlugins.AddGroupItems(i18n.T("setup_optional_configuration_header"), o.CustomPatterns, o.Jina, o.Language, o.Spotify, o.YouTube)
+	groupsPlugins.AddGroupItems(i18n.T("setup_optional_configuration_header"), o.CustomPatterns, o.Jina, o.Language, o.Spotify, o.WebSearch, o.YouTube)
 
 	for {
 		groupsPlugins.Print(false)
@@ -466,10 +506,11 @@ func (o *PluginRegistry) Configure() (err error) {
 		o.PatternsLoader.Patterns.CustomPatternsDir = customPatternsDir
 	}
 
-	//YouTube, Jina, Spotify are not mandatory, so ignore not configured error
+	//YouTube, Jina, Spotify, Web Search are not mandatory, so ignore not configured error
 	_ = o.YouTube.Configure()
 	_ = o.Jina.Configure()
 	_ = o.Spotify.Configure()
+	_ = o.WebSearch.Configure()
 	_ = o.Language.Configure()
 	return
 }
@@ -479,6 +520,10 @@ func (o *PluginRegistry) GetChatter(model string, modelContextLength int, vendor
 		db:     o.Db,
 		Stream: stream,
 		DryRun: dryRun,
+		hooks:  o.Hooks,
+	}
+	if o.WebSearch != nil {
+		ret.webSearch = o.WebSearch
 	}
 
 	defaultModel := o.Defaults.Model.Value
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+// speechSynthesizer returns the vendor as a speech synthesizer when the request asks for audio.
+func (o *Chatter) speechSynthesizer(opts *domain.ChatOptions) (ret ai.SpeechSynthesizer, ok bool) {
+	if !opts.AudioOutput {
+		return
+	}
+	ret, ok = o.vendor.(ai.SpeechSynthesizer)
+	return
+}
+
+// synthesizeSpeech speaks the text of the last user message. Long texts are split at
+// sentence boundaries into requests the vendor accepts, and the audio of the parts is
+// joined into one file. The audio is returned after ai.AudioDataPrefix for the CLI to save.
+func (o *Chatter) synthesizeSpeech(ctx context.Context, synthesizer ai.SpeechSynthesizer, messages []*chat.ChatCompletionMessage,
+	opts *domain.ChatOptions) (ret string, err error) {
+	chunks := ai.SplitSpeechText(ai.LastUserText(messages), ai.MaxSpeechChunk)
+	if len(chunks) == 0 {
+		err = errors.New(i18n.T("speech_no_text"))
+		return
+	}
+
+	audio := make([][]byte, 0, len(chunks))
+	for i, chunk := range chunks {
+		debuglog.Debug(debuglog.Basic, "Synthesizing speech part %d of %d (%d characters)\n", i+1, len(chunks), len(chunk))
+		var data []byte
+		if data, err = synthesizer.SynthesizeSpeech(ctx, chunk, opts); err != nil {
+			return
+		}
+		audio = append(audio, data)
+	}
+
+	var joined []byte
+	if joined, err = ai.ConcatAudio(opts.AudioFormat, audio); err != nil {
+		return
+	}
+	ret = ai.AudioDataPrefix + string(joined)
+	return
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+)
+
+// speechMockVendor speaks each text as its first letter in brackets and records the texts it was sent
+type speechMockVendor struct {
+	mockVendor
+	texts []string
+}
+
+func (m *speechMockVendor) SynthesizeSpeech(_ context.Context, text string, _ *domain.ChatOptions) ([]byte, error) {
+	m.texts = append(m.texts, text)
+	return []byte("[" + text[:1] + "]"), nil
+}
+
+func TestChatter_Send_SynthesizesLongTextInChunks(t *testing.T) {
+	vendor := &speechMockVendor{}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-tts"}
+
+	first := strings.Repeat("a", ai.MaxSpeechChunk-5) + "."
+	second := "Bye now."
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: first + " " + second}}
+	session, err := chatter.Send(context.Background(), request,
+		&domain.ChatOptions{Model: "test-tts", AudioOutput: true, AudioFormat: ai.SpeechFormatMP3})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if len(vendor.texts) != 2 || vendor.texts[0] != first || vendor.texts[1] != second {
+		t.Fatalf("Expected the text to be split at the sentence boundary, got %d parts", len(vendor.texts))
+	}
+	if got := session.GetLastMessage().Content; got != ai.AudioDataPrefix+"[a][B]" {
+		t.Errorf("Expected the joined audio after the audio prefix, got %q", got)
+	}
+}
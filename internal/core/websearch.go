@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/tools/websearch"
+)
+
+// webSearcher runs provider-independent web searches for models without native search.
+type webSearcher interface {
+	IsConfigured() bool
+	Search(ctx context.Context, query string) ([]websearch.Result, error)
+}
+
+// usesWebSearchBackend reports whether --search is served by the configured web search
+// backend because the model is not known to support native web search.
+func (o *Chatter) usesWebSearchBackend(opts *domain.ChatOptions) bool {
+	return opts != nil && opts.Search && !o.DryRun && o.webSearch != nil && o.webSearch.IsConfigured() &&
+		!o.Capabilities().WebSearch.IsSupported()
+}
+
+// addWebSearchResults searches the web for the request and appends the numbered results
+// to the last message of session. The results are returned as citations.
+func (o *Chatter) addWebSearchResults(ctx context.Context, session *fsdb.Session, request *domain.ChatRequest,
+	opts *domain.ChatOptions) (citations []chat.Citation, err error) {
+
+	query := opts.SearchQuery
+	if query == "" && request.Message != nil {
+		query = request.Message.Content
+	}
+	if query == "" {
+		err = errors.New(i18n.T("web_search_no_query"))
+		return
+	}
+
+	var results []websearch.Result
+	if results, err = o.webSearch.Search(ctx, query); err != nil || len(results) == 0 {
+		return
+	}
+
+	message := session.GetLastMessage()
+	if message == nil {
+		return
+	}
+	searchContext := websearch.FormatContext(query, results)
+	if len(message.MultiContent) > 0 {
+		message.MultiContent = append(message.MultiContent, chat.ChatMessagePart{Type: chat.ChatMessagePartTypeText, Text: searchContext})
+	} else {
+		message.Content = joinPromptSections(message.Content, searchContext)
+	}
+	citations = websearch.Citations(results)
+	return
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/internal/tools/websearch"
+)
+
+type mockWebSearcher struct {
+	results []websearch.Result
+	query   string
+}
+
+func (m *mockWebSearcher) IsConfigured() bool {
+	return true
+}
+
+func (m *mockWebSearcher) Search(_ context.Context, query string) ([]websearch.Result, error) {
+	m.query = query
+	return m.results, nil
+}
+
+func TestChatter_Send_UsesWebSearchBackend(t *testing.T) {
+	searcher := &mockWebSearcher{results: []websearch.Result{
+		{Title: "Example", URL: "https://example.com", Snippet: "example snippet"},
+	}}
+	var sentContent string
+	var sentSearch bool
+	vendor := &mockVendor{sendFunc: func(_ context.Context, messages []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+		sentContent = messages[len(messages)-1].Content
+		sentSearch = opts.Search
+		return "answer [1]", nil
+	}}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: vendor, model: "test-model", webSearch: searcher}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "latest news"}}
+	opts := &domain.ChatOptions{Model: "test-model", Search: true}
+	session, err := chatter.Send(context.Background(), request, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !opts.Search {
+		t.Error("Expected the caller's options to keep searching for the next request")
+	}
+
+	if searcher.query != "latest news" {
+		t.Errorf("Expected the input to be used as query, got %q", searcher.query)
+	}
+	if sentSearch {
+		t.Error("Expected native search to be disabled when the backend answered")
+	}
+	if !strings.Contains(sentContent, "# WEB SEARCH RESULTS") || !strings.Contains(sentContent, "https://example.com") {
+		t.Errorf("Expected search results in the prompt, got %q", sentContent)
+	}
+	last := session.GetLastMessage()
+	if len(last.Citations) != 1 || last.Citations[0].URL != "https://example.com" {
+		t.Errorf("Expected backend citations on the answer, got %+v", last.Citations)
+	}
+}
+
+func TestChatter_Send_WebSearchQueryOverride(t *testing.T) {
+	searcher := &mockWebSearcher{}
+	chatter := &Chatter{db: fsdb.NewDb(t.TempDir()), vendor: &mockVendor{}, model: "test-model", webSearch: searcher}
+
+	request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "long input"}}
+	if _, err := chatter.Send(context.Background(), request, &domain.ChatOptions{Model: "test-model", Search: true, SearchQuery: "short query"}); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if searcher.query != "short query" {
+		t.Errorf("Expected --search-query to be used, got %q", searcher.query)
+	}
+}
@@ -9,9 +9,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/i18n"
 	"github.com/gabriel-vasile/mimetype"
 )
@@ -114,6 +118,102 @@ func (a *Attachment) Base64Content() (ret string, err error) {
 	return
 }
 
+// Name returns the file name of the attachment, taken from its path or URL.
+func (a *Attachment) Name() string {
+	if a.Path != nil {
+		return filepath.Base(*a.Path)
+	}
+	if a.URL != nil {
+		if parsed, err := url.Parse(*a.URL); err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+			return path.Base(parsed.Path)
+		}
+		return *a.URL
+	}
+	return ""
+}
+
+// MessagePart converts the attachment into a typed message part: images become image_url
+// parts, audio becomes input_audio and every other file (PDF, DOCX, HTML, CSV, text, ...)
+// becomes a file part.
+func (a *Attachment) MessagePart() (ret chat.ChatMessagePart, err error) {
+	var mimeType string
+	if mimeType, err = a.ResolveType(); err != nil {
+		return
+	}
+	mimeType = BaseMimeType(mimeType)
+
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		imageURL := ""
+		if a.URL != nil {
+			imageURL = *a.URL
+		} else {
+			var data string
+			if data, err = a.Base64Content(); err != nil {
+				return
+			}
+			imageURL = fmt.Sprintf("data:%s;base64,%s", mimeType, data)
+		}
+		ret = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: imageURL}}
+	case strings.HasPrefix(mimeType, "audio/"):
+		var data string
+		if data, err = a.Base64Content(); err != nil {
+			return
+		}
+		ret = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeInputAudio,
+			InputAudio: &chat.ChatMessageInputAudio{Data: data, Format: AudioFormat(mimeType)}}
+	default:
+		file := &chat.ChatMessageFile{Filename: a.Name(), MimeType: mimeType}
+		if a.URL != nil {
+			file.URL = *a.URL
+		} else if file.Data, err = a.Base64Content(); err != nil {
+			return
+		}
+		ret = chat.ChatMessagePart{Type: chat.ChatMessagePartTypeFile, File: file}
+	}
+	return
+}
+
+// FormatAttachmentText labels the text of an attachment with its name so the model
+// can tell several attachments apart.
+func FormatAttachmentText(name, text string) string {
+	return fmt.Sprintf("[Attachment: %s]\n%s", name, strings.TrimSpace(text))
+}
+
+// BaseMimeType strips parameters such as "; charset=utf-8" from a MIME type.
+func BaseMimeType(mimeType string) string {
+	if idx := strings.Index(mimeType, ";"); idx >= 0 {
+		mimeType = mimeType[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// AudioFormat returns the short audio format name vendors expect for an audio MIME type.
+func AudioFormat(mimeType string) string {
+	switch mimeType {
+	case "audio/mpeg", "audio/mp3":
+		return "mp3"
+	case "audio/wav", "audio/wave", "audio/x-wav", "audio/vnd.wave":
+		return "wav"
+	case "audio/x-flac":
+		return "flac"
+	case "audio/mp4", "audio/x-m4a":
+		return "m4a"
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(mimeType, "audio/"), "x-")
+}
+
+// AudioMimeType is the inverse of AudioFormat for vendors that expect a MIME type.
+func AudioMimeType(format string) string {
+	switch format {
+	case "mp3":
+		return "audio/mpeg"
+	case "m4a":
+		return "audio/mp4"
+	}
+	return "audio/" + format
+}
+
 func NewAttachment(value string) (ret *Attachment, err error) {
 	if isURL(value) {
 		var mimeType string
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+func TestAttachmentMessagePart(t *testing.T) {
+	dir := t.TempDir()
+	pdfPath := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(pdfPath, []byte("%PDF-1.4\n%%EOF\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	notesPath := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(notesPath, []byte("# Notes\n\nplain text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	attachment, err := NewAttachment(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, err := attachment.MessagePart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Type != chat.ChatMessagePartTypeFile || part.File.Filename != "report.pdf" || part.File.MimeType != "application/pdf" {
+		t.Errorf("unexpected PDF part %+v", part.File)
+	}
+	if data, _ := base64.StdEncoding.DecodeString(part.File.Data); string(data) != "%PDF-1.4\n%%EOF\n" {
+		t.Errorf("unexpected PDF data %q", part.File.Data)
+	}
+
+	attachment, err = NewAttachment(notesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part, err = attachment.MessagePart(); err != nil {
+		t.Fatal(err)
+	}
+	if part.Type != chat.ChatMessagePartTypeFile || part.File.MimeType != "text/plain" {
+		t.Errorf("expected a text/plain file part, got %+v", part.File)
+	}
+
+	audioType := "audio/mpeg"
+	attachment = &Attachment{Type: &audioType, Content: []byte("ID3")}
+	if part, err = attachment.MessagePart(); err != nil {
+		t.Fatal(err)
+	}
+	if part.Type != chat.ChatMessagePartTypeInputAudio || part.InputAudio.Format != "mp3" {
+		t.Errorf("expected an mp3 audio part, got %+v", part)
+	}
+
+	imageType := "image/png; charset=binary"
+	imageURL := "https://example.com/cat.png"
+	attachment = &Attachment{Type: &imageType, URL: &imageURL}
+	if part, err = attachment.MessagePart(); err != nil {
+		t.Fatal(err)
+	}
+	if part.Type != chat.ChatMessagePartTypeImageURL || part.ImageURL.URL != imageURL {
+		t.Errorf("expected the image URL to be kept, got %+v", part)
+	}
+}
+
+func TestAudioFormat(t *testing.T) {
+	tests := map[string]string{
+		"audio/mpeg":  "mp3",
+		"audio/x-wav": "wav",
+		"audio/flac":  "flac",
+		"audio/x-m4a": "m4a",
+		"audio/ogg":   "ogg",
+	}
+	for mimeType, want := range tests {
+		if got := AudioFormat(mimeType); got != want {
+			t.Errorf("AudioFormat(%q) = %q, want %q", mimeType, got, want)
+		}
+	}
+	if got := AudioMimeType("mp3"); got != "audio/mpeg" {
+		t.Errorf("AudioMimeType(mp3) = %q", got)
+	}
+}
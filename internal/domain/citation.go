@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+// AppendCitations adds citations to list, skipping entries without a URL and
+// entries that cite the same URL for the same span as one already in list.
+func AppendCitations(list []chat.Citation, citations ...chat.Citation) []chat.Citation {
+	for _, citation := range citations {
+		if citation.URL == "" {
+			continue
+		}
+		duplicate := false
+		for _, existing := range list {
+			if existing.URL == citation.URL && existing.Span == citation.Span {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			list = append(list, citation)
+		}
+	}
+	return list
+}
+
+// FormatCitationFootnotes renders citations as Markdown footnotes, one per
+// source URL in order of first appearance. It returns "" when there are none.
+func FormatCitationFootnotes(citations []chat.Citation) string {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, citation := range citations {
+		if citation.URL == "" || seen[citation.URL] {
+			continue
+		}
+		seen[citation.URL] = true
+
+		title := citation.Title
+		if title == "" {
+			title = citation.URL
+		}
+		line := fmt.Sprintf("[^%d]: [%s](%s)", len(lines)+1, title, citation.URL)
+		if citation.Snippet != "" {
+			line += fmt.Sprintf(" - \"%s\"", citation.Snippet)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
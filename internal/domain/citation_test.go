@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+func TestAppendCitationsSkipsDuplicates(t *testing.T) {
+	list := AppendCitations(nil,
+		chat.Citation{URL: "https://a.example", Title: "A", Span: "first"},
+		chat.Citation{URL: "https://a.example", Title: "A", Span: "first"},
+		chat.Citation{URL: "https://a.example", Title: "A", Span: "second"},
+		chat.Citation{Title: "no url"},
+	)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 citations, got %+v", list)
+	}
+}
+
+func TestFormatCitationFootnotes(t *testing.T) {
+	got := FormatCitationFootnotes([]chat.Citation{
+		{URL: "https://a.example", Title: "A", Snippet: "quoted", Span: "first"},
+		{URL: "https://b.example"},
+		{URL: "https://a.example", Title: "A", Span: "second"},
+	})
+	want := "[^1]: [A](https://a.example) - \"quoted\"\n[^2]: [https://b.example](https://b.example)"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got := FormatCitationFootnotes(nil); got != "" {
+		t.Errorf("expected empty footnotes, got %q", got)
+	}
+}
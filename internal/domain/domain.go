@@ -13,7 +13,7 @@ const (
 )
 
 type ChatRequest struct {
-	ContextName           string
+	ContextNames          []string // Names, file paths or URLs, joined in order
 	SessionName           string
 	PatternName           string
 	PatternVariables      map[string]string
@@ -38,6 +38,7 @@ type ChatOptions struct {
 	MaxTokens           int
 	Search              bool
 	SearchLocation      string
+	SearchQuery         string
 	ImageFile           string
 	ImageSize           string
 	ImageQuality        string
@@ -53,7 +54,14 @@ type ChatOptions struct {
 	NotificationCommand string
 	ShowMetadata        bool
 	Quiet               bool
-	UpdateChan          chan StreamUpdate `json:"-"`
+	NoPromptCache       bool
+	Chunk               bool
+	ChunkSize           int
+	ChunkOverlap        int
+	MergePattern        string
+	ApplyChanges        bool
+	ChangeApplier       func(changes []FileChange) error `json:"-"`
+	UpdateChan          chan StreamUpdate                `json:"-"`
 }
 
 // NormalizeMessages remove empty messages and ensure messages order user-assist-user
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorCode classifies a failure for machine-readable output. Codes are stable across
+// versions and languages, unlike error messages.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidArguments ErrorCode = "invalid_arguments"
+	ErrorCodeNotConfigured    ErrorCode = "not_configured"
+	ErrorCodeModelUnavailable ErrorCode = "model_unavailable"
+	ErrorCodeInvalidRequest   ErrorCode = "invalid_request"
+	ErrorCodeUnsupported      ErrorCode = "unsupported_option"
+	ErrorCodeVendor           ErrorCode = "vendor_error"
+	ErrorCodeEmptyResponse    ErrorCode = "empty_response"
+	ErrorCodeCanceled         ErrorCode = "canceled"
+	ErrorCodeTimeout          ErrorCode = "timeout"
+	ErrorCodeOutput           ErrorCode = "output_error"
+	ErrorCodeChecksFailed     ErrorCode = "checks_failed"
+	ErrorCodeHookVetoed       ErrorCode = "hook_vetoed"
+	ErrorCodeHookFailed       ErrorCode = "hook_failed"
+	ErrorCodeUnknown          ErrorCode = "error"
+)
+
+// CodedError is an error with the code classifying it.
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (o *CodedError) Error() string {
+	return o.Err.Error()
+}
+
+func (o *CodedError) Unwrap() error {
+	return o.Err
+}
+
+// WithCode classifies err with code. An error that is already classified keeps its
+// code, since it was given closer to the cause.
+func WithCode(code ErrorCode, err error) error {
+	var coded *CodedError
+	if err == nil || errors.As(err, &coded) {
+		return err
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// CodeOf returns the code classifying err. Canceled and timed out requests are
+// recognized whatever code they were given.
+func CodeOf(err error) ErrorCode {
+	var coded *CodedError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	case errors.As(err, &coded):
+		return coded.Code
+	}
+	return ErrorCodeUnknown
+}
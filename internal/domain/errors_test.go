@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	vendorErr := WithCode(ErrorCodeVendor, errors.New("rate limited"))
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"coded", vendorErr, ErrorCodeVendor},
+		{"wrapped", fmt.Errorf("chunk 2 of 3: %w", vendorErr), ErrorCodeVendor},
+		{"first code kept", WithCode(ErrorCodeInvalidRequest, vendorErr), ErrorCodeVendor},
+		{"canceled", WithCode(ErrorCodeVendor, fmt.Errorf("stream: %w", context.Canceled)), ErrorCodeCanceled},
+		{"timeout", context.DeadlineExceeded, ErrorCodeTimeout},
+		{"not coded", errors.New("boom"), ErrorCodeUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if WithCode(ErrorCodeVendor, nil) != nil {
+		t.Error("WithCode(nil) should stay nil")
+	}
+	if vendorErr.Error() != "rate limited" {
+		t.Errorf("Error() = %q, want the message of the cause", vendorErr.Error())
+	}
+}
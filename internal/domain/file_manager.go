@@ -14,6 +14,15 @@ import (
 // FileChangesMarker identifies the start of a file changes section in output
 const FileChangesMarker = "__CREATE_CODING_FEATURE_FILE_CHANGES__"
 
+// Operations of a file change
+const (
+	FileOperationCreate = "create"
+	FileOperationUpdate = "update"
+	FileOperationDelete = "delete"
+	FileOperationRename = "rename"
+	FileOperationPatch  = "patch"
+)
+
 const (
 	// MaxFileSize is the maximum size of a file that can be created (10MB)
 	MaxFileSize = 10 * 1024 * 1024
@@ -21,9 +30,18 @@ const (
 
 // FileChange represents a single file change operation to be performed
 type FileChange struct {
-	Operation string `json:"operation"` // "create" or "update"
-	Path      string `json:"path"`      // Relative path from project root
-	Content   string `json:"content"`   // New file content
+	Operation string `json:"operation"`          // "create", "update", "delete", "rename" or "patch"
+	Path      string `json:"path"`               // Relative path from project root
+	NewPath   string `json:"new_path,omitempty"` // Destination of a rename
+	Content   string `json:"content,omitempty"`  // New file content, or the unified diff of a patch
+}
+
+// Paths returns the files the change touches, relative to the project root.
+func (o *FileChange) Paths() []string {
+	if o.Operation == FileOperationRename {
+		return []string{o.Path, o.NewPath}
+	}
+	return []string{o.Path}
 }
 
 // ParseFileChanges extracts and parses the file change marker section from LLM output
@@ -84,7 +102,13 @@ func ParseFileChanges(output string) (changeSummary string, changes []FileChange
 	// Validate file changes
 	for i, change := range fileChanges {
 		// Validate operation
-		if change.Operation != "create" && change.Operation != "update" {
+		switch change.Operation {
+		case FileOperationCreate, FileOperationUpdate, FileOperationDelete, FileOperationPatch:
+		case FileOperationRename:
+			if change.NewPath == "" {
+				return changeSummary, nil, fmt.Errorf(i18n.T("file_manager_rename_without_new_path"), i)
+			}
+		default:
 			return changeSummary, nil, fmt.Errorf(i18n.T("file_manager_invalid_operation"), i, change.Operation)
 		}
 
@@ -94,8 +118,10 @@ func ParseFileChanges(output string) (changeSummary string, changes []FileChange
 		}
 
 		// Check for suspicious paths (directory traversal)
-		if strings.Contains(change.Path, "..") {
-			return changeSummary, nil, fmt.Errorf(i18n.T("file_manager_suspicious_path"), i, change.Path)
+		for _, path := range change.Paths() {
+			if strings.Contains(path, "..") || filepath.IsAbs(path) {
+				return changeSummary, nil, fmt.Errorf(i18n.T("file_manager_suspicious_path"), i, path)
+			}
 		}
 
 		// Check file size
@@ -167,24 +193,107 @@ func fixInvalidEscapes(jsonStr string) string {
 	return result.String()
 }
 
+// ResolveChangePath returns the absolute path of a file change path, and an error when
+// it leads outside projectRoot, for example through a symbolic link.
+func ResolveChangePath(projectRoot, path string) (ret string, err error) {
+	var root string
+	if root, err = filepath.Abs(projectRoot); err != nil {
+		return
+	}
+	if resolved, evalErr := filepath.EvalSymlinks(root); evalErr == nil {
+		root = resolved
+	}
+	ret = filepath.Join(root, path)
+
+	// Symbolic links are resolved up to the deepest part of the path that exists
+	existing, rest := ret, ""
+	for {
+		if resolved, evalErr := filepath.EvalSymlinks(existing); evalErr == nil {
+			existing = filepath.Join(resolved, rest)
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	if relative, relErr := filepath.Rel(root, existing); relErr != nil || relative == ".." ||
+		strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("file_manager_path_outside_root"), path, projectRoot))
+	}
+	return
+}
+
+// Contents returns the content of the changed file before and after the change. For a
+// rename, both are the content of the file that is moved.
+func (o *FileChange) Contents(projectRoot string) (before string, after string, err error) {
+	var data []byte
+	if data, err = os.ReadFile(filepath.Join(projectRoot, o.Path)); err == nil {
+		before = string(data)
+	} else if os.IsNotExist(err) && (o.Operation == FileOperationCreate || o.Operation == FileOperationUpdate) {
+		err = nil
+	} else {
+		return
+	}
+
+	switch o.Operation {
+	case FileOperationCreate, FileOperationUpdate:
+		after = o.Content
+	case FileOperationRename:
+		after = before
+	case FileOperationPatch:
+		after, err = ApplyPatch(before, o.Content)
+	}
+	return
+}
+
 // ApplyFileChanges applies the parsed file changes to the file system
 func ApplyFileChanges(projectRoot string, changes []FileChange) error {
 	for i, change := range changes {
 		// Get the absolute path
 		absPath := filepath.Join(projectRoot, change.Path)
 
-		// Create directories if necessary
-		dir := filepath.Dir(absPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf(i18n.T("file_manager_failed_create_directory"), dir, i, err)
-		}
+		switch change.Operation {
+		case FileOperationDelete:
+			if err := os.Remove(absPath); err != nil {
+				return fmt.Errorf(i18n.T("file_manager_failed_delete_file"), absPath, i, err)
+			}
+		case FileOperationRename:
+			newPath := filepath.Join(projectRoot, change.NewPath)
+			if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+				return fmt.Errorf(i18n.T("file_manager_failed_create_directory"), filepath.Dir(newPath), i, err)
+			}
+			if err := os.Rename(absPath, newPath); err != nil {
+				return fmt.Errorf(i18n.T("file_manager_failed_rename_file"), absPath, newPath, i, err)
+			}
+		default:
+			content := change.Content
+			if change.Operation == FileOperationPatch {
+				var err error
+				if _, content, err = change.Contents(projectRoot); err != nil {
+					return fmt.Errorf(i18n.T("file_manager_failed_patch_file"), absPath, i, err)
+				}
+			}
 
-		// Write the file
-		if err := os.WriteFile(absPath, []byte(change.Content), 0644); err != nil {
-			return fmt.Errorf(i18n.T("file_manager_failed_write_file"), absPath, i, err)
+			// Create directories if necessary
+			dir := filepath.Dir(absPath)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf(i18n.T("file_manager_failed_create_directory"), dir, i, err)
+			}
+
+			// Write the file, keeping the mode of a file that exists
+			mode := os.FileMode(0644)
+			if info, err := os.Stat(absPath); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.WriteFile(absPath, []byte(content), mode); err != nil {
+				return fmt.Errorf(i18n.T("file_manager_failed_write_file"), absPath, i, err)
+			}
 		}
 
-		fmt.Printf(i18n.T("file_manager_applied_operation")+"\n", change.Operation, change.Path)
+		fmt.Fprintf(os.Stderr, i18n.T("file_manager_applied_operation")+"\n", change.Operation, change.Path)
 	}
 
 	return nil
@@ -64,10 +64,42 @@ Some text after.`,
 ` + FileChangesMarker + `
 [
 	{
-		"operation": "delete",
+		"operation": "chmod",
 		"path": "test.txt",
 		"content": ""
 	}
+]`,
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Delete, rename and patch operations",
+			input: `Some text before.
+` + FileChangesMarker + `
+[
+	{"operation": "delete", "path": "old.txt"},
+	{"operation": "rename", "path": "a.txt", "new_path": "b.txt"},
+	{"operation": "patch", "path": "c.txt", "content": "@@ -1 +1 @@\n-a\n+b\n"}
+]`,
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "Rename without new path",
+			input: `Some text before.
+` + FileChangesMarker + `
+[
+	{"operation": "rename", "path": "a.txt"}
+]`,
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Rename with directory traversal",
+			input: `Some text before.
+` + FileChangesMarker + `
+[
+	{"operation": "rename", "path": "a.txt", "new_path": "../b.txt"}
 ]`,
 			want:    0,
 			wantErr: true,
@@ -183,3 +215,75 @@ func TestApplyFileChanges(t *testing.T) {
 		t.Errorf("Updated file content = %q, want %q", string(content), "Updated content")
 	}
 }
+
+func TestApplyFileChangesDeleteRenamePatch(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"delete.txt": "delete me",
+		"rename.txt": "move me",
+		"patch.txt":  "one\ntwo\nthree\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	changes := []FileChange{
+		{Operation: FileOperationDelete, Path: "delete.txt"},
+		{Operation: FileOperationRename, Path: "rename.txt", NewPath: "moved/renamed.txt"},
+		{Operation: FileOperationPatch, Path: "patch.txt", Content: "@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"},
+	}
+	if err := ApplyFileChanges(tempDir, changes); err != nil {
+		t.Fatalf("ApplyFileChanges() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "delete.txt")); !os.IsNotExist(err) {
+		t.Errorf("delete.txt still exists, err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "rename.txt")); !os.IsNotExist(err) {
+		t.Errorf("rename.txt still exists, err = %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(tempDir, "moved/renamed.txt")); err != nil || string(content) != "move me" {
+		t.Errorf("moved/renamed.txt = %q, %v, want %q", content, err, "move me")
+	}
+	info, err := os.Stat(filepath.Join(tempDir, "patch.txt"))
+	if err != nil {
+		t.Fatalf("Failed to stat patch.txt: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("patch.txt mode = %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+	if content, _ := os.ReadFile(filepath.Join(tempDir, "patch.txt")); string(content) != "one\n2\nthree\n" {
+		t.Errorf("patch.txt = %q, want %q", content, "one\n2\nthree\n")
+	}
+
+	// A patch whose context does not match leaves the file alone
+	bad := []FileChange{{Operation: FileOperationPatch, Path: "patch.txt", Content: "@@ -1 +1 @@\n-missing\n+x\n"}}
+	if err := ApplyFileChanges(tempDir, bad); err == nil {
+		t.Error("ApplyFileChanges() with a mismatched patch succeeded")
+	}
+}
+
+func TestResolveChangePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("Symbolic links not supported: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "file.txt"},
+		{path: "new/dir/file.txt"},
+		{path: "link/file.txt", wantErr: true},
+		{path: "link", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := ResolveChangePath(root, tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("ResolveChangePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// patchHunkHeader matches the header of a unified diff hunk, like @@ -12,5 +12,7 @@
+var patchHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+// patchHunk is a hunk of a unified diff: the lines it replaces and the lines replacing them.
+type patchHunk struct {
+	start int // Index of the first replaced line
+	old   []string
+	new   []string
+}
+
+// ApplyPatch applies a unified diff to content. Hunks are located by their context, starting
+// from the line numbers of their headers, so that a patch written against a slightly
+// different version of the file applies as long as its context matches.
+func ApplyPatch(content, patch string) (ret string, err error) {
+	var hunks []patchHunk
+	if hunks, err = parsePatch(patch); err != nil {
+		return
+	}
+
+	var lines []string
+	trailingNewline := true
+	if content != "" {
+		trailingNewline = strings.HasSuffix(content, "\n")
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	offset, from := 0, 0
+	for i, hunk := range hunks {
+		expected := hunk.start + offset
+		at := findHunk(lines, hunk.old, expected, from)
+		if at < 0 {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("file_manager_patch_hunk_mismatch"), i+1))
+			return
+		}
+		lines = slices.Concat(lines[:at], hunk.new, lines[at+len(hunk.old):])
+		offset += at - expected + len(hunk.new) - len(hunk.old)
+		from = at + len(hunk.new)
+	}
+
+	ret = strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		ret += "\n"
+	}
+	return
+}
+
+// parsePatch reads the hunks of a unified diff, skipping the file headers before them.
+// Blank lines in a hunk are taken as blank context lines that lost their leading space.
+func parsePatch(patch string) (ret []patchHunk, err error) {
+	patch = strings.TrimRight(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for line := range strings.SplitSeq(patch, "\n") {
+		if match := patchHunkHeader.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.Atoi(match[1])
+			// A hunk that replaces no lines inserts its lines after the start line
+			if match[2] != "0" {
+				start--
+			}
+			ret = append(ret, patchHunk{start: max(start, 0)})
+			continue
+		}
+		if len(ret) == 0 {
+			continue
+		}
+		hunk := &ret[len(ret)-1]
+		switch {
+		case strings.HasPrefix(line, "+"):
+			hunk.new = append(hunk.new, line[1:])
+		case strings.HasPrefix(line, "-"):
+			hunk.old = append(hunk.old, line[1:])
+		case strings.HasPrefix(line, " "):
+			hunk.old = append(hunk.old, line[1:])
+			hunk.new = append(hunk.new, line[1:])
+		case line == "":
+			hunk.old = append(hunk.old, "")
+			hunk.new = append(hunk.new, "")
+		}
+	}
+	if len(ret) == 0 {
+		err = errors.New(i18n.T("file_manager_patch_no_hunks"))
+	}
+	return
+}
+
+// findHunk returns where the lines a hunk replaces are found, searching outward from the
+// expected index but not before from, or -1. Trailing whitespace is ignored.
+func findHunk(lines []string, old []string, expected int, from int) int {
+	last := len(lines) - len(old)
+	if len(old) == 0 {
+		return min(max(expected, from), len(lines))
+	}
+	matches := func(at int) bool {
+		for i, line := range old {
+			if strings.TrimRight(lines[at+i], " \t\r") != strings.TrimRight(line, " \t\r") {
+				return false
+			}
+		}
+		return true
+	}
+	for distance := 0; expected-distance >= from || expected+distance <= last; distance++ {
+		if at := expected + distance; at >= from && at <= last && matches(at) {
+			return at
+		}
+		if at := expected - distance; distance > 0 && at >= from && at <= last && matches(at) {
+			return at
+		}
+	}
+	return -1
+}
+
+// UnifiedDiff returns the unified diff between two versions of the file at path, with
+// three lines of context.
+func UnifiedDiff(path, before, after string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(before),
+		B:        diffLines(after),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+	return diff
+}
+
+// diffLines splits text into lines that keep their line ends, as the diff expects.
+func diffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	content := "one\ntwo\nthree\nfour\nfive\nsix\n"
+	tests := []struct {
+		name    string
+		content string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Replace a line",
+			content: content,
+			patch:   "--- a/f.txt\n+++ b/f.txt\n@@ -2,3 +2,3 @@\n two\n-three\n+3\n four\n",
+			want:    "one\ntwo\n3\nfour\nfive\nsix\n",
+		},
+		{
+			name:    "Two hunks",
+			content: content,
+			patch:   "@@ -1,2 +1,3 @@\n one\n+one and a half\n two\n@@ -5,2 +6,1 @@\n five\n-six\n",
+			want:    "one\none and a half\ntwo\nthree\nfour\nfive\n",
+		},
+		{
+			name:    "Wrong line numbers",
+			content: content,
+			patch:   "@@ -1,2 +1,2 @@\n five\n-six\n+6\n",
+			want:    "one\ntwo\nthree\nfour\nfive\n6\n",
+		},
+		{
+			name:    "Insert into an empty file",
+			content: "",
+			patch:   "@@ -0,0 +1,2 @@\n+hello\n+world\n",
+			want:    "hello\nworld\n",
+		},
+		{
+			name:    "No trailing newline",
+			content: "a\nb",
+			patch:   "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			want:    "a\nc",
+		},
+		{
+			name:    "Context mismatch",
+			content: content,
+			patch:   "@@ -2,2 +2,2 @@\n two\n-seven\n+7\n",
+			wantErr: true,
+		},
+		{
+			name:    "No hunks",
+			content: content,
+			patch:   "just text",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch(tt.content, tt.patch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ApplyPatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := UnifiedDiff("f.txt", "same\n", "same\n"); diff != "" {
+		t.Errorf("UnifiedDiff() of equal contents = %q, want empty", diff)
+	}
+
+	diff := UnifiedDiff("f.txt", "one\ntwo\n", "one\n2\n")
+	for _, want := range []string{"--- a/f.txt", "+++ b/f.txt", "-two", "+2"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("UnifiedDiff() = %q, missing %q", diff, want)
+		}
+	}
+
+	// The diff of a patch applies back
+	got, err := ApplyPatch("one\ntwo\n", diff)
+	if err != nil || got != "one\n2\n" {
+		t.Errorf("ApplyPatch(UnifiedDiff()) = %q, %v, want %q", got, err, "one\n2\n")
+	}
+}
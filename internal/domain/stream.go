@@ -1,24 +1,44 @@
 package domain
 
-// StreamType distinguishes between partial text content and metadata events.
+import "github.com/danielmiessler/fabric/internal/chat"
+
+// StreamType distinguishes between partial text content, reasoning, citations, warnings and metadata events.
 type StreamType string
 
 const (
-	StreamTypeContent StreamType = "content"
-	StreamTypeUsage   StreamType = "usage"
-	StreamTypeError   StreamType = "error"
+	StreamTypeContent   StreamType = "content"
+	StreamTypeReasoning StreamType = "reasoning"
+	StreamTypeCitation  StreamType = "citation"
+	StreamTypeUsage     StreamType = "usage"
+	StreamTypeError     StreamType = "error"
+	StreamTypeWarning   StreamType = "warning"
 )
 
 // StreamUpdate is the unified payload sent through the internal channels.
 type StreamUpdate struct {
-	Type    StreamType     `json:"type"`
-	Content string         `json:"content,omitempty"` // For text deltas
-	Usage   *UsageMetadata `json:"usage,omitempty"`   // For token counts
+	Type      StreamType      `json:"type"`
+	Content   string          `json:"content,omitempty"`   // For text and reasoning deltas
+	Usage     *UsageMetadata  `json:"usage,omitempty"`     // For token counts
+	Citations []chat.Citation `json:"citations,omitempty"` // For sources cited by the answer
 }
 
 // UsageMetadata normalizes token counts across different providers.
+// Cache token counts are only reported by providers that support prompt caching.
 type UsageMetadata struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
-	TotalTokens  int `json:"total_tokens"`
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// SendStreamParts sends the non-empty reasoning and content parts to channel,
+// reasoning first. It pairs with ThinkTagSplitter for vendors that parse think tags.
+func SendStreamParts(channel chan StreamUpdate, reasoning, content string) {
+	if reasoning != "" {
+		channel <- StreamUpdate{Type: StreamTypeReasoning, Content: reasoning}
+	}
+	if content != "" {
+		channel <- StreamUpdate{Type: StreamTypeContent, Content: content}
+	}
 }
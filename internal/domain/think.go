@@ -2,7 +2,9 @@ package domain
 
 import (
 	"regexp"
+	"strings"
 	"sync"
+	"unicode"
 )
 
 // StripThinkBlocks removes any content between the provided start and end tags
@@ -30,3 +32,126 @@ func StripThinkBlocks(input, startTag, endTag string) string {
 
 	return re.ReplaceAllString(input, "")
 }
+
+// Default tags used by models that emit their reasoning inline.
+const (
+	DefaultThinkStartTag = "<think>"
+	DefaultThinkEndTag   = "</think>"
+)
+
+// ThinkTagSplitter separates reasoning enclosed in think tags from regular
+// content in a stream of text chunks. Tags may be split across chunks; text
+// that could be the beginning of a tag is held back until the next chunk.
+type ThinkTagSplitter struct {
+	startTag string
+	endTag   string
+	inThink  bool
+	// trimLeading drops whitespace following an end tag, like StripThinkBlocks.
+	trimLeading bool
+	pending     string
+}
+
+// NewThinkTagSplitter creates a splitter for the given tags, falling back to
+// <think> and </think> when a tag is empty.
+func NewThinkTagSplitter(startTag, endTag string) *ThinkTagSplitter {
+	if startTag == "" {
+		startTag = DefaultThinkStartTag
+	}
+	if endTag == "" {
+		endTag = DefaultThinkEndTag
+	}
+	return &ThinkTagSplitter{startTag: startTag, endTag: endTag}
+}
+
+// Write consumes a chunk and returns the reasoning and content it completes.
+func (o *ThinkTagSplitter) Write(chunk string) (reasoning, content string) {
+	var reasoningBuilder, contentBuilder strings.Builder
+	buf := o.pending + chunk
+	o.pending = ""
+
+	for buf != "" {
+		tag := o.startTag
+		if o.inThink {
+			tag = o.endTag
+		}
+
+		if idx := strings.Index(buf, tag); idx >= 0 {
+			o.emit(buf[:idx], &reasoningBuilder, &contentBuilder)
+			buf = buf[idx+len(tag):]
+			o.inThink = !o.inThink
+			o.trimLeading = !o.inThink
+			continue
+		}
+
+		// Hold back a suffix that may be the start of the tag
+		keep := partialSuffixLen(buf, tag)
+		o.emit(buf[:len(buf)-keep], &reasoningBuilder, &contentBuilder)
+		o.pending = buf[len(buf)-keep:]
+		break
+	}
+	return reasoningBuilder.String(), contentBuilder.String()
+}
+
+// Flush returns any text held back while waiting for a possible tag.
+func (o *ThinkTagSplitter) Flush() (reasoning, content string) {
+	var reasoningBuilder, contentBuilder strings.Builder
+	o.emit(o.pending, &reasoningBuilder, &contentBuilder)
+	o.pending = ""
+	return reasoningBuilder.String(), contentBuilder.String()
+}
+
+func (o *ThinkTagSplitter) emit(text string, reasoning, content *strings.Builder) {
+	if o.inThink {
+		reasoning.WriteString(text)
+		return
+	}
+	if o.trimLeading {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		if text == "" {
+			return
+		}
+		o.trimLeading = false
+	}
+	content.WriteString(text)
+}
+
+// partialSuffixLen returns the length of the longest suffix of text that is a proper prefix of tag.
+func partialSuffixLen(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// SplitThinkBlocks separates the text enclosed in think tags from the rest of
+// the input. Reasoning from several blocks is concatenated. Like StripThinkBlocks,
+// only complete blocks are split: a start tag without an end tag, as in an answer
+// that mentions the tag, is left in the content.
+func SplitThinkBlocks(input, startTag, endTag string) (reasoning, content string) {
+	if startTag == "" {
+		startTag = DefaultThinkStartTag
+	}
+	if endTag == "" {
+		endTag = DefaultThinkEndTag
+	}
+
+	var reasoningBuilder, contentBuilder strings.Builder
+	for {
+		start := strings.Index(input, startTag)
+		if start < 0 {
+			break
+		}
+		inner := input[start+len(startTag):]
+		end := strings.Index(inner, endTag)
+		if end < 0 {
+			break
+		}
+		contentBuilder.WriteString(input[:start])
+		reasoningBuilder.WriteString(inner[:end])
+		input = strings.TrimLeftFunc(inner[end+len(endTag):], unicode.IsSpace)
+	}
+	contentBuilder.WriteString(input)
+	return reasoningBuilder.String(), contentBuilder.String()
+}
@@ -17,3 +17,48 @@ func TestStripThinkBlocksCustomTags(t *testing.T) {
 		t.Errorf("expected %q, got %q", "visible", got)
 	}
 }
+
+func TestSplitThinkBlocks(t *testing.T) {
+	reasoning, content := SplitThinkBlocks("<think>internal</think>\n\nresult", "", "")
+	if reasoning != "internal" || content != "result" {
+		t.Errorf("got reasoning %q content %q", reasoning, content)
+	}
+
+	reasoning, content = SplitThinkBlocks("no tags here", "<think>", "</think>")
+	if reasoning != "" || content != "no tags here" {
+		t.Errorf("got reasoning %q content %q", reasoning, content)
+	}
+
+	input := "Models like this one emit <think> before their reasoning."
+	reasoning, content = SplitThinkBlocks(input, "", "")
+	if reasoning != "" || content != input {
+		t.Errorf("unclosed tag: got reasoning %q content %q", reasoning, content)
+	}
+
+	reasoning, content = SplitThinkBlocks("<think>a</think> one <think>b</think>\ntwo, then <think> alone", "", "")
+	if reasoning != "ab" || content != "one two, then <think> alone" {
+		t.Errorf("several blocks: got reasoning %q content %q", reasoning, content)
+	}
+}
+
+func TestThinkTagSplitterChunkBoundaries(t *testing.T) {
+	chunks := []string{"<thi", "nk>step ", "one</th", "ink>", "\n", "answer <", "b>"}
+	splitter := NewThinkTagSplitter("<think>", "</think>")
+
+	var reasoning, content string
+	for _, chunk := range chunks {
+		r, c := splitter.Write(chunk)
+		reasoning += r
+		content += c
+	}
+	r, c := splitter.Flush()
+	reasoning += r
+	content += c
+
+	if reasoning != "step one" {
+		t.Errorf("expected reasoning %q, got %q", "step one", reasoning)
+	}
+	if content != "answer <b>" {
+		t.Errorf("expected content %q, got %q", "answer <b>", content)
+	}
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// Transcript output formats accepted by --transcribe-format.
+const (
+	TranscriptFormatText = "text"
+	TranscriptFormatSRT  = "srt"
+	TranscriptFormatVTT  = "vtt"
+	TranscriptFormatJSON = "json"
+)
+
+// TranscriptFormats lists the transcript output formats.
+var TranscriptFormats = []string{TranscriptFormatText, TranscriptFormatSRT, TranscriptFormatVTT, TranscriptFormatJSON}
+
+// TranscriptionOptions controls how an audio file is transcribed.
+type TranscriptionOptions struct {
+	Model string
+	// Split splits files over the upload limit into parts with ffmpeg
+	Split bool
+	// Timestamps asks for timed segments from models that provide them
+	Timestamps bool
+}
+
+// TranscriptSegment is a timed part of a transcript. Start and End are seconds from the
+// beginning of the audio; Speaker is set by models that tell speakers apart.
+type TranscriptSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
+}
+
+// Transcript is the result of transcribing an audio file. Segments are only present
+// when the model returned timestamps.
+type Transcript struct {
+	Text     string              `json:"text"`
+	Language string              `json:"language,omitempty"`
+	Duration float64             `json:"duration,omitempty"`
+	Segments []TranscriptSegment `json:"segments,omitempty"`
+}
+
+// Format renders the transcript as text, SRT or WebVTT subtitles, or JSON. Text
+// transcripts with speakers are written as one paragraph per speaker turn.
+func (t *Transcript) Format(format string) (ret string, err error) {
+	switch format {
+	case TranscriptFormatText, "":
+		ret = t.speakerText()
+	case TranscriptFormatJSON:
+		var data []byte
+		if data, err = json.MarshalIndent(t, "", "  "); err != nil {
+			return
+		}
+		ret = string(data)
+	case TranscriptFormatSRT, TranscriptFormatVTT:
+		if len(t.Segments) == 0 {
+			err = errors.New(i18n.T("transcript_no_timestamps"))
+			return
+		}
+		ret = t.subtitles(format)
+	default:
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("transcribe_format_not_supported"), format, strings.Join(TranscriptFormats, ", ")))
+	}
+	return
+}
+
+// speakerText returns the text with a "speaker: " label at every change of speaker, or
+// the plain text when no speakers are known.
+func (t *Transcript) speakerText() string {
+	var builder strings.Builder
+	speaker := ""
+	for _, segment := range t.Segments {
+		text := strings.TrimSpace(segment.Text)
+		if segment.Speaker == "" || text == "" {
+			continue
+		}
+		if segment.Speaker != speaker {
+			if builder.Len() > 0 {
+				builder.WriteString("\n\n")
+			}
+			speaker = segment.Speaker
+			builder.WriteString(speaker + ": ")
+		} else {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(text)
+	}
+	if builder.Len() == 0 {
+		return t.Text
+	}
+	return builder.String()
+}
+
+// subtitles renders the segments as numbered SRT cues or as a WebVTT file. Speakers are
+// written as a label in SRT and as a voice tag in WebVTT.
+func (t *Transcript) subtitles(format string) string {
+	var builder strings.Builder
+	if format == TranscriptFormatVTT {
+		builder.WriteString("WEBVTT\n\n")
+	}
+	cue := 0
+	for _, segment := range t.Segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		cue++
+		if format == TranscriptFormatSRT {
+			if segment.Speaker != "" {
+				text = segment.Speaker + ": " + text
+			}
+			fmt.Fprintf(&builder, "%d\n%s --> %s\n%s\n\n", cue, subtitleTime(segment.Start, ","), subtitleTime(segment.End, ","), text)
+			continue
+		}
+		if segment.Speaker != "" {
+			text = "<v " + segment.Speaker + ">" + text
+		}
+		fmt.Fprintf(&builder, "%s --> %s\n%s\n\n", subtitleTime(segment.Start, "."), subtitleTime(segment.End, "."), text)
+	}
+	return builder.String()
+}
+
+// subtitleTime formats seconds as hh:mm:ss followed by the separator and milliseconds.
+func subtitleTime(seconds float64, separator string) string {
+	millis := int64(math.Round(max(seconds, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", millis/3600000, millis/60000%60, millis/1000%60, separator, millis%1000)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTranscriptFormatSubtitles(t *testing.T) {
+	transcript := &Transcript{Text: "Hello there. Hi.", Segments: []TranscriptSegment{
+		{Start: 0, End: 2.5, Text: " Hello there.", Speaker: "A"},
+		{Start: 3661.25, End: 3662, Text: "Hi."},
+	}}
+
+	srt, err := transcript.Format(TranscriptFormatSRT)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:02,500\nA: Hello there.\n\n2\n01:01:01,250 --> 01:01:02,000\nHi.\n\n"
+	if srt != want {
+		t.Errorf("Expected SRT %q, got %q", want, srt)
+	}
+
+	vtt, err := transcript.Format(TranscriptFormatVTT)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want = "WEBVTT\n\n00:00:00.000 --> 00:00:02.500\n<v A>Hello there.\n\n01:01:01.250 --> 01:01:02.000\nHi.\n\n"
+	if vtt != want {
+		t.Errorf("Expected WebVTT %q, got %q", want, vtt)
+	}
+}
+
+func TestTranscriptFormatText(t *testing.T) {
+	plain := &Transcript{Text: "Just text."}
+	if text, _ := plain.Format(TranscriptFormatText); text != "Just text." {
+		t.Errorf("Expected the plain text, got %q", text)
+	}
+	if _, err := plain.Format(TranscriptFormatSRT); err == nil {
+		t.Error("Expected an error for subtitles without timestamps")
+	}
+	if _, err := plain.Format("docx"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+
+	diarized := &Transcript{Text: "Hi. How are you? Fine.", Segments: []TranscriptSegment{
+		{Text: "Hi.", Speaker: "A"}, {Text: "How are you?", Speaker: "A"}, {Text: "Fine.", Speaker: "B"},
+	}}
+	if text, _ := diarized.Format(TranscriptFormatText); text != "A: Hi. How are you?\n\nB: Fine." {
+		t.Errorf("Expected one paragraph per speaker turn, got %q", text)
+	}
+}
+
+func TestTranscriptFormatJSON(t *testing.T) {
+	transcript := &Transcript{Text: "Hi.", Duration: 1.5, Segments: []TranscriptSegment{{Start: 0, End: 1.5, Text: "Hi."}}}
+	out, err := transcript.Format(TranscriptFormatJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded Transcript
+	if err = json.Unmarshal([]byte(out), &decoded); err != nil || len(decoded.Segments) != 1 || decoded.Duration != 1.5 {
+		t.Errorf("Expected the transcript to round-trip, got %+v (%v)", decoded, err)
+	}
+}
@@ -1,6 +1,8 @@
 package i18n
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	gi18n "github.com/nicksnyder/go-i18n/v2/i18n"
@@ -38,3 +40,33 @@ func TestTranslation(t *testing.T) {
 		})
 	}
 }
+
+// Messages are templates, so literal braces in a message must be escaped
+func TestAllMessagesLocalize(t *testing.T) {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		lang := strings.TrimSuffix(file.Name(), ".json")
+		t.Run(lang, func(t *testing.T) {
+			data, err := localeFS.ReadFile("locales/" + file.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var messages map[string]string
+			if err = json.Unmarshal(data, &messages); err != nil {
+				t.Fatal(err)
+			}
+			loc, err := Init(lang)
+			if err != nil {
+				t.Fatalf("init failed for %s: %v", lang, err)
+			}
+			for id := range messages {
+				if _, err := loc.Localize(&gi18n.LocalizeConfig{MessageID: id}); err != nil {
+					t.Errorf("localize %s failed for %s: %v", id, lang, err)
+				}
+			}
+		})
+	}
+}
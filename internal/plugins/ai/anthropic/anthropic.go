@@ -17,32 +17,22 @@ import (
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 )
 
 const defaultBaseUrl = "https://api.anthropic.com/"
 
 const webSearchToolName = "web_search"
 const webSearchToolType = "web_search_20250305"
-const sourcesHeader = "## Sources"
+const vendorName = "Anthropic"
 
-// These models reject non-default sampling parameters.
+// modelDisallowsSamplingParams reports models that reject non-default sampling parameters.
 // Omit these params entirely for safest compatibility.
-var samplingParamsDisallowedPrefixes = []string{
-	"claude-opus-4-7",
-	"claude-opus-4-8",
-	"claude-opus-5",
-	"claude-sonnet-5",
-	"claude-fable-5",
-}
-
 func modelDisallowsSamplingParams(model string) bool {
-	return slices.ContainsFunc(samplingParamsDisallowedPrefixes, func(prefix string) bool {
-		return strings.HasPrefix(model, prefix)
-	})
+	return ai.LookupCapabilities(vendorName, model).NoSamplingParams.IsSupported()
 }
 
 func NewClient() (ret *Client) {
-	vendorName := "Anthropic"
 	ret = &Client{}
 
 	ret.PluginBase = plugins.NewVendorPluginBase(vendorName, ret.configure)
@@ -173,7 +163,7 @@ func parseThinking(level domain.ThinkingLevel) (anthropic.ThinkingConfigParamUni
 func (an *Client) SendStream(
 	ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions, channel chan domain.StreamUpdate,
 ) (err error) {
-	messages := an.toMessages(msgs)
+	messages := an.prepareMessages(msgs, opts)
 	if len(messages) == 0 {
 		close(channel)
 		// No messages to send after normalization, consider this a non-error condition for streaming.
@@ -192,34 +182,71 @@ func (an *Client) SendStream(
 		stream = an.client.Messages.NewStreaming(ctx, params)
 	}
 
+	var blockText strings.Builder
+	var blockCitations []chat.Citation
 	for stream.Next() {
 		event := stream.Current()
 
+		// Handle extended thinking
+		if event.Delta.Thinking != "" {
+			channel <- domain.StreamUpdate{
+				Type:    domain.StreamTypeReasoning,
+				Content: event.Delta.Thinking,
+			}
+		}
+
 		// Handle Content
 		if event.Delta.Text != "" {
+			blockText.WriteString(event.Delta.Text)
 			channel <- domain.StreamUpdate{
 				Type:    domain.StreamTypeContent,
 				Content: event.Delta.Text,
 			}
 		}
 
+		// Citations arrive before the text they support, so send them when their block ends
+		if event.Delta.Type == "citations_delta" && event.Delta.Citation.Type == "web_search_result_location" {
+			blockCitations = append(blockCitations, chat.Citation{
+				URL:     event.Delta.Citation.URL,
+				Title:   event.Delta.Citation.Title,
+				Snippet: event.Delta.Citation.CitedText,
+			})
+		}
+		if event.Type == "content_block_stop" {
+			if len(blockCitations) > 0 {
+				for i := range blockCitations {
+					blockCitations[i].Span = blockText.String()
+				}
+				channel <- domain.StreamUpdate{
+					Type:      domain.StreamTypeCitation,
+					Citations: blockCitations,
+				}
+			}
+			blockText.Reset()
+			blockCitations = nil
+		}
+
 		// Handle Usage
 		if event.Message.Usage.InputTokens != 0 || event.Message.Usage.OutputTokens != 0 {
 			channel <- domain.StreamUpdate{
 				Type: domain.StreamTypeUsage,
 				Usage: &domain.UsageMetadata{
-					InputTokens:  int(event.Message.Usage.InputTokens),
-					OutputTokens: int(event.Message.Usage.OutputTokens),
-					TotalTokens:  int(event.Message.Usage.InputTokens + event.Message.Usage.OutputTokens),
+					InputTokens:      int(event.Message.Usage.InputTokens),
+					OutputTokens:     int(event.Message.Usage.OutputTokens),
+					TotalTokens:      int(event.Message.Usage.InputTokens + event.Message.Usage.OutputTokens),
+					CacheReadTokens:  int(event.Message.Usage.CacheReadInputTokens),
+					CacheWriteTokens: int(event.Message.Usage.CacheCreationInputTokens),
 				},
 			}
 		} else if event.Usage.InputTokens != 0 || event.Usage.OutputTokens != 0 {
 			channel <- domain.StreamUpdate{
 				Type: domain.StreamTypeUsage,
 				Usage: &domain.UsageMetadata{
-					InputTokens:  int(event.Usage.InputTokens),
-					OutputTokens: int(event.Usage.OutputTokens),
-					TotalTokens:  int(event.Usage.InputTokens + event.Usage.OutputTokens),
+					InputTokens:      int(event.Usage.InputTokens),
+					OutputTokens:     int(event.Usage.OutputTokens),
+					TotalTokens:      int(event.Usage.InputTokens + event.Usage.OutputTokens),
+					CacheReadTokens:  int(event.Usage.CacheReadInputTokens),
+					CacheWriteTokens: int(event.Usage.CacheCreationInputTokens),
 				},
 			}
 		}
@@ -285,8 +312,15 @@ func (an *Client) buildMessageParams(msgs []anthropic.MessageParam, opts *domain
 
 func (an *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
 	ret string, err error) {
+	ret, _, err = an.SendWithCitations(ctx, msgs, opts)
+	return
+}
+
+// SendWithCitations sends the messages and returns the answer text and the web sources it cites.
+func (an *Client) SendWithCitations(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	ret string, citations []chat.Citation, err error) {
 
-	messages := an.toMessages(msgs)
+	messages := an.prepareMessages(msgs, opts)
 	if len(messages) == 0 {
 		// No messages to send after normalization, return empty string and no error.
 		return
@@ -310,44 +344,68 @@ func (an *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage,
 		}
 	}
 
-	var textParts []string
-	var citations []string
-	citationMap := make(map[string]bool) // To avoid duplicate citations
+	ret, citations = extractTextAndCitations(message)
+	return
+}
 
+// extractTextAndCitations joins the text blocks of message and collects the web search
+// results they cite. The span of a citation is the text block that carries it.
+func extractTextAndCitations(message *anthropic.Message) (ret string, citations []chat.Citation) {
+	var textParts []string
 	for _, block := range message.Content {
 		if block.Type == "text" && block.Text != "" {
 			textParts = append(textParts, block.Text)
 
-			// Extract citations from this text block
 			for _, citation := range block.Citations {
 				if citation.Type == "web_search_result_location" {
-					citationKey := citation.URL + "|" + citation.Title
-					if !citationMap[citationKey] {
-						citationMap[citationKey] = true
-						citationText := fmt.Sprintf("- [%s](%s)", citation.Title, citation.URL)
-						if citation.CitedText != "" {
-							citationText += fmt.Sprintf(" - \"%s\"", citation.CitedText)
-						}
-						citations = append(citations, citationText)
-					}
+					citations = domain.AppendCitations(citations, chat.Citation{
+						URL:     citation.URL,
+						Title:   citation.Title,
+						Snippet: citation.CitedText,
+						Span:    block.Text,
+					})
 				}
 			}
 		}
 	}
+	ret = strings.Join(textParts, "")
+	return
+}
 
-	var resultBuilder strings.Builder
-	resultBuilder.WriteString(strings.Join(textParts, ""))
+// prepareMessages converts chat messages and, unless disabled, adds prompt cache breakpoints.
+func (an *Client) prepareMessages(msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (ret []anthropic.MessageParam) {
+	ret = an.toMessages(msgs)
+	if !opts.NoPromptCache {
+		hasSystemContent := slices.ContainsFunc(msgs, func(msg *chat.ChatCompletionMessage) bool {
+			return msg.Role == chat.ChatMessageRoleSystem && msg.TextContent() != ""
+		})
+		addCacheBreakpoints(ret, hasSystemContent)
+	}
+	return
+}
 
-	// Append citations if any were found
-	if len(citations) > 0 {
-		resultBuilder.WriteString("\n\n")
-		resultBuilder.WriteString(sourcesHeader)
-		resultBuilder.WriteString("\n\n")
-		resultBuilder.WriteString(strings.Join(citations, "\n"))
+// addCacheBreakpoints marks the system prompt block (pattern, context and strategy) and the
+// end of the earlier session turns, so repeated calls reuse the cached prefix. Anthropic
+// silently skips caching for prefixes below the model's minimum cacheable length.
+func addCacheBreakpoints(messages []anthropic.MessageParam, hasSystemBlock bool) {
+	if len(messages) == 0 {
+		return
+	}
+	if hasSystemBlock && len(messages[0].Content) > 0 {
+		setCacheControl(messages[0].Content[0])
+	}
+	if len(messages) > 1 {
+		previousTurn := messages[len(messages)-2].Content
+		if len(previousTurn) > 0 {
+			setCacheControl(previousTurn[len(previousTurn)-1])
+		}
 	}
-	ret = resultBuilder.String()
+}
 
-	return
+func setCacheControl(block anthropic.ContentBlockParamUnion) {
+	if cacheControl := block.GetCacheControl(); cacheControl != nil {
+		*cacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
 }
 
 func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropic.MessageParam) {
@@ -372,7 +430,7 @@ func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropi
 		switch msg.Role {
 		case chat.ChatMessageRoleSystem:
 			// Accumulate system content. It will be prepended to the first user message.
-			systemText := messageTextFromParts(msg)
+			systemText := msg.TextContent()
 			if systemText == "" {
 				continue
 			}
@@ -426,23 +484,9 @@ func (an *Client) toMessages(msgs []*chat.ChatCompletionMessage) (ret []anthropi
 	return anthropicMessages
 }
 
-// messageTextFromParts extracts and concatenates all text content from a message,
-// combining both the Content field and any text parts in MultiContent.
-func messageTextFromParts(msg *chat.ChatCompletionMessage) string {
-	textParts := []string{}
-	if strings.TrimSpace(msg.Content) != "" {
-		textParts = append(textParts, msg.Content)
-	}
-	for _, part := range msg.MultiContent {
-		if part.Type == chat.ChatMessagePartTypeText && strings.TrimSpace(part.Text) != "" {
-			textParts = append(textParts, part.Text)
-		}
-	}
-	return strings.Join(textParts, "\n")
-}
-
 // contentBlocksFromMessage converts a chat message into Anthropic content blocks,
-// handling text content, image URLs (both data URLs and remote URLs), and PDF attachments.
+// handling text content, image URLs (both data URLs and remote URLs), and PDF attachments
+// given either as file parts or as PDF data URLs.
 func contentBlocksFromMessage(msg *chat.ChatCompletionMessage) []anthropic.ContentBlockParamUnion {
 	var blocks []anthropic.ContentBlockParamUnion
 	if strings.TrimSpace(msg.Content) != "" {
@@ -461,21 +505,31 @@ func contentBlocksFromMessage(msg *chat.ChatCompletionMessage) []anthropic.Conte
 			if block, ok := contentBlockFromAttachmentURL(part.ImageURL.URL); ok {
 				blocks = append(blocks, block)
 			}
+		case chat.ChatMessagePartTypeFile:
+			if block, ok := contentBlockFromFile(part.File); ok {
+				blocks = append(blocks, block)
+			}
 		}
 	}
 	return blocks
 }
 
-// prependSystemContentToBlocks prepends system content to content blocks. If the first
-// block is text, it merges the system content with it; otherwise, it prepends a new text block.
-func prependSystemContentToBlocks(systemContent string, blocks []anthropic.ContentBlockParamUnion) []anthropic.ContentBlockParamUnion {
-	if len(blocks) == 0 {
-		return []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(systemContent)}
+// contentBlockFromFile converts a PDF file part into a document block. Other documents
+// reach the vendor as extracted text, so they are not expected here.
+func contentBlockFromFile(file *chat.ChatMessageFile) (anthropic.ContentBlockParamUnion, bool) {
+	if file == nil || !strings.EqualFold(file.MimeType, "application/pdf") {
+		debuglog.Debug(debuglog.Basic, "contentBlockFromFile: unsupported file part")
+		return anthropic.ContentBlockParamUnion{}, false
 	}
-	if blocks[0].OfText != nil {
-		blocks[0].OfText.Text = systemContent + "\n\n" + blocks[0].OfText.Text
-		return blocks
+	if file.Data != "" {
+		return anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{Data: file.Data}), true
 	}
+	return anthropic.NewDocumentBlock(anthropic.URLPDFSourceParam{URL: file.URL}), true
+}
+
+// prependSystemContentToBlocks prepends system content to content blocks as its own text block,
+// keeping it byte-identical across calls so it can serve as a prompt cache breakpoint.
+func prependSystemContentToBlocks(systemContent string, blocks []anthropic.ContentBlockParamUnion) []anthropic.ContentBlockParamUnion {
 	return append([]anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(systemContent)}, blocks...)
 }
 
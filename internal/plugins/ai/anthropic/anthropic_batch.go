@@ -0,0 +1,97 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+// SubmitBatch creates a Message Batch with one request per input.
+func (an *Client) SubmitBatch(ctx context.Context, requests []ai.BatchRequest, opts *domain.ChatOptions) (batchID string, err error) {
+	batchRequests := make([]anthropic.MessageBatchNewParamsRequest, 0, len(requests))
+	for _, request := range requests {
+		params := an.buildMessageParams(an.prepareMessages(request.Messages, opts), opts)
+		batchRequests = append(batchRequests, anthropic.MessageBatchNewParamsRequest{
+			CustomID: request.ID,
+			Params: anthropic.MessageBatchNewParamsRequestParams{
+				Model:       params.Model,
+				MaxTokens:   params.MaxTokens,
+				Messages:    params.Messages,
+				Temperature: params.Temperature,
+				TopP:        params.TopP,
+				Tools:       params.Tools,
+				Thinking:    params.Thinking,
+			},
+		})
+	}
+
+	var batch *anthropic.MessageBatch
+	if batch, err = an.client.Messages.Batches.New(ctx, anthropic.MessageBatchNewParams{Requests: batchRequests}); err != nil {
+		return
+	}
+	batchID = batch.ID
+	return
+}
+
+// GetBatchStatus returns the processing status and request counts of a Message Batch.
+func (an *Client) GetBatchStatus(ctx context.Context, batchID string) (ret *ai.BatchStatus, err error) {
+	var batch *anthropic.MessageBatch
+	if batch, err = an.client.Messages.Batches.Get(ctx, batchID); err != nil {
+		return
+	}
+	ret = toBatchStatus(batch)
+	return
+}
+
+// FetchBatchResults streams the results of an ended Message Batch.
+func (an *Client) FetchBatchResults(ctx context.Context, batchID string) (ret []ai.BatchResult, err error) {
+	var batch *anthropic.MessageBatch
+	if batch, err = an.client.Messages.Batches.Get(ctx, batchID); err != nil {
+		return
+	}
+	if !toBatchStatus(batch).Done {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_not_finished"), batchID, batch.ProcessingStatus))
+		return
+	}
+
+	stream := an.client.Messages.Batches.ResultsStreaming(ctx, batchID)
+	defer stream.Close()
+	for stream.Next() {
+		response := stream.Current()
+		result := ai.BatchResult{ID: response.CustomID}
+		switch response.Result.Type {
+		case "succeeded":
+			var textParts []string
+			for _, block := range response.Result.Message.Content {
+				if block.Type == "text" && block.Text != "" {
+					textParts = append(textParts, block.Text)
+				}
+			}
+			result.Content = strings.Join(textParts, "")
+		case "errored":
+			result.Error = response.Result.Error.Error.Message
+		default:
+			result.Error = response.Result.Type
+		}
+		ret = append(ret, result)
+	}
+	err = stream.Err()
+	return
+}
+
+func toBatchStatus(batch *anthropic.MessageBatch) *ai.BatchStatus {
+	counts := batch.RequestCounts
+	return &ai.BatchStatus{
+		ID:        batch.ID,
+		Status:    string(batch.ProcessingStatus),
+		Total:     counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+		Completed: counts.Succeeded,
+		Failed:    counts.Errored + counts.Canceled + counts.Expired,
+		Done:      batch.ProcessingStatus == anthropic.MessageBatchProcessingStatusEnded,
+	}
+}
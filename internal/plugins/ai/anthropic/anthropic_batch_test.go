@@ -0,0 +1,91 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+)
+
+const testMessageBatch = `{"id":"msgbatch_1","type":"message_batch","processing_status":"%s","created_at":"2026-01-01T00:00:00Z","expires_at":"2026-01-02T00:00:00Z",
+"request_counts":{"processing":%d,"succeeded":%d,"errored":%d,"canceled":0,"expired":0}}`
+
+func TestBatchSubmitStatusAndFetch(t *testing.T) {
+	var submitted struct {
+		Requests []struct {
+			CustomID string         `json:"custom_id"`
+			Params   map[string]any `json:"params"`
+		} `json:"requests"`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&submitted); err != nil {
+			t.Errorf("failed to decode batch request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, testMessageBatch, "in_progress", 2, 0, 0)
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, testMessageBatch, "ended", 0, 1, 1)
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1/results", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-jsonl")
+		_, _ = io.WriteString(w, `{"custom_id":"b","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"too long"}}}}
+{"custom_id":"a","result":{"type":"succeeded","message":{"id":"m1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"first"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}}}
+`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	if err := client.configure(); err != nil {
+		t.Fatalf("configure() error = %v", err)
+	}
+
+	requests := []ai.BatchRequest{
+		{ID: "a", Messages: []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "one"}}},
+		{ID: "b", Messages: []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "two"}}},
+	}
+	opts := &domain.ChatOptions{Model: "claude-sonnet-4-5", Temperature: 0.3, TopP: domain.DefaultTopP}
+	batchID, err := client.SubmitBatch(context.Background(), requests, opts)
+	if err != nil {
+		t.Fatalf("SubmitBatch() error = %v", err)
+	}
+	if batchID != "msgbatch_1" {
+		t.Errorf("batchID = %q, want msgbatch_1", batchID)
+	}
+	if len(submitted.Requests) != 2 || submitted.Requests[1].CustomID != "b" {
+		t.Fatalf("unexpected submitted requests %+v", submitted.Requests)
+	}
+	if params := submitted.Requests[0].Params; params["model"] != "claude-sonnet-4-5" || params["temperature"] != 0.3 {
+		t.Errorf("unexpected request params %+v", params)
+	}
+
+	status, err := client.GetBatchStatus(context.Background(), batchID)
+	if err != nil {
+		t.Fatalf("GetBatchStatus() error = %v", err)
+	}
+	if !status.Done || status.Total != 2 || status.Completed != 1 || status.Failed != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	results, err := client.FetchBatchResults(context.Background(), batchID)
+	if err != nil {
+		t.Fatalf("FetchBatchResults() error = %v", err)
+	}
+	want := []ai.BatchResult{{ID: "b", Error: "too long"}, {ID: "a", Content: "first"}}
+	if len(results) != len(want) || results[0] != want[0] || results[1] != want[1] {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+}
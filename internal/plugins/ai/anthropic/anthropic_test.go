@@ -2,6 +2,10 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -276,59 +280,29 @@ func TestCitationFormatting(t *testing.T) {
 		},
 	}
 
-	// Extract text and citations using the same logic as the Send method
-	var textParts []string
-	var citations []string
-	citationMap := make(map[string]bool)
-
-	for _, block := range message.Content {
-		if block.Type == "text" && block.Text != "" {
-			textParts = append(textParts, block.Text)
-
-			for _, citation := range block.Citations {
-				if citation.Type == "web_search_result_location" {
-					citationKey := citation.URL + "|" + citation.Title
-					if !citationMap[citationKey] {
-						citationMap[citationKey] = true
-						citationText := "- [" + citation.Title + "](" + citation.URL + ")"
-						if citation.CitedText != "" {
-							citationText += " - \"" + citation.CitedText + "\""
-						}
-						citations = append(citations, citationText)
-					}
-				}
-			}
-		}
-	}
+	result, citations := extractTextAndCitations(message)
 
-	result := strings.Join(textParts, "")
-	if len(citations) > 0 {
-		result += "\n\n## Sources\n\n" + strings.Join(citations, "\n")
-	}
-
-	// Verify the result contains the expected text
 	expectedText := "Based on recent research, artificial intelligence is advancing rapidly. Machine learning models are becoming more sophisticated."
-	if !strings.Contains(result, expectedText) {
-		t.Errorf("Expected result to contain text: %s", expectedText)
+	if result != expectedText {
+		t.Errorf("Expected text %q, got %q", expectedText, result)
 	}
 
-	// Verify citations are included
-	if !strings.Contains(result, "## Sources") {
-		t.Error("Expected result to contain Sources section")
+	// Sources are returned separately, one per cited span
+	if len(citations) != 3 {
+		t.Fatalf("Expected 3 citations, got %+v", citations)
 	}
-
-	if !strings.Contains(result, "[AI Research Advances 2025](https://example.com/ai-research)") {
-		t.Error("Expected result to contain first citation")
+	first := citations[0]
+	if first.URL != "https://example.com/ai-research" || first.Title != "AI Research Advances 2025" ||
+		first.Snippet != "artificial intelligence is advancing rapidly" || first.Span != message.Content[0].Text {
+		t.Errorf("Unexpected first citation %+v", first)
 	}
-
-	if !strings.Contains(result, "[Technology News Today](https://another-source.com/tech-news)") {
-		t.Error("Expected result to contain second citation")
+	if citations[2].Span != message.Content[1].Text {
+		t.Errorf("Expected third citation to cite the second block, got %+v", citations[2])
 	}
 
-	// Verify deduplication - should only have 2 unique citations, not 3
-	citationCount := strings.Count(result, "- [")
-	if citationCount != 2 {
-		t.Errorf("Expected 2 unique citations, got %d", citationCount)
+	// Footnotes list each source once
+	if footnotes := domain.FormatCitationFootnotes(citations); strings.Count(footnotes, "[^") != 2 {
+		t.Errorf("Expected 2 footnotes, got %q", footnotes)
 	}
 }
 
@@ -424,3 +398,120 @@ func TestToMessages_MultiContentPDFAttachment(t *testing.T) {
 		t.Fatalf("Expected document data to match base64 payload, got %s", document.Source.OfBase64.Data)
 	}
 }
+
+func TestToMessages_FilePartPDF(t *testing.T) {
+	client := NewClient()
+	msg := &chat.ChatCompletionMessage{
+		Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{Filename: "a.pdf", MimeType: "application/pdf", Data: "SGVsbG8="}},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{Filename: "b.pdf", MimeType: "application/pdf", URL: "https://example.com/b.pdf"}},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{Filename: "c.docx", MimeType: "application/zip", Data: "UEs="}},
+		},
+	}
+
+	messages := client.toMessages([]*chat.ChatCompletionMessage{msg})
+	if len(messages) != 1 || len(messages[0].Content) != 2 {
+		t.Fatalf("Expected 2 document blocks, got %#v", messages)
+	}
+	if document := messages[0].Content[0].OfDocument; document == nil || document.Source.OfBase64 == nil {
+		t.Errorf("Expected a base64 document, got %#v", messages[0].Content[0])
+	}
+	if document := messages[0].Content[1].OfDocument; document == nil || document.Source.OfURL == nil {
+		t.Errorf("Expected a URL document, got %#v", messages[0].Content[1])
+	}
+}
+
+func TestPrepareMessages_AddsCacheBreakpoints(t *testing.T) {
+	client := NewClient()
+	msgs := []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleSystem, Content: "You are a pattern."},
+		{Role: chat.ChatMessageRoleUser, Content: "First question"},
+		{Role: chat.ChatMessageRoleAssistant, Content: "First answer"},
+		{Role: chat.ChatMessageRoleUser, Content: "Second question"},
+	}
+
+	messages := client.prepareMessages(msgs, &domain.ChatOptions{})
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+
+	system := messages[0].Content[0].OfText
+	if system == nil || system.Text != "You are a pattern." {
+		t.Fatalf("Expected system content as its own first block, got %#v", messages[0].Content[0])
+	}
+	if system.CacheControl.Type != "ephemeral" {
+		t.Error("Expected cache breakpoint on the system block")
+	}
+	if messages[0].Content[1].OfText.CacheControl.Type != "" {
+		t.Error("Expected no cache breakpoint on the first user input")
+	}
+	if messages[1].Content[0].OfText.CacheControl.Type != "ephemeral" {
+		t.Error("Expected cache breakpoint at the end of the earlier session turns")
+	}
+	if messages[2].Content[0].OfText.CacheControl.Type != "" {
+		t.Error("Expected no cache breakpoint on the current user message")
+	}
+}
+
+func TestPrepareMessages_NoPromptCache(t *testing.T) {
+	client := NewClient()
+	msgs := []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleSystem, Content: "You are a pattern."},
+		{Role: chat.ChatMessageRoleUser, Content: "Question"},
+	}
+
+	messages := client.prepareMessages(msgs, &domain.ChatOptions{NoPromptCache: true})
+	for _, block := range messages[0].Content {
+		if block.OfText.CacheControl.Type != "" {
+			t.Fatalf("Expected no cache breakpoints when caching is disabled, got %#v", block)
+		}
+	}
+}
+
+func TestSendStream_EmitsCitationsAfterBlock(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"m1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":1,"output_tokens":0}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","url":"https://example.com","title":"Example","cited_text":"quoted","encrypted_index":"x"}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"cited "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"claim"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_stop"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var head struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(event), &head)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", head.Type, event)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	if err := client.configure(); err != nil {
+		t.Fatalf("configure() error = %v", err)
+	}
+
+	channel := make(chan domain.StreamUpdate, 16)
+	msgs := []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "hi"}}
+	if err := client.SendStream(context.Background(), msgs, &domain.ChatOptions{Model: "claude-sonnet-4-5"}, channel); err != nil {
+		t.Fatalf("SendStream() error = %v", err)
+	}
+
+	var citations []chat.Citation
+	for update := range channel {
+		if update.Type == domain.StreamTypeCitation {
+			citations = append(citations, update.Citations...)
+		}
+	}
+	want := chat.Citation{URL: "https://example.com", Title: "Example", Snippet: "quoted", Span: "cited claim"}
+	if len(citations) != 1 || citations[0] != want {
+		t.Errorf("citations = %+v, want [%+v]", citations, want)
+	}
+}
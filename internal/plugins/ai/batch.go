@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+)
+
+// BatchVendor is implemented by vendors that can run many requests asynchronously
+// through a provider batch API, usually at a discount and outside the regular rate limits.
+type BatchVendor interface {
+	SubmitBatch(ctx context.Context, requests []BatchRequest, opts *domain.ChatOptions) (batchID string, err error)
+	GetBatchStatus(ctx context.Context, batchID string) (*BatchStatus, error)
+	FetchBatchResults(ctx context.Context, batchID string) ([]BatchResult, error)
+}
+
+// BatchRequest is a single request of a batch, keyed by the caller's input ID.
+type BatchRequest struct {
+	ID       string
+	Messages []*chat.ChatCompletionMessage
+}
+
+// BatchStatus reports the progress of a submitted batch.
+type BatchStatus struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Failed    int64  `json:"failed"`
+	// Done is true once the provider has finished processing and results can be fetched.
+	Done bool `json:"done"`
+}
+
+// BatchResult is the outcome of a single batch request.
+type BatchResult struct {
+	ID      string `json:"id"`
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
@@ -618,7 +618,7 @@ func (c *BedrockClient) toMessages(inputMessages []*chat.ChatCompletionMessage)
 
 		message := types.Message{
 			Role:    role,
-			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: msg.Content}},
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: msg.TextContent()}},
 		}
 		messages = append(messages, message)
 
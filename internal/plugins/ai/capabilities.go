@@ -0,0 +1,439 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// Support is a tri-state capability flag. The zero value means the capability
+// is unknown, which keeps validation permissive for models nobody described.
+type Support int8
+
+const (
+	SupportUnknown Support = iota
+	SupportYes
+	SupportNo
+)
+
+// IsSupported reports whether the capability is known to be available.
+func (s Support) IsSupported() bool {
+	return s == SupportYes
+}
+
+// IsUnsupported reports whether the capability is known to be unavailable.
+func (s Support) IsUnsupported() bool {
+	return s == SupportNo
+}
+
+// UnmarshalYAML accepts plain booleans in override files.
+func (s *Support) UnmarshalYAML(value *yaml.Node) (err error) {
+	var supported bool
+	if err = value.Decode(&supported); err != nil {
+		return
+	}
+	if supported {
+		*s = SupportYes
+	} else {
+		*s = SupportNo
+	}
+	return
+}
+
+// MarshalYAML writes known values as booleans and unknown values as null.
+func (s Support) MarshalYAML() (any, error) {
+	switch s {
+	case SupportYes:
+		return true, nil
+	case SupportNo:
+		return false, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ModelCapabilities describes what a model can do. Zero values mean "unknown".
+type ModelCapabilities struct {
+	ContextWindow   int64   `yaml:"context_window,omitempty"`
+	MaxOutputTokens int64   `yaml:"max_output_tokens,omitempty"`
+	Vision          Support `yaml:"vision,omitempty"`
+	AudioInput      Support `yaml:"audio_input,omitempty"`
+	// DocumentInput marks models that read PDF attachments natively; for other
+	// models the text of documents is extracted locally.
+	DocumentInput     Support `yaml:"document_input,omitempty"`
+	AudioOutput       Support `yaml:"audio_output,omitempty"`
+	ImageGeneration   Support `yaml:"image_generation,omitempty"`
+	Tools             Support `yaml:"tools,omitempty"`
+	Thinking          Support `yaml:"thinking,omitempty"`
+	ThinkingBudgetMin int64   `yaml:"thinking_budget_min,omitempty"`
+	ThinkingBudgetMax int64   `yaml:"thinking_budget_max,omitempty"`
+	WebSearch         Support `yaml:"web_search,omitempty"`
+	// NoSamplingParams marks models that reject temperature/top_p.
+	NoSamplingParams Support `yaml:"no_sampling_params,omitempty"`
+	// RawMode marks models that must be called without system role or chat options.
+	RawMode Support `yaml:"raw_mode,omitempty"`
+	// InputPrice and OutputPrice are list prices in USD per million tokens.
+	InputPrice  float64 `yaml:"input_price,omitempty"`
+	OutputPrice float64 `yaml:"output_price,omitempty"`
+}
+
+// merge overlays every known value of other onto o.
+func (o *ModelCapabilities) merge(other ModelCapabilities) {
+	mergeInt := func(dst *int64, src int64) {
+		if src != 0 {
+			*dst = src
+		}
+	}
+	mergeSupport := func(dst *Support, src Support) {
+		if src != SupportUnknown {
+			*dst = src
+		}
+	}
+	mergeInt(&o.ContextWindow, other.ContextWindow)
+	mergeInt(&o.MaxOutputTokens, other.MaxOutputTokens)
+	mergeSupport(&o.Vision, other.Vision)
+	mergeSupport(&o.AudioInput, other.AudioInput)
+	mergeSupport(&o.DocumentInput, other.DocumentInput)
+	mergeSupport(&o.AudioOutput, other.AudioOutput)
+	mergeSupport(&o.ImageGeneration, other.ImageGeneration)
+	mergeSupport(&o.Tools, other.Tools)
+	mergeSupport(&o.Thinking, other.Thinking)
+	mergeInt(&o.ThinkingBudgetMin, other.ThinkingBudgetMin)
+	mergeInt(&o.ThinkingBudgetMax, other.ThinkingBudgetMax)
+	mergeSupport(&o.WebSearch, other.WebSearch)
+	mergeSupport(&o.NoSamplingParams, other.NoSamplingParams)
+	mergeSupport(&o.RawMode, other.RawMode)
+	if other.InputPrice != 0 {
+		o.InputPrice = other.InputPrice
+	}
+	if other.OutputPrice != 0 {
+		o.OutputPrice = other.OutputPrice
+	}
+}
+
+// EstimateCost returns the cost in USD of the token usage, and false when the
+// prices of the model are unknown.
+func (o ModelCapabilities) EstimateCost(usage *domain.UsageMetadata) (float64, bool) {
+	if usage == nil || (o.InputPrice == 0 && o.OutputPrice == 0) {
+		return 0, false
+	}
+	return (float64(usage.InputTokens)*o.InputPrice + float64(usage.OutputTokens)*o.OutputPrice) / 1_000_000, true
+}
+
+// String returns a compact, human-readable summary used by --listmodels --capabilities.
+func (o ModelCapabilities) String() string {
+	var parts []string
+	if o.ContextWindow > 0 {
+		parts = append(parts, "context="+formatTokenCount(o.ContextWindow))
+	}
+	if o.MaxOutputTokens > 0 {
+		parts = append(parts, "output="+formatTokenCount(o.MaxOutputTokens))
+	}
+	flags := []struct {
+		name    string
+		support Support
+	}{
+		{"vision", o.Vision},
+		{"audio-in", o.AudioInput},
+		{"documents", o.DocumentInput},
+		{"audio-out", o.AudioOutput},
+		{"image-gen", o.ImageGeneration},
+		{"tools", o.Tools},
+		{"thinking", o.Thinking},
+		{"web-search", o.WebSearch},
+	}
+	for _, flag := range flags {
+		if !flag.support.IsSupported() {
+			continue
+		}
+		if flag.name == "thinking" && o.ThinkingBudgetMax > 0 {
+			parts = append(parts, fmt.Sprintf("thinking(%d-%d)", o.ThinkingBudgetMin, o.ThinkingBudgetMax))
+			continue
+		}
+		parts = append(parts, flag.name)
+	}
+	if o.InputPrice > 0 || o.OutputPrice > 0 {
+		parts = append(parts, "price="+strconv.FormatFloat(o.InputPrice, 'f', -1, 64)+"/"+strconv.FormatFloat(o.OutputPrice, 'f', -1, 64))
+	}
+	if len(parts) == 0 {
+		return "?"
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTokenCount(tokens int64) string {
+	switch {
+	case tokens >= 1_000_000 && tokens%1_000_000 == 0:
+		return fmt.Sprintf("%dM", tokens/1_000_000)
+	case tokens >= 1_000:
+		return fmt.Sprintf("%dK", tokens/1_000)
+	default:
+		return strconv.FormatInt(tokens, 10)
+	}
+}
+
+// Validate returns an error when the options or attachments of a request ask for
+// something the model is known not to support. Unknown capabilities never fail.
+func (o ModelCapabilities) Validate(model string, request *domain.ChatRequest, opts *domain.ChatOptions) error {
+	if opts != nil {
+		if opts.Thinking != "" && opts.Thinking != domain.ThinkingOff {
+			if o.Thinking.IsUnsupported() {
+				return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_thinking_not_supported"), model, opts.Thinking))
+			}
+			if budget, err := strconv.ParseInt(string(opts.Thinking), 10, 64); err == nil {
+				if (o.ThinkingBudgetMin > 0 && budget < o.ThinkingBudgetMin) ||
+					(o.ThinkingBudgetMax > 0 && budget > o.ThinkingBudgetMax) {
+					return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_thinking_budget_out_of_range"),
+						budget, o.ThinkingBudgetMin, o.ThinkingBudgetMax, model))
+				}
+			}
+		}
+		if opts.ImageFile != "" && o.ImageGeneration.IsUnsupported() {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_image_generation_not_supported"), model))
+		}
+		if opts.Search && o.WebSearch.IsUnsupported() {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_web_search_not_supported"), model))
+		}
+	}
+
+	if request != nil && request.Message != nil {
+		for _, part := range request.Message.MultiContent {
+			if part.Type == chat.ChatMessagePartTypeInputAudio {
+				if o.AudioInput.IsUnsupported() {
+					return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_audio_input_not_supported"), model))
+				}
+				continue
+			}
+			if part.Type != chat.ChatMessagePartTypeImageURL || part.ImageURL == nil {
+				continue
+			}
+			if strings.HasPrefix(part.ImageURL.URL, "data:audio/") {
+				if o.AudioInput.IsUnsupported() {
+					return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_audio_input_not_supported"), model))
+				}
+			} else if o.Vision.IsUnsupported() {
+				return fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_vision_not_supported"), model))
+			}
+		}
+	}
+	return nil
+}
+
+// CapabilityRule assigns capabilities to every model whose name matches the
+// Model glob (path.Match syntax, case-insensitive). An empty Vendor matches
+// every vendor; otherwise the vendor name must match case-insensitively.
+type CapabilityRule struct {
+	Vendor            string `yaml:"vendor,omitempty"`
+	Model             string `yaml:"model"`
+	ModelCapabilities `yaml:",inline"`
+}
+
+func (o *CapabilityRule) matches(vendor, model string) bool {
+	if o.Vendor != "" && !strings.EqualFold(o.Vendor, vendor) {
+		return false
+	}
+	pattern := strings.ToLower(o.Model)
+	model = strings.ToLower(model)
+	if matched, _ := path.Match(pattern, model); matched {
+		return true
+	}
+	// Aggregators such as OpenRouter prefix model names with the provider ("openai/gpt-5").
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		matched, _ := path.Match(pattern, model[idx+1:])
+		return matched
+	}
+	return false
+}
+
+// capabilityOverridesFile is the YAML document users can drop into the fabric
+// config directory to extend or correct the built-in table.
+type capabilityOverridesFile struct {
+	Models []CapabilityRule `yaml:"models"`
+}
+
+// CapabilityRegistry resolves model capabilities from the built-in table and user overrides.
+// Matching rules are applied in order, so later rules refine earlier ones and
+// user overrides always win over built-in entries.
+type CapabilityRegistry struct {
+	mu        sync.RWMutex
+	builtin   []CapabilityRule
+	overrides []CapabilityRule
+}
+
+func NewCapabilityRegistry(rules ...CapabilityRule) *CapabilityRegistry {
+	return &CapabilityRegistry{builtin: rules}
+}
+
+// Lookup returns the merged capabilities of all rules matching the vendor and model.
+func (o *CapabilityRegistry) Lookup(vendor, model string) (ret ModelCapabilities) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, rules := range [][]CapabilityRule{o.builtin, o.overrides} {
+		for i := range rules {
+			if rules[i].matches(vendor, model) {
+				ret.merge(rules[i].ModelCapabilities)
+			}
+		}
+	}
+	return
+}
+
+// AddOverrides appends user rules that take precedence over the built-in table.
+func (o *CapabilityRegistry) AddOverrides(rules ...CapabilityRule) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.overrides = append(o.overrides, rules...)
+}
+
+// LoadOverrides reads user rules from a YAML file. A missing file is not an error.
+func (o *CapabilityRegistry) LoadOverrides(filePath string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(filePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	var file capabilityOverridesFile
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_overrides_invalid"), filePath, err))
+		return
+	}
+	for _, rule := range file.Models {
+		if rule.Model == "" {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_override_missing_model"), filePath))
+			return
+		}
+	}
+	o.AddOverrides(file.Models...)
+	return
+}
+
+// ModelsWith lists the model patterns of the rules for vendor that grant a capability.
+func (o *CapabilityRegistry) ModelsWith(vendor string, has func(ModelCapabilities) bool) (ret []string) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, rules := range [][]CapabilityRule{o.builtin, o.overrides} {
+		for _, rule := range rules {
+			if (rule.Vendor == "" || strings.EqualFold(rule.Vendor, vendor)) && has(rule.ModelCapabilities) {
+				ret = append(ret, rule.Model)
+			}
+		}
+	}
+	return
+}
+
+// Capabilities is the process-wide registry seeded with the built-in table.
+var Capabilities = NewCapabilityRegistry(builtinCapabilityRules...)
+
+// LookupCapabilities resolves capabilities of a model using the process-wide registry.
+func LookupCapabilities(vendor, model string) ModelCapabilities {
+	return Capabilities.Lookup(vendor, model)
+}
+
+const (
+	yes = SupportYes
+	no  = SupportNo
+)
+
+// builtinCapabilityRules is ordered from broad families to specific models.
+var builtinCapabilityRules = []CapabilityRule{
+	// Name-based rules shared by every vendor serving these models.
+	{Model: "*tts*", ModelCapabilities: ModelCapabilities{AudioOutput: yes}},
+	{Model: "*text-to-speech*", ModelCapabilities: ModelCapabilities{AudioOutput: yes}},
+	{Model: "glm*", ModelCapabilities: ModelCapabilities{RawMode: yes}},
+
+	// OpenAI
+	{Model: "gpt-4o*", ModelCapabilities: ModelCapabilities{ContextWindow: 128_000, MaxOutputTokens: 16_384,
+		Vision: yes, Tools: yes, Thinking: no, WebSearch: yes, ImageGeneration: no}},
+	{Model: "gpt-4o*-search-preview*", ModelCapabilities: ModelCapabilities{RawMode: yes, Tools: no}},
+	{Model: "gpt-4.1*", ModelCapabilities: ModelCapabilities{ContextWindow: 1_047_576, MaxOutputTokens: 32_768,
+		Vision: yes, Tools: yes, Thinking: no, WebSearch: yes, ImageGeneration: no}},
+	{Model: "gpt-4.1-nano", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "gpt-5*", ModelCapabilities: ModelCapabilities{ContextWindow: 400_000, MaxOutputTokens: 128_000,
+		Vision: yes, Tools: yes, Thinking: yes, WebSearch: yes, ImageGeneration: no, RawMode: yes}},
+	{Model: "gpt-5", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "gpt-5-nano", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "gpt-5.2", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "o1*", ModelCapabilities: ModelCapabilities{ContextWindow: 200_000, MaxOutputTokens: 100_000,
+		Thinking: yes, ImageGeneration: no, RawMode: yes}},
+	{Model: "o3*", ModelCapabilities: ModelCapabilities{ContextWindow: 200_000, MaxOutputTokens: 100_000,
+		Vision: yes, Tools: yes, Thinking: yes, WebSearch: yes, ImageGeneration: no, RawMode: yes}},
+	{Model: "o3", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "o4*", ModelCapabilities: ModelCapabilities{ContextWindow: 200_000, MaxOutputTokens: 100_000,
+		Vision: yes, Tools: yes, Thinking: yes, WebSearch: yes, ImageGeneration: no, RawMode: yes}},
+	{Model: "gpt-image-*", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "dall-e-*", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+
+	// Anthropic
+	{Model: "claude-*", ModelCapabilities: ModelCapabilities{ContextWindow: 200_000, MaxOutputTokens: 64_000,
+		Vision: yes, Tools: yes, Thinking: yes, ThinkingBudgetMin: 1_024, ThinkingBudgetMax: 10_000,
+		WebSearch: yes, AudioInput: no, AudioOutput: no, ImageGeneration: no}},
+	{Model: "claude-3-*", ModelCapabilities: ModelCapabilities{MaxOutputTokens: 8_192}},
+	{Model: "claude-sonnet-4-5*", ModelCapabilities: ModelCapabilities{ContextWindow: 1_000_000}},
+	{Model: "claude-sonnet-4-6*", ModelCapabilities: ModelCapabilities{ContextWindow: 1_000_000}},
+	{Model: "claude-opus-4-6*", ModelCapabilities: ModelCapabilities{ContextWindow: 1_000_000}},
+	{Model: "claude-opus-4-7*", ModelCapabilities: ModelCapabilities{NoSamplingParams: yes}},
+	{Model: "claude-opus-4-8*", ModelCapabilities: ModelCapabilities{NoSamplingParams: yes}},
+	{Model: "claude-opus-5*", ModelCapabilities: ModelCapabilities{NoSamplingParams: yes}},
+	{Model: "claude-sonnet-5*", ModelCapabilities: ModelCapabilities{NoSamplingParams: yes}},
+	{Model: "claude-fable-5*", ModelCapabilities: ModelCapabilities{NoSamplingParams: yes}},
+
+	// Google Gemini
+	{Model: "gemini-*", ModelCapabilities: ModelCapabilities{ContextWindow: 1_048_576, MaxOutputTokens: 8_192,
+		Vision: yes, AudioInput: yes, Tools: yes, WebSearch: yes}},
+	{Model: "gemini-2.5*", ModelCapabilities: ModelCapabilities{MaxOutputTokens: 65_536,
+		Thinking: yes, ThinkingBudgetMin: 1, ThinkingBudgetMax: 32_768}},
+	{Model: "gemini-3*", ModelCapabilities: ModelCapabilities{MaxOutputTokens: 65_536,
+		Thinking: yes, ThinkingBudgetMin: 1, ThinkingBudgetMax: 32_768}},
+	// Image models are served by Google's image endpoints, not by OpenAI-compatible vendors
+	{Vendor: "Gemini", Model: "gemini-*image*", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Vendor: "Gemini", Model: "imagen-*", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Vendor: "VertexAI", Model: "gemini-*image*", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Vendor: "VertexAI", Model: "imagen-*", ModelCapabilities: ModelCapabilities{ImageGeneration: yes}},
+	{Model: "gemini-*tts*", ModelCapabilities: ModelCapabilities{AudioOutput: yes, Tools: no, WebSearch: no, Thinking: no}},
+
+	// PDF attachments are passed natively only by the vendors that convert file parts.
+	{Vendor: "OpenAI", Model: "gpt-4o*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "OpenAI", Model: "gpt-4.1*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "OpenAI", Model: "gpt-5*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "OpenAI", Model: "o3*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "OpenAI", Model: "o4*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "Anthropic", Model: "claude-*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "Gemini", Model: "gemini-*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+	{Vendor: "VertexAI", Model: "gemini-*", ModelCapabilities: ModelCapabilities{DocumentInput: yes}},
+
+	// Perplexity models always search the web.
+	{Vendor: "Perplexity", Model: "sonar*", ModelCapabilities: ModelCapabilities{ContextWindow: 128_000, WebSearch: yes}},
+	{Vendor: "Perplexity", Model: "sonar-reasoning*", ModelCapabilities: ModelCapabilities{Thinking: yes}},
+
+	// List prices in USD per million input/output tokens, used to estimate the cost of --compare runs.
+	{Model: "gpt-4o*", ModelCapabilities: ModelCapabilities{InputPrice: 2.5, OutputPrice: 10}},
+	{Model: "gpt-4o-mini*", ModelCapabilities: ModelCapabilities{InputPrice: 0.15, OutputPrice: 0.6}},
+	{Model: "gpt-4.1*", ModelCapabilities: ModelCapabilities{InputPrice: 2, OutputPrice: 8}},
+	{Model: "gpt-4.1-mini*", ModelCapabilities: ModelCapabilities{InputPrice: 0.4, OutputPrice: 1.6}},
+	{Model: "gpt-4.1-nano*", ModelCapabilities: ModelCapabilities{InputPrice: 0.1, OutputPrice: 0.4}},
+	{Model: "gpt-5*", ModelCapabilities: ModelCapabilities{InputPrice: 1.25, OutputPrice: 10}},
+	{Model: "gpt-5-mini*", ModelCapabilities: ModelCapabilities{InputPrice: 0.25, OutputPrice: 2}},
+	{Model: "gpt-5-nano*", ModelCapabilities: ModelCapabilities{InputPrice: 0.05, OutputPrice: 0.4}},
+	{Model: "claude-sonnet-4*", ModelCapabilities: ModelCapabilities{InputPrice: 3, OutputPrice: 15}},
+	{Model: "claude-opus-4*", ModelCapabilities: ModelCapabilities{InputPrice: 15, OutputPrice: 75}},
+	{Model: "claude-opus-4-5*", ModelCapabilities: ModelCapabilities{InputPrice: 5, OutputPrice: 25}},
+	{Model: "claude-haiku-4-5*", ModelCapabilities: ModelCapabilities{InputPrice: 1, OutputPrice: 5}},
+	{Model: "claude-3-5-haiku*", ModelCapabilities: ModelCapabilities{InputPrice: 0.8, OutputPrice: 4}},
+	{Model: "gemini-2.5-pro*", ModelCapabilities: ModelCapabilities{InputPrice: 1.25, OutputPrice: 10}},
+	{Model: "gemini-2.5-flash*", ModelCapabilities: ModelCapabilities{InputPrice: 0.3, OutputPrice: 2.5}},
+	{Model: "gemini-2.5-flash-lite*", ModelCapabilities: ModelCapabilities{InputPrice: 0.1, OutputPrice: 0.4}},
+}
@@ -0,0 +1,193 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+)
+
+func TestCapabilityRegistryLookupAppliesRulesInOrder(t *testing.T) {
+	registry := NewCapabilityRegistry(
+		CapabilityRule{Model: "family-*", ModelCapabilities: ModelCapabilities{ContextWindow: 1000, Vision: SupportYes}},
+		CapabilityRule{Model: "family-small", ModelCapabilities: ModelCapabilities{Vision: SupportNo}},
+		CapabilityRule{Vendor: "Other", Model: "family-*", ModelCapabilities: ModelCapabilities{ContextWindow: 5}},
+	)
+
+	caps := registry.Lookup("Vendor", "FAMILY-SMALL")
+	if caps.ContextWindow != 1000 {
+		t.Errorf("ContextWindow = %d, want 1000", caps.ContextWindow)
+	}
+	if !caps.Vision.IsUnsupported() {
+		t.Errorf("Vision = %v, want unsupported", caps.Vision)
+	}
+
+	if caps = registry.Lookup("other", "family-large"); caps.ContextWindow != 5 {
+		t.Errorf("vendor rule not applied: ContextWindow = %d, want 5", caps.ContextWindow)
+	}
+
+	if caps = registry.Lookup("Vendor", "unknown"); caps != (ModelCapabilities{}) {
+		t.Errorf("unknown model should have no capabilities, got %+v", caps)
+	}
+}
+
+func TestCapabilityRegistryMatchesProviderPrefixedModels(t *testing.T) {
+	registry := NewCapabilityRegistry(CapabilityRule{Model: "gpt-5*", ModelCapabilities: ModelCapabilities{RawMode: SupportYes}})
+
+	if !registry.Lookup("OpenRouter", "openai/gpt-5-mini").RawMode.IsSupported() {
+		t.Error("expected provider-prefixed model to match")
+	}
+}
+
+func TestCapabilityRegistryLoadOverrides(t *testing.T) {
+	registry := NewCapabilityRegistry(CapabilityRule{Model: "local-*", ModelCapabilities: ModelCapabilities{Thinking: SupportNo}})
+
+	overridesPath := filepath.Join(t.TempDir(), "capabilities.yaml")
+	if err := registry.LoadOverrides(overridesPath); err != nil {
+		t.Fatalf("missing overrides file should be ignored, got %v", err)
+	}
+
+	content := `models:
+  - vendor: Ollama
+    model: "local-*"
+    context_window: 32768
+    thinking: true
+    vision: false
+`
+	if err := os.WriteFile(overridesPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write overrides: %v", err)
+	}
+	if err := registry.LoadOverrides(overridesPath); err != nil {
+		t.Fatalf("LoadOverrides() error = %v", err)
+	}
+
+	caps := registry.Lookup("Ollama", "local-model")
+	if caps.ContextWindow != 32768 || !caps.Thinking.IsSupported() || !caps.Vision.IsUnsupported() {
+		t.Errorf("override not applied: %+v", caps)
+	}
+	if caps = registry.Lookup("LM Studio", "local-model"); !caps.Thinking.IsUnsupported() {
+		t.Errorf("override should only apply to Ollama, got %+v", caps)
+	}
+}
+
+func TestCapabilityRegistryLoadOverridesRequiresModel(t *testing.T) {
+	overridesPath := filepath.Join(t.TempDir(), "capabilities.yaml")
+	if err := os.WriteFile(overridesPath, []byte("models:\n  - vision: true\n"), 0o644); err != nil {
+		t.Fatalf("failed to write overrides: %v", err)
+	}
+	if err := NewCapabilityRegistry().LoadOverrides(overridesPath); err == nil {
+		t.Error("expected error for rule without model pattern")
+	}
+}
+
+func TestModelCapabilitiesValidate(t *testing.T) {
+	caps := ModelCapabilities{
+		Thinking:          SupportYes,
+		ThinkingBudgetMin: 1024,
+		ThinkingBudgetMax: 10000,
+		ImageGeneration:   SupportNo,
+		Vision:            SupportYes,
+		AudioInput:        SupportNo,
+	}
+
+	tests := []struct {
+		name    string
+		opts    *domain.ChatOptions
+		parts   []chat.ChatMessagePart
+		wantErr bool
+	}{
+		{name: "thinking level", opts: &domain.ChatOptions{Thinking: domain.ThinkingHigh}},
+		{name: "thinking budget in range", opts: &domain.ChatOptions{Thinking: "2048"}},
+		{name: "thinking budget too large", opts: &domain.ChatOptions{Thinking: "20000"}, wantErr: true},
+		{name: "thinking budget too small", opts: &domain.ChatOptions{Thinking: "100"}, wantErr: true},
+		{name: "image generation unsupported", opts: &domain.ChatOptions{ImageFile: "out.png"}, wantErr: true},
+		{name: "unknown web search is allowed", opts: &domain.ChatOptions{Search: true}},
+		{
+			name:  "image attachment",
+			opts:  &domain.ChatOptions{},
+			parts: []chat.ChatMessagePart{{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "data:image/png;base64,AAAA"}}},
+		},
+		{
+			name:    "audio attachment",
+			opts:    &domain.ChatOptions{},
+			parts:   []chat.ChatMessagePart{{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "data:audio/mpeg;base64,AAAA"}}},
+			wantErr: true,
+		},
+		{
+			name:    "typed audio attachment",
+			opts:    &domain.ChatOptions{},
+			parts:   []chat.ChatMessagePart{{Type: chat.ChatMessagePartTypeInputAudio, InputAudio: &chat.ChatMessageInputAudio{Data: "AAAA", Format: "mp3"}}},
+			wantErr: true,
+		},
+		{
+			name:  "document attachment",
+			opts:  &domain.ChatOptions{},
+			parts: []chat.ChatMessagePart{{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{MimeType: "application/pdf", Data: "AAAA"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &domain.ChatRequest{Message: &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, MultiContent: tt.parts}}
+			err := caps.Validate("test-model", request, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (ModelCapabilities{Thinking: SupportNo}).Validate("m", nil, &domain.ChatOptions{Thinking: domain.ThinkingLow}); err == nil {
+		t.Error("expected error when thinking is unsupported")
+	}
+	if err := (ModelCapabilities{Thinking: SupportNo}).Validate("m", nil, &domain.ChatOptions{Thinking: domain.ThinkingOff}); err != nil {
+		t.Errorf("thinking off should always be allowed, got %v", err)
+	}
+}
+
+func TestBuiltinCapabilities(t *testing.T) {
+	tests := []struct {
+		vendor, model string
+		check         func(ModelCapabilities) bool
+	}{
+		{"OpenAI", "gpt-5", func(c ModelCapabilities) bool { return c.RawMode.IsSupported() && c.ImageGeneration.IsSupported() }},
+		{"OpenAI", "gpt-5-mini", func(c ModelCapabilities) bool { return c.ImageGeneration.IsUnsupported() }},
+		{"OpenAI", "gpt-4o", func(c ModelCapabilities) bool { return !c.RawMode.IsSupported() && c.Vision.IsSupported() }},
+		{"OpenAI", "gpt-4o-mini-search-preview", func(c ModelCapabilities) bool { return c.RawMode.IsSupported() }},
+		{"Anthropic", "claude-opus-4-7", func(c ModelCapabilities) bool { return c.NoSamplingParams.IsSupported() }},
+		{"Anthropic", "claude-sonnet-4-5", func(c ModelCapabilities) bool { return !c.NoSamplingParams.IsSupported() }},
+		{"Gemini", "gemini-2.5-flash-preview-tts", func(c ModelCapabilities) bool { return c.AudioOutput.IsSupported() }},
+		{"Gemini", "gemini-2.5-flash", func(c ModelCapabilities) bool { return !c.AudioOutput.IsSupported() && c.Thinking.IsSupported() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vendor+"/"+tt.model, func(t *testing.T) {
+			if caps := LookupCapabilities(tt.vendor, tt.model); !tt.check(caps) {
+				t.Errorf("unexpected capabilities %+v", caps)
+			}
+		})
+	}
+}
+
+func TestModelCapabilitiesEstimateCost(t *testing.T) {
+	registry := NewCapabilityRegistry(
+		CapabilityRule{Model: "priced-*", ModelCapabilities: ModelCapabilities{InputPrice: 2, OutputPrice: 8}},
+		CapabilityRule{Model: "priced-mini", ModelCapabilities: ModelCapabilities{InputPrice: 0.5}},
+	)
+	usage := &domain.UsageMetadata{InputTokens: 1_000_000, OutputTokens: 500_000}
+
+	cost, known := registry.Lookup("Vendor", "priced-large").EstimateCost(usage)
+	if !known || cost != 6 {
+		t.Errorf("EstimateCost() = %v, %v; want 6, true", cost, known)
+	}
+	if cost, _ = registry.Lookup("Vendor", "priced-mini").EstimateCost(usage); cost != 4.5 {
+		t.Errorf("later rule should override the input price: cost = %v, want 4.5", cost)
+	}
+	if _, known = registry.Lookup("Vendor", "unpriced").EstimateCost(usage); known {
+		t.Error("expected unknown cost for a model without prices")
+	}
+	if _, known = registry.Lookup("Vendor", "priced-large").EstimateCost(nil); known {
+		t.Error("expected unknown cost without usage")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	plugins "github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	openaivendor "github.com/danielmiessler/fabric/internal/plugins/ai/openai"
 	openaiapi "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -211,12 +212,23 @@ func (c *Client) ListModels(ctx context.Context) ([]string, error) {
 
 // Send sends a request to Codex and returns the final text output.
 func (c *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+	text, _, err := c.SendWithCitations(ctx, msgs, opts)
+	return text, err
+}
+
+// SendWithCitations sends a request to Codex and returns the final text output and the URL citations it carries.
+func (c *Client) SendWithCitations(
+	ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions,
+) (string, []chat.Citation, error) {
 	if opts.ImageFile != "" {
-		return "", errors.New(i18n.T("codex_image_file_not_supported"))
+		return "", nil, errors.New(i18n.T("codex_image_file_not_supported"))
+	}
+	if openaivendor.HasInputAudio(msgs) {
+		return "", nil, errors.New(i18n.T("codex_audio_input_not_supported"))
 	}
 	if c.ApiClient == nil {
 		if err := c.configure(); err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
@@ -240,16 +252,30 @@ func (c *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, o
 	}
 
 	if err := c.mapRequestError(stream.Err()); err != nil {
-		return "", err
+		return "", nil, err
 	}
 	streamedText := builder.String()
+	var citations []chat.Citation
 	if completedResp != nil {
-		if extractedText := c.ExtractText(completedResp); strings.TrimSpace(extractedText) != "" {
-			return extractedText, nil
+		var extractedText string
+		if extractedText, citations = c.ExtractText(completedResp); strings.TrimSpace(extractedText) != "" {
+			return extractedText, citations, nil
 		}
 	}
 
-	return streamedText, nil
+	return streamedText, citations, nil
+}
+
+// GenerateImages rejects image generation, which the Codex backend does not offer.
+func (c *Client) GenerateImages(
+	context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions,
+) (string, []ai.ImageData, error) {
+	return "", nil, errors.New(i18n.T("codex_image_file_not_supported"))
+}
+
+// SynthesizeSpeech rejects speech synthesis, which the Codex backend does not offer.
+func (c *Client) SynthesizeSpeech(context.Context, string, *domain.ChatOptions) ([]byte, error) {
+	return nil, errors.New(i18n.T("codex_speech_not_supported"))
 }
 
 // SendStream sends a request to Codex and streams the response text updates.
@@ -261,6 +287,9 @@ func (c *Client) SendStream(
 	if opts.ImageFile != "" {
 		return errors.New(i18n.T("codex_image_file_not_supported"))
 	}
+	if openaivendor.HasInputAudio(msgs) {
+		return errors.New(i18n.T("codex_audio_input_not_supported"))
+	}
 	if c.ApiClient == nil {
 		if err := c.configure(); err != nil {
 			return err
@@ -282,6 +311,16 @@ func (c *Client) SendStream(
 			}
 		case string(constant.ResponseOutputTextDone("").Default()):
 			continue
+		case "response.completed":
+			resp := event.AsResponseCompleted().Response
+			if _, citations := c.ExtractText(&resp); len(citations) > 0 {
+				if err := sendStreamUpdate(ctx, channel, domain.StreamUpdate{
+					Type:      domain.StreamTypeCitation,
+					Citations: citations,
+				}); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
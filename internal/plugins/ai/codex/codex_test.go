@@ -365,7 +365,7 @@ func TestSendRefreshesAfterUnauthorized(t *testing.T) {
 	}
 }
 
-func TestSendIncludesSourcesFromAnnotatedResponse(t *testing.T) {
+func TestSendWithCitationsReturnsSourcesFromAnnotatedResponse(t *testing.T) {
 	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/responses" {
 			http.NotFound(w, r)
@@ -407,7 +407,7 @@ func TestSendIncludesSourcesFromAnnotatedResponse(t *testing.T) {
 
 	client := newConfiguredTestClient(t, apiServer.URL, "acct_sources", testJWT("acct_sources", time.Now().Add(time.Hour)))
 
-	message, err := client.Send(context.Background(), []*chat.ChatCompletionMessage{
+	message, citations, err := client.SendWithCitations(context.Background(), []*chat.ChatCompletionMessage{
 		{Role: chat.ChatMessageRoleUser, Content: "Hello"},
 	}, &domain.ChatOptions{
 		Model:       "gpt-5.4",
@@ -415,17 +415,14 @@ func TestSendIncludesSourcesFromAnnotatedResponse(t *testing.T) {
 		Search:      true,
 	})
 	if err != nil {
-		t.Fatalf("Send() error = %v", err)
+		t.Fatalf("SendWithCitations() error = %v", err)
 	}
 
-	if !strings.Contains(message, "hello from codex") {
-		t.Fatalf("Send() missing response text: %q", message)
-	}
-	if !strings.Contains(message, "## Sources") {
-		t.Fatalf("Send() missing sources section: %q", message)
+	if message != "hello from codex" {
+		t.Fatalf("SendWithCitations() text = %q, want only the response text", message)
 	}
-	if !strings.Contains(message, "[Example](https://example.com)") {
-		t.Fatalf("Send() missing expected citation: %q", message)
+	if len(citations) != 1 || citations[0].URL != "https://example.com" || citations[0].Title != "Example" {
+		t.Fatalf("SendWithCitations() citations = %+v", citations)
 	}
 }
 
@@ -33,9 +33,14 @@ func (c *Client) formatMultiContentMessage(msg *chat.ChatCompletionMessage) stri
 		builder.WriteString(fmt.Sprintf("%s:\n", msg.Role))
 		for _, part := range msg.MultiContent {
 			builder.WriteString(fmt.Sprintf("  - Type: %s\n", part.Type))
-			if part.Type == chat.ChatMessagePartTypeImageURL {
+			switch {
+			case part.Type == chat.ChatMessagePartTypeImageURL:
 				builder.WriteString(fmt.Sprintf("    Image URL: %s\n", part.ImageURL.URL))
-			} else {
+			case part.Type == chat.ChatMessagePartTypeFile && part.File != nil:
+				builder.WriteString(fmt.Sprintf("    File: %s (%s)\n", part.File.Filename, part.File.MimeType))
+			case part.Type == chat.ChatMessagePartTypeInputAudio && part.InputAudio != nil:
+				builder.WriteString(fmt.Sprintf("    Audio: %s\n", part.InputAudio.Format))
+			default:
 				builder.WriteString(fmt.Sprintf("    Text: %s\n", part.Text))
 			}
 		}
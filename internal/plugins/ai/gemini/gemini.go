@@ -15,6 +15,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
 	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	"github.com/danielmiessler/fabric/internal/plugins/ai/geminicommon"
 	"google.golang.org/genai"
 )
@@ -28,7 +29,7 @@ const (
 	RIFFHeaderSize       = 36
 	MaxAudioDataSize     = 100 * 1024 * 1024 // 100MB limit for security
 	MinAudioDataSize     = 44                // Minimum viable audio data
-	AudioDataPrefix      = "FABRIC_AUDIO_DATA:"
+	AudioDataPrefix      = ai.AudioDataPrefix
 )
 
 const (
@@ -37,16 +38,13 @@ const (
 	langCodeSeparator           = "_"
 	langCodeNormalizedSep       = "-"
 
-	modelPrefix           = "models/"
-	modelTypeTTS          = "tts"
-	modelTypePreviewTTS   = "preview-tts"
-	modelTypeTextToSpeech = "text-to-speech"
+	modelPrefix = "models/"
+	vendorName  = "Gemini"
 )
 
 var langCodeRegex = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
 
 func NewClient() (ret *Client) {
-	vendorName := "Gemini"
 	ret = &Client{}
 
 	ret.PluginBase = plugins.NewVendorPluginBase(vendorName, nil)
@@ -86,6 +84,13 @@ func (o *Client) ListModels(_ context.Context) (ret []string, err error) {
 }
 
 func (o *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (ret string, err error) {
+	ret, _, err = o.SendWithCitations(ctx, msgs, opts)
+	return
+}
+
+// SendWithCitations returns the generated text and the web sources it is grounded on.
+func (o *Client) SendWithCitations(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	ret string, citations []chat.Citation, err error) {
 	// Check if this is a TTS model request
 	if o.isTTSModel(opts.Model) {
 		if !opts.AudioOutput {
@@ -94,7 +99,8 @@ func (o *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, o
 		}
 
 		// Handle TTS generation
-		return o.generateTTSAudio(ctx, msgs, opts)
+		ret, err = o.generateTTSAudio(ctx, msgs, opts)
+		return
 	}
 
 	// Regular text generation
@@ -109,22 +115,37 @@ func (o *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, o
 	// Convert messages to new SDK format
 	contents := geminicommon.ConvertMessages(msgs)
 
-	cfg, err := o.buildGenerateContentConfig(opts)
-	if err != nil {
-		return "", err
+	var cfg *genai.GenerateContentConfig
+	if cfg, err = o.buildGenerateContentConfig(opts); err != nil {
+		return
 	}
 
 	// Generate content with optional tools
-	response, err := client.Models.GenerateContent(ctx, o.buildModelNameFull(opts.Model), contents, cfg)
-	if err != nil {
-		return "", err
+	var response *genai.GenerateContentResponse
+	if response, err = client.Models.GenerateContent(ctx, o.buildModelNameFull(opts.Model), contents, cfg); err != nil {
+		return
 	}
 
-	// Extract text from response
-	ret = geminicommon.ExtractTextWithCitations(response)
+	// Extract text and sources from response
+	ret = geminicommon.ExtractText(response)
+	citations = geminicommon.ExtractCitations(response)
 	return
 }
 
+// GenerateImages generates images with Imagen or a Gemini image model, editing the images
+// of the conversation when the model supports it.
+func (o *Client) GenerateImages(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	ret string, images []ai.ImageData, err error) {
+	var client *genai.Client
+	if client, err = genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  o.ApiKey.Value,
+		Backend: genai.BackendGeminiAPI,
+	}); err != nil {
+		return
+	}
+	return geminicommon.GenerateImages(ctx, client, o.buildModelNameFull(opts.Model), msgs, opts)
+}
+
 func (o *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions, channel chan domain.StreamUpdate) (err error) {
 	ctx := context.Background()
 	defer close(channel)
@@ -157,7 +178,7 @@ func (o *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 			return err
 		}
 
-		text := geminicommon.ExtractTextWithCitations(response)
+		text := geminicommon.ExtractText(response)
 		if text != "" {
 			channel <- domain.StreamUpdate{
 				Type:    domain.StreamTypeContent,
@@ -165,6 +186,13 @@ func (o *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 			}
 		}
 
+		if citations := geminicommon.ExtractCitations(response); len(citations) > 0 {
+			channel <- domain.StreamUpdate{
+				Type:      domain.StreamTypeCitation,
+				Citations: citations,
+			}
+		}
+
 		if response.UsageMetadata != nil {
 			channel <- domain.StreamUpdate{
 				Type: domain.StreamTypeUsage,
@@ -283,10 +311,7 @@ func isValidLanguageCode(code string) bool {
 
 // isTTSModel checks if the model is a text-to-speech model
 func (o *Client) isTTSModel(modelName string) bool {
-	lowerModel := strings.ToLower(modelName)
-	return strings.Contains(lowerModel, modelTypeTTS) ||
-		strings.Contains(lowerModel, modelTypePreviewTTS) ||
-		strings.Contains(lowerModel, modelTypeTextToSpeech)
+	return ai.LookupCapabilities(vendorName, modelName).AudioOutput.IsSupported()
 }
 
 // extractTextForTTS extracts text content from chat messages for TTS generation
@@ -314,22 +339,37 @@ func (o *Client) generateTTSAudio(ctx context.Context, msgs []*chat.ChatCompleti
 		return "", err
 	}
 
+	wavData, err := o.SynthesizeSpeech(ctx, textToSpeak, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Store the binary audio data in a special format that the CLI can detect
+	return AudioDataPrefix + string(wavData), nil
+}
+
+// SynthesizeSpeech turns text into WAV audio with a Gemini TTS model.
+func (o *Client) SynthesizeSpeech(ctx context.Context, text string, opts *domain.ChatOptions) ([]byte, error) {
+	if opts.AudioFormat != "" && opts.AudioFormat != ai.SpeechFormatWAV {
+		return nil, fmt.Errorf(i18n.T("gemini_tts_wav_only"), opts.AudioFormat)
+	}
+
 	// Validate voice name before making API call
 	if opts.Voice != "" && !IsValidGeminiVoice(opts.Voice) {
 		validVoices := GetGeminiVoiceNames()
-		return "", fmt.Errorf(i18n.T("gemini_invalid_voice"), opts.Voice, validVoices)
+		return nil, fmt.Errorf(i18n.T("gemini_invalid_voice"), opts.Voice, validVoices)
 	}
 
 	client, err := o.createGenaiClient(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return o.performTTSGeneration(ctx, client, textToSpeak, opts)
+	return o.performTTSGeneration(ctx, client, text, opts)
 }
 
-// performTTSGeneration performs the actual TTS generation and audio processing
-func (o *Client) performTTSGeneration(ctx context.Context, client *genai.Client, textToSpeak string, opts *domain.ChatOptions) (string, error) {
+// performTTSGeneration performs the actual TTS generation and returns the audio as WAV
+func (o *Client) performTTSGeneration(ctx context.Context, client *genai.Client, textToSpeak string, opts *domain.ChatOptions) ([]byte, error) {
 
 	// Create content for TTS
 	contents := []*genai.Content{{
@@ -356,7 +396,7 @@ func (o *Client) performTTSGeneration(ctx context.Context, client *genai.Client,
 	// Generate TTS content
 	response, err := client.Models.GenerateContent(ctx, o.buildModelNameFull(opts.Model), contents, config)
 	if err != nil {
-		return "", fmt.Errorf(i18n.T("gemini_tts_failed"), err)
+		return nil, fmt.Errorf(i18n.T("gemini_tts_failed"), err)
 	}
 
 	// Extract and process audio data
@@ -365,32 +405,30 @@ func (o *Client) performTTSGeneration(ctx context.Context, client *genai.Client,
 		if part.InlineData != nil && len(part.InlineData.Data) > 0 {
 			// Validate audio data format and size
 			if part.InlineData.MIMEType != "" && !strings.HasPrefix(part.InlineData.MIMEType, "audio/") {
-				return "", fmt.Errorf(i18n.T("gemini_unexpected_data_type"), part.InlineData.MIMEType)
+				return nil, fmt.Errorf(i18n.T("gemini_unexpected_data_type"), part.InlineData.MIMEType)
 			}
 
 			pcmData := part.InlineData.Data
 			if len(pcmData) < MinAudioDataSize {
-				return "", fmt.Errorf(i18n.T("gemini_audio_data_too_small"), len(pcmData), MinAudioDataSize)
+				return nil, fmt.Errorf(i18n.T("gemini_audio_data_too_small"), len(pcmData), MinAudioDataSize)
 			}
 
 			// Generate WAV file with proper headers and return the binary data
 			wavData, err := o.generateWAVFile(pcmData)
 			if err != nil {
-				return "", fmt.Errorf(i18n.T("gemini_wav_generation_failed"), err)
+				return nil, fmt.Errorf(i18n.T("gemini_wav_generation_failed"), err)
 			}
 
 			// Validate generated WAV data
 			if len(wavData) < WAVHeaderSize {
-				return "", fmt.Errorf(i18n.T("gemini_wav_data_invalid"), len(wavData), WAVHeaderSize)
+				return nil, fmt.Errorf(i18n.T("gemini_wav_data_invalid"), len(wavData), WAVHeaderSize)
 			}
 
-			// Store the binary audio data in a special format that the CLI can detect
-			// Use more efficient string concatenation
-			return AudioDataPrefix + string(wavData), nil
+			return wavData, nil
 		}
 	}
 
-	return "", errors.New(i18n.T("gemini_no_audio_data"))
+	return nil, errors.New(i18n.T("gemini_no_audio_data"))
 }
 
 // generateWAVFile creates WAV data from PCM data with proper headers
@@ -31,7 +31,7 @@ func TestBuildModelNameFull(t *testing.T) {
 	}
 }
 
-// Test ExtractTextWithCitations from geminicommon
+// Test ExtractText from geminicommon
 func TestExtractTextFromResponse(t *testing.T) {
 	response := &genai.GenerateContentResponse{
 		Candidates: []*genai.Candidate{
@@ -47,7 +47,7 @@ func TestExtractTextFromResponse(t *testing.T) {
 	}
 	expected := "Hello, world!"
 
-	result := geminicommon.ExtractTextWithCitations(response)
+	result := geminicommon.ExtractText(response)
 
 	if result != expected {
 		t.Errorf("Expected %v, got %v", expected, result)
@@ -55,9 +55,12 @@ func TestExtractTextFromResponse(t *testing.T) {
 }
 
 func TestExtractTextFromResponse_Nil(t *testing.T) {
-	if got := geminicommon.ExtractTextWithCitations(nil); got != "" {
+	if got := geminicommon.ExtractText(nil); got != "" {
 		t.Fatalf("expected empty string, got %q", got)
 	}
+	if got := geminicommon.ExtractCitations(nil); got != nil {
+		t.Fatalf("expected no citations, got %+v", got)
+	}
 }
 
 func TestExtractTextFromResponse_EmptyGroundingChunks(t *testing.T) {
@@ -69,9 +72,12 @@ func TestExtractTextFromResponse_EmptyGroundingChunks(t *testing.T) {
 			},
 		},
 	}
-	if got := geminicommon.ExtractTextWithCitations(response); got != "Hello" {
+	if got := geminicommon.ExtractText(response); got != "Hello" {
 		t.Fatalf("expected 'Hello', got %q", got)
 	}
+	if got := geminicommon.ExtractCitations(response); len(got) != 0 {
+		t.Fatalf("expected no citations, got %+v", got)
+	}
 }
 
 func TestBuildGenerateContentConfig_WithSearch(t *testing.T) {
@@ -158,7 +164,7 @@ func TestBuildGenerateContentConfig_ThinkingTokens(t *testing.T) {
 	}
 }
 
-func TestCitationFormatting(t *testing.T) {
+func TestCitationExtraction(t *testing.T) {
 	response := &genai.GenerateContentResponse{
 		Candidates: []*genai.Candidate{
 			{
@@ -169,17 +175,26 @@ func TestCitationFormatting(t *testing.T) {
 						{Web: &genai.GroundingChunkWeb{URI: "https://news.com/tech", Title: "Tech News"}},
 						{Web: &genai.GroundingChunkWeb{URI: "https://example.com/ai", Title: "AI Research"}}, // duplicate
 					},
+					GroundingSupports: []*genai.GroundingSupport{
+						{Segment: &genai.Segment{Text: "AI is advancing rapidly."}, GroundingChunkIndices: []int32{0, 2}},
+					},
 				},
 			},
 		},
 	}
 
-	result := geminicommon.ExtractTextWithCitations(response)
-	if !strings.Contains(result, "## Sources") {
-		t.Fatalf("expected sources section in result: %s", result)
+	if text := geminicommon.ExtractText(response); strings.Contains(text, "Sources") {
+		t.Fatalf("expected sources to be kept out of the text: %s", text)
 	}
-	if strings.Count(result, "- [") != 2 {
-		t.Errorf("expected 2 unique citations, got %d", strings.Count(result, "- ["))
+	citations := geminicommon.ExtractCitations(response)
+	if len(citations) != 2 {
+		t.Fatalf("expected 2 unique citations, got %+v", citations)
+	}
+	if citations[0].URL != "https://example.com/ai" || citations[0].Span != "AI is advancing rapidly." {
+		t.Errorf("expected first citation to carry the supported segment, got %+v", citations[0])
+	}
+	if citations[1].URL != "https://news.com/tech" || citations[1].Span != "" {
+		t.Errorf("expected unsupported source without span, got %+v", citations[1])
 	}
 }
 
@@ -206,7 +221,59 @@ func TestConvertMessagesRoles(t *testing.T) {
 	}
 }
 
+func TestConvertMessagesInlineAttachments(t *testing.T) {
+	msgs := []*chat.ChatCompletionMessage{{
+		Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "describe"},
+			{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "data:image/png;base64,iVBORw=="}},
+			{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "https://example.com/remote.png"}},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{MimeType: "application/pdf", Data: "JVBERi0="}},
+			{Type: chat.ChatMessagePartTypeInputAudio, InputAudio: &chat.ChatMessageInputAudio{Data: "SUQz", Format: "mp3"}},
+		},
+	}}
+
+	parts := geminicommon.ConvertMessages(msgs)[0].Parts
+	if len(parts) != 4 {
+		t.Fatalf("expected text and 3 inline parts, got %d", len(parts))
+	}
+	expected := []string{"image/png", "application/pdf", "audio/mpeg"}
+	for i, mimeType := range expected {
+		inline := parts[i+1].InlineData
+		if inline == nil || inline.MIMEType != mimeType || len(inline.Data) == 0 {
+			t.Errorf("part %d: expected inline %s data, got %+v", i+1, mimeType, inline)
+		}
+	}
+}
+
 // Test isTTSModel method
+func TestExtractImages(t *testing.T) {
+	response := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{
+		{Text: "Here is your cat"},
+		{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("png")}},
+		{InlineData: &genai.Blob{MIMEType: "audio/wav", Data: []byte("wav")}},
+	}}}}}
+
+	images := geminicommon.ExtractImages(response)
+	if len(images) != 1 || images[0].MimeType != "image/png" || string(images[0].Data) != "png" {
+		t.Errorf("Expected the single inline image, got %+v", images)
+	}
+	if text := geminicommon.ExtractText(response); text != "Here is your cat" {
+		t.Errorf("Expected the text next to the image, got %q", text)
+	}
+}
+
+func TestImageModelHelpers(t *testing.T) {
+	if !geminicommon.IsImagenModel("models/imagen-4.0-generate-001") || geminicommon.IsImagenModel("gemini-2.5-flash-image") {
+		t.Error("Expected only Imagen models to use the image generation endpoint")
+	}
+	for size, want := range map[string]string{"1024x1024": "1:1", "1536x1024": "3:2", "1024x1536": "2:3", "auto": ""} {
+		if got := geminicommon.ImageAspectRatio(size); got != want {
+			t.Errorf("ImageAspectRatio(%q) = %q, want %q", size, got, want)
+		}
+	}
+}
+
 func TestIsTTSModel(t *testing.T) {
 	client := &Client{}
 
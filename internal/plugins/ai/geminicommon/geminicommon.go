@@ -3,20 +3,15 @@
 package geminicommon
 
 import (
-	"fmt"
+	"encoding/base64"
 	"strings"
 
 	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"google.golang.org/genai"
 )
 
-// Citation formatting constants
-const (
-	CitationHeader    = "\n\n## Sources\n\n"
-	CitationSeparator = "\n"
-	CitationFormat    = "- [%s](%s)"
-)
-
 // ConvertMessages converts fabric chat messages to genai Content format.
 // Gemini's API only accepts "user" and "model" roles, so other roles are mapped to "user".
 func ConvertMessages(msgs []*chat.ChatCompletionMessage) []*genai.Content {
@@ -42,14 +37,35 @@ func ConvertMessages(msgs []*chat.ChatCompletionMessage) []*genai.Content {
 			content.Parts = append(content.Parts, &genai.Part{Text: msg.Content})
 		}
 
-		// Handle multi-content messages (images, etc.)
+		// Handle multi-content messages (images, documents and audio)
 		for _, part := range msg.MultiContent {
 			switch part.Type {
 			case chat.ChatMessagePartTypeText:
 				content.Parts = append(content.Parts, &genai.Part{Text: part.Text})
 			case chat.ChatMessagePartTypeImageURL:
-				// TODO: Handle image URLs if needed
-				// This would require downloading and converting to inline data
+				// Remote image URLs would have to be downloaded first; only data URLs are sent
+				if part.ImageURL == nil || !strings.HasPrefix(part.ImageURL.URL, "data:") {
+					continue
+				}
+				header, data, _ := strings.Cut(strings.TrimPrefix(part.ImageURL.URL, "data:"), ",")
+				mimeType, _, _ := strings.Cut(header, ";")
+				if inline := inlinePart(mimeType, data); inline != nil {
+					content.Parts = append(content.Parts, inline)
+				}
+			case chat.ChatMessagePartTypeFile:
+				if part.File == nil {
+					continue
+				}
+				if inline := inlinePart(part.File.MimeType, part.File.Data); inline != nil {
+					content.Parts = append(content.Parts, inline)
+				}
+			case chat.ChatMessagePartTypeInputAudio:
+				if part.InputAudio == nil {
+					continue
+				}
+				if inline := inlinePart(domain.AudioMimeType(part.InputAudio.Format), part.InputAudio.Data); inline != nil {
+					content.Parts = append(content.Parts, inline)
+				}
 			}
 		}
 
@@ -59,6 +75,16 @@ func ConvertMessages(msgs []*chat.ChatCompletionMessage) []*genai.Content {
 	return contents
 }
 
+// inlinePart decodes base64 data into an inline data part. It returns nil for empty or invalid data.
+func inlinePart(mimeType, data string) *genai.Part {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(decoded) == 0 {
+		debuglog.Debug(debuglog.Basic, "geminicommon: skipping invalid %s attachment\n", mimeType)
+		return nil
+	}
+	return &genai.Part{InlineData: &genai.Blob{MIMEType: mimeType, Data: decoded}}
+}
+
 // ExtractText extracts just the text parts from a Gemini response.
 func ExtractText(response *genai.GenerateContentResponse) string {
 	if response == nil {
@@ -79,52 +105,41 @@ func ExtractText(response *genai.GenerateContentResponse) string {
 	return builder.String()
 }
 
-// ExtractTextWithCitations extracts text content from the response and appends
-// any web citations in a standardized format.
-func ExtractTextWithCitations(response *genai.GenerateContentResponse) string {
+// ExtractCitations extracts web citations from grounding metadata. A source supporting
+// several segments of the answer yields one citation per segment, with the segment as span.
+func ExtractCitations(response *genai.GenerateContentResponse) (citations []chat.Citation) {
 	if response == nil {
-		return ""
-	}
-
-	text := ExtractText(response)
-	citations := ExtractCitations(response)
-	if len(citations) > 0 {
-		return text + CitationHeader + strings.Join(citations, CitationSeparator)
-	}
-	return text
-}
-
-// ExtractCitations extracts web citations from grounding metadata.
-func ExtractCitations(response *genai.GenerateContentResponse) []string {
-	if response == nil || len(response.Candidates) == 0 {
 		return nil
 	}
 
-	citationMap := make(map[string]bool)
-	var citations []string
 	for _, candidate := range response.Candidates {
 		if candidate == nil || candidate.GroundingMetadata == nil {
 			continue
 		}
 		chunks := candidate.GroundingMetadata.GroundingChunks
-		if len(chunks) == 0 {
-			continue
+		spans := make(map[int][]string)
+		for _, support := range candidate.GroundingMetadata.GroundingSupports {
+			if support == nil || support.Segment == nil || support.Segment.Text == "" {
+				continue
+			}
+			for _, index := range support.GroundingChunkIndices {
+				spans[int(index)] = append(spans[int(index)], support.Segment.Text)
+			}
 		}
-		for _, chunk := range chunks {
-			if chunk == nil || chunk.Web == nil {
+		for i, chunk := range chunks {
+			if chunk == nil || chunk.Web == nil || chunk.Web.URI == "" {
 				continue
 			}
-			uri := chunk.Web.URI
-			title := chunk.Web.Title
-			if uri == "" || title == "" {
+			citation := chat.Citation{URL: chunk.Web.URI, Title: chunk.Web.Title}
+			if len(spans[i]) == 0 {
+				citations = domain.AppendCitations(citations, citation)
 				continue
 			}
-			key := uri + "|" + title
-			if !citationMap[key] {
-				citationMap[key] = true
-				citations = append(citations, fmt.Sprintf(CitationFormat, title, uri))
+			for _, span := range spans[i] {
+				citation.Span = span
+				citations = domain.AppendCitations(citations, citation)
 			}
 		}
 	}
-	return citations
+	return
 }
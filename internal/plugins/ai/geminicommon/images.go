@@ -0,0 +1,118 @@
+package geminicommon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"google.golang.org/genai"
+)
+
+// IsImagenModel reports whether model is an Imagen model, served by the image generation
+// endpoint rather than GenerateContent.
+func IsImagenModel(model string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimPrefix(model, "models/")), "imagen-")
+}
+
+// GenerateImages generates images with model, which is either an Imagen model or a Gemini
+// model with image output. Gemini models receive the whole conversation, so images attached
+// to the request or generated earlier in the session are edited; Imagen only generates.
+func GenerateImages(ctx context.Context, client *genai.Client, model string, msgs []*chat.ChatCompletionMessage,
+	opts *domain.ChatOptions) (ret string, images []ai.ImageData, err error) {
+	if IsImagenModel(model) {
+		var references []ai.ImageData
+		if references, err = ai.ReferenceImages(msgs); err != nil {
+			return
+		}
+		if len(references) > 0 {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("image_edit_not_supported"), opts.Model))
+			return
+		}
+		var response *genai.GenerateImagesResponse
+		if response, err = client.Models.GenerateImages(ctx, model, ai.LastUserText(msgs), &genai.GenerateImagesConfig{
+			NumberOfImages: 1,
+			AspectRatio:    imagenAspectRatio(opts.ImageSize),
+			OutputMIMEType: imageMimeType(opts.ImageFile),
+		}); err != nil {
+			return
+		}
+		for _, generated := range response.GeneratedImages {
+			if generated != nil && generated.Image != nil && len(generated.Image.ImageBytes) > 0 {
+				images = append(images, ai.ImageData{Data: generated.Image.ImageBytes, MimeType: generated.Image.MIMEType})
+			}
+		}
+		return
+	}
+
+	cfg := &genai.GenerateContentConfig{ResponseModalities: []string{string(genai.ModalityText), string(genai.ModalityImage)}}
+	if aspectRatio := ImageAspectRatio(opts.ImageSize); aspectRatio != "" {
+		cfg.ImageConfig = &genai.ImageConfig{AspectRatio: aspectRatio}
+	}
+	var response *genai.GenerateContentResponse
+	if response, err = client.Models.GenerateContent(ctx, model, ConvertMessages(msgs), cfg); err != nil {
+		return
+	}
+	ret = ExtractText(response)
+	images = ExtractImages(response)
+	return
+}
+
+// ExtractImages returns the inline images of a Gemini response.
+func ExtractImages(response *genai.GenerateContentResponse) (ret []ai.ImageData) {
+	if response == nil {
+		return nil
+	}
+	for _, candidate := range response.Candidates {
+		if candidate == nil || candidate.Content == nil {
+			continue
+		}
+		for _, part := range candidate.Content.Parts {
+			if part != nil && part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "image/") {
+				ret = append(ret, ai.ImageData{Data: part.InlineData.Data, MimeType: part.InlineData.MIMEType})
+			}
+		}
+	}
+	return
+}
+
+// ImageAspectRatio maps an --image-size value to the aspect ratio of Gemini image models.
+// It returns "" for "auto" and unset sizes, leaving the choice to the model.
+func ImageAspectRatio(size string) string {
+	switch size {
+	case "1024x1024":
+		return "1:1"
+	case "1536x1024":
+		return "3:2"
+	case "1024x1536":
+		return "2:3"
+	default:
+		return ""
+	}
+}
+
+// imagenAspectRatio maps an --image-size value to the closest aspect ratio Imagen offers.
+func imagenAspectRatio(size string) string {
+	switch size {
+	case "1024x1024":
+		return "1:1"
+	case "1536x1024":
+		return "4:3"
+	case "1024x1536":
+		return "3:4"
+	default:
+		return ""
+	}
+}
+
+// imageMimeType returns the Imagen output type matching the extension of path.
+func imageMimeType(path string) string {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".jpg") || strings.HasSuffix(lower, ".jpeg") {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
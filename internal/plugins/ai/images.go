@@ -0,0 +1,167 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// ImageGenerator is implemented by vendors that can generate images, and edit them
+// when the request carries reference images. It returns the text the model answered
+// with, which may be empty, and the generated images.
+type ImageGenerator interface {
+	GenerateImages(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, []ImageData, error)
+}
+
+// ImageData is a generated image.
+type ImageData struct {
+	Data     []byte
+	MimeType string
+}
+
+// DataURL returns the image as a base64 data URL.
+func (o ImageData) DataURL() string {
+	return fmt.Sprintf("data:%s;base64,%s", o.MimeType, base64.StdEncoding.EncodeToString(o.Data))
+}
+
+// LastUserText returns the text of the last user message, which is what image and
+// speech endpoints without a conversation take as their input.
+func LastUserText(msgs []*chat.ChatCompletionMessage) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == chat.ChatMessageRoleUser {
+			return strings.TrimSpace(msgs[i].TextContent())
+		}
+	}
+	return ""
+}
+
+// ReferenceImages returns the images to edit: those of the most recent message carrying
+// images, which is either the user's request with its attachments or a previously
+// generated image in the session. Only data URL images are returned.
+func ReferenceImages(msgs []*chat.ChatCompletionMessage) (ret []ImageData, err error) {
+	for i := len(msgs) - 1; i >= 0 && len(ret) == 0; i-- {
+		for _, part := range msgs[i].MultiContent {
+			if part.Type != chat.ChatMessagePartTypeImageURL || part.ImageURL == nil {
+				continue
+			}
+			var img ImageData
+			var ok bool
+			if img, ok, err = ParseImageDataURL(part.ImageURL.URL); err != nil {
+				return
+			}
+			if ok {
+				ret = append(ret, img)
+			}
+		}
+	}
+	return
+}
+
+// WithoutGeneratedImages returns the messages with the assistant messages that recorded
+// generated images reduced to their text, for models that only take images from the user.
+func WithoutGeneratedImages(msgs []*chat.ChatCompletionMessage) (ret []*chat.ChatCompletionMessage) {
+	ret = make([]*chat.ChatCompletionMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Role != chat.ChatMessageRoleAssistant || len(msg.MultiContent) == 0 {
+			ret = append(ret, msg)
+			continue
+		}
+		text := *msg
+		text.Content = msg.TextContent()
+		text.MultiContent = nil
+		ret = append(ret, &text)
+	}
+	return
+}
+
+// ParseImageDataURL decodes a base64 image data URL. It reports false for any other URL.
+func ParseImageDataURL(url string) (ret ImageData, ok bool, err error) {
+	if !strings.HasPrefix(url, "data:image/") {
+		return
+	}
+	header, data, _ := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	mimeType, _, _ := strings.Cut(header, ";")
+	if ret.Data, err = base64.StdEncoding.DecodeString(data); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("image_failed_to_decode_data"), err))
+		return
+	}
+	ret.MimeType = mimeType
+	ok = true
+	return
+}
+
+// ImageFileName returns the file the index-th generated image is saved to: the first image
+// goes to path itself and the others get a numeric suffix, as in image-2.png.
+func ImageFileName(path string, index int) string {
+	if index == 0 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), index+1, ext)
+}
+
+// SaveImages writes images to path, numbering every image after the first, and returns
+// the files written. PNG and JPEG images are converted when the extension of path asks
+// for the other format; any other image is written as returned by the vendor.
+func SaveImages(images []ImageData, path string) (ret []string, err error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("image_failed_to_create_directory"), dir, err))
+			return
+		}
+	}
+
+	for i, img := range images {
+		name := ImageFileName(path, i)
+		data := convertImage(img, strings.ToLower(filepath.Ext(name)))
+		if err = os.WriteFile(name, data, 0644); err != nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("image_failed_to_save"), name, err))
+			return
+		}
+		ret = append(ret, name)
+	}
+	return
+}
+
+// convertImage re-encodes PNG and JPEG images to the format named by ext, returning the
+// original data when no conversion is needed or possible.
+func convertImage(img ImageData, ext string) []byte {
+	var target string
+	switch ext {
+	case ".png":
+		target = "image/png"
+	case ".jpg", ".jpeg":
+		target = "image/jpeg"
+	default:
+		return img.Data
+	}
+	if img.MimeType == target || (img.MimeType != "image/png" && img.MimeType != "image/jpeg") {
+		return img.Data
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return img.Data
+	}
+	var buf bytes.Buffer
+	if target == "image/png" {
+		err = png.Encode(&buf, decoded)
+	} else {
+		err = jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: 95})
+	}
+	if err != nil {
+		return img.Data
+	}
+	return buf.Bytes()
+}
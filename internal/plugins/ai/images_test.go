@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+)
+
+func TestReferenceImages(t *testing.T) {
+	generated := ImageData{Data: []byte("generated"), MimeType: "image/png"}
+	attached := ImageData{Data: []byte("attached"), MimeType: "image/jpeg"}
+	imagePart := func(url string) chat.ChatMessagePart {
+		return chat.ChatMessagePart{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: url}}
+	}
+	history := []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleUser, Content: "draw a cat"},
+		{Role: chat.ChatMessageRoleAssistant, MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "Here it is"}, imagePart(generated.DataURL())}},
+	}
+
+	followUp := append(history, &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, Content: "make it blue"})
+	images, err := ReferenceImages(followUp)
+	if err != nil || len(images) != 1 || string(images[0].Data) != "generated" {
+		t.Errorf("Expected the previously generated image, got %+v (%v)", images, err)
+	}
+	if prompt := LastUserText(followUp); prompt != "make it blue" {
+		t.Errorf("Expected the last user text as prompt, got %q", prompt)
+	}
+
+	withAttachment := append(history, &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+		{Type: chat.ChatMessagePartTypeText, Text: "combine"}, imagePart(attached.DataURL()), imagePart("https://example.com/a.png")}})
+	images, err = ReferenceImages(withAttachment)
+	if err != nil || len(images) != 1 || images[0].MimeType != "image/jpeg" {
+		t.Errorf("Expected only the attached data URL image, got %+v (%v)", images, err)
+	}
+
+	if _, err = ReferenceImages([]*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{imagePart("data:image/png;base64,!!")}}}); err == nil {
+		t.Error("Expected an error for an invalid data URL")
+	}
+}
+
+func TestWithoutGeneratedImages(t *testing.T) {
+	user := &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+		{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "data:image/png;base64,AA=="}}}}
+	answer := &chat.ChatCompletionMessage{Role: chat.ChatMessageRoleAssistant, MultiContent: []chat.ChatMessagePart{
+		{Type: chat.ChatMessagePartTypeText, Text: "Saved"},
+		{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: "data:image/png;base64,AA=="}}}}
+
+	got := WithoutGeneratedImages([]*chat.ChatCompletionMessage{user, answer})
+	if got[0] != user {
+		t.Error("Expected user messages to be kept as they are")
+	}
+	if got[1].Content != "Saved" || got[1].MultiContent != nil || len(answer.MultiContent) != 2 {
+		t.Errorf("Expected a text-only copy of the answer, got %+v", got[1])
+	}
+}
+
+func TestSaveImages(t *testing.T) {
+	var encoded bytes.Buffer
+	picture := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	picture.Set(0, 0, color.RGBA{R: 255, A: 255})
+	if err := png.Encode(&encoded, picture); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "nested", "out.jpg")
+	saved, err := SaveImages([]ImageData{
+		{Data: encoded.Bytes(), MimeType: "image/png"},
+		{Data: []byte("webp data"), MimeType: "image/webp"},
+	}, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(saved) != 2 || saved[0] != path || saved[1] != filepath.Join(filepath.Dir(path), "out-2.jpg") {
+		t.Fatalf("Unexpected file names %v", saved)
+	}
+
+	first, err := os.ReadFile(saved[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jpeg.Decode(bytes.NewReader(first)); err != nil {
+		t.Errorf("Expected the PNG to be converted to JPEG, got %v", err)
+	}
+	if second, _ := os.ReadFile(saved[1]); string(second) != "webp data" {
+		t.Errorf("Expected other formats to be written as is, got %q", second)
+	}
+}
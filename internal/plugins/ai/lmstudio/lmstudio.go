@@ -130,6 +130,7 @@ func (c *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 
 	defer close(channel)
 
+	splitter := domain.NewThinkTagSplitter(opts.ThinkStartTag, opts.ThinkEndTag)
 	reader := bufio.NewReader(resp.Body)
 	for {
 		var line []byte
@@ -188,14 +189,17 @@ func (c *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 			continue
 		}
 
-		var content string
-		if content, _ = delta["content"].(string); content != "" {
-			channel <- domain.StreamUpdate{
-				Type:    domain.StreamTypeContent,
-				Content: content,
-			}
+		// Newer LM Studio versions send reasoning separately, older ones inline in think tags
+		if reasoning, _ := delta["reasoning_content"].(string); reasoning != "" {
+			domain.SendStreamParts(channel, reasoning, "")
+		}
+		if content, _ := delta["content"].(string); content != "" {
+			reasoning, text := splitter.Write(content)
+			domain.SendStreamParts(channel, reasoning, text)
 		}
 	}
+	reasoning, text := splitter.Flush()
+	domain.SendStreamParts(channel, reasoning, text)
 
 	return
 }
@@ -50,6 +50,19 @@ func (o *VendorsModels) FindModelNameCaseInsensitive(modelQuery string) string {
 // When shellCompleteList is true, output is suitable for shell completion.
 // Default vendor and model are highlighted with an asterisk.
 func (o *VendorsModels) PrintWithVendor(shellCompleteList bool, defaultVendor, defaultModel string) {
+	o.printWithVendor(shellCompleteList, defaultVendor, defaultModel, nil)
+}
+
+// PrintWithCapabilities prints models like PrintWithVendor, followed by the
+// capabilities known for each of them in the capability registry.
+func (o *VendorsModels) PrintWithCapabilities(defaultVendor, defaultModel string) {
+	o.printWithVendor(false, defaultVendor, defaultModel, func(vendor, model string) string {
+		return LookupCapabilities(vendor, model).String()
+	})
+}
+
+func (o *VendorsModels) printWithVendor(shellCompleteList bool, defaultVendor, defaultModel string,
+	describe func(vendor, model string) string) {
 	if !shellCompleteList {
 		fmt.Printf("%s:\n\n", o.SelectionLabel)
 	}
@@ -77,7 +90,11 @@ func (o *VendorsModels) PrintWithVendor(shellCompleteList bool, defaultVendor, d
 				if strings.EqualFold(groupItems.Group, defaultVendor) && strings.EqualFold(item, defaultModel) {
 					mark = "      *"
 				}
-				fmt.Printf("%s\t[%d]\t%s|%s\n", mark, currentItemIndex, groupItems.Group, item)
+				if describe != nil {
+					fmt.Printf("%s\t[%d]\t%s|%s\t%s\n", mark, currentItemIndex, groupItems.Group, item, describe(groupItems.Group, item))
+				} else {
+					fmt.Printf("%s\t[%d]\t%s|%s\n", mark, currentItemIndex, groupItems.Group, item)
+				}
 			}
 		}
 	}
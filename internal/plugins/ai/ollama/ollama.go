@@ -113,13 +113,17 @@ func (o *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 		return
 	}
 
+	// Reasoning models either send thinking separately or inline in think tags
+	splitter := domain.NewThinkTagSplitter(opts.ThinkStartTag, opts.ThinkEndTag)
 	respFunc := func(resp ollamaapi.ChatResponse) (streamErr error) {
-		channel <- domain.StreamUpdate{
-			Type:    domain.StreamTypeContent,
-			Content: resp.Message.Content,
-		}
+		domain.SendStreamParts(channel, resp.Message.Thinking, "")
+		reasoning, content := splitter.Write(resp.Message.Content)
+		domain.SendStreamParts(channel, reasoning, content)
 
 		if resp.Done {
+			reasoning, content = splitter.Flush()
+			domain.SendStreamParts(channel, reasoning, content)
+
 			channel <- domain.StreamUpdate{
 				Type: domain.StreamTypeUsage,
 				Usage: &domain.UsageMetadata{
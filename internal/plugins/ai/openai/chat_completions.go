@@ -120,7 +120,7 @@ func (o *Client) convertChatMessage(msg chat.ChatCompletionMessage) openai.ChatC
 	case chat.ChatMessageRoleSystem:
 		return openai.SystemMessage(result.Content)
 	case chat.ChatMessageRoleUser:
-		// Handle multi-content messages (text + images)
+		// Handle multi-content messages (text, images, files and audio)
 		if result.HasMultiContent {
 			var parts []openai.ChatCompletionContentPartUnionParam
 			for _, p := range result.MultiContent {
@@ -129,6 +129,20 @@ func (o *Client) convertChatMessage(msg chat.ChatCompletionMessage) openai.ChatC
 					parts = append(parts, openai.TextContentPart(p.Text))
 				case chat.ChatMessagePartTypeImageURL:
 					parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: p.ImageURL.URL}))
+				case chat.ChatMessagePartTypeFile:
+					if p.File != nil && p.File.Data != "" {
+						parts = append(parts, openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+							Filename: openai.String(p.File.Filename),
+							FileData: openai.String(fileDataURL(p.File)),
+						}))
+					}
+				case chat.ChatMessagePartTypeInputAudio:
+					if p.InputAudio != nil {
+						parts = append(parts, openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+							Data:   p.InputAudio.Data,
+							Format: p.InputAudio.Format,
+						}))
+					}
 				}
 			}
 			return openai.UserMessage(parts)
@@ -19,3 +19,8 @@ func convertMessageCommon(msg chat.ChatCompletionMessage) MessageConversionResul
 		HasMultiContent: len(msg.MultiContent) > 0,
 	}
 }
+
+// fileDataURL returns the inline data of a file part as the data URL OpenAI expects in file_data.
+func fileDataURL(file *chat.ChatMessageFile) string {
+	return "data:" + file.MimeType + ";base64," + file.Data
+}
@@ -2,10 +2,10 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
-	"slices"
 	"strings"
 	"time"
 
@@ -14,6 +14,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	openai "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/packages/pagination"
@@ -101,7 +102,7 @@ func (o *Client) SetEnableXSearch(enabled bool) {
 func checkImageGenerationCompatibility(model string) {
 	if !supportsImageGeneration(model) {
 		fmt.Fprintf(os.Stderr, "%s", fmt.Sprintf(i18n.T("openai_warning_model_no_image_generation"),
-			model, strings.Join(ImageGenerationSupportedModels, ", ")))
+			model, strings.Join(ImageGenerationSupportedModels(), ", ")))
 	}
 }
 
@@ -140,8 +141,8 @@ func (o *Client) ListModels(ctx context.Context) (ret []string, err error) {
 func (o *Client) SendStream(
 	ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions, channel chan domain.StreamUpdate,
 ) (err error) {
-	// Use Responses API for OpenAI, Chat Completions API for other providers
-	if o.supportsResponsesAPI() {
+	// Use Responses API for OpenAI, Chat Completions API for other providers and for audio input
+	if o.supportsResponsesAPI() && !HasInputAudio(msgs) {
 		return o.sendStreamResponses(ctx, msgs, opts, channel)
 	}
 	return o.sendStreamChatCompletions(ctx, msgs, opts, channel)
@@ -153,7 +154,16 @@ func (o *Client) sendStreamResponses(
 	defer close(channel)
 
 	req := o.buildResponseParams(msgs, opts)
+	// Ask for a reasoning summary so it can be streamed separately from the answer
+	if req.Reasoning.Effort != "" && !opts.SuppressThink {
+		req.Reasoning.Summary = shared.ReasoningSummaryAuto
+	}
 	stream := o.ApiClient.Responses.NewStreaming(ctx, req)
+	if req.Reasoning.Summary != "" && reasoningSummaryRejected(stream.Err()) {
+		debuglog.Debug(debuglog.Basic, "Reasoning summary rejected for %s, retrying without it\n", opts.Model)
+		req.Reasoning.Summary = ""
+		stream = o.ApiClient.Responses.NewStreaming(ctx, req)
+	}
 	for stream.Next() {
 		event := stream.Current()
 		switch event.Type {
@@ -162,12 +172,31 @@ func (o *Client) sendStreamResponses(
 				Type:    domain.StreamTypeContent,
 				Content: event.AsResponseOutputTextDelta().Delta,
 			}
+		case string(constant.ResponseReasoningSummaryTextDelta("").Default()):
+			channel <- domain.StreamUpdate{
+				Type:    domain.StreamTypeReasoning,
+				Content: event.AsResponseReasoningSummaryTextDelta().Delta,
+			}
+		case string(constant.ResponseReasoningSummaryTextDone("").Default()):
+			// Separate consecutive reasoning summary parts
+			channel <- domain.StreamUpdate{
+				Type:    domain.StreamTypeReasoning,
+				Content: "\n\n",
+			}
 		case string(constant.ResponseOutputTextDone("").Default()):
 			// The Responses API sends the full text again in the
 			// final "done" event. Since we've already streamed all
 			// delta chunks above, sending it would duplicate the
 			// output. Ignore it here to prevent doubled results.
 			continue
+		case "response.completed":
+			resp := event.AsResponseCompleted().Response
+			if _, citations := o.extractText(&resp); len(citations) > 0 {
+				channel <- domain.StreamUpdate{
+					Type:      domain.StreamTypeCitation,
+					Citations: citations,
+				}
+			}
 		}
 	}
 	if stream.Err() == nil {
@@ -179,15 +208,31 @@ func (o *Client) sendStreamResponses(
 	return stream.Err()
 }
 
+// reasoningSummaryRejected tells whether the API refused the reasoning summary, as it
+// does for organizations that are not verified.
+func reasoningSummaryRejected(err error) bool {
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && apiErr.Param == "reasoning.summary"
+}
+
 func (o *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (ret string, err error) {
-	// Use Responses API for OpenAI, Chat Completions API for other providers
-	if o.supportsResponsesAPI() {
+	ret, _, err = o.SendWithCitations(ctx, msgs, opts)
+	return
+}
+
+// SendWithCitations returns the answer text and, for the Responses API, the URL citations it carries.
+func (o *Client) SendWithCitations(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	ret string, citations []chat.Citation, err error) {
+	// Use Responses API for OpenAI, Chat Completions API for other providers and for audio input
+	if o.supportsResponsesAPI() && !HasInputAudio(msgs) {
 		return o.sendResponses(ctx, msgs, opts)
 	}
-	return o.sendChatCompletions(ctx, msgs, opts)
+	ret, err = o.sendChatCompletions(ctx, msgs, opts)
+	return
 }
 
-func (o *Client) sendResponses(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (ret string, err error) {
+func (o *Client) sendResponses(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	ret string, citations []chat.Citation, err error) {
 	// Warn if model doesn't support image generation when image file is specified
 	if opts.ImageFile != "" {
 		checkImageGenerationCompatibility(opts.Model)
@@ -195,7 +240,7 @@ func (o *Client) sendResponses(ctx context.Context, msgs []*chat.ChatCompletionM
 
 	// Validate model supports image generation if image file is specified
 	if opts.ImageFile != "" && !supportsImageGeneration(opts.Model) {
-		return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_model_no_image_generation"), opts.Model, strings.Join(ImageGenerationSupportedModels, ", ")))
+		return "", nil, fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_model_no_image_generation"), opts.Model, strings.Join(ImageGenerationSupportedModels(), ", ")))
 	}
 
 	req := o.buildResponseParams(msgs, opts)
@@ -210,35 +255,30 @@ func (o *Client) sendResponses(ctx context.Context, msgs []*chat.ChatCompletionM
 		return
 	}
 
-	ret = o.extractText(resp)
+	ret, citations = o.extractText(resp)
 	return
 }
 
+// HasInputAudio tells whether a message has an audio part. The Responses API takes no
+// audio input, so such requests need Chat Completions.
+func HasInputAudio(msgs []*chat.ChatCompletionMessage) bool {
+	for _, msg := range msgs {
+		for _, part := range msg.MultiContent {
+			if part.Type == chat.ChatMessagePartTypeInputAudio {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // supportsResponsesAPI determines if the provider supports the new Responses API
 func (o *Client) supportsResponsesAPI() bool {
 	return o.ImplementsResponses
 }
 
 func (o *Client) NeedsRawMode(modelName string) bool {
-	openaiModelsPrefixes := []string{
-		"glm",
-		"gpt-5",
-		"o1",
-		"o3",
-		"o4",
-	}
-	openAIModelsNeedingRaw := []string{
-		"gpt-4o-mini-search-preview",
-		"gpt-4o-mini-search-preview-2025-03-11",
-		"gpt-4o-search-preview",
-		"gpt-4o-search-preview-2025-03-11",
-	}
-	for _, prefix := range openaiModelsPrefixes {
-		if strings.HasPrefix(modelName, prefix) {
-			return true
-		}
-	}
-	return slices.Contains(openAIModelsNeedingRaw, modelName)
+	return ai.LookupCapabilities(o.GetName(), modelName).RawMode.IsSupported()
 }
 
 func parseReasoningEffort(level domain.ThinkingLevel) (shared.ReasoningEffort, bool) {
@@ -375,6 +415,17 @@ func convertMessage(msg chat.ChatCompletionMessage) responses.ResponseInputItemU
 					part.OfInputImage.ImageURL = openai.String(p.ImageURL.URL)
 				}
 				parts = append(parts, part)
+			case chat.ChatMessagePartTypeFile:
+				if p.File == nil {
+					continue
+				}
+				file := responses.ResponseInputFileParam{Filename: openai.String(p.File.Filename)}
+				if p.File.Data != "" {
+					file.FileData = openai.String(fileDataURL(p.File))
+				} else {
+					file.FileURL = openai.String(p.File.URL)
+				}
+				parts = append(parts, responses.ResponseInputContentUnionParam{OfInputFile: &file})
 			}
 		}
 		contentList := responses.ResponseInputMessageContentListParam(parts)
@@ -383,11 +434,10 @@ func convertMessage(msg chat.ChatCompletionMessage) responses.ResponseInputItemU
 	return responses.ResponseInputItemParamOfMessage(result.Content, role)
 }
 
-func (o *Client) extractText(resp *responses.Response) (ret string) {
+// extractText joins the output text of the first message and collects its URL citations.
+// The span of a citation is the part of the text its annotation indexes.
+func (o *Client) extractText(resp *responses.Response) (ret string, citations []chat.Citation) {
 	var textParts []string
-	var citations []string
-	citationMap := make(map[string]bool) // To avoid duplicate citations
-
 	for _, item := range resp.Output {
 		if item.Type == "message" {
 			for _, c := range item.Content {
@@ -399,12 +449,11 @@ func (o *Client) extractText(resp *responses.Response) (ret string) {
 					for _, annotation := range outputText.Annotations {
 						if annotation.Type == "url_citation" {
 							urlCitation := annotation.AsURLCitation()
-							citationKey := urlCitation.URL + "|" + urlCitation.Title
-							if !citationMap[citationKey] {
-								citationMap[citationKey] = true
-								citationText := fmt.Sprintf("- [%s](%s)", urlCitation.Title, urlCitation.URL)
-								citations = append(citations, citationText)
-							}
+							citations = domain.AppendCitations(citations, chat.Citation{
+								URL:   urlCitation.URL,
+								Title: urlCitation.Title,
+								Span:  annotatedSpan(outputText.Text, urlCitation.StartIndex, urlCitation.EndIndex),
+							})
 						}
 					}
 				}
@@ -414,17 +463,21 @@ func (o *Client) extractText(resp *responses.Response) (ret string) {
 	}
 
 	ret = strings.Join(textParts, "")
+	return
+}
 
-	// Append citations if any were found
-	if len(citations) > 0 {
-		ret += "\n\n## Sources\n\n" + strings.Join(citations, "\n")
+// annotatedSpan returns the characters of text between start and end, or "" when the
+// indexes do not describe a span of text.
+func annotatedSpan(text string, start, end int64) string {
+	runes := []rune(text)
+	if start < 0 || end <= start || end > int64(len(runes)) {
+		return ""
 	}
-
-	return
+	return string(runes[start:end])
 }
 
 // ExtractText exposes the shared Responses API text extraction logic so other
 // vendors can reuse Fabric's response formatting and citation handling.
-func (o *Client) ExtractText(resp *responses.Response) string {
+func (o *Client) ExtractText(resp *responses.Response) (string, []chat.Citation) {
 	return o.extractText(resp)
 }
@@ -3,6 +3,8 @@ package openai
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -10,30 +12,46 @@ import (
 	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 
 	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared/constant"
 )
 
 // transcriptionResult holds the result of a single chunk transcription.
 type transcriptionResult struct {
-	index int
-	text  string
-	err   error
+	index      int
+	transcript *domain.Transcript
+	err        error
+}
+
+// audioChunk is a part of the file to transcribe and where it starts in that file, in seconds.
+type audioChunk struct {
+	path  string
+	start float64
 }
 
 // MaxAudioFileSize defines the maximum allowed size for audio uploads (25MB).
 const MaxAudioFileSize int64 = 25 * 1024 * 1024
 
+// TranscriptionModelDiarize tells speakers apart. The SDK has no constant for it yet.
+const TranscriptionModelDiarize = "gpt-4o-transcribe-diarize"
+
+// audioResponseFormatDiarizedJSON returns segments with speaker labels from the diarization model.
+const audioResponseFormatDiarizedJSON openai.AudioResponseFormat = "diarized_json"
+
 // AllowedTranscriptionModels lists the models supported for transcription.
 var AllowedTranscriptionModels = []string{
 	string(openai.AudioModelWhisper1),
 	string(openai.AudioModelGPT4oMiniTranscribe),
 	string(openai.AudioModelGPT4oTranscribe),
+	TranscriptionModelDiarize,
 }
 
 // allowedAudioExtensions defines the supported input file extensions.
@@ -47,78 +65,72 @@ var allowedAudioExtensions = map[string]struct{}{
 	".webm": {},
 }
 
-// TranscribeFile transcribes the given audio file using the specified model. If the file
-// exceeds the size limit, it can optionally be split into chunks using ffmpeg.
-func (o *Client) TranscribeFile(ctx context.Context, filePath, model string, split bool) (string, error) {
+// Transcribe transcribes the given audio file using opts.Model. If the file exceeds the
+// size limit, it can optionally be split into chunks using ffmpeg. With opts.Timestamps,
+// whisper-1 returns timed segments; the diarization model always returns timed segments
+// with speaker labels. Segments of split files are shifted to their place in the file.
+// Invalid requests are classified as such, so that callers can tell them from failures.
+func (o *Client) Transcribe(ctx context.Context, filePath string, opts *domain.TranscriptionOptions) (*domain.Transcript, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	model := opts.Model
 
 	if !slices.Contains(AllowedTranscriptionModels, model) {
-		return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_model_not_supported_for_transcription"), model))
+		return nil, domain.WithCode(domain.ErrorCodeUnsupported,
+			fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_model_not_supported_for_transcription"), model)))
 	}
 
 	ext := strings.ToLower(filepath.Ext(filePath))
 	if _, ok := allowedAudioExtensions[ext]; !ok {
-		return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_unsupported_audio_format"), ext))
+		return nil, domain.WithCode(domain.ErrorCodeUnsupported,
+			fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_unsupported_audio_format"), ext)))
 	}
 
 	info, err := os.Stat(filePath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var files []string
+	var chunks []audioChunk
 	var cleanup func()
 	if info.Size() > MaxAudioFileSize {
-		if !split {
-			return "", fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_file_exceeds_limit_enable_split"), filePath))
+		if !opts.Split {
+			return nil, domain.WithCode(domain.ErrorCodeInvalidRequest,
+				fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_file_exceeds_limit_enable_split"), filePath)))
 		}
 		debuglog.Log("%s\n", fmt.Sprintf(i18n.T("openai_audio_file_exceeds_limit_splitting"), filePath))
-		if files, cleanup, err = splitAudioFile(filePath, ext, MaxAudioFileSize); err != nil {
-			return "", err
+		chunks, cleanup, err = splitAudioFile(filePath, ext, MaxAudioFileSize)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		if err != nil {
+			return nil, err
 		}
-		defer cleanup()
 	} else {
-		files = []string{filePath}
+		chunks = []audioChunk{{path: filePath}}
 	}
 
-	resultsChan := make(chan transcriptionResult, len(files))
+	resultsChan := make(chan transcriptionResult, len(chunks))
 	var wg sync.WaitGroup
 
-	for i, f := range files {
+	for i, c := range chunks {
 		wg.Add(1)
 		go func(index int, filePath string) {
 			defer wg.Done()
 			debuglog.Log("%s\n", fmt.Sprintf(i18n.T("openai_audio_using_model_to_transcribe_part"), model, index+1, filePath))
-
-			chunk, openErr := os.Open(filePath)
-			if openErr != nil {
-				resultsChan <- transcriptionResult{index: index, err: openErr}
-				return
-			}
-			defer chunk.Close()
-
-			params := openai.AudioTranscriptionNewParams{
-				File:  chunk,
-				Model: openai.AudioModel(model),
-			}
-			resp, transcribeErr := o.ApiClient.Audio.Transcriptions.New(ctx, params)
-			if transcribeErr != nil {
-				resultsChan <- transcriptionResult{index: index, err: transcribeErr}
-				return
-			}
-			resultsChan <- transcriptionResult{index: index, text: resp.Text}
-		}(i, f)
+			transcript, transcribeErr := o.transcribeChunk(ctx, filePath, opts)
+			resultsChan <- transcriptionResult{index: index, transcript: transcript, err: transcribeErr}
+		}(i, c.path)
 	}
 
 	wg.Wait()
 	close(resultsChan)
 
-	results := make([]transcriptionResult, 0, len(files))
+	results := make([]transcriptionResult, 0, len(chunks))
 	for result := range resultsChan {
 		if result.err != nil {
-			return "", result.err
+			return nil, result.err
 		}
 		results = append(results, result)
 	}
@@ -127,20 +139,73 @@ func (o *Client) TranscribeFile(ctx context.Context, filePath, model string, spl
 		return results[i].index < results[j].index
 	})
 
-	var builder strings.Builder
-	for i, result := range results {
-		if i > 0 {
-			builder.WriteString(" ")
+	return mergeTranscripts(chunks, results), nil
+}
+
+// transcribeChunk transcribes one file of at most MaxAudioFileSize.
+func (o *Client) transcribeChunk(ctx context.Context, filePath string, opts *domain.TranscriptionOptions) (ret *domain.Transcript, err error) {
+	var chunk *os.File
+	if chunk, err = os.Open(filePath); err != nil {
+		return
+	}
+	defer chunk.Close()
+
+	params := openai.AudioTranscriptionNewParams{
+		File:  chunk,
+		Model: openai.AudioModel(opts.Model),
+	}
+	switch {
+	case opts.Model == TranscriptionModelDiarize:
+		params.ResponseFormat = audioResponseFormatDiarizedJSON
+		// Inputs longer than 30 seconds must be chunked by the server
+		params.ChunkingStrategy = openai.AudioTranscriptionNewParamsChunkingStrategyUnion{OfAuto: constant.ValueOf[constant.Auto]()}
+	case opts.Timestamps && opts.Model == string(openai.AudioModelWhisper1):
+		params.ResponseFormat = openai.AudioResponseFormatVerboseJSON
+		params.TimestampGranularities = []string{"segment"}
+	}
+
+	var resp *openai.Transcription
+	if resp, err = o.ApiClient.Audio.Transcriptions.New(ctx, params); err != nil {
+		return
+	}
+	ret = &domain.Transcript{Text: resp.Text}
+	if params.ResponseFormat != "" {
+		// The verbose and diarized responses carry the segments the SDK does not model
+		if err = json.Unmarshal([]byte(resp.RawJSON()), ret); err != nil {
+			return
 		}
-		builder.WriteString(result.text)
 	}
+	return
+}
 
-	return builder.String(), nil
+// mergeTranscripts joins the transcripts of consecutive chunks, shifting the segments of
+// each by the time its chunk starts at.
+func mergeTranscripts(chunks []audioChunk, results []transcriptionResult) (ret *domain.Transcript) {
+	ret = &domain.Transcript{}
+	texts := make([]string, 0, len(results))
+	for _, result := range results {
+		transcript := result.transcript
+		start := chunks[result.index].start
+		texts = append(texts, transcript.Text)
+		if ret.Language == "" {
+			ret.Language = transcript.Language
+		}
+		if transcript.Duration > 0 {
+			ret.Duration = start + transcript.Duration
+		}
+		for _, segment := range transcript.Segments {
+			segment.Start += start
+			segment.End += start
+			ret.Segments = append(ret.Segments, segment)
+		}
+	}
+	ret.Text = strings.Join(texts, " ")
+	return
 }
 
 // splitAudioFile splits the source file into chunks smaller than maxSize using ffmpeg.
-// It returns the list of chunk file paths and a cleanup function.
-func splitAudioFile(src, ext string, maxSize int64) (files []string, cleanup func(), err error) {
+// It returns the chunks with the times they start at and a cleanup function.
+func splitAudioFile(src, ext string, maxSize int64) (chunks []audioChunk, cleanup func(), err error) {
 	if _, err = exec.LookPath("ffmpeg"); err != nil {
 		return nil, nil, errors.New(i18n.T("openai_audio_ffmpeg_not_found_install"))
 	}
@@ -154,23 +219,25 @@ func splitAudioFile(src, ext string, maxSize int64) (files []string, cleanup fun
 	segmentTime := 600 // start with 10 minutes
 	for {
 		pattern := filepath.Join(dir, "chunk-%03d"+ext)
+		// The segment list records where each chunk starts, since copying cuts at frame boundaries
+		list := filepath.Join(dir, "chunks.csv")
 		debuglog.Log("%s\n", fmt.Sprintf(i18n.T("openai_audio_running_ffmpeg_split_chunks"), segmentTime))
-		cmd := exec.Command("ffmpeg", "-y", "-i", src, "-f", "segment", "-segment_time", fmt.Sprintf("%d", segmentTime), "-c", "copy", pattern)
+		cmd := exec.Command("ffmpeg", "-y", "-i", src, "-f", "segment", "-segment_time", fmt.Sprintf("%d", segmentTime),
+			"-segment_list", list, "-segment_list_type", "csv", "-c", "copy", pattern)
 		var stderr bytes.Buffer
 		cmd.Stderr = &stderr
 		if err = cmd.Run(); err != nil {
 			return nil, cleanup, fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_audio_ffmpeg_failed"), err, stderr.String()))
 		}
 
-		if files, err = filepath.Glob(filepath.Join(dir, "chunk-*"+ext)); err != nil {
+		if chunks, err = readSegmentList(list); err != nil {
 			return nil, cleanup, err
 		}
-		sort.Strings(files)
 
 		tooBig := false
-		for _, f := range files {
+		for _, c := range chunks {
 			var info os.FileInfo
-			if info, err = os.Stat(f); err != nil {
+			if info, err = os.Stat(c.path); err != nil {
 				return nil, cleanup, err
 			}
 			if info.Size() > maxSize {
@@ -179,10 +246,10 @@ func splitAudioFile(src, ext string, maxSize int64) (files []string, cleanup fun
 			}
 		}
 		if !tooBig {
-			return files, cleanup, nil
+			return chunks, cleanup, nil
 		}
-		for _, f := range files {
-			_ = os.Remove(f)
+		for _, c := range chunks {
+			_ = os.Remove(c.path)
 		}
 		if segmentTime <= 1 {
 			return nil, cleanup, errors.New(i18n.T("openai_audio_unable_to_split_acceptable_size_chunks"))
@@ -190,3 +257,29 @@ func splitAudioFile(src, ext string, maxSize int64) (files []string, cleanup fun
 		segmentTime /= 2
 	}
 }
+
+// readSegmentList reads the chunks from the CSV segment list ffmpeg writes: one line per
+// chunk with its file name, start and end time. File names are relative to the list.
+func readSegmentList(list string) (ret []audioChunk, err error) {
+	var file *os.File
+	if file, err = os.Open(list); err != nil {
+		return
+	}
+	defer file.Close()
+
+	var records [][]string
+	if records, err = csv.NewReader(file).ReadAll(); err != nil {
+		return
+	}
+	for _, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		var start float64
+		if start, err = strconv.ParseFloat(record[1], 64); err != nil {
+			return
+		}
+		ret = append(ret, audioChunk{path: filepath.Join(filepath.Dir(list), record[0]), start: start})
+	}
+	return
+}
@@ -3,16 +3,21 @@ package openai
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestTranscribeFile_ValidationErrorsAreLocalized(t *testing.T) {
+func TestTranscribe_ValidationErrorsAreLocalized(t *testing.T) {
 	_, err := i18n.Init("en")
 	require.NoError(t, err)
 
@@ -23,19 +28,20 @@ func TestTranscribeFile_ValidationErrorsAreLocalized(t *testing.T) {
 	require.NoError(t, audioFile.Close())
 	t.Cleanup(func() { _ = os.Remove(audioFile.Name()) })
 
-	_, err = client.TranscribeFile(context.Background(), audioFile.Name(), "not-a-model", false)
+	_, err = client.Transcribe(context.Background(), audioFile.Name(), &domain.TranscriptionOptions{Model: "not-a-model"})
 	require.Error(t, err)
 	assert.Equal(t,
 		fmt.Sprintf(i18n.T("openai_audio_model_not_supported_for_transcription"), "not-a-model"),
 		err.Error(),
 	)
+	assert.Equal(t, domain.ErrorCodeUnsupported, domain.CodeOf(err))
 
 	unsupportedFile, err := os.CreateTemp("", "transcribe-invalid-*.txt")
 	require.NoError(t, err)
 	require.NoError(t, unsupportedFile.Close())
 	t.Cleanup(func() { _ = os.Remove(unsupportedFile.Name()) })
 
-	_, err = client.TranscribeFile(context.Background(), unsupportedFile.Name(), AllowedTranscriptionModels[0], false)
+	_, err = client.Transcribe(context.Background(), unsupportedFile.Name(), &domain.TranscriptionOptions{Model: AllowedTranscriptionModels[0]})
 	require.Error(t, err)
 	assert.Equal(t,
 		fmt.Sprintf(i18n.T("openai_audio_unsupported_audio_format"), filepath.Ext(unsupportedFile.Name())),
@@ -43,7 +49,7 @@ func TestTranscribeFile_ValidationErrorsAreLocalized(t *testing.T) {
 	)
 }
 
-func TestTranscribeFile_FileSizeLimitErrorIsLocalized(t *testing.T) {
+func TestTranscribe_FileSizeLimitErrorIsLocalized(t *testing.T) {
 	_, err := i18n.Init("en")
 	require.NoError(t, err)
 
@@ -55,10 +61,99 @@ func TestTranscribeFile_FileSizeLimitErrorIsLocalized(t *testing.T) {
 	require.NoError(t, largeFile.Truncate(MaxAudioFileSize+1))
 	require.NoError(t, largeFile.Close())
 
-	_, err = client.TranscribeFile(context.Background(), largeFile.Name(), AllowedTranscriptionModels[0], false)
+	_, err = client.Transcribe(context.Background(), largeFile.Name(), &domain.TranscriptionOptions{Model: AllowedTranscriptionModels[0]})
 	require.Error(t, err)
 	assert.Equal(t,
 		fmt.Sprintf(i18n.T("openai_audio_file_exceeds_limit_enable_split"), largeFile.Name()),
 		err.Error(),
 	)
+	assert.Equal(t, domain.ErrorCodeInvalidRequest, domain.CodeOf(err))
+}
+
+func TestTranscribe_SplitFailureRemovesTempDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg is a shell script")
+	}
+	_, err := i18n.Init("en")
+	require.NoError(t, err)
+
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("#!/bin/sh\nexit 1\n"), 0o755))
+	t.Setenv("PATH", binDir)
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	largeFile := filepath.Join(t.TempDir(), "talk.mp3")
+	require.NoError(t, os.WriteFile(largeFile, nil, 0o644))
+	require.NoError(t, os.Truncate(largeFile, MaxAudioFileSize+1))
+
+	client := &Client{}
+	_, err = client.Transcribe(context.Background(), largeFile,
+		&domain.TranscriptionOptions{Model: AllowedTranscriptionModels[0], Split: true})
+	require.Error(t, err)
+
+	leftovers, err := filepath.Glob(filepath.Join(tmpDir, "fabric-audio-*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestTranscribe_RequestsTimestampsAndParsesSegments(t *testing.T) {
+	var responseFormat, granularity string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audio/transcriptions", r.URL.Path)
+		responseFormat = r.FormValue("response_format")
+		granularity = r.FormValue("timestamp_granularities[]")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"text":"Hello there.","language":"english","duration":2.5,`+
+			`"segments":[{"id":0,"start":0.0,"end":2.5,"text":" Hello there.","tokens":[1,2]}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	require.NoError(t, client.configure())
+
+	audioFile := filepath.Join(t.TempDir(), "talk.mp3")
+	require.NoError(t, os.WriteFile(audioFile, []byte("audio"), 0644))
+
+	transcript, err := client.Transcribe(context.Background(), audioFile,
+		&domain.TranscriptionOptions{Model: "whisper-1", Timestamps: true})
+	require.NoError(t, err)
+	assert.Equal(t, "verbose_json", responseFormat)
+	assert.Equal(t, "segment", granularity)
+	assert.Equal(t, "Hello there.", transcript.Text)
+	assert.Equal(t, "english", transcript.Language)
+	require.Len(t, transcript.Segments, 1)
+	assert.Equal(t, 2.5, transcript.Segments[0].End)
+}
+
+func TestMergeTranscripts_ShiftsSegmentsByChunkStart(t *testing.T) {
+	chunks := []audioChunk{{path: "chunk-000.mp3"}, {path: "chunk-001.mp3", start: 600.5}}
+	results := []transcriptionResult{
+		{index: 0, transcript: &domain.Transcript{Text: "One.", Duration: 600.5,
+			Segments: []domain.TranscriptSegment{{Start: 0, End: 2, Text: "One.", Speaker: "A"}}}},
+		{index: 1, transcript: &domain.Transcript{Text: "Two.", Duration: 10,
+			Segments: []domain.TranscriptSegment{{Start: 1, End: 3, Text: "Two.", Speaker: "B"}}}},
+	}
+
+	merged := mergeTranscripts(chunks, results)
+	assert.Equal(t, "One. Two.", merged.Text)
+	assert.Equal(t, 610.5, merged.Duration)
+	require.Len(t, merged.Segments, 2)
+	assert.Equal(t, 601.5, merged.Segments[1].Start)
+	assert.Equal(t, 603.5, merged.Segments[1].End)
+	assert.Equal(t, "B", merged.Segments[1].Speaker)
+}
+
+func TestReadSegmentList(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "chunks.csv")
+	require.NoError(t, os.WriteFile(list, []byte("chunk-000.mp3,0.000000,600.024000\nchunk-001.mp3,600.024000,812.100000\n"), 0644))
+
+	chunks, err := readSegmentList(list)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, filepath.Join(dir, "chunk-001.mp3"), chunks[1].path)
+	assert.Equal(t, 600.024, chunks[1].start)
 }
@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	openai "github.com/openai/openai-go"
+)
+
+// batchInputLine is one line of the JSONL file uploaded for the Batch API.
+type batchInputLine struct {
+	CustomID string                         `json:"custom_id"`
+	Method   string                         `json:"method"`
+	URL      string                         `json:"url"`
+	Body     openai.ChatCompletionNewParams `json:"body"`
+}
+
+// batchOutputLine is one line of a Batch API output or error file.
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SubmitBatch uploads the requests as a JSONL file and creates a Chat Completions batch.
+func (o *Client) SubmitBatch(ctx context.Context, requests []ai.BatchRequest, opts *domain.ChatOptions) (batchID string, err error) {
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, request := range requests {
+		line := batchInputLine{
+			CustomID: request.ID,
+			Method:   "POST",
+			URL:      string(openai.BatchNewParamsEndpointV1ChatCompletions),
+			Body:     o.buildChatCompletionParams(request.Messages, opts),
+		}
+		if err = encoder.Encode(line); err != nil {
+			return
+		}
+	}
+
+	var file *openai.FileObject
+	if file, err = o.ApiClient.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(&input, "fabric-batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	}); err != nil {
+		return
+	}
+	debuglog.Debug(debuglog.Detailed, "Uploaded batch input file %s (%d requests)\n", file.ID, len(requests))
+
+	var batch *openai.Batch
+	if batch, err = o.ApiClient.Batches.New(ctx, openai.BatchNewParams{
+		InputFileID:      file.ID,
+		Endpoint:         openai.BatchNewParamsEndpointV1ChatCompletions,
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+	}); err != nil {
+		return
+	}
+	batchID = batch.ID
+	return
+}
+
+// GetBatchStatus returns the provider status and request counts of a batch.
+func (o *Client) GetBatchStatus(ctx context.Context, batchID string) (ret *ai.BatchStatus, err error) {
+	var batch *openai.Batch
+	if batch, err = o.ApiClient.Batches.Get(ctx, batchID); err != nil {
+		return
+	}
+	ret = toBatchStatus(batch)
+	return
+}
+
+// FetchBatchResults downloads the output and error files of a finished batch.
+func (o *Client) FetchBatchResults(ctx context.Context, batchID string) (ret []ai.BatchResult, err error) {
+	var batch *openai.Batch
+	if batch, err = o.ApiClient.Batches.Get(ctx, batchID); err != nil {
+		return
+	}
+	if !toBatchStatus(batch).Done {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_not_finished"), batchID, batch.Status))
+		return
+	}
+	if batch.Status == openai.BatchStatusFailed && len(batch.Errors.Data) > 0 {
+		messages := make([]string, 0, len(batch.Errors.Data))
+		for _, batchErr := range batch.Errors.Data {
+			messages = append(messages, batchErr.Message)
+		}
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_failed"), batchID, strings.Join(messages, "; ")))
+		return
+	}
+
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		var results []ai.BatchResult
+		if results, err = o.readBatchFile(ctx, fileID); err != nil {
+			return
+		}
+		ret = append(ret, results...)
+	}
+	return
+}
+
+func (o *Client) readBatchFile(ctx context.Context, fileID string) (ret []ai.BatchResult, err error) {
+	var resp *http.Response
+	if resp, err = o.ApiClient.Files.Content(ctx, fileID); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	return parseBatchOutput(resp.Body)
+}
+
+// parseBatchOutput converts Batch API output lines into results keyed by custom ID.
+func parseBatchOutput(reader io.Reader) (ret []ai.BatchResult, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var line batchOutputLine
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return
+		}
+
+		result := ai.BatchResult{ID: line.CustomID}
+		switch {
+		case line.Error != nil:
+			result.Error = line.Error.Message
+		case line.Response == nil:
+			result.Error = i18n.T("batch_result_missing_response")
+		case line.Response.StatusCode != 200:
+			result.Error = fmt.Sprintf("HTTP %d: %s", line.Response.StatusCode, string(line.Response.Body))
+		default:
+			var completion openai.ChatCompletion
+			if err = json.Unmarshal(line.Response.Body, &completion); err != nil {
+				return
+			}
+			if len(completion.Choices) > 0 {
+				result.Content = completion.Choices[0].Message.Content
+			}
+		}
+		ret = append(ret, result)
+	}
+	err = scanner.Err()
+	return
+}
+
+func toBatchStatus(batch *openai.Batch) *ai.BatchStatus {
+	var done bool
+	switch batch.Status {
+	case openai.BatchStatusCompleted, openai.BatchStatusFailed, openai.BatchStatusExpired, openai.BatchStatusCancelled:
+		done = true
+	}
+	return &ai.BatchStatus{
+		ID:        batch.ID,
+		Status:    string(batch.Status),
+		Total:     batch.RequestCounts.Total,
+		Completed: batch.RequestCounts.Completed,
+		Failed:    batch.RequestCounts.Failed,
+		Done:      done,
+	}
+}
@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchStandIn serves the Files and Batches endpoints used by the Batch API flow.
+func newBatchStandIn(t *testing.T, uploaded *[]map[string]any) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /files", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "batch", r.FormValue("purpose"))
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			*uploaded = append(*uploaded, line)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"file-in","object":"file","purpose":"batch","filename":"fabric-batch.jsonl","bytes":1,"created_at":1,"status":"processed"}`)
+	})
+	mux.HandleFunc("POST /batches", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "file-in", body["input_file_id"])
+		assert.Equal(t, "/v1/chat/completions", body["endpoint"])
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"batch_1","object":"batch","status":"validating","endpoint":"/v1/chat/completions","input_file_id":"file-in","completion_window":"24h","created_at":1}`)
+	})
+	mux.HandleFunc("GET /batches/batch_1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"batch_1","object":"batch","status":"completed","endpoint":"/v1/chat/completions","input_file_id":"file-in","completion_window":"24h","created_at":1,
+			"output_file_id":"file-out","error_file_id":"file-err","request_counts":{"total":3,"completed":2,"failed":1}}`)
+	})
+	mux.HandleFunc("GET /files/file-out/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"custom_id":"a","response":{"status_code":200,"body":{"id":"c1","object":"chat.completion","created":1,"model":"gpt-4o","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"first"}}]}},"error":null}
+{"custom_id":"b","response":{"status_code":200,"body":{"id":"c2","object":"chat.completion","created":1,"model":"gpt-4o","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"second"}}]}},"error":null}
+`)
+	})
+	mux.HandleFunc("GET /files/file-err/content", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"custom_id":"c","response":null,"error":{"code":"invalid_request","message":"bad input"}}`+"\n")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBatchSubmitStatusAndFetch(t *testing.T) {
+	var uploaded []map[string]any
+	srv := newBatchStandIn(t, &uploaded)
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	require.NoError(t, client.configure())
+
+	requests := []ai.BatchRequest{
+		{ID: "a", Messages: []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "one"}}},
+		{ID: "b", Messages: []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "two"}}},
+	}
+	batchID, err := client.SubmitBatch(context.Background(), requests, &domain.ChatOptions{Model: "gpt-4o", Temperature: 0.2})
+	require.NoError(t, err)
+	assert.Equal(t, "batch_1", batchID)
+
+	require.Len(t, uploaded, 2)
+	assert.Equal(t, "a", uploaded[0]["custom_id"])
+	assert.Equal(t, "/v1/chat/completions", uploaded[0]["url"])
+	body := uploaded[1]["body"].(map[string]any)
+	assert.Equal(t, "gpt-4o", body["model"])
+	assert.Equal(t, 0.2, body["temperature"])
+
+	status, err := client.GetBatchStatus(context.Background(), batchID)
+	require.NoError(t, err)
+	assert.True(t, status.Done)
+	assert.Equal(t, int64(3), status.Total)
+	assert.Equal(t, int64(1), status.Failed)
+
+	results, err := client.FetchBatchResults(context.Background(), batchID)
+	require.NoError(t, err)
+	assert.Equal(t, []ai.BatchResult{
+		{ID: "a", Content: "first"},
+		{ID: "b", Content: "second"},
+		{ID: "c", Error: "bad input"},
+	}, results)
+}
@@ -4,15 +4,20 @@ package openai
 // using OpenAI's Responses API and Image API.
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
-	"os"
+	"io"
+	"net/http"
 	"path/filepath"
-	"slices"
 	"strings"
 
+	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/domain"
 	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/packages/param"
 	"github.com/openai/openai-go/responses"
 )
@@ -21,18 +26,19 @@ import (
 const ImageGenerationResponseType = "image_generation_call"
 const ImageGenerationToolType = "image_generation"
 
-// ImageGenerationSupportedModels lists all models that support image generation
-var ImageGenerationSupportedModels = []string{
-	"gpt-4.1-nano",
-	"o3",
-	"gpt-5",
-	"gpt-5-nano",
-	"gpt-5.2",
+// ImageGenerationSupportedModels lists the models that support image generation, built-in
+// and from the user's capability overrides, which are loaded after startup.
+func ImageGenerationSupportedModels() []string {
+	return ai.Capabilities.ModelsWith(imageGenerationVendor,
+		func(capabilities ai.ModelCapabilities) bool { return capabilities.ImageGeneration.IsSupported() })
 }
 
+// imageGenerationVendor is the vendor whose capability rules describe the image_generation tool
+const imageGenerationVendor = "OpenAI"
+
 // supportsImageGeneration checks if the given model supports the image_generation tool
 func supportsImageGeneration(model string) bool {
-	return slices.Contains(ImageGenerationSupportedModels, model)
+	return ai.LookupCapabilities(imageGenerationVendor, model).ImageGeneration.IsSupported()
 }
 
 // getOutputFormatFromExtension determines the API output format based on file extension
@@ -108,35 +114,146 @@ func (o *Client) extractAndSaveImages(resp *responses.Response, opts *domain.Cha
 		return nil // No image file specified, skip saving
 	}
 
-	// Extract image data from response
+	images, err := responseImages(resp)
+	if err != nil || len(images) == 0 {
+		return err
+	}
+	saved, err := ai.SaveImages(images[:1], opts.ImageFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", fmt.Sprintf(i18n.T("image_saved_to"), saved[0]))
+	return nil
+}
+
+// responseImages decodes the images of the completed image generation calls of a response.
+func responseImages(resp *responses.Response) (ret []ai.ImageData, err error) {
 	for _, item := range resp.Output {
-		if item.Type == ImageGenerationResponseType {
-			imageCall := item.AsImageGenerationCall()
-			if imageCall.Status == "completed" && imageCall.Result != "" {
-				// Decode base64 image data
-				imageData, err := base64.StdEncoding.DecodeString(imageCall.Result)
-				if err != nil {
-					return fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_image_failed_to_decode_image_data"), err))
-				}
-
-				// Ensure directory exists
-				dir := filepath.Dir(opts.ImageFile)
-				if dir != "." {
-					if err := os.MkdirAll(dir, 0755); err != nil {
-						return fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_image_failed_to_create_directory"), dir, err))
-					}
-				}
-
-				// Save image to file
-				if err := os.WriteFile(opts.ImageFile, imageData, 0644); err != nil {
-					return fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_image_failed_to_save_image"), opts.ImageFile, err))
-				}
-
-				fmt.Printf("%s\n", fmt.Sprintf(i18n.T("openai_image_saved_to"), opts.ImageFile))
-				return nil
+		if item.Type != ImageGenerationResponseType {
+			continue
+		}
+		imageCall := item.AsImageGenerationCall()
+		if imageCall.Status != "completed" || imageCall.Result == "" {
+			continue
+		}
+		var data []byte
+		if data, err = base64.StdEncoding.DecodeString(imageCall.Result); err != nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("image_failed_to_decode_data"), err))
+			return
+		}
+		ret = append(ret, ai.ImageData{Data: data, MimeType: http.DetectContentType(data)})
+	}
+	return
+}
+
+// isImageModel reports whether model is served by the Images API rather than used as a chat model.
+func isImageModel(model string) bool {
+	model = strings.ToLower(model)
+	return strings.HasPrefix(model, "gpt-image-") || strings.HasPrefix(model, "dall-e-")
+}
+
+// GenerateImages generates images, or edits the reference images of the request. OpenAI chat
+// models use the image_generation tool of the Responses API; image models and providers
+// without the Responses API use the /images/generations and /images/edits endpoints.
+func (o *Client) GenerateImages(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	ret string, images []ai.ImageData, err error) {
+	if o.supportsResponsesAPI() && !isImageModel(opts.Model) {
+		checkImageGenerationCompatibility(opts.Model)
+		if !supportsImageGeneration(opts.Model) {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("openai_model_no_image_generation"), opts.Model, strings.Join(ImageGenerationSupportedModels(), ", ")))
+			return
+		}
+		var resp *responses.Response
+		if resp, err = o.ApiClient.Responses.New(ctx, o.buildResponseParams(ai.WithoutGeneratedImages(msgs), opts)); err != nil {
+			return
+		}
+		if images, err = responseImages(resp); err != nil {
+			return
+		}
+		ret, _ = o.extractText(resp)
+		return
+	}
+
+	var references []ai.ImageData
+	if references, err = ai.ReferenceImages(msgs); err != nil {
+		return
+	}
+	var resp *openai.ImagesResponse
+	if len(references) > 0 {
+		resp, err = o.ApiClient.Images.Edit(ctx, buildImageEditParams(ai.LastUserText(msgs), references, opts))
+	} else {
+		resp, err = o.ApiClient.Images.Generate(ctx, buildImageGenerateParams(ai.LastUserText(msgs), opts))
+	}
+	if err != nil {
+		return
+	}
+
+	var revised []string
+	for _, item := range resp.Data {
+		var data []byte
+		switch {
+		case item.B64JSON != "":
+			if data, err = base64.StdEncoding.DecodeString(item.B64JSON); err != nil {
+				err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("image_failed_to_decode_data"), err))
+				return
+			}
+		case item.URL != "":
+			if data, err = (&domain.Attachment{URL: &item.URL}).ContentBytes(); err != nil {
+				return
 			}
+		default:
+			continue
+		}
+		images = append(images, ai.ImageData{Data: data, MimeType: http.DetectContentType(data)})
+		if item.RevisedPrompt != "" {
+			revised = append(revised, item.RevisedPrompt)
 		}
 	}
+	ret = strings.Join(revised, "\n\n")
+	return
+}
 
-	return nil
+func buildImageGenerateParams(prompt string, opts *domain.ChatOptions) (ret openai.ImageGenerateParams) {
+	ret = openai.ImageGenerateParams{
+		Prompt:     prompt,
+		Model:      opts.Model,
+		Size:       openai.ImageGenerateParamsSize(opts.ImageSize),
+		Quality:    openai.ImageGenerateParamsQuality(opts.ImageQuality),
+		Background: openai.ImageGenerateParamsBackground(opts.ImageBackground),
+	}
+	if strings.HasPrefix(strings.ToLower(opts.Model), "gpt-image-") {
+		ret.OutputFormat = openai.ImageGenerateParamsOutputFormat(getOutputFormatFromExtension(opts.ImageFile))
+		if opts.ImageCompression != 0 {
+			ret.OutputCompression = param.NewOpt(int64(opts.ImageCompression))
+		}
+	} else {
+		// Other models return URLs unless asked for the image data itself
+		ret.ResponseFormat = openai.ImageGenerateParamsResponseFormatB64JSON
+	}
+	return
+}
+
+func buildImageEditParams(prompt string, references []ai.ImageData, opts *domain.ChatOptions) (ret openai.ImageEditParams) {
+	files := make([]io.Reader, 0, len(references))
+	for i, reference := range references {
+		name := fmt.Sprintf("image-%d.%s", i+1, strings.TrimPrefix(reference.MimeType, "image/"))
+		files = append(files, openai.File(bytes.NewReader(reference.Data), name, reference.MimeType))
+	}
+	ret = openai.ImageEditParams{
+		Image:      openai.ImageEditParamsImageUnion{OfFileArray: files},
+		Prompt:     prompt,
+		Model:      opts.Model,
+		Size:       openai.ImageEditParamsSize(opts.ImageSize),
+		Quality:    openai.ImageEditParamsQuality(opts.ImageQuality),
+		Background: openai.ImageEditParamsBackground(opts.ImageBackground),
+	}
+	if strings.HasPrefix(strings.ToLower(opts.Model), "gpt-image-") {
+		ret.OutputFormat = openai.ImageEditParamsOutputFormat(getOutputFormatFromExtension(opts.ImageFile))
+		if opts.ImageCompression != 0 {
+			ret.OutputCompression = param.NewOpt(int64(opts.ImageCompression))
+		}
+	} else {
+		ret.ResponseFormat = openai.ImageEditParamsResponseFormatB64JSON
+	}
+	return
 }
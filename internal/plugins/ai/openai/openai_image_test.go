@@ -3,15 +3,22 @@ package openai
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/danielmiessler/fabric/internal/chat"
 	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	"github.com/openai/openai-go/responses"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestShouldUseImageGeneration(t *testing.T) {
@@ -224,6 +231,24 @@ func TestAddImageGenerationToolWithDynamicFormat(t *testing.T) {
 	}
 }
 
+func TestImageGenerationSupportedModels(t *testing.T) {
+	models := ImageGenerationSupportedModels()
+	assert.Contains(t, models, "gpt-image-*")
+	for _, model := range models {
+		assert.NotContains(t, model, "gemini", "OpenAI lists a Gemini image model")
+		assert.NotContains(t, model, "imagen", "OpenAI lists an Imagen model")
+	}
+
+	// Overrides loaded after startup are listed too
+	builtin := ai.Capabilities
+	t.Cleanup(func() { ai.Capabilities = builtin })
+	ai.Capabilities = ai.NewCapabilityRegistry()
+	ai.Capabilities.AddOverrides(ai.CapabilityRule{Vendor: "OpenAI", Model: "my-image-model",
+		ModelCapabilities: ai.ModelCapabilities{ImageGeneration: ai.SupportYes}})
+	assert.Equal(t, []string{"my-image-model"}, ImageGenerationSupportedModels())
+	assert.True(t, supportsImageGeneration("my-image-model"))
+}
+
 func TestSupportsImageGeneration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -305,6 +330,11 @@ func TestSupportsImageGeneration(t *testing.T) {
 			model:    "",
 			expected: false,
 		},
+		{
+			name:     "Gemini image models are not served by the image_generation tool",
+			model:    "gemini-2.5-flash-image",
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -324,7 +354,7 @@ func TestModelValidationLogic(t *testing.T) {
 
 		// Test the validation logic directly
 		if opts.ImageFile != "" && !supportsImageGeneration(opts.Model) {
-			err := fmt.Errorf("model '%s' does not support image generation. Supported models: %s", opts.Model, strings.Join(ImageGenerationSupportedModels, ", "))
+			err := fmt.Errorf("model '%s' does not support image generation. Supported models: %s", opts.Model, strings.Join(ImageGenerationSupportedModels(), ", "))
 
 			assert.Contains(t, err.Error(), "does not support image generation")
 			assert.Contains(t, err.Error(), "o1-mini")
@@ -588,7 +618,7 @@ func TestSendResponses_WithWarningIntegration(t *testing.T) {
 			}
 
 			// Call sendResponses - this will trigger the warning and potentially error
-			_, err := client.sendResponses(context.TODO(), msgs, opts)
+			_, _, err := client.sendResponses(context.TODO(), msgs, opts)
 
 			// Close writer and read warning output
 			w.Close()
@@ -622,3 +652,63 @@ func TestSendResponses_WithWarningIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateImagesWithImagesEndpoint(t *testing.T) {
+	var generateBody map[string]any
+	var editPrompt, editFile string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /images/generations", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&generateBody))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"created":1,"data":[{"b64_json":"`+base64.StdEncoding.EncodeToString([]byte("generated"))+`","revised_prompt":"a red cat"}]}`)
+	})
+	mux.HandleFunc("POST /images/edits", func(w http.ResponseWriter, r *http.Request) {
+		editPrompt = r.FormValue("prompt")
+		file, header, err := r.FormFile("image[]")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		editFile = header.Filename + ":" + string(data)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"created":1,"data":[{"b64_json":"`+base64.StdEncoding.EncodeToString([]byte("edited"))+`"}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	client.ImplementsResponses = false
+	require.NoError(t, client.configure())
+
+	opts := &domain.ChatOptions{Model: "dall-e-3", ImageFile: "cat.png", ImageSize: "1024x1024"}
+	text, images, err := client.GenerateImages(context.Background(), []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleSystem, Content: "You draw."},
+		{Role: chat.ChatMessageRoleUser, Content: "a cat"},
+	}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "a red cat", text)
+	require.Len(t, images, 1)
+	assert.Equal(t, "generated", string(images[0].Data))
+	assert.Equal(t, "a cat", generateBody["prompt"])
+	assert.Equal(t, "b64_json", generateBody["response_format"])
+	assert.Equal(t, "1024x1024", generateBody["size"])
+
+	reference := ai.ImageData{Data: []byte("reference"), MimeType: "image/png"}
+	_, images, err = client.GenerateImages(context.Background(), []*chat.ChatCompletionMessage{
+		{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "make it blue"},
+			{Type: chat.ChatMessagePartTypeImageURL, ImageURL: &chat.ChatMessageImageURL{URL: reference.DataURL()}},
+		}},
+	}, &domain.ChatOptions{Model: "gpt-image-1", ImageFile: "cat.png"})
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "edited", string(images[0].Data))
+	assert.Equal(t, "make it blue", editPrompt)
+	assert.Equal(t, "image-1.png:reference", editFile)
+}
+
+func TestIsImageModel(t *testing.T) {
+	assert.True(t, isImageModel("gpt-image-1"))
+	assert.True(t, isImageModel("DALL-E-3"))
+	assert.False(t, isImageModel("gpt-5"))
+}
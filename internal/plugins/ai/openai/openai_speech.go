@@ -0,0 +1,41 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	openai "github.com/openai/openai-go"
+)
+
+// defaultSpeechVoice is used when no --voice is given.
+const defaultSpeechVoice = "alloy"
+
+// SynthesizeSpeech turns text into speech with the /audio/speech endpoint, which OpenAI
+// and OpenAI-compatible speech servers provide. Voices are passed through unchecked
+// because compatible servers have their own.
+func (o *Client) SynthesizeSpeech(ctx context.Context, text string, opts *domain.ChatOptions) (ret []byte, err error) {
+	voice := opts.Voice
+	if voice == "" {
+		voice = defaultSpeechVoice
+	}
+	format := opts.AudioFormat
+	if format == "" {
+		format = ai.SpeechFormatWAV
+	}
+
+	params := openai.AudioSpeechNewParams{
+		Input:          text,
+		Model:          opts.Model,
+		Voice:          openai.AudioSpeechNewParamsVoice(voice),
+		ResponseFormat: openai.AudioSpeechNewParamsResponseFormat(format),
+	}
+	var resp *http.Response
+	if resp, err = o.ApiClient.Audio.Speech.New(ctx, params); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,44 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynthesizeSpeech(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/audio/speech", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = io.WriteString(w, "mp3 audio")
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	require.NoError(t, client.configure())
+
+	audio, err := client.SynthesizeSpeech(context.Background(), "Hello there.",
+		&domain.ChatOptions{Model: "gpt-4o-mini-tts", AudioFormat: "mp3"})
+	require.NoError(t, err)
+	assert.Equal(t, "mp3 audio", string(audio))
+	assert.Equal(t, "Hello there.", body["input"])
+	assert.Equal(t, "gpt-4o-mini-tts", body["model"])
+	assert.Equal(t, defaultSpeechVoice, body["voice"])
+	assert.Equal(t, "mp3", body["response_format"])
+
+	_, err = client.SynthesizeSpeech(context.Background(), "Hi.", &domain.ChatOptions{Model: "tts-1", Voice: "nova"})
+	require.NoError(t, err)
+	assert.Equal(t, "nova", body["voice"])
+	assert.Equal(t, "wav", body["response_format"])
+}
@@ -1,7 +1,11 @@
 package openai
 
 import (
-	"strings"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/danielmiessler/fabric/internal/chat"
@@ -10,6 +14,7 @@ import (
 	"github.com/openai/openai-go/responses"
 	"github.com/openai/openai-go/shared"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuildResponseRequestWithMaxTokens(t *testing.T) {
@@ -211,49 +216,142 @@ func TestBuildResponseParams_GrokAI_WithoutSearch(t *testing.T) {
 	assert.Nil(t, params.Tools, "Expected no tools when search is disabled")
 }
 
-func TestCitationFormatting(t *testing.T) {
-	// Test the citation formatting logic by simulating the citation extraction
-	var textParts []string
-	var citations []string
-	citationMap := make(map[string]bool)
-
-	// Simulate text content
-	textParts = append(textParts, "Based on recent research, artificial intelligence is advancing rapidly.")
-
-	// Simulate citations (as they would be extracted from OpenAI response)
-	mockCitations := []struct {
-		URL   string
-		Title string
-	}{
-		{"https://example.com/ai-research", "AI Research Advances 2025"},
-		{"https://another-source.com/tech-news", "Technology News Today"},
-		{"https://example.com/ai-research", "AI Research Advances 2025"}, // Duplicate to test deduplication
+func TestConvertMessage_FilePart(t *testing.T) {
+	msg := chat.ChatCompletionMessage{
+		Role: chat.ChatMessageRoleUser,
+		MultiContent: []chat.ChatMessagePart{
+			{Type: chat.ChatMessagePartTypeText, Text: "summarize"},
+			{Type: chat.ChatMessagePartTypeFile, File: &chat.ChatMessageFile{Filename: "report.pdf", MimeType: "application/pdf", Data: "JVBERi0="}},
+		},
 	}
 
-	for _, citation := range mockCitations {
-		citationKey := citation.URL + "|" + citation.Title
-		if !citationMap[citationKey] {
-			citationMap[citationKey] = true
-			citationText := "- [" + citation.Title + "](" + citation.URL + ")"
-			citations = append(citations, citationText)
+	item := convertMessage(msg)
+	require.NotNil(t, item.OfMessage)
+	parts := item.OfMessage.Content.OfInputItemContentList
+	require.Len(t, parts, 2)
+	require.NotNil(t, parts[1].OfInputFile)
+	assert.Equal(t, "report.pdf", parts[1].OfInputFile.Filename.Value)
+	assert.Equal(t, "data:application/pdf;base64,JVBERi0=", parts[1].OfInputFile.FileData.Value)
+}
+
+func TestSend_AudioInputUsesChatCompletions(t *testing.T) {
+	var paths []string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"heard it"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	client.ImplementsResponses = true
+	require.NoError(t, client.configure())
+
+	msgs := []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, MultiContent: []chat.ChatMessagePart{
+		{Type: chat.ChatMessagePartTypeText, Text: "transcribe"},
+		{Type: chat.ChatMessagePartTypeInputAudio, InputAudio: &chat.ChatMessageInputAudio{Data: "UklGRg==", Format: "wav"}},
+	}}}
+	answer, err := client.Send(context.Background(), msgs, &domain.ChatOptions{Model: "gpt-4o-audio-preview"})
+	require.NoError(t, err)
+	assert.Equal(t, "heard it", answer)
+	assert.Equal(t, []string{"/chat/completions"}, paths)
+	assert.Contains(t, string(body), `"input_audio"`)
+}
+
+func TestSendStream_RetriesWithoutRejectedReasoningSummary(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"message":"Your organization must be verified to generate reasoning summaries.","type":"invalid_request_error","param":"reasoning.summary","code":"unsupported_value"}}`)
+			return
 		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"answer\"}\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	client.ImplementsResponses = true
+	require.NoError(t, client.configure())
+
+	msgs := []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "Hello"}}
+	channel := make(chan domain.StreamUpdate, 10)
+	err := client.SendStream(context.Background(), msgs,
+		&domain.ChatOptions{Model: "o4-mini", Thinking: domain.ThinkingHigh}, channel)
+	require.NoError(t, err)
+
+	var content string
+	for update := range channel {
+		content += update.Content
 	}
+	assert.Equal(t, "answer\n", content)
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[0], `"summary":"auto"`)
+	assert.NotContains(t, bodies[1], `"summary"`)
+}
+
+func TestSendStream_SuppressThinkSkipsReasoningSummary(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"answer\"}\n\n")
+	}))
+	defer srv.Close()
 
-	result := strings.Join(textParts, "")
-	if len(citations) > 0 {
-		result += "\n\n## Sources\n\n" + strings.Join(citations, "\n")
+	client := NewClient()
+	client.ApiKey.Value = "test-key"
+	client.ApiBaseURL.Value = srv.URL
+	client.ImplementsResponses = true
+	require.NoError(t, client.configure())
+
+	msgs := []*chat.ChatCompletionMessage{{Role: chat.ChatMessageRoleUser, Content: "Hello"}}
+	channel := make(chan domain.StreamUpdate, 10)
+	err := client.SendStream(context.Background(), msgs,
+		&domain.ChatOptions{Model: "o4-mini", Thinking: domain.ThinkingHigh, SuppressThink: true}, channel)
+	require.NoError(t, err)
+	for range channel {
 	}
+	assert.Contains(t, string(body), `"effort":"high"`)
+	assert.NotContains(t, string(body), `"summary"`)
+}
 
-	// Verify the result contains the expected text
-	expectedText := "Based on recent research, artificial intelligence is advancing rapidly."
-	assert.Contains(t, result, expectedText, "Expected result to contain original text")
+func TestCitationExtraction(t *testing.T) {
+	var resp responses.Response
+	err := json.Unmarshal([]byte(`{"output":[{"type":"message","content":[{"type":"output_text",
+		"text":"AI is advancing rapidly. Tech moves fast.",
+		"annotations":[
+			{"type":"url_citation","url":"https://example.com/ai-research","title":"AI Research Advances 2025","start_index":0,"end_index":24},
+			{"type":"url_citation","url":"https://another-source.com/tech-news","title":"Technology News Today","start_index":25,"end_index":41},
+			{"type":"url_citation","url":"https://example.com/ai-research","title":"AI Research Advances 2025","start_index":0,"end_index":24}
+		]}]}]}`), &resp)
+	require.NoError(t, err)
 
-	// Verify citations are included
-	assert.Contains(t, result, "## Sources", "Expected result to contain Sources section")
-	assert.Contains(t, result, "[AI Research Advances 2025](https://example.com/ai-research)", "Expected result to contain first citation")
-	assert.Contains(t, result, "[Technology News Today](https://another-source.com/tech-news)", "Expected result to contain second citation")
+	client := NewClient()
+	text, citations := client.extractText(&resp)
+
+	// Sources are no longer appended to the text
+	assert.Equal(t, "AI is advancing rapidly. Tech moves fast.", text)
+
+	// Duplicate annotations are dropped and the annotated span is kept
+	assert.Equal(t, []chat.Citation{
+		{URL: "https://example.com/ai-research", Title: "AI Research Advances 2025", Span: "AI is advancing rapidly."},
+		{URL: "https://another-source.com/tech-news", Title: "Technology News Today", Span: "Tech moves fast."},
+	}, citations)
+}
 
-	// Verify deduplication - should only have 2 unique citations, not 3
-	citationCount := strings.Count(result, "- [")
-	assert.Equal(t, 2, citationCount, "Expected 2 unique citations")
+func TestAnnotatedSpanOutOfRange(t *testing.T) {
+	assert.Equal(t, "", annotatedSpan("short", 2, 99))
+	assert.Equal(t, "", annotatedSpan("short", 3, 3))
+	assert.Equal(t, "héllo", annotatedSpan("say héllo", 4, 9))
 }
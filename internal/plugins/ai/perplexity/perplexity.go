@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 	"sync"
 
 	"github.com/danielmiessler/fabric/internal/chat"
@@ -60,9 +59,15 @@ func (c *Client) ListModels(_ context.Context) ([]string, error) {
 }
 
 func (c *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+	content, _, err := c.SendWithCitations(ctx, msgs, opts)
+	return content, err
+}
+
+// SendWithCitations returns the answer and the search results Perplexity cites for it.
+func (c *Client) SendWithCitations(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, []chat.Citation, error) {
 	if c.client == nil {
 		if err := c.Configure(); err != nil {
-			return "", fmt.Errorf(i18n.T("perplexity_failed_configure"), err)
+			return "", nil, fmt.Errorf(i18n.T("perplexity_failed_configure"), err)
 		}
 	}
 
@@ -70,7 +75,7 @@ func (c *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, o
 	for _, msg := range msgs {
 		perplexityMessages = append(perplexityMessages, perplexity.Message{
 			Role:    msg.Role,
-			Content: msg.Content,
+			Content: msg.TextContent(),
 		})
 	}
 
@@ -101,22 +106,25 @@ func (c *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, o
 	// Corrected: Use SendCompletionRequest method from perplexity-go library
 	resp, err := c.client.SendCompletionRequest(request) // Pass request directly
 	if err != nil {
-		return "", fmt.Errorf(i18n.T("perplexity_api_request_failed"), err)
+		return "", nil, fmt.Errorf(i18n.T("perplexity_api_request_failed"), err)
 	}
 
-	var content strings.Builder
-	content.WriteString(resp.GetLastContent())
+	return resp.GetLastContent(), responseCitations(resp), nil
+}
 
-	// Append citations if available
-	citations := resp.GetCitations()
-	if len(citations) > 0 {
-		content.WriteString(i18n.T("perplexity_citations_header"))
-		for i, citation := range citations {
-			content.WriteString(fmt.Sprintf("- [%d] %s\n", i+1, citation))
+// responseCitations converts the search results of a response to citations, keeping their
+// order so the [n] markers in the answer match the footnote numbers. Older responses only
+// list the cited URLs.
+func responseCitations(resp *perplexity.CompletionResponse) (citations []chat.Citation) {
+	for _, result := range resp.GetSearchResults() {
+		citations = domain.AppendCitations(citations, chat.Citation{URL: result.URL, Title: result.Title})
+	}
+	if len(citations) == 0 {
+		for _, url := range resp.GetCitations() {
+			citations = domain.AppendCitations(citations, chat.Citation{URL: url})
 		}
 	}
-
-	return content.String(), nil
+	return
 }
 
 func (c *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions, channel chan domain.StreamUpdate) error {
@@ -131,7 +139,7 @@ func (c *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 	for _, msg := range msgs {
 		perplexityMessages = append(perplexityMessages, perplexity.Message{
 			Role:    msg.Role,
-			Content: msg.Content,
+			Content: msg.TextContent(),
 		})
 	}
 
@@ -213,16 +221,10 @@ func (c *Client) SendStream(_ context.Context, msgs []*chat.ChatCompletionMessag
 
 		// Send citations at the end if available
 		if lastResponse != nil {
-			citations := lastResponse.GetCitations()
-			if len(citations) > 0 {
-				var citationsText strings.Builder
-				citationsText.WriteString(i18n.T("perplexity_citations_header"))
-				for i, citation := range citations {
-					citationsText.WriteString(fmt.Sprintf("- [%d] %s\n", i+1, citation))
-				}
+			if citations := responseCitations(lastResponse); len(citations) > 0 {
 				channel <- domain.StreamUpdate{
-					Type:    domain.StreamTypeContent,
-					Content: citationsText.String(),
+					Type:      domain.StreamTypeCitation,
+					Citations: citations,
 				}
 			}
 		}
@@ -0,0 +1,27 @@
+package perplexity
+
+import (
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	perplexity "github.com/sgaunet/perplexity-go/v2"
+)
+
+func TestResponseCitations(t *testing.T) {
+	results := []perplexity.SearchResult{
+		{Title: "First", URL: "https://a.example"},
+		{Title: "Second", URL: "https://b.example"},
+	}
+	urls := []string{"https://ignored.example"}
+	got := responseCitations(&perplexity.CompletionResponse{SearchResults: &results, Citations: &urls})
+	want := []chat.Citation{{URL: "https://a.example", Title: "First"}, {URL: "https://b.example", Title: "Second"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("citations = %+v, want %+v", got, want)
+	}
+
+	// Older responses only list URLs
+	got = responseCitations(&perplexity.CompletionResponse{Citations: &urls})
+	if len(got) != 1 || got[0].URL != "https://ignored.example" {
+		t.Errorf("citations = %+v, want the listed URL", got)
+	}
+}
@@ -0,0 +1,269 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables limiting every model of a vendor, prefixed with the vendor's
+// environment name, e.g. OPENAI_RATE_LIMIT_RPM.
+const (
+	rateLimitRPMEnvSuffix = "RATE_LIMIT_RPM"
+	rateLimitTPMEnvSuffix = "RATE_LIMIT_TPM"
+)
+
+// rateLimitLockTimeout bounds how long a process waits for another one to release a
+// bucket, and after which a lock left behind by a crashed process is removed.
+const rateLimitLockTimeout = 5 * time.Second
+
+// RateLimitRule limits the requests and tokens per minute sent to the models of a vendor.
+// Model is a glob like in capability rules; an empty Model limits all models of the
+// vendor together. Every matching rule is enforced, each with its own budget.
+type RateLimitRule struct {
+	Vendor string `yaml:"vendor"`
+	Model  string `yaml:"model,omitempty"`
+	RPM    int    `yaml:"rpm,omitempty"`
+	TPM    int    `yaml:"tpm,omitempty"`
+}
+
+func (o *RateLimitRule) matches(vendor, model string) bool {
+	if !strings.EqualFold(o.Vendor, vendor) {
+		return false
+	}
+	if o.Model == "" {
+		return true
+	}
+	matched, _ := path.Match(strings.ToLower(o.Model), strings.ToLower(model))
+	return matched
+}
+
+// key names the budget of the rule in the state directory.
+func (o *RateLimitRule) key() string {
+	key := o.Vendor
+	if o.Model != "" {
+		key += "-" + o.Model
+	}
+	return rateLimitKeySanitizer.ReplaceAllString(key, "_")
+}
+
+var rateLimitKeySanitizer = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// rateLimitsFile is the YAML document users can put in the fabric config directory.
+type rateLimitsFile struct {
+	Limits []RateLimitRule `yaml:"limits"`
+}
+
+// rateBucket is the stored state of a token bucket that refills its capacity once a minute.
+type rateBucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// RateLimiter enforces rate limits with token buckets. The buckets are kept in files
+// so that fabric processes running at the same time share them. Requests over the
+// limit reserve their budget and wait until it is available, instead of failing.
+type RateLimiter struct {
+	mu       sync.Mutex
+	rules    []RateLimitRule
+	stateDir func() (string, error)
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter that keeps its buckets in the directory
+// returned by stateDir.
+func NewRateLimiter(stateDir func() (string, error)) *RateLimiter {
+	return &RateLimiter{stateDir: stateDir, now: time.Now}
+}
+
+// RateLimits is the process-wide rate limiter used by every chat request.
+var RateLimits = NewRateLimiter(defaultRateLimitStateDir)
+
+func defaultRateLimitStateDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "fabric", "cache", "ratelimits"), nil
+}
+
+// AddRules appends rate limit rules.
+func (o *RateLimiter) AddRules(rules ...RateLimitRule) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.rules = append(o.rules, rules...)
+}
+
+// LoadRules reads rules from a YAML file. A missing file is not an error.
+func (o *RateLimiter) LoadRules(filePath string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(filePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	var file rateLimitsFile
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("rate_limit_rules_invalid"), filePath, err))
+		return
+	}
+	for _, rule := range file.Limits {
+		if rule.Vendor == "" {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("rate_limit_rule_missing_vendor"), filePath))
+			return
+		}
+	}
+	o.AddRules(file.Limits...)
+	return
+}
+
+// Rules returns the rules matching the vendor and model: the vendor-wide limits from
+// the environment first, then those from the rules file.
+func (o *RateLimiter) Rules(vendor, model string) (ret []RateLimitRule) {
+	prefix := plugins.BuildEnvVariablePrefix(vendor)
+	envRule := RateLimitRule{Vendor: vendor}
+	envRule.RPM, _ = strconv.Atoi(os.Getenv(prefix + rateLimitRPMEnvSuffix))
+	envRule.TPM, _ = strconv.Atoi(os.Getenv(prefix + rateLimitTPMEnvSuffix))
+	if envRule.RPM > 0 || envRule.TPM > 0 {
+		ret = append(ret, envRule)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, rule := range o.rules {
+		if rule.matches(vendor, model) && (rule.RPM > 0 || rule.TPM > 0) {
+			ret = append(ret, rule)
+		}
+	}
+	return
+}
+
+// Wait reserves one request and the estimated tokens in every matching budget, and
+// waits until the reservations are covered. It returns early when ctx is done.
+func (o *RateLimiter) Wait(ctx context.Context, vendor, model string, tokens int) (err error) {
+	var wait time.Duration
+	for _, rule := range o.Rules(vendor, model) {
+		if rule.RPM > 0 {
+			wait = max(wait, o.reserve(rule.key()+"-rpm", rule.RPM, 1))
+		}
+		if rule.TPM > 0 {
+			wait = max(wait, o.reserve(rule.key()+"-tpm", rule.TPM, float64(tokens)))
+		}
+	}
+	if wait <= 0 {
+		return
+	}
+
+	debuglog.Debug(debuglog.Basic, "Rate limit reached for %s|%s, waiting %s\n", vendor, model, wait.Round(time.Millisecond))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+	}
+	return
+}
+
+// Record corrects the token budgets once the actual token count of a request is known.
+// Estimates that were too high are given back.
+func (o *RateLimiter) Record(vendor, model string, estimated, actual int) {
+	if actual == estimated {
+		return
+	}
+	for _, rule := range o.Rules(vendor, model) {
+		if rule.TPM > 0 {
+			o.reserve(rule.key()+"-tpm", rule.TPM, float64(actual-estimated))
+		}
+	}
+}
+
+// reserve takes amount from the bucket and returns how long to wait until the bucket
+// is no longer in debt. Negative amounts give tokens back. Failing to read or write the
+// bucket never blocks a request.
+func (o *RateLimiter) reserve(key string, limit int, amount float64) (wait time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	dir, err := o.stateDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err != nil {
+		debuglog.Debug(debuglog.Basic, "Rate limit state unavailable: %v\n", err)
+		return
+	}
+	bucketPath := filepath.Join(dir, key+".json")
+	unlock := lockRateBucket(bucketPath + ".lock")
+	defer unlock()
+
+	now := o.now()
+	capacity := float64(limit)
+	perSecond := capacity / 60
+	bucket := rateBucket{Tokens: capacity, Updated: now}
+	if data, readErr := os.ReadFile(bucketPath); readErr == nil {
+		if json.Unmarshal(data, &bucket) != nil {
+			bucket = rateBucket{Tokens: capacity, Updated: now}
+		}
+	}
+	if elapsed := now.Sub(bucket.Updated).Seconds(); elapsed > 0 {
+		bucket.Tokens = min(capacity, bucket.Tokens+elapsed*perSecond)
+	}
+	bucket.Tokens = min(capacity, bucket.Tokens-amount)
+	bucket.Updated = now
+	if bucket.Tokens < 0 {
+		wait = time.Duration(-bucket.Tokens / perSecond * float64(time.Second))
+	}
+
+	if data, marshalErr := json.Marshal(bucket); marshalErr == nil {
+		if writeErr := os.WriteFile(bucketPath, data, 0o644); writeErr != nil {
+			debuglog.Debug(debuglog.Basic, "Failed to save rate limit state %s: %v\n", bucketPath, writeErr)
+		}
+	}
+	return
+}
+
+// lockRateBucket takes a lock file shared by all fabric processes and returns the
+// function releasing it. A lock older than rateLimitLockTimeout is assumed to be left
+// behind and is taken over.
+func lockRateBucket(lockPath string) (unlock func()) {
+	deadline := time.Now().Add(rateLimitLockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > rateLimitLockTimeout {
+			os.Remove(lockPath)
+			continue
+		}
+		if !errors.Is(err, os.ErrExist) || time.Now().After(deadline) {
+			debuglog.Debug(debuglog.Basic, "Rate limit state used without lock: %v\n", err)
+			return func() {}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// EstimateTokens roughly estimates the number of tokens of a text, at four characters
+// per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
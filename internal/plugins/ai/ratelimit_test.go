@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRateLimiter(t *testing.T) (*RateLimiter, *time.Time) {
+	dir := t.TempDir()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(func() (string, error) { return dir, nil })
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestRateLimiterReserveRefillsOverTime(t *testing.T) {
+	limiter, now := newTestRateLimiter(t)
+
+	for i := range 2 {
+		if wait := limiter.reserve("vendor-rpm", 2, 1); wait != 0 {
+			t.Fatalf("request %d within the limit waited %s", i+1, wait)
+		}
+	}
+	if wait := limiter.reserve("vendor-rpm", 2, 1); wait != 30*time.Second {
+		t.Errorf("third request waits %s, want 30s", wait)
+	}
+
+	// The bucket refills two requests per minute, which pays off the debt of the third one
+	*now = now.Add(time.Minute)
+	if wait := limiter.reserve("vendor-rpm", 2, 1); wait != 0 {
+		t.Errorf("request after the refill waited %s", wait)
+	}
+}
+
+func TestRateLimiterSharesStateBetweenLimiters(t *testing.T) {
+	limiter, _ := newTestRateLimiter(t)
+	other := NewRateLimiter(limiter.stateDir)
+	other.now = limiter.now
+
+	limiter.reserve("vendor-tpm", 600, 600)
+	if wait := other.reserve("vendor-tpm", 600, 100); wait != 10*time.Second {
+		t.Errorf("second process waits %s, want 10s", wait)
+	}
+
+	dir, _ := limiter.stateDir()
+	if _, err := os.Stat(filepath.Join(dir, "vendor-tpm.json.lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed after use, stat error = %v", err)
+	}
+}
+
+func TestRateLimiterRules(t *testing.T) {
+	limiter, _ := newTestRateLimiter(t)
+	limiter.AddRules(
+		RateLimitRule{Vendor: "OpenAI", Model: "gpt-4o*", TPM: 1000},
+		RateLimitRule{Vendor: "OpenAI", Model: "o3", RPM: 5},
+		RateLimitRule{Vendor: "Anthropic", RPM: 50},
+	)
+	t.Setenv("OPENAI_RATE_LIMIT_RPM", "100")
+
+	rules := limiter.Rules("OpenAI", "GPT-4o-mini")
+	if len(rules) != 2 {
+		t.Fatalf("expected the env rule and one YAML rule, got %+v", rules)
+	}
+	if rules[0].RPM != 100 || rules[0].Model != "" || rules[1].TPM != 1000 {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+	if rules := limiter.Rules("Gemini", "gemini-2.5-pro"); len(rules) != 0 {
+		t.Errorf("expected no rules for an unlimited vendor, got %+v", rules)
+	}
+}
+
+func TestRateLimiterWaitAndRecord(t *testing.T) {
+	limiter, _ := newTestRateLimiter(t)
+	limiter.AddRules(RateLimitRule{Vendor: "Vendor", TPM: 600})
+
+	if err := limiter.Wait(context.Background(), "Vendor", "model", 500); err != nil {
+		t.Fatalf("Wait() within the limit error = %v", err)
+	}
+	// The answer was shorter than estimated, so tokens are given back
+	limiter.Record("Vendor", "model", 500, 100)
+	if wait := limiter.reserve("Vendor-tpm", 600, 500); wait != 0 {
+		t.Errorf("expected the refund to cover the request, waits %s", wait)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx, "Vendor", "model", 600); err == nil {
+		t.Error("expected a canceled wait to return the context error")
+	}
+}
+
+func TestRateLimiterLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	limiter, _ := newTestRateLimiter(t)
+
+	if err := limiter.LoadRules(filepath.Join(dir, "missing.yaml")); err != nil {
+		t.Fatalf("a missing file should be ignored, got %v", err)
+	}
+
+	valid := filepath.Join(dir, "ratelimits.yaml")
+	if err := os.WriteFile(valid, []byte("limits:\n  - vendor: Groq\n    model: llama-*\n    rpm: 30\n    tpm: 6000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.LoadRules(valid); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if rules := limiter.Rules("Groq", "llama-3.3-70b"); len(rules) != 1 || rules[0].RPM != 30 || rules[0].TPM != 6000 {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("limits:\n  - model: llama-*\n    rpm: 30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.LoadRules(invalid); err == nil {
+		t.Error("expected an error for a rule without a vendor")
+	}
+}
@@ -0,0 +1,251 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// AudioDataPrefix marks a chat result that carries synthesized audio instead of text.
+const AudioDataPrefix = "FABRIC_AUDIO_DATA:"
+
+// MaxSpeechChunk is the longest text, in characters, sent in a single speech request.
+// It stays under the 4096 character limit of OpenAI's speech endpoint.
+const MaxSpeechChunk = 4000
+
+// SpeechSynthesizer is implemented by vendors that can turn text into speech. The audio
+// is returned in opts.AudioFormat; vendors reject formats they cannot produce.
+type SpeechSynthesizer interface {
+	SynthesizeSpeech(ctx context.Context, text string, opts *domain.ChatOptions) ([]byte, error)
+}
+
+// Speech output formats, named as OpenAI's speech endpoint names them.
+const (
+	SpeechFormatWAV  = "wav"
+	SpeechFormatMP3  = "mp3"
+	SpeechFormatOpus = "opus"
+	SpeechFormatAAC  = "aac"
+	SpeechFormatFLAC = "flac"
+)
+
+// SpeechFormat returns the speech format to write to fileName, chosen by its extension.
+// It returns "" for extensions no vendor produces.
+func SpeechFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".wav":
+		return SpeechFormatWAV
+	case ".mp3":
+		return SpeechFormatMP3
+	case ".opus", ".ogg":
+		return SpeechFormatOpus
+	case ".aac":
+		return SpeechFormatAAC
+	case ".flac":
+		return SpeechFormatFLAC
+	default:
+		return ""
+	}
+}
+
+// sentencePattern matches a sentence with the whitespace after it, a line, or the rest of the text.
+var sentencePattern = regexp.MustCompile(`(?s).*?(?:[.!?…]+["'”’)\]]*\s+|[。！？]+\s*|\n\s*|$)`)
+
+// SplitSpeechText splits text into chunks of at most limit characters for speech requests.
+// Chunks end at sentence boundaries; a sentence longer than limit is split between words.
+func SplitSpeechText(text string, limit int) (ret []string) {
+	var current strings.Builder
+	length := 0
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			ret = append(ret, chunk)
+		}
+		current.Reset()
+		length = 0
+	}
+
+	for _, sentence := range sentencePattern.FindAllString(text, -1) {
+		for _, piece := range splitLongSentence(sentence, limit) {
+			pieceLength := utf8.RuneCountInString(piece)
+			if length > 0 && length+pieceLength > limit {
+				flush()
+			}
+			current.WriteString(piece)
+			length += pieceLength
+		}
+	}
+	flush()
+	return
+}
+
+// splitLongSentence splits a sentence longer than limit between words, and words longer
+// than limit anywhere.
+func splitLongSentence(sentence string, limit int) (ret []string) {
+	if utf8.RuneCountInString(sentence) <= limit {
+		return []string{sentence}
+	}
+
+	var current []rune
+	word := []rune{}
+	flushWord := func() {
+		if len(current)+len(word) > limit && len(current) > 0 {
+			ret = append(ret, string(current))
+			current = nil
+		}
+		for len(word) > limit {
+			ret = append(ret, string(word[:limit]))
+			word = word[limit:]
+		}
+		current = append(current, word...)
+		word = word[:0]
+	}
+	for _, r := range sentence {
+		word = append(word, r)
+		if unicode.IsSpace(r) {
+			flushWord()
+		}
+	}
+	flushWord()
+	if len(current) > 0 {
+		ret = append(ret, string(current))
+	}
+	return
+}
+
+// ConcatAudio joins the audio of consecutive speech chunks into one file of format.
+// MP3 and AAC frames are simply appended and Opus chunks form a chained Ogg stream;
+// WAV and FLAC chunks are merged into a single stream under the first chunk's header.
+func ConcatAudio(format string, chunks [][]byte) ([]byte, error) {
+	if len(chunks) == 1 {
+		return chunks[0], nil
+	}
+	switch format {
+	case SpeechFormatWAV:
+		return concatWAV(chunks)
+	case SpeechFormatFLAC:
+		return concatFLAC(chunks)
+	default:
+		return bytes.Join(chunks, nil), nil
+	}
+}
+
+// concatWAV keeps the format chunk of the first file and joins the samples of every file.
+func concatWAV(chunks [][]byte) (ret []byte, err error) {
+	var format, samples []byte
+	for _, chunk := range chunks {
+		if len(chunk) < 12 || string(chunk[:4]) != "RIFF" || string(chunk[8:12]) != "WAVE" {
+			err = errors.New(i18n.T("speech_invalid_wav"))
+			return
+		}
+		for offset := 12; offset+8 <= len(chunk); {
+			id := string(chunk[offset : offset+4])
+			size := binary.LittleEndian.Uint32(chunk[offset+4 : offset+8])
+			start := offset + 8
+			end := min(start+int(size), len(chunk))
+			if id == "data" && (size == 0 || size == math.MaxUint32) {
+				// Streamed WAV files leave the size open, so the data runs to the end of the file
+				end = len(chunk)
+			}
+			switch id {
+			case "fmt ":
+				if format == nil {
+					format = chunk[start:end]
+				}
+			case "data":
+				samples = append(samples, chunk[start:end]...)
+			}
+			offset = end + (end-start)%2
+		}
+	}
+	if format == nil {
+		err = errors.New(i18n.T("speech_invalid_wav"))
+		return
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 20+len(format)+len(samples)))
+	buf.WriteString("RIFF")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(4+8+len(format)+8+len(samples)))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(format)))
+	buf.Write(format)
+	buf.WriteString("data")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+	ret = buf.Bytes()
+	return
+}
+
+// concatFLAC keeps the metadata of the first file and appends the audio frames of every
+// file. The stream info is updated with the total sample count and marks the frame sizes
+// and MD5 signature, which no longer apply, as unknown.
+func concatFLAC(chunks [][]byte) (ret []byte, err error) {
+	const streamInfoLength = 34
+	var totalSamples uint64
+	known := true
+	for i, chunk := range chunks {
+		var framesStart, streamInfo int
+		if framesStart, streamInfo, err = flacFrames(chunk); err != nil {
+			return
+		}
+		packed := binary.BigEndian.Uint64(chunk[streamInfo+10 : streamInfo+18])
+		samples := packed & (1<<36 - 1)
+		// A chunk of unknown length makes the length of the whole stream unknown
+		known = known && samples > 0
+		totalSamples += samples
+		if i == 0 {
+			ret = append(ret, chunk...)
+			continue
+		}
+		ret = append(ret, chunk[framesStart:]...)
+	}
+
+	_, streamInfo, _ := flacFrames(ret)
+	info := ret[streamInfo : streamInfo+streamInfoLength]
+	clear(info[4:10])
+	packed := binary.BigEndian.Uint64(info[10:18])
+	if !known || totalSamples >= 1<<36 {
+		totalSamples = 0
+	}
+	binary.BigEndian.PutUint64(info[10:18], packed&^(1<<36-1)|totalSamples)
+	clear(info[18:34])
+	return
+}
+
+// flacFrames returns where the audio frames of a FLAC file start and where its
+// STREAMINFO block data is.
+func flacFrames(data []byte) (framesStart, streamInfo int, err error) {
+	if len(data) < 8+34 || string(data[:4]) != "fLaC" || data[4]&0x7f != 0 {
+		err = errors.New(i18n.T("speech_invalid_flac"))
+		return
+	}
+	streamInfo = 8
+	offset := 4
+	for {
+		if offset+4 > len(data) {
+			err = errors.New(i18n.T("speech_invalid_flac"))
+			return
+		}
+		last := data[offset]&0x80 != 0
+		length := int(data[offset+1])<<16 | int(data[offset+2])<<8 | int(data[offset+3])
+		offset += 4 + length
+		if last {
+			break
+		}
+	}
+	if offset > len(data) {
+		err = errors.New(i18n.T("speech_invalid_flac"))
+		return
+	}
+	framesStart = offset
+	return
+}
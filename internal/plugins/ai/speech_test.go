@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitSpeechText(t *testing.T) {
+	text := "First sentence here. Second one follows! Third? Fourth sentence ends it."
+	chunks := SplitSpeechText(text, 45)
+	want := []string{"First sentence here. Second one follows!", "Third? Fourth sentence ends it."}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %q", len(want), chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], chunks[i])
+		}
+	}
+
+	long := strings.Repeat("word ", 30) + strings.Repeat("x", 25)
+	for _, chunk := range SplitSpeechText(long, 20) {
+		if utf8.RuneCountInString(chunk) > 20 {
+			t.Errorf("Expected chunks of at most 20 characters, got %q", chunk)
+		}
+	}
+
+	if chunks := SplitSpeechText("  \n ", 10); len(chunks) != 0 {
+		t.Errorf("Expected no chunks for blank text, got %q", chunks)
+	}
+}
+
+func TestSpeechFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"out.wav": SpeechFormatWAV, "OUT.MP3": SpeechFormatMP3, "a.ogg": SpeechFormatOpus,
+		"a.opus": SpeechFormatOpus, "a.aac": SpeechFormatAAC, "a.flac": SpeechFormatFLAC, "a.m4a": "",
+	} {
+		if got := SpeechFormat(name); got != want {
+			t.Errorf("SpeechFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func testWAV(samples []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(4+8+16+8+len(samples)))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))
+	buf.Write([]byte{1, 0, 1, 0, 0x80, 0x3e, 0, 0, 0, 0x7d, 0, 0, 2, 0, 16, 0})
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+	return buf.Bytes()
+}
+
+func TestConcatAudioWAV(t *testing.T) {
+	joined, err := ConcatAudio(SpeechFormatWAV, [][]byte{testWAV([]byte{1, 2}), testWAV([]byte{3, 4, 5, 6})})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := testWAV([]byte{1, 2, 3, 4, 5, 6}); !bytes.Equal(joined, want) {
+		t.Errorf("Expected %v, got %v", want, joined)
+	}
+
+	if _, err = ConcatAudio(SpeechFormatWAV, [][]byte{testWAV(nil), []byte("not a wav")}); err == nil {
+		t.Error("Expected an error for an invalid WAV chunk")
+	}
+}
+
+func testFLAC(samples uint64, frames string) []byte {
+	info := make([]byte, 34)
+	binary.BigEndian.PutUint64(info[10:18], 0x0AC44<<44|samples)
+	copy(info[18:], "md5 signature...")
+	data := append([]byte("fLaC"), 0x80, 0, 0, 34)
+	data = append(data, info...)
+	return append(data, frames...)
+}
+
+func TestConcatAudioFLAC(t *testing.T) {
+	joined, err := ConcatAudio(SpeechFormatFLAC, [][]byte{testFLAC(100, "AAA"), testFLAC(50, "BB")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasSuffix(string(joined), "AAABB") || len(joined) != 8+34+5 {
+		t.Fatalf("Expected the frames of both files after one header, got %q", joined)
+	}
+	info := joined[8 : 8+34]
+	if samples := binary.BigEndian.Uint64(info[10:18]) & (1<<36 - 1); samples != 150 {
+		t.Errorf("Expected 150 total samples, got %d", samples)
+	}
+	if !bytes.Equal(info[18:34], make([]byte, 16)) {
+		t.Error("Expected the MD5 signature to be cleared")
+	}
+
+	if _, err = ConcatAudio(SpeechFormatFLAC, [][]byte{testFLAC(1, ""), []byte("fLaC")}); err == nil {
+		t.Error("Expected an error for an invalid FLAC chunk")
+	}
+}
+
+func TestConcatAudioAppendsOtherFormats(t *testing.T) {
+	if joined, _ := ConcatAudio(SpeechFormatMP3, [][]byte{[]byte("ab"), []byte("cd")}); string(joined) != "abcd" {
+		t.Errorf("Expected the chunks to be appended, got %q", joined)
+	}
+}
@@ -0,0 +1,12 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+)
+
+// Transcriber is implemented by vendors that can transcribe audio and video files.
+type Transcriber interface {
+	Transcribe(ctx context.Context, filePath string, opts *domain.TranscriptionOptions) (*domain.Transcript, error)
+}
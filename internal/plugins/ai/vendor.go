@@ -16,3 +16,10 @@ type Vendor interface {
 	Send(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, error)
 	NeedsRawMode(modelName string) bool
 }
+
+// CitationSender is implemented by vendors that can return the sources an answer
+// cites, for example after a web search, separately from the answer text.
+// Their Send returns only the text.
+type CitationSender interface {
+	SendWithCitations(context.Context, []*chat.ChatCompletionMessage, *domain.ChatOptions) (string, []chat.Citation, error)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/i18n"
 	debuglog "github.com/danielmiessler/fabric/internal/log"
 	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
 	"github.com/danielmiessler/fabric/internal/plugins/ai/geminicommon"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -119,10 +120,17 @@ func (c *Client) ListModels(_ context.Context) ([]string, error) {
 }
 
 func (c *Client) Send(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+	text, _, err := c.SendWithCitations(ctx, msgs, opts)
+	return text, err
+}
+
+// SendWithCitations returns the answer text and, for Gemini models, the web sources it is grounded on.
+func (c *Client) SendWithCitations(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, []chat.Citation, error) {
 	if isGeminiModel(opts.Model) {
 		return c.sendGemini(ctx, msgs, opts)
 	}
-	return c.sendClaude(ctx, msgs, opts)
+	text, err := c.sendClaude(ctx, msgs, opts)
+	return text, nil, err
 }
 
 // getMaxTokens returns the max output tokens to use for a request
@@ -266,29 +274,47 @@ func (c *Client) getGeminiRegion(model string) string {
 	return c.Region.Value
 }
 
-func (c *Client) sendGemini(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, error) {
+func (c *Client) sendGemini(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (string, []chat.Citation, error) {
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		Project:  c.ProjectID.Value,
 		Location: c.getGeminiRegion(opts.Model),
 		Backend:  genai.BackendVertexAI,
 	})
 	if err != nil {
-		return "", fmt.Errorf(i18n.T("vertexai_failed_gemini_client"), err)
+		return "", nil, fmt.Errorf(i18n.T("vertexai_failed_gemini_client"), err)
 	}
 
 	contents := geminicommon.ConvertMessages(msgs)
 	if len(contents) == 0 {
-		return "", errors.New(i18n.T("vertexai_no_valid_messages"))
+		return "", nil, errors.New(i18n.T("vertexai_no_valid_messages"))
 	}
 
 	config := c.buildGeminiConfig(opts)
 
 	response, err := client.Models.GenerateContent(ctx, opts.Model, contents, config)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return geminicommon.ExtractTextWithCitations(response), nil
+	return geminicommon.ExtractText(response), geminicommon.ExtractCitations(response), nil
+}
+
+// GenerateImages generates images with Imagen or a Gemini image model. Other publishers'
+// models on Vertex AI do not generate images.
+func (c *Client) GenerateImages(ctx context.Context, msgs []*chat.ChatCompletionMessage, opts *domain.ChatOptions) (
+	string, []ai.ImageData, error) {
+	if !isGeminiModel(opts.Model) && !geminicommon.IsImagenModel(opts.Model) {
+		return "", nil, fmt.Errorf("%s", fmt.Sprintf(i18n.T("capability_image_generation_not_supported"), opts.Model))
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		Project:  c.ProjectID.Value,
+		Location: c.getGeminiRegion(opts.Model),
+		Backend:  genai.BackendVertexAI,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf(i18n.T("vertexai_failed_gemini_client"), err)
+	}
+	return geminicommon.GenerateImages(ctx, client, opts.Model, msgs, opts)
 }
 
 // buildGeminiConfig creates the generation config for Gemini models
@@ -376,6 +402,13 @@ func (c *Client) sendStreamGemini(msgs []*chat.ChatCompletionMessage, opts *doma
 			}
 		}
 
+		if citations := geminicommon.ExtractCitations(response); len(citations) > 0 {
+			channel <- domain.StreamUpdate{
+				Type:      domain.StreamTypeCitation,
+				Citations: citations,
+			}
+		}
+
 		if response.UsageMetadata != nil {
 			channel <- domain.StreamUpdate{
 				Type: domain.StreamTypeUsage,
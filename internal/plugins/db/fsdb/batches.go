@@ -0,0 +1,42 @@
+package fsdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// BatchesEntity stores the state of batches submitted to provider batch APIs,
+// so their status and results can be retrieved in a later run.
+type BatchesEntity struct {
+	*StorageEntity
+}
+
+// Get loads a batch record by its provider batch ID
+func (o *BatchesEntity) Get(id string) (batch *Batch, err error) {
+	if !o.Exists(id) {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("batch_not_found"), id))
+		return
+	}
+	batch = &Batch{}
+	err = o.LoadAsJson(id, batch)
+	return
+}
+
+// SaveBatch stores the batch record under its provider batch ID
+func (o *BatchesEntity) SaveBatch(batch *Batch) (err error) {
+	batch.UpdatedAt = time.Now()
+	return o.SaveAsJson(batch.ID, batch)
+}
+
+type Batch struct {
+	ID         string    `json:"id"`
+	Vendor     string    `json:"vendor"`
+	Model      string    `json:"model"`
+	Pattern    string    `json:"pattern,omitempty"`
+	RequestIDs []string  `json:"request_ids"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
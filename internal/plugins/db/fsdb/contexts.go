@@ -1,6 +1,23 @@
 package fsdb
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/danielmiessler/fabric/internal/plugins/template"
+	"github.com/danielmiessler/fabric/internal/util"
+)
+
+var contextTokenPattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// literalOpen and literalClose stand in for the braces of unknown tokens while templating
+const (
+	literalOpen  = "__FABRIC_LITERAL_OPEN__"
+	literalClose = "__FABRIC_LITERAL_CLOSE__"
+)
 
 type ContextsEntity struct {
 	*StorageEntity
@@ -17,6 +34,84 @@ func (o *ContextsEntity) Get(name string) (ret *Context, err error) {
 	return
 }
 
+// GetFromSource loads a context by name, by file path like patterns, or by http(s) URL.
+// URLs are fetched with the limits of the fetch template plugin: text only, up to 1MB.
+func (o *ContextsEntity) GetFromSource(source string) (ret *Context, err error) {
+	switch {
+	case isURL(source):
+		var content string
+		if content, err = (&template.FetchPlugin{}).Apply("get", source); err != nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("contexts_error_fetch"), source, err))
+			return
+		}
+		ret = &Context{Name: source, Content: content}
+	case isFilePath(source):
+		var absPath string
+		if absPath, err = util.GetAbsolutePath(source); err != nil {
+			return
+		}
+		var content []byte
+		if content, err = os.ReadFile(absPath); err != nil {
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("contexts_error_load_from_file"), absPath, err))
+			return
+		}
+		ret = &Context{Name: source, Content: string(content)}
+	default:
+		ret, err = o.Get(source)
+	}
+	return
+}
+
+// GetApplyVariables loads a context from any source and substitutes its {{variables}},
+// {{input}} and plugin and extension calls, as patterns do. Like the input of patterns,
+// the input is put in after the templating, so that it is never run as a template.
+// Unknown {{name}} tokens are left as they are, since contexts often hold literal braces.
+// Contexts fetched from URLs only get their variables and input, never plugin or
+// extension calls.
+func (o *ContextsEntity) GetApplyVariables(
+	source string, variables map[string]string, input string) (ret *Context, err error) {
+
+	if ret, err = o.GetFromSource(source); err != nil {
+		return
+	}
+	if isURL(source) {
+		ret.Content = substituteVariables(ret.Content, variables, input)
+		return
+	}
+
+	protected := contextTokenPattern.ReplaceAllStringFunc(ret.Content, func(token string) string {
+		name := token[2 : len(token)-2]
+		if _, ok := variables[name]; ok || name == "input" ||
+			strings.HasPrefix(name, "plugin:") || strings.HasPrefix(name, "ext:") {
+			return token
+		}
+		return literalOpen + name + literalClose
+	})
+	withSentinel := strings.ReplaceAll(protected, "{{input}}", template.InputSentinel)
+	var processed string
+	if processed, err = template.ApplyTemplate(withSentinel, variables, input); err != nil {
+		return
+	}
+	processed = strings.NewReplacer(literalOpen, "{{", literalClose, "}}").Replace(processed)
+	ret.Content = strings.ReplaceAll(processed, template.InputSentinel, input)
+	return
+}
+
+// substituteVariables puts the given variables and the input in place of their {{name}}
+// tokens in a single pass, leaving every other token as it is.
+func substituteVariables(content string, variables map[string]string, input string) string {
+	return contextTokenPattern.ReplaceAllStringFunc(content, func(token string) string {
+		name := token[2 : len(token)-2]
+		if name == "input" {
+			return input
+		}
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return token
+	})
+}
+
 func (o *ContextsEntity) PrintContext(name string) (err error) {
 	var context *Context
 	if context, err = o.Get(name); err != nil {
@@ -30,3 +125,14 @@ type Context struct {
 	Name    string
 	Content string
 }
+
+// isURL tells whether a context is given by http(s) URL rather than by name
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// IsExternalSource tells whether a context is given by file path or URL instead of by
+// the name of a stored context.
+func IsExternalSource(source string) bool {
+	return isURL(source) || isFilePath(source)
+}
@@ -1,6 +1,8 @@
 package fsdb
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,3 +29,143 @@ func TestContexts_GetContext(t *testing.T) {
 		t.Errorf("expected %v, got %v", expectedContext, context)
 	}
 }
+
+func TestContexts_GetFromSource(t *testing.T) {
+	dir := t.TempDir()
+	contexts := &ContextsEntity{
+		StorageEntity: &StorageEntity{Dir: dir},
+	}
+	filePath := filepath.Join(t.TempDir(), "style.md")
+	if err := os.WriteFile(filePath, []byte("file context"), 0644); err != nil {
+		t.Fatalf("failed to write context file: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/glossary.md" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/markdown")
+		w.Write([]byte("url context"))
+	}))
+	defer server.Close()
+
+	context, err := contexts.GetFromSource(filePath)
+	if err != nil {
+		t.Fatalf("failed to get context from file: %v", err)
+	}
+	if context.Content != "file context" {
+		t.Errorf("expected file context, got %q", context.Content)
+	}
+
+	context, err = contexts.GetFromSource(server.URL + "/glossary.md")
+	if err != nil {
+		t.Fatalf("failed to get context from URL: %v", err)
+	}
+	if context.Content != "url context" {
+		t.Errorf("expected URL context, got %q", context.Content)
+	}
+
+	if _, err = contexts.GetFromSource(server.URL + "/missing.md"); err == nil {
+		t.Error("expected an error for a missing URL")
+	}
+	if _, err = contexts.GetFromSource(filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestContexts_GetApplyVariables(t *testing.T) {
+	dir := t.TempDir()
+	contexts := &ContextsEntity{
+		StorageEntity: &StorageEntity{Dir: dir},
+	}
+	content := "Write for {{audience}} in {{plugin:text:upper:plain}} words about: {{input}}"
+	if err := os.WriteFile(filepath.Join(dir, "audience"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write context file: %v", err)
+	}
+
+	context, err := contexts.GetApplyVariables("audience", map[string]string{"audience": "engineers"}, "caching")
+	if err != nil {
+		t.Fatalf("failed to apply variables: %v", err)
+	}
+	expected := "Write for engineers in PLAIN words about: caching"
+	if context.Content != expected {
+		t.Errorf("expected %q, got %q", expected, context.Content)
+	}
+
+	// A variable that is not given is left as it is
+	context, err = contexts.GetApplyVariables("audience", nil, "caching")
+	if err != nil {
+		t.Fatalf("failed to apply variables without the audience: %v", err)
+	}
+	expected = "Write for {{audience}} in PLAIN words about: caching"
+	if context.Content != expected {
+		t.Errorf("expected %q, got %q", expected, context.Content)
+	}
+	// The input is never run as a template, so it cannot call plugins or need variables
+	input := "{{plugin:sys:env:HOME}} and {{name}}"
+	context, err = contexts.GetApplyVariables("audience", map[string]string{"audience": "engineers"}, input)
+	if err != nil {
+		t.Fatalf("failed to apply variables with braces in the input: %v", err)
+	}
+	expected = "Write for engineers in PLAIN words about: " + input
+	if context.Content != expected {
+		t.Errorf("expected %q, got %q", expected, context.Content)
+	}
+}
+
+func TestContexts_GetApplyVariablesKeepsLiteralBraces(t *testing.T) {
+	dir := t.TempDir()
+	contexts := &ContextsEntity{
+		StorageEntity: &StorageEntity{Dir: dir},
+	}
+	content := "Templates look like {{foo}} and {{ .Values.name }}, for {{audience}}"
+	if err := os.WriteFile(filepath.Join(dir, "templates"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write context file: %v", err)
+	}
+
+	context, err := contexts.GetApplyVariables("templates", map[string]string{"audience": "engineers"}, "")
+	if err != nil {
+		t.Fatalf("failed to load a context with literal braces: %v", err)
+	}
+	expected := "Templates look like {{foo}} and {{ .Values.name }}, for engineers"
+	if context.Content != expected {
+		t.Errorf("expected %q, got %q", expected, context.Content)
+	}
+}
+
+func TestContexts_GetApplyVariablesFromURL(t *testing.T) {
+	t.Setenv("FABRIC_CONTEXT_SECRET", "secret")
+	contexts := &ContextsEntity{
+		StorageEntity: &StorageEntity{Dir: t.TempDir()},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("{{plugin:sys:env:FABRIC_CONTEXT_SECRET}} {{ext:tool:run}} {{foo}} for {{audience}}: {{input}}"))
+	}))
+	defer server.Close()
+
+	// Fetched contexts only get their variables and input, never plugin or extension calls
+	context, err := contexts.GetApplyVariables(server.URL, map[string]string{"audience": "engineers"}, "caching")
+	if err != nil {
+		t.Fatalf("failed to apply variables to a URL context: %v", err)
+	}
+	expected := "{{plugin:sys:env:FABRIC_CONTEXT_SECRET}} {{ext:tool:run}} {{foo}} for engineers: caching"
+	if context.Content != expected {
+		t.Errorf("expected %q, got %q", expected, context.Content)
+	}
+}
+
+func TestIsExternalSource(t *testing.T) {
+	for source, want := range map[string]bool{
+		"coding":                  false,
+		"./style.md":              true,
+		"/etc/contexts/style.md":  true,
+		"~/style.md":              true,
+		"https://example.com/ctx": true,
+		"http://example.com/ctx":  true,
+	} {
+		if got := IsExternalSource(source); got != want {
+			t.Errorf("IsExternalSource(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ func NewDb(dir string) (db *Db) {
 	db = &Db{Dir: dir}
 
 	db.EnvFilePath = db.FilePath(".env")
+	db.SecretsFilePath = db.FilePath("secrets.enc")
 
 	db.Patterns = &PatternsEntity{
 		StorageEntity:          &StorageEntity{Label: "Patterns", Dir: db.FilePath("patterns"), ItemIsDir: true},
@@ -30,17 +31,26 @@ func NewDb(dir string) (db *Db) {
 	db.Contexts = &ContextsEntity{
 		&StorageEntity{Label: "Contexts", Dir: db.FilePath("contexts")}}
 
+	db.Batches = &BatchesEntity{
+		&StorageEntity{Label: "Batches", Dir: db.FilePath("batches"), FileExtension: ".json"}}
+
+	db.Snapshots = &SnapshotsEntity{
+		&StorageEntity{Label: "Snapshots", Dir: db.FilePath("snapshots"), FileExtension: ".json"}}
+
 	return
 }
 
 type Db struct {
 	Dir string
 
-	Patterns *PatternsEntity
-	Sessions *SessionsEntity
-	Contexts *ContextsEntity
+	Patterns  *PatternsEntity
+	Sessions  *SessionsEntity
+	Contexts  *ContextsEntity
+	Batches   *BatchesEntity
+	Snapshots *SnapshotsEntity
 
-	EnvFilePath string
+	EnvFilePath     string
+	SecretsFilePath string
 }
 
 func (o *Db) Configure() (err error) {
@@ -52,6 +62,14 @@ func (o *Db) Configure() (err error) {
 		return
 	}
 
+	// A locked secret store leaves its settings unset, so commands that need them
+	// fail as unconfigured, while the others still run
+	if o.SecretsEnabled() {
+		if secretsErr := o.ApplySecrets(); secretsErr != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", fmt.Sprintf(i18n.T("secrets_warning_not_loaded"), secretsErr))
+		}
+	}
+
 	// Set custom patterns directory after loading .env file
 	customPatternsDir := os.Getenv("CUSTOM_PATTERNS_DIRECTORY")
 	if customPatternsDir != "" {
@@ -76,6 +94,14 @@ func (o *Db) Configure() (err error) {
 		return
 	}
 
+	if err = o.Batches.Configure(); err != nil {
+		return
+	}
+
+	if err = o.Snapshots.Configure(); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -0,0 +1,70 @@
+package fsdb
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// The OS keyring entry holding the unlock secret of the secret store.
+const (
+	keyringService = "fabric"
+	keyringAccount = "secrets"
+)
+
+// keyringGet and keyringSet read and write the unlock secret in the OS keyring through
+// the macOS security tool or libsecret's secret-tool. They are variables so tests can
+// replace them.
+var (
+	keyringGet = osKeyringGet
+	keyringSet = osKeyringSet
+)
+
+func osKeyringGet() (ret string, err error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	default:
+		return "", errors.New(i18n.T("secrets_keyring_not_supported"))
+	}
+	var output []byte
+	if output, err = cmd.Output(); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(string(output))
+	return
+}
+
+func osKeyringSet(secret string) (err error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security reads the command from stdin in interactive mode, which keeps the
+		// secret off the command line; the secret is base64 and needs no escaping
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %q\n",
+			keyringService, keyringAccount, secret))
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// secret-tool reads the secret from stdin, which keeps it off the command line
+		cmd = exec.Command("secret-tool", "store", "--label=Fabric secrets", "service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return errors.New(i18n.T("secrets_keyring_not_supported"))
+	}
+	if err = cmd.Run(); err != nil || runtime.GOOS != "darwin" {
+		return
+	}
+	// security -i does not fail when the command it reads fails, so read the secret back
+	var stored string
+	if stored, err = osKeyringGet(); err == nil && stored != secret {
+		err = errors.New(i18n.T("secrets_keyring_not_saved"))
+	}
+	return
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/danielmiessler/fabric/internal/i18n"
 	"github.com/danielmiessler/fabric/internal/plugins/template"
 	"github.com/danielmiessler/fabric/internal/util"
+	"gopkg.in/yaml.v3"
 )
 
 type PatternsEntity struct {
@@ -24,6 +25,46 @@ type Pattern struct {
 	Name        string
 	Description string
 	Pattern     string
+	FrontMatter PatternFrontMatter
+}
+
+// PatternFrontMatter holds the settings a pattern declares in a YAML block between ---
+// lines at the top of its system.md. The block is not part of the prompt.
+type PatternFrontMatter struct {
+	// ApplyChanges offers the file changes the answer proposes to be applied
+	ApplyChanges bool `yaml:"apply_changes"`
+}
+
+// frontMatterDelimiter opens and closes the front matter of a pattern
+const frontMatterDelimiter = "---"
+
+// newPattern makes a pattern from the content of its system.md, taking out its front
+// matter. Content that only looks like front matter is kept as it is.
+func newPattern(name string, content string) (ret *Pattern) {
+	ret = &Pattern{Name: name, Pattern: content}
+	text := strings.TrimPrefix(content, "\ufeff")
+	rest, found := strings.CutPrefix(text, frontMatterDelimiter+"\n")
+	if !found {
+		rest, found = strings.CutPrefix(text, frontMatterDelimiter+"\r\n")
+	}
+	if !found {
+		return
+	}
+	end := strings.Index(rest, "\n"+frontMatterDelimiter)
+	if end < 0 {
+		return
+	}
+	after := rest[end+len(frontMatterDelimiter)+1:]
+	if after != "" && after[0] != '\n' && after[0] != '\r' {
+		return
+	}
+	var frontMatter PatternFrontMatter
+	if err := yaml.Unmarshal([]byte(rest[:end]), &frontMatter); err != nil {
+		return
+	}
+	ret.FrontMatter = frontMatter
+	ret.Pattern = strings.TrimLeft(after, "\r\n")
+	return
 }
 
 // GetApplyVariables main entry point for getting patterns from any source
@@ -50,19 +91,31 @@ func (o *PatternsEntity) GetWithoutVariables(source, input string) (pattern *Pat
 	return
 }
 
+// GetFrontMatter returns the front matter of a pattern, given by name or file path.
+func (o *PatternsEntity) GetFrontMatter(source string) (ret PatternFrontMatter, err error) {
+	var pattern *Pattern
+	if pattern, err = o.loadPattern(source); err != nil {
+		return
+	}
+	ret = pattern.FrontMatter
+	return
+}
+
 // GetRaw returns a pattern from storage without applying variable processing.
 func (o *PatternsEntity) GetRaw(name string) (*Pattern, error) {
 	return o.getFromDB(name)
 }
 
-func (o *PatternsEntity) loadPattern(source string) (pattern *Pattern, err error) {
-	// Determine if this is a file path
-	isFilePath := strings.HasPrefix(source, "\\") ||
+// isFilePath tells whether a pattern or context is given by file path rather than by name
+func isFilePath(source string) bool {
+	return strings.HasPrefix(source, "\\") ||
 		strings.HasPrefix(source, "/") ||
 		strings.HasPrefix(source, "~") ||
 		strings.HasPrefix(source, ".")
+}
 
-	if isFilePath {
+func (o *PatternsEntity) loadPattern(source string) (pattern *Pattern, err error) {
+	if isFilePath(source) {
 		// Resolve the file path using GetAbsolutePath
 		var absPath string
 		if absPath, err = util.GetAbsolutePath(source); err != nil {
@@ -127,11 +180,7 @@ func (o *PatternsEntity) getFromDB(name string) (ret *Pattern, err error) {
 	if o.CustomPatternsDir != "" {
 		customPatternPath := filepath.Join(o.CustomPatternsDir, name, o.SystemPatternFile)
 		if pattern, customErr := os.ReadFile(customPatternPath); customErr == nil {
-			ret = &Pattern{
-				Name:    name,
-				Pattern: string(pattern),
-			}
-			return ret, nil
+			return newPattern(name, string(pattern)), nil
 		}
 	}
 
@@ -152,11 +201,7 @@ func (o *PatternsEntity) getFromDB(name string) (ret *Pattern, err error) {
 		return nil, fmt.Errorf(i18n.T("pattern_not_found_list_available"), name)
 	}
 
-	patternStr := string(pattern)
-	ret = &Pattern{
-		Name:    name,
-		Pattern: patternStr,
-	}
+	ret = newPattern(name, string(pattern))
 	return
 }
 
@@ -205,10 +250,7 @@ func (o *PatternsEntity) getFromFile(pathStr string) (pattern *Pattern, err erro
 		err = fmt.Errorf(i18n.T("patterns_error_read_pattern_file"), pathStr, err)
 		return
 	}
-	pattern = &Pattern{
-		Name:    pathStr,
-		Pattern: string(content),
-	}
+	pattern = newPattern(pathStr, string(content))
 	return
 }
 
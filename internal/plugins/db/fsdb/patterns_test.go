@@ -3,6 +3,7 @@ package fsdb
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -386,3 +387,54 @@ func TestPatternsEntity_CustomPatternsEmpty(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "Main pattern content", pattern.Pattern)
 }
+
+func TestPatternFrontMatter(t *testing.T) {
+	entity, cleanup := setupTestPatternsEntity(t)
+	defer cleanup()
+
+	tests := []struct {
+		name        string
+		content     string
+		wantApply   bool
+		wantPattern string
+	}{
+		{
+			name:        "front matter",
+			content:     "---\napply_changes: true\n---\n\nYou are a coder.\n",
+			wantApply:   true,
+			wantPattern: "You are a coder.\n",
+		},
+		{
+			name:        "front matter with CRLF and BOM",
+			content:     "\ufeff---\r\napply_changes: true\r\n---\r\nYou are a coder.\r\n",
+			wantApply:   true,
+			wantPattern: "You are a coder.\r\n",
+		},
+		{
+			name:        "no front matter",
+			content:     "You are a writer.\n---\nMore.",
+			wantPattern: "You are a writer.\n---\nMore.",
+		},
+		{
+			name:        "invalid front matter",
+			content:     "---\n: [\n---\nText",
+			wantPattern: "---\n: [\n---\nText",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := strings.ReplaceAll(tt.name, " ", "_")
+			createTestPattern(t, entity, name, tt.content)
+
+			pattern, err := entity.GetRaw(name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantApply, pattern.FrontMatter.ApplyChanges)
+			assert.Equal(t, tt.wantPattern, pattern.Pattern)
+
+			frontMatter, err := entity.GetFrontMatter(name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantApply, frontMatter.ApplyChanges)
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package fsdb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Ways to unlock the secret store.
+const (
+	SecretsUnlockPassphrase = "passphrase"
+	SecretsUnlockKeyFile    = "keyfile"
+	SecretsUnlockKeyring    = "keyring"
+)
+
+// Environment variables unlocking the secret store without a keyring, for headless use.
+const (
+	SecretsPassphraseEnv = "FABRIC_SECRETS_PASSPHRASE"
+	SecretsKeyFileEnv    = "FABRIC_SECRETS_KEYFILE"
+)
+
+const secretsFileVersion = 1
+
+// scrypt parameters deriving the encryption key from the passphrase or key file.
+const (
+	secretsScryptN   = 1 << 15
+	secretsScryptR   = 8
+	secretsScryptP   = 1
+	secretsKeyLength = 32
+)
+
+// secretsFile is the encrypted secret store. Data holds the secrets as a JSON object,
+// sealed with AES-256-GCM under a key derived from the unlock secret and Salt.
+type secretsFile struct {
+	Version int    `json:"version"`
+	Unlock  string `json:"unlock"`
+	KeyFile string `json:"key_file,omitempty"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// SecretsEnabled tells whether sensitive settings are kept in the encrypted secret store
+// instead of the .env file.
+func (o *Db) SecretsEnabled() bool {
+	_, err := os.Stat(o.SecretsFilePath)
+	return err == nil
+}
+
+// InitSecrets creates an empty secret store unlocked by the given method. For the key
+// file method, keyFile is recorded so that later runs find it; for the keyring method,
+// a random unlock secret is generated and saved in the OS keyring.
+func (o *Db) InitSecrets(unlock string, keyFile string) (err error) {
+	file := &secretsFile{Version: secretsFileVersion, Unlock: unlock}
+	switch unlock {
+	case SecretsUnlockPassphrase:
+		if os.Getenv(SecretsPassphraseEnv) == "" {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_passphrase_not_set"), SecretsPassphraseEnv))
+		}
+	case SecretsUnlockKeyFile:
+		if keyFile == "" {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_keyfile_not_set"), SecretsKeyFileEnv))
+		}
+		if file.KeyFile, err = filepath.Abs(keyFile); err != nil {
+			return
+		}
+	case SecretsUnlockKeyring:
+		secret := make([]byte, secretsKeyLength)
+		if _, err = rand.Read(secret); err != nil {
+			return
+		}
+		if err = keyringSet(base64.StdEncoding.EncodeToString(secret)); err != nil {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_keyring_failed"), err))
+		}
+	default:
+		return fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_invalid_unlock"), unlock))
+	}
+	return o.writeSecrets(file, map[string]string{})
+}
+
+// LoadSecrets decrypts the secret store.
+func (o *Db) LoadSecrets() (ret map[string]string, err error) {
+	var file *secretsFile
+	if file, err = o.readSecretsFile(); err != nil {
+		return
+	}
+	var key []byte
+	if key, err = file.deriveKey(); err != nil {
+		return
+	}
+	var gcm cipher.AEAD
+	if gcm, err = newSecretsCipher(key); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = gcm.Open(nil, file.Nonce, file.Data, nil); err != nil {
+		err = errors.New(i18n.T("secrets_unlock_failed"))
+		return
+	}
+	err = json.Unmarshal(data, &ret)
+	return
+}
+
+// ApplySecrets decrypts the secret store into the environment, like the .env file.
+// Variables already set in the environment take precedence.
+func (o *Db) ApplySecrets() (err error) {
+	var secrets map[string]string
+	if secrets, err = o.LoadSecrets(); err != nil {
+		return
+	}
+	for name, value := range secrets {
+		if _, exists := os.LookupEnv(name); !exists {
+			if err = os.Setenv(name, value); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// SaveEnvSecrets saves .env content, moving the variables named in sensitive to the
+// secret store when it is enabled. Sensitive variables missing from the content are
+// removed from the store; the other secrets in the store are kept.
+func (o *Db) SaveEnvSecrets(content string, sensitive map[string]bool) (err error) {
+	if !o.SecretsEnabled() {
+		return o.SaveEnv(content)
+	}
+
+	var file *secretsFile
+	if file, err = o.readSecretsFile(); err != nil {
+		return
+	}
+	var secrets map[string]string
+	if secrets, err = o.LoadSecrets(); err != nil {
+		return
+	}
+	for name := range sensitive {
+		delete(secrets, name)
+	}
+
+	var values map[string]string
+	if values, err = godotenv.Unmarshal(content); err != nil {
+		return
+	}
+	var env strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, _, _ := strings.Cut(line, "=")
+		name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "export "))
+		if sensitive[name] {
+			secrets[name] = values[name]
+			continue
+		}
+		env.WriteString(line + "\n")
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	if err = o.writeSecrets(file, secrets); err != nil {
+		return
+	}
+	return o.SaveEnv(env.String())
+}
+
+// MigrateSecrets moves the variables of the .env file for which isSensitive is true
+// to the secret store, creating the store with the given unlock method if needed.
+// It returns the names of the moved variables.
+func (o *Db) MigrateSecrets(unlock string, keyFile string, isSensitive func(name string) bool) (ret []string, err error) {
+	if !o.SecretsEnabled() {
+		if err = o.InitSecrets(unlock, keyFile); err != nil {
+			return
+		}
+	}
+
+	var content []byte
+	if content, err = os.ReadFile(o.EnvFilePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	var values map[string]string
+	if values, err = godotenv.Unmarshal(string(content)); err != nil {
+		return
+	}
+	sensitive := map[string]bool{}
+	for name := range values {
+		if isSensitive(name) {
+			sensitive[name] = true
+			ret = append(ret, name)
+		}
+	}
+	slices.Sort(ret)
+	err = o.SaveEnvSecrets(string(content), sensitive)
+	return
+}
+
+// SetEnvValue sets a variable of the .env file, or removes it when value is empty, and
+// keeps the other lines as they are. A sensitive variable goes to the secret store
+// instead when it is enabled.
+func (o *Db) SetEnvValue(name string, value string, sensitive bool) (err error) {
+	var content []byte
+	if content, err = os.ReadFile(o.EnvFilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	var env strings.Builder
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineName, _, _ := strings.Cut(line, "=")
+		if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lineName), "export ")) == name {
+			continue
+		}
+		env.WriteString(line + "\n")
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if value != "" {
+		env.WriteString(name + "=" + value + "\n")
+	}
+
+	sensitiveNames := map[string]bool{}
+	if sensitive {
+		sensitiveNames[name] = true
+	}
+	return o.SaveEnvSecrets(env.String(), sensitiveNames)
+}
+
+// MaskedSecrets returns the saved values of the named variables, read from the .env file
+// and the secret store and masked by MaskSecret, so that callers never handle the secrets
+// themselves. Variables that are not saved, or that a locked store keeps, are empty.
+func (o *Db) MaskedSecrets(names ...string) (ret map[string]string) {
+	saved, _ := godotenv.Read(o.EnvFilePath)
+	var secrets map[string]string
+	if o.SecretsEnabled() {
+		secrets, _ = o.LoadSecrets()
+	}
+	ret = make(map[string]string, len(names))
+	for _, name := range names {
+		value := saved[name]
+		if value == "" {
+			value = secrets[name]
+		}
+		ret[name] = MaskSecret(value)
+	}
+	return
+}
+
+// MaskSecret returns value with all but its last four characters redacted.
+func MaskSecret(value string) string {
+	const visible = 4
+	if len(value) <= visible {
+		return value
+	}
+	return strings.Repeat("*", len(value)-visible) + value[len(value)-visible:]
+}
+
+func (o *Db) readSecretsFile() (ret *secretsFile, err error) {
+	var data []byte
+	if data, err = os.ReadFile(o.SecretsFilePath); err != nil {
+		return
+	}
+	ret = &secretsFile{}
+	if err = json.Unmarshal(data, ret); err != nil || ret.Version != secretsFileVersion {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_invalid_file"), o.SecretsFilePath))
+	}
+	return
+}
+
+// writeSecrets seals the secrets with a fresh salt and nonce and writes the store,
+// readable only by the user.
+func (o *Db) writeSecrets(file *secretsFile, secrets map[string]string) (err error) {
+	file.Salt = make([]byte, 16)
+	if _, err = rand.Read(file.Salt); err != nil {
+		return
+	}
+	var key []byte
+	if key, err = file.deriveKey(); err != nil {
+		return
+	}
+	var gcm cipher.AEAD
+	if gcm, err = newSecretsCipher(key); err != nil {
+		return
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(file.Nonce); err != nil {
+		return
+	}
+	var plain []byte
+	if plain, err = json.Marshal(secrets); err != nil {
+		return
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	var data []byte
+	if data, err = json.MarshalIndent(file, "", "  "); err != nil {
+		return
+	}
+	return os.WriteFile(o.SecretsFilePath, data, 0600)
+}
+
+// deriveKey derives the encryption key from the unlock secret of the store's method.
+func (o *secretsFile) deriveKey() (ret []byte, err error) {
+	var secret string
+	switch o.Unlock {
+	case SecretsUnlockPassphrase:
+		if secret = os.Getenv(SecretsPassphraseEnv); secret == "" {
+			return nil, fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_locked"), SecretsPassphraseEnv))
+		}
+	case SecretsUnlockKeyFile:
+		keyFile := o.KeyFile
+		if env := os.Getenv(SecretsKeyFileEnv); env != "" {
+			keyFile = env
+		}
+		var data []byte
+		if data, err = os.ReadFile(keyFile); err != nil {
+			return nil, fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_keyfile_read_failed"), keyFile, err))
+		}
+		secret = string(data)
+	case SecretsUnlockKeyring:
+		if secret, err = keyringGet(); err != nil {
+			return nil, fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_keyring_failed"), err))
+		}
+	default:
+		return nil, fmt.Errorf("%s", fmt.Sprintf(i18n.T("secrets_invalid_unlock"), o.Unlock))
+	}
+	return scrypt.Key([]byte(secret), o.Salt, secretsScryptN, secretsScryptR, secretsScryptP, secretsKeyLength)
+}
+
+func newSecretsCipher(key []byte) (ret cipher.AEAD, err error) {
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+	return cipher.NewGCM(block)
+}
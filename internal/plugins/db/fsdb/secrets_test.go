@@ -0,0 +1,188 @@
+package fsdb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDb_MigrateSecrets(t *testing.T) {
+	db := NewDb(t.TempDir())
+	t.Setenv(SecretsPassphraseEnv, "correct horse battery staple")
+	env := "DEFAULT_VENDOR=OpenAI\nOPENAI_API_KEY=sk-secret\nOPENAI_API_BASE_URL=https://api.openai.com/v1\n"
+	if err := db.SaveEnv(env); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := db.MigrateSecrets(SecretsUnlockPassphrase, "", func(name string) bool {
+		return strings.HasSuffix(name, "_KEY")
+	})
+	if err != nil {
+		t.Fatalf("MigrateSecrets() error = %v", err)
+	}
+	if len(moved) != 1 || moved[0] != "OPENAI_API_KEY" {
+		t.Errorf("moved = %v, want [OPENAI_API_KEY]", moved)
+	}
+
+	content, _ := os.ReadFile(db.EnvFilePath)
+	if strings.Contains(string(content), "sk-secret") || !strings.Contains(string(content), "DEFAULT_VENDOR=OpenAI") {
+		t.Errorf("unexpected .env after migration:\n%s", content)
+	}
+	stored, _ := os.ReadFile(db.SecretsFilePath)
+	if strings.Contains(string(stored), "sk-secret") {
+		t.Error("the secret store must not contain the secret in plain text")
+	}
+
+	secrets, err := db.LoadSecrets()
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if secrets["OPENAI_API_KEY"] != "sk-secret" {
+		t.Errorf("secrets = %v", secrets)
+	}
+
+	t.Setenv(SecretsPassphraseEnv, "wrong")
+	if _, err = db.LoadSecrets(); err == nil {
+		t.Error("expected an error for a wrong passphrase")
+	}
+}
+
+func TestDb_SaveEnvSecrets(t *testing.T) {
+	db := NewDb(t.TempDir())
+	keyFile := filepath.Join(t.TempDir(), "fabric.key")
+	if err := os.WriteFile(keyFile, []byte("key file content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InitSecrets(SecretsUnlockKeyFile, keyFile); err != nil {
+		t.Fatalf("InitSecrets() error = %v", err)
+	}
+
+	sensitive := map[string]bool{"GROQ_API_KEY": true, "MISTRAL_API_KEY": true}
+	if err := db.SaveEnvSecrets("GROQ_API_KEY=gsk_1\nMISTRAL_API_KEY=m1\nLANGUAGE=en\n", sensitive); err != nil {
+		t.Fatalf("SaveEnvSecrets() error = %v", err)
+	}
+	// MISTRAL_API_KEY was reset, so it is removed from the store
+	if err := db.SaveEnvSecrets("GROQ_API_KEY=gsk_2\nLANGUAGE=de\n", sensitive); err != nil {
+		t.Fatalf("SaveEnvSecrets() error = %v", err)
+	}
+
+	secrets, err := db.LoadSecrets()
+	if err != nil {
+		t.Fatalf("LoadSecrets() error = %v", err)
+	}
+	if len(secrets) != 1 || secrets["GROQ_API_KEY"] != "gsk_2" {
+		t.Errorf("secrets = %v, want only GROQ_API_KEY=gsk_2", secrets)
+	}
+	if content, _ := os.ReadFile(db.EnvFilePath); string(content) != "LANGUAGE=de\n" {
+		t.Errorf(".env = %q, want only the non-sensitive setting", content)
+	}
+}
+
+func TestDb_SetEnvValue(t *testing.T) {
+	db := NewDb(t.TempDir())
+	if err := db.SaveEnv("# comment\nDEFAULT_VENDOR=OpenAI\nOPENAI_API_KEY=sk-old\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetEnvValue("DEFAULT_MODEL", "gpt-4o", false); err != nil {
+		t.Fatalf("SetEnvValue() error = %v", err)
+	}
+	if err := db.SetEnvValue("OPENAI_API_KEY", "sk-new", true); err != nil {
+		t.Fatalf("SetEnvValue() error = %v", err)
+	}
+	if err := db.SetEnvValue("DEFAULT_VENDOR", "", false); err != nil {
+		t.Fatalf("SetEnvValue() error = %v", err)
+	}
+	want := "# comment\nDEFAULT_MODEL=gpt-4o\nOPENAI_API_KEY=sk-new\n"
+	if content, _ := os.ReadFile(db.EnvFilePath); string(content) != want {
+		t.Errorf(".env = %q, want %q", content, want)
+	}
+
+	// With the secret store enabled, sensitive settings are kept in it
+	t.Setenv(SecretsPassphraseEnv, "correct horse battery staple")
+	if err := db.InitSecrets(SecretsUnlockPassphrase, ""); err != nil {
+		t.Fatalf("InitSecrets() error = %v", err)
+	}
+	if err := db.SetEnvValue("OPENAI_API_KEY", "sk-secret", true); err != nil {
+		t.Fatalf("SetEnvValue() error = %v", err)
+	}
+	if content, _ := os.ReadFile(db.EnvFilePath); strings.Contains(string(content), "OPENAI_API_KEY") {
+		t.Errorf(".env = %q, must not contain the secret", content)
+	}
+	if secrets, err := db.LoadSecrets(); err != nil || secrets["OPENAI_API_KEY"] != "sk-secret" {
+		t.Errorf("secrets = %v, %v, want OPENAI_API_KEY=sk-secret", secrets, err)
+	}
+	if err := db.SetEnvValue("OPENAI_API_KEY", "", true); err != nil {
+		t.Fatalf("SetEnvValue() error = %v", err)
+	}
+	if secrets, _ := db.LoadSecrets(); len(secrets) != 0 {
+		t.Errorf("secrets = %v, want none after unsetting", secrets)
+	}
+}
+
+func TestDb_ApplySecretsFromKeyring(t *testing.T) {
+	var keyring string
+	keyringGet = func() (string, error) { return keyring, nil }
+	keyringSet = func(secret string) error { keyring = secret; return nil }
+	t.Cleanup(func() { keyringGet, keyringSet = osKeyringGet, osKeyringSet })
+
+	db := NewDb(t.TempDir())
+	if err := db.InitSecrets(SecretsUnlockKeyring, ""); err != nil {
+		t.Fatalf("InitSecrets() error = %v", err)
+	}
+	if keyring == "" {
+		t.Fatal("expected an unlock secret in the keyring")
+	}
+	sensitive := map[string]bool{"FABRIC_TEST_SECRET_KEY": true, "FABRIC_TEST_OTHER_KEY": true}
+	if err := db.SaveEnvSecrets("FABRIC_TEST_SECRET_KEY=from-store\nFABRIC_TEST_OTHER_KEY=from-store\n", sensitive); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FABRIC_TEST_OTHER_KEY", "from-env")
+	os.Unsetenv("FABRIC_TEST_SECRET_KEY")
+	t.Cleanup(func() { os.Unsetenv("FABRIC_TEST_SECRET_KEY") })
+	if err := db.ApplySecrets(); err != nil {
+		t.Fatalf("ApplySecrets() error = %v", err)
+	}
+	if got := os.Getenv("FABRIC_TEST_SECRET_KEY"); got != "from-store" {
+		t.Errorf("FABRIC_TEST_SECRET_KEY = %q, want the stored value", got)
+	}
+	if got := os.Getenv("FABRIC_TEST_OTHER_KEY"); got != "from-env" {
+		t.Errorf("FABRIC_TEST_OTHER_KEY = %q, the environment must take precedence", got)
+	}
+}
+
+func TestDb_MaskedSecrets(t *testing.T) {
+	db := NewDb(t.TempDir())
+	t.Setenv(SecretsPassphraseEnv, "correct horse battery staple")
+	if err := db.InitSecrets(SecretsUnlockPassphrase, ""); err != nil {
+		t.Fatal(err)
+	}
+	env := "GROQ_API_KEY=gsk-abcdef1234\nOPENAI_API_KEY=sk-abcdef5678\n"
+	if err := db.SaveEnvSecrets(env, map[string]bool{"OPENAI_API_KEY": true}); err != nil {
+		t.Fatal(err)
+	}
+	// Only saved values are masked, not what the process environment holds
+	t.Setenv("FABRIC_TEST_ENV_ONLY_KEY", "sk-from-the-environment")
+
+	masked := db.MaskedSecrets("GROQ_API_KEY", "OPENAI_API_KEY", "FABRIC_TEST_ENV_ONLY_KEY")
+	want := map[string]string{
+		"GROQ_API_KEY":             "**********1234",
+		"OPENAI_API_KEY":           "*********5678",
+		"FABRIC_TEST_ENV_ONLY_KEY": "",
+	}
+	for name, value := range want {
+		if masked[name] != value {
+			t.Errorf("MaskedSecrets()[%s] = %q, want %q", name, masked[name], value)
+		}
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	if got := MaskSecret("sk-abcdef1234"); got != "*********1234" {
+		t.Errorf("MaskSecret() = %q", got)
+	}
+	if got := MaskSecret(""); got != "" {
+		t.Errorf("MaskSecret() of an empty value = %q, want empty", got)
+	}
+}
@@ -92,6 +92,10 @@ func (o *Session) String() (ret string) {
 					ret += fmt.Sprintf("\n%v: %v", part.Type, *part.ImageURL)
 				case chat.ChatMessagePartTypeText:
 					ret += fmt.Sprintf("\n%v: %v", part.Type, part.Text)
+				case chat.ChatMessagePartTypeFile:
+					ret += fmt.Sprintf("\n%v: %v (%v)", part.Type, part.File.Filename, part.File.MimeType)
+				case chat.ChatMessagePartTypeInputAudio:
+					ret += fmt.Sprintf("\n%v: %v", part.Type, part.InputAudio.Format)
 				}
 			}
 		}
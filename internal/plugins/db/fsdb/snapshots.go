@@ -0,0 +1,117 @@
+package fsdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// snapshotIDFormat names snapshots after the time they were taken, so they sort by age
+const snapshotIDFormat = "20060102-150405"
+
+// SnapshotsEntity stores the files that applied file changes replaced, so that the
+// changes can be rolled back in a later run.
+type SnapshotsEntity struct {
+	*StorageEntity
+}
+
+// Get loads a snapshot by its ID, or the most recent one for "latest"
+func (o *SnapshotsEntity) Get(id string) (snapshot *Snapshot, err error) {
+	if id == "latest" {
+		var names []string
+		if names, err = o.GetNames(); err != nil {
+			return
+		}
+		if len(names) == 0 {
+			err = errors.New(i18n.T("snapshot_none"))
+			return
+		}
+		slices.Sort(names)
+		id = names[len(names)-1]
+	}
+	if !o.Exists(id) {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("snapshot_not_found"), id))
+		return
+	}
+	snapshot = &Snapshot{}
+	err = o.LoadAsJson(id, snapshot)
+	return
+}
+
+// SaveSnapshot stores the snapshot, naming it after the time it was taken
+func (o *SnapshotsEntity) SaveSnapshot(snapshot *Snapshot) (err error) {
+	id := snapshot.CreatedAt.Format(snapshotIDFormat)
+	snapshot.ID = id
+	for i := 2; o.Exists(snapshot.ID); i++ {
+		snapshot.ID = fmt.Sprintf("%s-%d", id, i)
+	}
+	return o.SaveAsJson(snapshot.ID, snapshot)
+}
+
+// Snapshot records files of a project as they were before file changes were applied.
+type Snapshot struct {
+	ID        string         `json:"id"`
+	Root      string         `json:"root"`
+	Pattern   string         `json:"pattern,omitempty"`
+	Files     []SnapshotFile `json:"files"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// SnapshotFile is a file as it was, or a file that did not exist yet.
+type SnapshotFile struct {
+	Path    string      `json:"path"` // Relative to the root of the snapshot
+	Existed bool        `json:"existed"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	Content []byte      `json:"content,omitempty"`
+}
+
+// NewSnapshot records the files at paths, relative to root, as they are now.
+func NewSnapshot(root string, paths []string) (ret *Snapshot, err error) {
+	ret = &Snapshot{Root: root, CreatedAt: time.Now()}
+	for _, path := range paths {
+		if slices.ContainsFunc(ret.Files, func(file SnapshotFile) bool { return file.Path == path }) {
+			continue
+		}
+		file := SnapshotFile{Path: path}
+		absPath := filepath.Join(root, path)
+		var info os.FileInfo
+		if info, err = os.Stat(absPath); err == nil {
+			file.Existed = true
+			file.Mode = info.Mode().Perm()
+			if file.Content, err = os.ReadFile(absPath); err != nil {
+				return
+			}
+		} else if !os.IsNotExist(err) {
+			return
+		}
+		err = nil
+		ret.Files = append(ret.Files, file)
+	}
+	return
+}
+
+// Restore puts the files back as they were, deleting those that did not exist.
+func (o *Snapshot) Restore() (err error) {
+	for _, file := range o.Files {
+		absPath := filepath.Join(o.Root, file.Path)
+		if !file.Existed {
+			if err = os.Remove(absPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("%s", fmt.Sprintf(i18n.T("snapshot_restore_failed"), file.Path, err))
+			}
+			err = nil
+			continue
+		}
+		if err = os.MkdirAll(filepath.Dir(absPath), 0755); err == nil {
+			err = os.WriteFile(absPath, file.Content, file.Mode)
+		}
+		if err != nil {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("snapshot_restore_failed"), file.Path, err))
+		}
+	}
+	return
+}
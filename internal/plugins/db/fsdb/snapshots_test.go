@@ -0,0 +1,87 @@
+package fsdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshots_SaveRestore(t *testing.T) {
+	root := t.TempDir()
+	snapshots := &SnapshotsEntity{
+		StorageEntity: &StorageEntity{Dir: t.TempDir(), FileExtension: ".json"},
+	}
+	if err := os.WriteFile(filepath.Join(root, "existing.txt"), []byte("before"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	snapshot, err := NewSnapshot(root, []string{"existing.txt", "new/created.txt", "existing.txt"})
+	if err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if len(snapshot.Files) != 2 {
+		t.Fatalf("expected 2 files in snapshot, got %d", len(snapshot.Files))
+	}
+	if err = snapshots.SaveSnapshot(snapshot); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+
+	// Change the files as applied file changes would
+	if err = os.WriteFile(filepath.Join(root, "existing.txt"), []byte("after"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err = os.MkdirAll(filepath.Join(root, "new"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(root, "new", "created.txt"), []byte("created"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	loaded, err := snapshots.Get("latest")
+	if err != nil {
+		t.Fatalf("failed to get latest snapshot: %v", err)
+	}
+	if loaded.ID != snapshot.ID {
+		t.Errorf("expected latest snapshot %v, got %v", snapshot.ID, loaded.ID)
+	}
+	if err = loaded.Restore(); err != nil {
+		t.Fatalf("failed to restore snapshot: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "existing.txt"))
+	if err != nil || string(content) != "before" {
+		t.Errorf("expected existing.txt to be restored, got %q, %v", content, err)
+	}
+	if info, statErr := os.Stat(filepath.Join(root, "existing.txt")); statErr != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected existing.txt to keep mode 0600, got %v", info)
+	}
+	if _, err = os.Stat(filepath.Join(root, "new", "created.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected created.txt to be removed, got %v", err)
+	}
+}
+
+func TestSnapshots_IDs(t *testing.T) {
+	snapshots := &SnapshotsEntity{
+		StorageEntity: &StorageEntity{Dir: t.TempDir(), FileExtension: ".json"},
+	}
+	if _, err := snapshots.Get("latest"); err == nil {
+		t.Error("expected an error without snapshots")
+	}
+
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local)
+	first := &Snapshot{CreatedAt: createdAt}
+	second := &Snapshot{CreatedAt: createdAt}
+	if err := snapshots.SaveSnapshot(first); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	if err := snapshots.SaveSnapshot(second); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	if first.ID != "20240501-123000" || second.ID != "20240501-123000-2" {
+		t.Errorf("unexpected snapshot IDs %v and %v", first.ID, second.ID)
+	}
+	if _, err := snapshots.Get("missing"); err == nil {
+		t.Error("expected an error for a missing snapshot")
+	}
+}
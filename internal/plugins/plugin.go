@@ -46,6 +46,16 @@ func NewVendorPluginBase(name string, configure func() error) *PluginBase {
 	}
 }
 
+// GetSettings returns the settings of the plugin, for diagnostics.
+func (o *PluginBase) GetSettings() Settings {
+	return o.Settings
+}
+
+// GetEnvNamePrefix returns the prefix of the environment variables of the settings.
+func (o *PluginBase) GetEnvNamePrefix() string {
+	return o.EnvNamePrefix
+}
+
 func (o *PluginBase) GetSetupDescription() (ret string) {
 	if ret = o.SetupDescription; ret == "" {
 		ret = o.GetName()
@@ -143,9 +153,16 @@ func NewSetting(envVariable string, required bool) *Setting {
 	return &Setting{
 		EnvVariable: envVariable,
 		Required:    required,
+		Sensitive:   IsSensitiveEnvVariable(envVariable),
 	}
 }
 
+// IsSensitiveEnvVariable tells whether a variable holds a key, token or secret by its name.
+func IsSensitiveEnvVariable(envVariable string) bool {
+	return strings.HasSuffix(envVariable, "_KEY") || strings.Contains(envVariable, "TOKEN") ||
+		strings.Contains(envVariable, "SECRET")
+}
+
 // In plugins/plugin.go
 
 type Setting struct {
@@ -153,6 +170,8 @@ type Setting struct {
 	Value       string
 	Required    bool
 	Type        string // "string" (default), "bool"
+	// Sensitive settings are kept in the encrypted secret store when it is enabled
+	Sensitive bool
 }
 
 func (o *Setting) IsValid() bool {
@@ -112,6 +112,14 @@ func TestSetting_Configure(t *testing.T) {
 	assert.Equal(t, "test_value", setting.Value)
 }
 
+func TestNewSetting_Sensitive(t *testing.T) {
+	assert.True(t, NewSetting("OPENAI_API_KEY", true).Sensitive)
+	assert.True(t, NewSetting("COPILOT_ACCESS_TOKEN", false).Sensitive)
+	assert.True(t, NewSetting("SPOTIFY_CLIENT_SECRET", false).Sensitive)
+	assert.False(t, NewSetting("OPENAI_API_BASE_URL", false).Sensitive)
+	assert.False(t, NewSetting("DEFAULT_MODEL", false).Sensitive)
+}
+
 func TestSetting_FillEnvFileContent(t *testing.T) {
 	buffer := &bytes.Buffer{}
 	setting := &Setting{
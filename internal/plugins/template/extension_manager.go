@@ -131,6 +131,20 @@ func (em *ExtensionManager) RemoveExtension(name string) error {
 	return nil
 }
 
+// VerifyExtensions checks every registered extension against the hashes recorded when it
+// was registered. It returns the error found for each extension, nil for intact ones.
+func (em *ExtensionManager) VerifyExtensions() (ret map[string]error, err error) {
+	if em.registry == nil || em.registry.registry.Extensions == nil {
+		err = errors.New(i18n.T("extension_registry_not_initialized"))
+		return
+	}
+	ret = make(map[string]error, len(em.registry.registry.Extensions))
+	for name := range em.registry.registry.Extensions {
+		ret[name] = em.registry.Verify(name)
+	}
+	return
+}
+
 // ProcessExtension handles template processing for extension directives
 func (em *ExtensionManager) ProcessExtension(name, operation, value string) (string, error) {
 	return em.executor.Execute(name, operation, value)
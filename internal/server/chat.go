@@ -25,7 +25,8 @@ type PromptRequest struct {
 	UserInput    string            `json:"userInput"`
 	Vendor       string            `json:"vendor"`
 	Model        string            `json:"model"`
-	ContextName  string            `json:"contextName"`
+	ContextName  string            `json:"contextName"`            // Single context, kept for older clients
+	ContextNames []string          `json:"contextNames,omitempty"` // Contexts stacked in order, after contextName
 	PatternName  string            `json:"patternName"`
 	StrategyName string            `json:"strategyName"`        // Optional strategy name
 	SessionName  string            `json:"sessionName"`         // Session name for multi-turn conversations
@@ -40,10 +41,11 @@ type ChatRequest struct {
 }
 
 type StreamResponse struct {
-	Type    string                `json:"type"`             // "content", "usage", "error", "complete"
-	Format  string                `json:"format,omitempty"` // "markdown", "mermaid", "plain"
-	Content string                `json:"content,omitempty"`
-	Usage   *domain.UsageMetadata `json:"usage,omitempty"`
+	Type      string                `json:"type"`             // "content", "reasoning", "citation", "usage", "warning", "error", "complete"
+	Format    string                `json:"format,omitempty"` // "markdown", "mermaid", "plain"
+	Content   string                `json:"content,omitempty"`
+	Usage     *domain.UsageMetadata `json:"usage,omitempty"`
+	Citations []chat.Citation       `json:"citations,omitempty"`
 }
 
 func NewChatHandler(r *gin.Engine, registry *core.PluginRegistry, db *fsdb.Db) *ChatHandler {
@@ -78,6 +80,17 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 		return
 	}
 
+	// Contexts are only read from the contexts directory, so that requests cannot make
+	// the server read its files or fetch URLs
+	for _, prompt := range request.Prompts {
+		for _, name := range prompt.contextNames() {
+			if fsdb.IsExternalSource(name) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf(i18n.T("server_context_source_not_allowed"), name)})
+				return
+			}
+		}
+	}
+
 	// Add log to check received language field
 	log.Printf("Received chat request - Language: '%s', Prompts: %d", request.Language, len(request.Prompts))
 
@@ -97,7 +110,7 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 			return
 		default:
 			log.Printf("Processing prompt %d: Model=%s Pattern=%s Context=%s",
-				i+1, prompt.Model, prompt.PatternName, prompt.ContextName)
+				i+1, prompt.Model, prompt.PatternName, strings.Join(prompt.contextNames(), ","))
 
 			streamChan := make(chan domain.StreamUpdate)
 			// Send returns an error for a failure that happens before it starts
@@ -133,6 +146,10 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 					Thinking:         request.Thinking,
 					Search:           request.Search,
 					SearchLocation:   request.SearchLocation,
+					Chunk:            request.Chunk,
+					ChunkSize:        request.ChunkSize,
+					ChunkOverlap:     request.ChunkOverlap,
+					MergePattern:     request.MergePattern,
 					UpdateChan:       streamChan,
 					Quiet:            true,
 				}
@@ -159,11 +176,28 @@ func (h *ChatHandler) HandleChat(c *gin.Context) {
 							Format:  detectFormat(update.Content),
 							Content: update.Content,
 						}
+					case domain.StreamTypeReasoning:
+						response = StreamResponse{
+							Type:    "reasoning",
+							Format:  "plain",
+							Content: update.Content,
+						}
+					case domain.StreamTypeCitation:
+						response = StreamResponse{
+							Type:      "citation",
+							Citations: update.Citations,
+						}
 					case domain.StreamTypeUsage:
 						response = StreamResponse{
 							Type:  "usage",
 							Usage: update.Usage,
 						}
+					case domain.StreamTypeWarning:
+						response = StreamResponse{
+							Type:    "warning",
+							Format:  "plain",
+							Content: update.Content,
+						}
 					case domain.StreamTypeError:
 						sawError = true
 						response = StreamResponse{
@@ -235,7 +269,7 @@ func buildPromptChatRequest(p PromptRequest, language string) *domain.ChatReques
 			Content: p.UserInput,
 		},
 		PatternName:      p.PatternName,
-		ContextName:      p.ContextName,
+		ContextNames:     p.contextNames(),
 		SessionName:      p.SessionName,
 		PatternVariables: p.Variables,
 		StrategyName:     p.StrategyName,
@@ -243,6 +277,14 @@ func buildPromptChatRequest(p PromptRequest, language string) *domain.ChatReques
 	}
 }
 
+// contextNames returns the contexts of the prompt, in the order they are stacked.
+func (p PromptRequest) contextNames() (ret []string) {
+	if p.ContextName != "" {
+		ret = append(ret, p.ContextName)
+	}
+	return append(ret, p.ContextNames...)
+}
+
 func writeSSEResponse(w gin.ResponseWriter, response StreamResponse) error {
 	data, err := json.Marshal(response)
 	if err != nil {
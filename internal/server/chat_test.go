@@ -2,7 +2,12 @@ package restapi
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestBuildPromptChatRequest_PreservesStrategyAndUserInput(t *testing.T) {
@@ -11,6 +16,7 @@ func TestBuildPromptChatRequest_PreservesStrategyAndUserInput(t *testing.T) {
 		Vendor:       "TestVendor",
 		Model:        "test-model",
 		ContextName:  "ctx",
+		ContextNames: []string{"style", "glossary"},
 		PatternName:  "pattern",
 		StrategyName: "strategy",
 		SessionName:  "session",
@@ -33,8 +39,8 @@ func TestBuildPromptChatRequest_PreservesStrategyAndUserInput(t *testing.T) {
 	if request.PatternName != "pattern" {
 		t.Fatalf("expected pattern name to be preserved, got %q", request.PatternName)
 	}
-	if request.ContextName != "ctx" {
-		t.Fatalf("expected context name to be preserved, got %q", request.ContextName)
+	if got := strings.Join(request.ContextNames, ","); got != "ctx,style,glossary" {
+		t.Fatalf("expected contexts to be stacked in order, got %q", got)
 	}
 	if request.SessionName != "session" {
 		t.Fatalf("expected session name to be preserved, got %q", request.SessionName)
@@ -115,3 +121,18 @@ func TestUnreportedSendErrorWithEmptyChannel(t *testing.T) {
 		t.Error("want no report from an empty channel")
 	}
 }
+
+func TestHandleChat_RejectsExternalContexts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewChatHandler(router, nil, nil)
+
+	for _, context := range []string{"./secrets.md", "https://example.com/ctx"} {
+		body := `{"prompts":[{"userInput":"hi","contextNames":["` + context + `"]}]}`
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(body)))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for context %q, got %d", context, recorder.Code)
+		}
+	}
+}
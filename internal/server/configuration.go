@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/danielmiessler/fabric/internal/plugins"
 	"github.com/danielmiessler/fabric/internal/plugins/db/fsdb"
 	"github.com/gin-gonic/gin"
 )
@@ -28,19 +29,8 @@ func NewConfigHandler(r *gin.Engine, db *fsdb.Db) *ConfigHandler {
 	return handler
 }
 
-// maskAPIKey redacts all but the last 4 characters of a secret key (CWE-200).
-// An empty value (key not configured) is returned unchanged so the UI can
-// distinguish "not set" from "set but redacted".
-func maskAPIKey(key string) string {
-	const visible = 4
-	if len(key) <= visible {
-		return key
-	}
-	return strings.Repeat("*", len(key)-visible) + key[len(key)-visible:]
-}
-
 // isRedacted returns true when a submitted value looks like a masked key
-// returned by maskAPIKey, signalling that the user did not change the field.
+// returned by GetConfig, signalling that the user did not change the field.
 func isRedacted(value string) bool {
 	return strings.Contains(value, "*")
 }
@@ -73,19 +63,22 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 		return
 	}
 
-	// API keys are masked to their last 4 characters (CWE-200).
-	// URLs are not secrets and are returned as-is so the UI can display them.
+	// API keys are read from the .env file and the secret store and masked to their last
+	// 4 characters by the db, so the handler never sees them (CWE-200). URLs are not
+	// secrets and are returned as-is so the UI can display them.
+	masked := h.db.MaskedSecrets("OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GROQ_API_KEY", "MISTRAL_API_KEY",
+		"GEMINI_API_KEY", "OPENROUTER_API_KEY", "SILICON_API_KEY", "DEEPSEEK_API_KEY", "GROKAI_API_KEY")
 	config := map[string]string{
-		"openai":     maskAPIKey(os.Getenv("OPENAI_API_KEY")),
-		"anthropic":  maskAPIKey(os.Getenv("ANTHROPIC_API_KEY")),
-		"groq":       maskAPIKey(os.Getenv("GROQ_API_KEY")),
-		"mistral":    maskAPIKey(os.Getenv("MISTRAL_API_KEY")),
-		"gemini":     maskAPIKey(os.Getenv("GEMINI_API_KEY")),
+		"openai":     masked["OPENAI_API_KEY"],
+		"anthropic":  masked["ANTHROPIC_API_KEY"],
+		"groq":       masked["GROQ_API_KEY"],
+		"mistral":    masked["MISTRAL_API_KEY"],
+		"gemini":     masked["GEMINI_API_KEY"],
 		"ollama":     os.Getenv("OLLAMA_URL"),
-		"openrouter": maskAPIKey(os.Getenv("OPENROUTER_API_KEY")),
-		"silicon":    maskAPIKey(os.Getenv("SILICON_API_KEY")),
-		"deepseek":   maskAPIKey(os.Getenv("DEEPSEEK_API_KEY")),
-		"grokai":     maskAPIKey(os.Getenv("GROKAI_API_KEY")),
+		"openrouter": masked["OPENROUTER_API_KEY"],
+		"silicon":    masked["SILICON_API_KEY"],
+		"deepseek":   masked["DEEPSEEK_API_KEY"],
+		"grokai":     masked["GROKAI_API_KEY"],
 		"lmstudio":   os.Getenv("LM_STUDIO_API_BASE_URL"),
 	}
 
@@ -132,17 +125,21 @@ func (h *ConfigHandler) UpdateConfig(c *gin.Context) {
 	}
 
 	var envContent strings.Builder
+	sensitive := map[string]bool{}
 	for key, value := range envVars {
 		// Skip empty values and redacted placeholders returned by GET /config.
 		// Writing a masked value back would corrupt the stored key.
 		if value != "" && !isRedacted(value) {
 			envContent.WriteString(fmt.Sprintf("%s=%s\n", key, value))
 			os.Setenv(key, value)
+			if plugins.IsSensitiveEnvVariable(key) {
+				sensitive[key] = true
+			}
 		}
 	}
 
-	// Save configuration to file
-	if err := h.db.SaveEnv(envContent.String()); err != nil {
+	// Save configuration to file, keys to the secret store when it is enabled
+	if err := h.db.SaveEnvSecrets(envContent.String(), sensitive); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -310,7 +310,6 @@ func (f APIConvert) ollamaChat(c *gin.Context) {
 			UserInput:   prompt.Messages[0].Content,
 			Vendor:      "",
 			Model:       "",
-			ContextName: "",
 			PatternName: strings.Split(prompt.Model, ":")[0],
 			Variables:   variables,
 		}}
@@ -323,7 +322,6 @@ func (f APIConvert) ollamaChat(c *gin.Context) {
 			UserInput:   content,
 			Vendor:      "",
 			Model:       "",
-			ContextName: "",
 			PatternName: strings.Split(prompt.Model, ":")[0],
 			Variables:   variables,
 		}}
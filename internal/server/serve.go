@@ -72,6 +72,7 @@ func Serve(registry *core.PluginRegistry, address string, apiKey string) (err er
 	NewSessionsHandler(r, fabricDb.Sessions)
 	NewChatHandler(r, registry, fabricDb)
 	NewYouTubeHandler(r, registry)
+	NewTranscriptionHandler(r, registry.VendorManager)
 	NewConfigHandler(r, fabricDb)
 	NewModelsHandler(r, registry.VendorManager)
 	NewStrategiesHandler(r)
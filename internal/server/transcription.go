@@ -0,0 +1,112 @@
+package restapi
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/domain"
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/gin-gonic/gin"
+)
+
+type TranscriptionHandler struct {
+	vendorManager *ai.VendorsManager
+}
+
+// TranscriptionResponse holds the transcript and its rendering in the requested format
+type TranscriptionResponse struct {
+	domain.Transcript
+	Format string `json:"format" example:"srt"`          // Requested output format
+	Output string `json:"output" example:"Hello there."` // Transcript in the requested format
+}
+
+func NewTranscriptionHandler(r *gin.Engine, vendorManager *ai.VendorsManager) *TranscriptionHandler {
+	handler := &TranscriptionHandler{vendorManager: vendorManager}
+	r.POST("/transcribe", handler.Transcribe)
+	return handler
+}
+
+// Transcribe godoc
+// @Summary Transcribe an audio or video file
+// @Description Transcribes an uploaded file as plain text, SRT or WebVTT subtitles, or JSON with timed segments. Speaker labels are included for diarization models.
+// @Tags transcription
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Audio or video file (mp3, mp4, mpeg, mpga, m4a, wav, webm)"
+// @Param model formData string true "Transcription model, e.g. whisper-1 or gpt-4o-transcribe-diarize"
+// @Param format formData string false "Output format: text, srt, vtt, json (default: text)"
+// @Param vendor formData string false "Vendor to transcribe with (default: OpenAI)"
+// @Param split formData bool false "Split files larger than 25MB using ffmpeg"
+// @Success 200 {object} TranscriptionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Router /transcribe [post]
+func (h *TranscriptionHandler) Transcribe(c *gin.Context) {
+	upload, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	model := c.PostForm("model")
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	format := strings.ToLower(c.DefaultPostForm("format", domain.TranscriptFormatText))
+	if !slices.Contains(domain.TranscriptFormats, format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of: " + strings.Join(domain.TranscriptFormats, ", ")})
+		return
+	}
+
+	vendorName := c.DefaultPostForm("vendor", "OpenAI")
+	transcriber, ok := h.vendorManager.FindByName(vendorName).(ai.Transcriber)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vendor " + vendorName + " is not configured or does not support transcription"})
+		return
+	}
+
+	// The vendor picks the audio format by the extension of the saved upload
+	var dir string
+	if dir, err = os.MkdirTemp("", "fabric-transcribe-*"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "upload"+strings.ToLower(filepath.Ext(upload.Filename)))
+	if err = c.SaveUploadedFile(upload, filePath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := &domain.TranscriptionOptions{
+		Model:      model,
+		Split:      c.PostForm("split") == "true",
+		Timestamps: format != domain.TranscriptFormatText,
+	}
+	var transcript *domain.Transcript
+	if transcript, err = transcriber.Transcribe(c.Request.Context(), filePath, opts); err != nil {
+		c.JSON(transcriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	var output string
+	if output, err = transcript.Format(format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TranscriptionResponse{Transcript: *transcript, Format: format, Output: output})
+}
+
+// transcriptionErrorStatus answers errors the vendor classified as invalid requests, such
+// as an unsupported model or file type, with 400 and other failures with 500.
+func transcriptionErrorStatus(err error) int {
+	switch domain.CodeOf(err) {
+	case domain.ErrorCodeInvalidArguments, domain.ErrorCodeInvalidRequest, domain.ErrorCodeUnsupported:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
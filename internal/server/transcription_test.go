@@ -0,0 +1,52 @@
+package restapi
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielmiessler/fabric/internal/plugins/ai"
+	"github.com/danielmiessler/fabric/internal/plugins/ai/openai"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTranscribeAnswersInvalidRequestsWithBadRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	vendorManager := ai.NewVendorsManager()
+	vendorManager.AddVendors(openai.NewClient())
+	router := gin.New()
+	NewTranscriptionHandler(router, vendorManager)
+
+	tests := []struct {
+		name     string
+		fileName string
+		model    string
+	}{
+		{name: "unsupported model", fileName: "talk.mp3", model: "not-a-model"},
+		{name: "unsupported file type", fileName: "talk.txt", model: "whisper-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, err := writer.CreateFormFile("file", tt.fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = part.Write([]byte("audio"))
+			_ = writer.WriteField("model", tt.model)
+			_ = writer.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/transcribe", &body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/ledongthuc/pdf"
+)
+
+// Document MIME types that DocumentText can convert.
+const (
+	MimeTypePDF  = "application/pdf"
+	MimeTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeTypeHTML = "text/html"
+	MimeTypeCSV  = "text/csv"
+)
+
+// DocumentText extracts the plain text of a PDF, DOCX, HTML, CSV or text document so it
+// can be given to models that do not accept the document itself.
+func DocumentText(mimeType string, content []byte) (ret string, err error) {
+	switch {
+	case mimeType == MimeTypePDF:
+		ret, err = pdfText(content)
+	case mimeType == MimeTypeDOCX:
+		ret, err = docxText(content)
+	case mimeType == MimeTypeHTML:
+		ret, err = HtmlReadability(string(content))
+	case mimeType == MimeTypeCSV:
+		ret = csvText(content)
+	case isPlainText(mimeType):
+		ret = strings.TrimSpace(string(content))
+	default:
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("document_type_not_supported"), mimeType))
+	}
+	return
+}
+
+// isPlainText reports whether content of mimeType can be given to a model as is.
+func isPlainText(mimeType string) bool {
+	switch mimeType {
+	case MimeTypeHTML, MimeTypeCSV:
+		return false
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/javascript":
+		return true
+	}
+	return strings.HasPrefix(mimeType, "text/")
+}
+
+func pdfText(content []byte) (ret string, err error) {
+	var reader *pdf.Reader
+	if reader, err = pdf.NewReader(bytes.NewReader(content), int64(len(content))); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("document_pdf_read_failed"), err))
+		return
+	}
+	var text io.Reader
+	if text, err = reader.GetPlainText(); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("document_pdf_read_failed"), err))
+		return
+	}
+	var data []byte
+	if data, err = io.ReadAll(text); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(string(data))
+	return
+}
+
+// docxText reads the paragraphs of word/document.xml, keeping tabs and line breaks.
+func docxText(content []byte) (ret string, err error) {
+	var archive *zip.Reader
+	if archive, err = zip.NewReader(bytes.NewReader(content), int64(len(content))); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("document_docx_read_failed"), err))
+		return
+	}
+	var document io.ReadCloser
+	for _, file := range archive.File {
+		if file.Name == "word/document.xml" {
+			if document, err = file.Open(); err != nil {
+				return
+			}
+			break
+		}
+	}
+	if document == nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("document_docx_read_failed"), "word/document.xml not found"))
+		return
+	}
+	defer document.Close()
+
+	var builder strings.Builder
+	decoder := xml.NewDecoder(document)
+	inText := false
+	for {
+		var token xml.Token
+		if token, err = decoder.Token(); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+			err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("document_docx_read_failed"), err))
+			return
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				builder.WriteString("\t")
+			case "br", "cr":
+				builder.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				builder.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				builder.Write(element)
+			}
+		}
+	}
+	ret = strings.TrimSpace(builder.String())
+	return
+}
+
+// csvText renders CSV as a Markdown table, falling back to the raw text when it cannot be parsed.
+func csvText(content []byte) string {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil || len(records) == 0 {
+		return strings.TrimSpace(string(content))
+	}
+
+	columns := 0
+	for _, record := range records {
+		columns = max(columns, len(record))
+	}
+	var builder strings.Builder
+	writeRow := func(cells []string) {
+		builder.WriteString("|")
+		for i := range columns {
+			cell := ""
+			if i < len(cells) {
+				cell = strings.ReplaceAll(strings.TrimSpace(cells[i]), "|", "\\|")
+				cell = strings.ReplaceAll(cell, "\n", " ")
+			}
+			builder.WriteString(" " + cell + " |")
+		}
+		builder.WriteString("\n")
+	}
+	writeRow(records[0])
+	builder.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, record := range records[1:] {
+		writeRow(record)
+	}
+	return strings.TrimSpace(builder.String())
+}
@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentTextCSV(t *testing.T) {
+	got, err := DocumentText(MimeTypeCSV, []byte("name,amount\nrent,1200\n\"a|b\",3\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "| name | amount |\n| --- | --- |\n| rent | 1200 |\n| a\\|b | 3 |", got)
+}
+
+func TestDocumentTextDOCX(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>First</w:t></w:r><w:r><w:tab/><w:t>paragraph</w:t></w:r></w:p>
+<w:p><w:r><w:t>Second</w:t><w:br/><w:t>line</w:t></w:r></w:p>
+</w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	got, err := DocumentText(MimeTypeDOCX, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "First\tparagraph\nSecond\nline", got)
+
+	_, err = DocumentText(MimeTypeDOCX, []byte("not a zip"))
+	assert.Error(t, err)
+}
+
+func TestDocumentTextPDF(t *testing.T) {
+	got, err := DocumentText(MimeTypePDF, minimalPDF("Hello PDF"))
+	require.NoError(t, err)
+	assert.Contains(t, got, "Hello PDF")
+
+	_, err = DocumentText(MimeTypePDF, []byte("not a pdf"))
+	assert.Error(t, err)
+}
+
+func TestDocumentTextPlainAndUnsupported(t *testing.T) {
+	got, err := DocumentText("application/json", []byte(" {\"a\": 1}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, got)
+
+	got, err = DocumentText(MimeTypeHTML, []byte("<html><body><p>Hello World</p></body></html>"))
+	require.NoError(t, err)
+	assert.Contains(t, got, "Hello World")
+
+	_, err = DocumentText("application/zip", []byte("PK"))
+	assert.Error(t, err)
+}
+
+// minimalPDF builds a one-page PDF showing text, with a correct cross-reference table.
+func minimalPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,305 @@
+// Package filescan reads the text files of directories, glob patterns and file lists,
+// leaving out what git ignores, binary files and files over a size limit.
+package filescan
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// binarySniffLength is how much of a file is looked at to tell whether it is binary,
+// the same amount git looks at.
+const binarySniffLength = 8000
+
+// Options limit the files a scan reads.
+type Options struct {
+	MaxDepth    int      // Levels below a scanned directory to read, 0 for no limit
+	MaxFileSize int64    // Bytes above which files are skipped, 0 for no limit
+	Ignore      []string // Patterns in .gitignore syntax, ignored in addition to .gitignore files
+}
+
+// File is a text file read by a scan.
+type File struct {
+	Path    string // The path as given, or joined to the directory it was found in
+	Content string
+}
+
+// Skip reasons of Skipped files.
+const (
+	SkipBinary   = "binary"
+	SkipTooLarge = "too large"
+)
+
+// Skipped is a file a scan found but did not read.
+type Skipped struct {
+	Path   string
+	Reason string
+	Size   int64
+}
+
+// Result holds the files of a scan, in the order they were found.
+type Result struct {
+	Files       []File
+	Skipped     []Skipped
+	Directories int // Directories walked, including the scanned ones
+}
+
+// Scan reads the files and directories of paths and then the files matching globs.
+// Directories are walked in lexical order, leaving out what their .gitignore files
+// and those of the directories above them in the repository ignore. Files named in
+// paths are read even when ignored. In globs, ** matches any number of directories.
+// A file found more than once is read once.
+func Scan(paths []string, globs []string, opts Options) (ret *Result, err error) {
+	scan := &scanner{opts: opts, seen: map[string]bool{}}
+	ret = &scan.result
+	for _, p := range paths {
+		var info os.FileInfo
+		if info, err = os.Stat(p); err != nil {
+			return
+		}
+		if info.IsDir() {
+			err = scan.walk(p, opts.MaxDepth, nil)
+		} else {
+			err = scan.read(p, info)
+		}
+		if err != nil {
+			return
+		}
+	}
+	for _, glob := range globs {
+		if err = scan.glob(glob); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// IsBinary tells whether data looks like the content of a binary file, which like in
+// git is when its beginning contains a NUL byte.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffLength)], 0) >= 0
+}
+
+type scanner struct {
+	opts   Options
+	result Result
+	seen   map[string]bool
+}
+
+// read adds the file at p unless it was read before, is too large or is binary.
+func (o *scanner) read(p string, info os.FileInfo) (err error) {
+	key := p
+	if abs, absErr := filepath.Abs(p); absErr == nil {
+		key = abs
+	}
+	if o.seen[key] {
+		return
+	}
+	o.seen[key] = true
+
+	if o.opts.MaxFileSize > 0 && info.Size() > o.opts.MaxFileSize {
+		o.result.Skipped = append(o.result.Skipped, Skipped{Path: p, Reason: SkipTooLarge, Size: info.Size()})
+		return
+	}
+	var content []byte
+	if content, err = os.ReadFile(p); err != nil {
+		return
+	}
+	if IsBinary(content) {
+		o.result.Skipped = append(o.result.Skipped, Skipped{Path: p, Reason: SkipBinary, Size: info.Size()})
+		return
+	}
+	o.result.Files = append(o.result.Files, File{Path: p, Content: string(content)})
+	return
+}
+
+// glob reads the files matching pattern, walking the directory before its first
+// component with wildcards. A pattern without wildcards names a file or directory.
+func (o *scanner) glob(pattern string) (err error) {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	base := 0
+	for base < len(parts) && !strings.ContainsAny(parts[base], `*?[\`) {
+		base++
+	}
+	if base == len(parts) {
+		var info os.FileInfo
+		if info, err = os.Stat(pattern); err != nil {
+			return
+		}
+		if info.IsDir() {
+			return o.walk(pattern, o.opts.MaxDepth, nil)
+		}
+		return o.read(pattern, info)
+	}
+	for _, part := range parts[base:] {
+		if _, err = path.Match(part, ""); err != nil {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("filescan_invalid_glob"), pattern, err))
+		}
+	}
+
+	dir := filepath.FromSlash(strings.Join(parts[:base], "/"))
+	if base == 1 && parts[0] == "" {
+		dir = string(filepath.Separator)
+	} else if dir == "" {
+		dir = "."
+	}
+	// Without **, files deeper than the pattern cannot match
+	depth := len(parts) - base
+	if slices.Contains(parts[base:], "**") {
+		depth = 0
+	}
+	matches := 0
+	if _, statErr := os.Stat(dir); statErr == nil {
+		err = o.walk(dir, depth, func(rel []string) bool {
+			if matchGlob(parts[base:], rel) {
+				matches++
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return
+		}
+	}
+	if matches == 0 {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("filescan_glob_no_match"), pattern))
+	}
+	return
+}
+
+// walk reads the files of dir and its subdirectories up to maxDepth levels below it
+// that match, or all of them when match is nil. match is given the path of a file
+// relative to dir.
+func (o *scanner) walk(dir string, maxDepth int, match func(rel []string) bool) (err error) {
+	var root string
+	var prefix []string
+	if root, prefix, err = ignoreRoot(dir); err != nil {
+		return
+	}
+	var patterns []gitignore.Pattern
+	for _, line := range o.opts.Ignore {
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, gitignore.ParsePattern(line, prefix))
+		}
+	}
+	for i := range prefix {
+		patterns = append(patterns, readIgnoreFile(root, prefix[:i])...)
+	}
+
+	return filepath.WalkDir(dir, func(p string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, relErr := filepath.Rel(dir, p)
+		if relErr != nil {
+			return relErr
+		}
+		var parts []string
+		if rel != "." {
+			parts = strings.Split(filepath.ToSlash(rel), "/")
+		}
+		full := append(append([]string{}, prefix...), parts...)
+
+		if entry.IsDir() {
+			if len(parts) > 0 {
+				if entry.Name() == ".git" || gitignore.NewMatcher(patterns).Match(full, true) {
+					return filepath.SkipDir
+				}
+				if maxDepth > 0 && len(parts) > maxDepth {
+					return filepath.SkipDir
+				}
+			}
+			o.result.Directories++
+			patterns = append(patterns, readIgnoreFile(root, full)...)
+			return nil
+		}
+		if !entry.Type().IsRegular() || gitignore.NewMatcher(patterns).Match(full, false) {
+			return nil
+		}
+		if maxDepth > 0 && len(parts) > maxDepth {
+			return nil
+		}
+		if match != nil && !match(parts) {
+			return nil
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		return o.read(p, info)
+	})
+}
+
+// ignoreRoot returns the root of the git repository of dir, or dir itself outside a
+// repository, and the path of dir below it. The patterns of .gitignore files apply to
+// paths relative to that root.
+func ignoreRoot(dir string) (root string, prefix []string, err error) {
+	var abs string
+	if abs, err = filepath.Abs(dir); err != nil {
+		return
+	}
+	root = abs
+	for current := abs; ; {
+		if _, statErr := os.Stat(filepath.Join(current, ".git")); statErr == nil {
+			root = current
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+	if rel, relErr := filepath.Rel(root, abs); relErr == nil && rel != "." {
+		prefix = strings.Split(filepath.ToSlash(rel), "/")
+	}
+	return
+}
+
+// readIgnoreFile reads the patterns of the .gitignore file in the directory at domain
+// below root, if there is one.
+func readIgnoreFile(root string, domain []string) (ret []gitignore.Pattern) {
+	domain = append([]string{}, domain...)
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(strings.Join(domain, "/")), ".gitignore"))
+	if err != nil {
+		return
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ret = append(ret, gitignore.ParsePattern(line, domain))
+	}
+	return
+}
+
+// matchGlob tells whether the components of a path match those of a glob pattern,
+// where a ** component matches any number of path components.
+func matchGlob(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchGlob(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	matched, _ := path.Match(pattern[0], name[0])
+	return matched && matchGlob(pattern[1:], name[1:])
+}
@@ -0,0 +1,120 @@
+package filescan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates the files of tree, by slash-separated path, below dir.
+func writeTree(t *testing.T, dir string, tree map[string]string) {
+	t.Helper()
+	for name, content := range tree {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func filePaths(t *testing.T, dir string, result *Result) (ret []string) {
+	t.Helper()
+	for _, file := range result.Files {
+		rel, err := filepath.Rel(dir, file.Path)
+		require.NoError(t, err)
+		ret = append(ret, filepath.ToSlash(rel))
+	}
+	return
+}
+
+func TestScanDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".git/HEAD":            "ref: refs/heads/main\n",
+		".gitignore":           "*.log\nbuild/\n",
+		"main.go":              "package main\n",
+		"debug.log":            "ignored\n",
+		"build/out.txt":        "ignored\n",
+		"src/.gitignore":       "generated.go\n!keep.log\n",
+		"src/lib.go":           "package src\n",
+		"src/generated.go":     "ignored\n",
+		"src/keep.log":         "kept\n",
+		"src/deep/nested.go":   "package deep\n",
+		"node_modules/x/y.js":  "ignored\n",
+		"image.png":            "\x89PNG\x00\x00",
+		"src/deep/big/big.txt": strings.Repeat("x", 100),
+	})
+
+	result, err := Scan([]string{dir}, nil, Options{MaxFileSize: 50, Ignore: []string{"node_modules"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", "main.go", "src/.gitignore", "src/deep/nested.go", "src/keep.log", "src/lib.go"}, filePaths(t, dir, result))
+	require.Len(t, result.Skipped, 2)
+	assert.Equal(t, SkipBinary, result.Skipped[0].Reason)
+	assert.Equal(t, SkipTooLarge, result.Skipped[1].Reason)
+	assert.Equal(t, "package main\n", result.Files[1].Content)
+
+	// The .gitignore files above a scanned subdirectory apply as well
+	result, err = Scan([]string{filepath.Join(dir, "src")}, nil, Options{MaxDepth: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/.gitignore", "src/keep.log", "src/lib.go"}, filePaths(t, dir, result))
+}
+
+func TestScanNamedFilesAndGlobs(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".gitignore":     "*.log\n",
+		"a.md":           "a\n",
+		"docs/b.md":      "b\n",
+		"docs/sub/c.md":  "c\n",
+		"docs/sub/d.txt": "d\n",
+		"notes.log":      "log\n",
+	})
+
+	// Named files are read even when ignored, and files found twice are read once
+	result, err := Scan(
+		[]string{filepath.Join(dir, "notes.log"), filepath.Join(dir, "a.md")},
+		[]string{filepath.Join(dir, "**", "*.md"), filepath.Join(dir, "docs", "*", "*.txt")},
+		Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.log", "a.md", "docs/b.md", "docs/sub/c.md", "docs/sub/d.txt"}, filePaths(t, dir, result))
+
+	// Without **, a glob does not match files in deeper directories
+	_, err = Scan(nil, []string{filepath.Join(dir, "docs", "*.txt")}, Options{})
+	assert.Error(t, err)
+	_, err = Scan(nil, []string{filepath.Join(dir, "[")}, Options{})
+	assert.Error(t, err)
+	_, err = Scan([]string{filepath.Join(dir, "missing.md")}, nil, Options{})
+	assert.Error(t, err)
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/fabric/main.go", true},
+		{"cmd/**", "cmd/fabric/main.go", true},
+		{"cmd/**/main.go", "cmd/main.go", true},
+		{"cmd/**/main.go", "internal/main.go", false},
+		{"*/*.md", "docs/a.md", true},
+		{"?.md", "ab.md", false},
+	}
+	for _, tt := range tests {
+		got := matchGlob(strings.Split(tt.pattern, "/"), strings.Split(tt.name, "/"))
+		assert.Equal(t, tt.want, got, "matchGlob(%q, %q)", tt.pattern, tt.name)
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	assert.False(t, IsBinary([]byte("plain text\n")))
+	assert.False(t, IsBinary(nil))
+	assert.True(t, IsBinary([]byte("PK\x03\x04\x00")))
+	assert.False(t, IsBinary(append([]byte(strings.Repeat("a", binarySniffLength)), 0)))
+}
@@ -0,0 +1,161 @@
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/internal/i18n"
+)
+
+// Backend names accepted by the web search setup.
+const (
+	BackendSearXNG = "searxng"
+	BackendBrave   = "brave"
+	BackendHTTP    = "http"
+)
+
+const defaultBraveURL = "https://api.search.brave.com/res/v1/web/search"
+
+// Result is one web search hit. Content holds the cleaned page text when the page was fetched.
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// Backend runs a web search query and returns at most limit results.
+type Backend interface {
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+// JSONBackend queries an HTTP endpoint that answers with JSON. URL is a template in which
+// {query} and {limit} are replaced by the escaped query and the result limit. ResultsPath
+// is the dot-separated path to the result list, and the field names select the title, URL
+// and snippet of each result.
+type JSONBackend struct {
+	URL          string
+	Headers      map[string]string
+	ResultsPath  string
+	TitleField   string
+	URLField     string
+	SnippetField string
+
+	HTTPClient *http.Client
+}
+
+// NewSearXNGBackend returns a backend for the JSON API of the SearXNG instance at baseURL.
+func NewSearXNGBackend(baseURL string) *JSONBackend {
+	return &JSONBackend{
+		URL:          strings.TrimSuffix(baseURL, "/") + "/search?q={query}&format=json",
+		ResultsPath:  "results",
+		TitleField:   "title",
+		URLField:     "url",
+		SnippetField: "content",
+	}
+}
+
+// NewBraveBackend returns a backend for the Brave Search API. An empty baseURL uses the public endpoint.
+func NewBraveBackend(baseURL, apiKey string) *JSONBackend {
+	if baseURL == "" {
+		baseURL = defaultBraveURL
+	}
+	return &JSONBackend{
+		URL:          baseURL + "?q={query}&count={limit}",
+		Headers:      map[string]string{"Accept": "application/json", "X-Subscription-Token": apiKey},
+		ResultsPath:  "web.results",
+		TitleField:   "title",
+		URLField:     "url",
+		SnippetField: "description",
+	}
+}
+
+func (o *JSONBackend) Search(ctx context.Context, query string, limit int) (ret []Result, err error) {
+	requestURL := strings.NewReplacer(
+		"{query}", url.QueryEscape(query),
+		"{limit}", strconv.Itoa(limit),
+	).Replace(o.URL)
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil); err != nil {
+		return
+	}
+	for name, value := range o.Headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_request_failed"), err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_unexpected_status"), resp.StatusCode, strings.TrimSpace(string(body))))
+		return
+	}
+
+	var decoded any
+	if err = json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_invalid_response"), err))
+		return
+	}
+
+	items, ok := lookupPath(decoded, o.ResultsPath).([]any)
+	if !ok {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_results_path_not_found"), o.ResultsPath))
+		return
+	}
+	for _, item := range items {
+		result := Result{
+			Title:   stringField(item, o.TitleField),
+			URL:     stringField(item, o.URLField),
+			Snippet: stringField(item, o.SnippetField),
+		}
+		if result.URL == "" {
+			continue
+		}
+		ret = append(ret, result)
+		if limit > 0 && len(ret) == limit {
+			break
+		}
+	}
+	return
+}
+
+// lookupPath follows a dot-separated path of object keys. An empty path returns value itself.
+func lookupPath(value any, path string) any {
+	if path == "" {
+		return value
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func stringField(item any, path string) string {
+	if path == "" {
+		return ""
+	}
+	value, _ := lookupPath(item, path).(string)
+	return strings.TrimSpace(value)
+}
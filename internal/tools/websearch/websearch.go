@@ -0,0 +1,212 @@
+// Package websearch runs web searches through a configurable backend so that models
+// without native search can still answer from current, cited sources.
+package websearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/internal/chat"
+	"github.com/danielmiessler/fabric/internal/i18n"
+	debuglog "github.com/danielmiessler/fabric/internal/log"
+	"github.com/danielmiessler/fabric/internal/plugins"
+	"github.com/danielmiessler/fabric/internal/tools/converter"
+)
+
+const (
+	defaultMaxResults = 5
+	// maxPageBytes bounds how much of a fetched page is read before cleaning it
+	maxPageBytes = 2 << 20
+	// maxContentChars bounds the cleaned text kept per fetched page
+	maxContentChars = 8000
+)
+
+var backends = []string{BackendSearXNG, BackendBrave, BackendHTTP}
+
+type Client struct {
+	*plugins.PluginBase
+	Backend      *plugins.SetupQuestion
+	URL          *plugins.SetupQuestion
+	ApiKey       *plugins.SetupQuestion
+	ResultsPath  *plugins.SetupQuestion
+	ResultFields *plugins.SetupQuestion
+	MaxResults   *plugins.SetupQuestion
+	FetchResults *plugins.SetupQuestion
+
+	backend    Backend
+	httpClient *http.Client
+}
+
+func NewClient() (ret *Client) {
+	label := "Web Search"
+
+	ret = &Client{httpClient: &http.Client{Timeout: 30 * time.Second}}
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             i18n.T("web_search_label"),
+		SetupDescription: i18n.T("web_search_setup_description") + " " + i18n.T("optional_marker"),
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+		ConfigureCustom:  ret.configure,
+	}
+
+	ret.Backend = ret.AddSetupQuestionWithEnvName("Backend", false, i18n.T("web_search_backend_question"))
+	ret.URL = ret.AddSetupQuestionWithEnvName("URL", false, i18n.T("web_search_url_question"))
+	ret.ApiKey = ret.AddSetupQuestionWithEnvName("API Key", false, i18n.T("web_search_api_key_question"))
+	ret.ResultsPath = ret.AddSetupQuestionWithEnvName("Results Path", false, i18n.T("web_search_results_path_question"))
+	ret.ResultFields = ret.AddSetupQuestionWithEnvName("Result Fields", false, i18n.T("web_search_result_fields_question"))
+	ret.MaxResults = ret.AddSetupQuestionWithEnvName("Max Results", false, i18n.T("web_search_max_results_question"))
+	ret.FetchResults = ret.AddSetupQuestionWithEnvName("Fetch Results", false, i18n.T("web_search_fetch_results_question"))
+
+	ret.ResultsPath.Value = "results"
+	ret.ResultFields.Value = "title,url,content"
+	ret.MaxResults.Value = strconv.Itoa(defaultMaxResults)
+	ret.FetchResults.Value = "0"
+
+	return
+}
+
+// IsConfigured reports whether a search backend has been chosen.
+func (o *Client) IsConfigured() bool {
+	return o.backend != nil
+}
+
+func (o *Client) configure() (err error) {
+	o.backend = nil
+	name := strings.ToLower(strings.TrimSpace(o.Backend.Value))
+	if name == "" {
+		return
+	}
+	if !slices.Contains(backends, name) {
+		return fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_unknown_backend"), o.Backend.Value, strings.Join(backends, ", ")))
+	}
+
+	var backend *JSONBackend
+	switch name {
+	case BackendSearXNG:
+		if o.URL.Value == "" {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_url_required"), name))
+		}
+		backend = NewSearXNGBackend(o.URL.Value)
+	case BackendBrave:
+		if o.ApiKey.Value == "" {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_api_key_required"), name))
+		}
+		backend = NewBraveBackend(o.URL.Value, o.ApiKey.Value)
+	case BackendHTTP:
+		if o.URL.Value == "" {
+			return fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_url_required"), name))
+		}
+		fields := strings.Split(o.ResultFields.Value, ",")
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		backend = &JSONBackend{
+			URL:          o.URL.Value,
+			ResultsPath:  o.ResultsPath.Value,
+			TitleField:   strings.TrimSpace(fields[0]),
+			URLField:     strings.TrimSpace(fields[1]),
+			SnippetField: strings.TrimSpace(fields[2]),
+		}
+		if o.ApiKey.Value != "" {
+			backend.Headers = map[string]string{"Authorization": "Bearer " + o.ApiKey.Value}
+		}
+	}
+	backend.HTTPClient = o.httpClient
+	o.backend = backend
+	return
+}
+
+// Search runs query through the configured backend and, for the first FetchResults
+// results, replaces the snippet-only hit with the readable text of the page.
+func (o *Client) Search(ctx context.Context, query string) (ret []Result, err error) {
+	if o.backend == nil {
+		err = fmt.Errorf("%s", i18n.T("web_search_not_configured"))
+		return
+	}
+
+	limit := settingInt(o.MaxResults, defaultMaxResults)
+	if ret, err = o.backend.Search(ctx, query, limit); err != nil {
+		return
+	}
+
+	fetch := min(settingInt(o.FetchResults, 0), len(ret))
+	for i := range fetch {
+		var content string
+		if content, err = o.fetchReadable(ctx, ret[i].URL); err != nil {
+			// A page that cannot be fetched still contributes its snippet
+			debuglog.Debug(debuglog.Detailed, "web search: could not fetch %s: %v\n", ret[i].URL, err)
+			err = nil
+			continue
+		}
+		ret[i].Content = content
+	}
+	return
+}
+
+// fetchReadable downloads a page and returns its main text.
+func (o *Client) fetchReadable(ctx context.Context, pageURL string) (ret string, err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil); err != nil {
+		return
+	}
+	var resp *http.Response
+	if resp, err = o.httpClient.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s", fmt.Sprintf(i18n.T("web_search_unexpected_status"), resp.StatusCode, pageURL))
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(resp.Body, maxPageBytes)); err != nil {
+		return
+	}
+	if ret, err = converter.HtmlReadability(string(body)); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(ret)
+	if runes := []rune(ret); len(runes) > maxContentChars {
+		ret = string(runes[:maxContentChars]) + "…"
+	}
+	return
+}
+
+// FormatContext renders results as numbered sources the model can cite as [n].
+func FormatContext(query string, results []Result) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# WEB SEARCH RESULTS\n\nResults for %q. Cite the sources you use by their number, like [1].\n", query)
+	for i, result := range results {
+		fmt.Fprintf(&builder, "\n[%d] %s\nURL: %s\n", i+1, result.Title, result.URL)
+		text := result.Content
+		if text == "" {
+			text = result.Snippet
+		}
+		if text != "" {
+			builder.WriteString(text)
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// Citations converts results to citations, in the same order as FormatContext numbers them.
+func Citations(results []Result) (ret []chat.Citation) {
+	for _, result := range results {
+		ret = append(ret, chat.Citation{URL: result.URL, Title: result.Title, Snippet: result.Snippet})
+	}
+	return
+}
+
+func settingInt(setting *plugins.SetupQuestion, fallback int) int {
+	if value, err := strconv.Atoi(strings.TrimSpace(setting.Value)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,123 @@
+package websearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearXNGBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "go generics", r.URL.Query().Get("q"))
+		assert.Equal(t, "json", r.URL.Query().Get("format"))
+		w.Write([]byte(`{"results":[
+			{"title":"One","url":"https://one.example","content":"first"},
+			{"title":"No URL"},
+			{"title":"Two","url":"https://two.example","content":"second"},
+			{"title":"Three","url":"https://three.example","content":"third"}]}`))
+	}))
+	defer server.Close()
+
+	results, err := NewSearXNGBackend(server.URL+"/").Search(context.Background(), "go generics", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []Result{
+		{Title: "One", URL: "https://one.example", Snippet: "first"},
+		{Title: "Two", URL: "https://two.example", Snippet: "second"},
+	}, results)
+}
+
+func TestBraveBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Subscription-Token"))
+		assert.Equal(t, "3", r.URL.Query().Get("count"))
+		w.Write([]byte(`{"web":{"results":[{"title":"Brave","url":"https://brave.example","description":"found"}]}}`))
+	}))
+	defer server.Close()
+
+	results, err := NewBraveBackend(server.URL, "secret").Search(context.Background(), "query", 3)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "found", results[0].Snippet)
+}
+
+func TestJSONBackendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "fail" {
+			http.Error(w, "boom", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer server.Close()
+
+	backend := &JSONBackend{URL: server.URL + "?q={query}", ResultsPath: "data.items", URLField: "url"}
+	_, err := backend.Search(context.Background(), "fail", 5)
+	assert.ErrorContains(t, err, "502")
+
+	_, err = backend.Search(context.Background(), "ok", 5)
+	assert.ErrorContains(t, err, "data.items")
+}
+
+func TestClientConfigure(t *testing.T) {
+	client := NewClient()
+	require.NoError(t, client.Configure())
+	assert.False(t, client.IsConfigured())
+
+	client.Backend.Value = "bing"
+	assert.Error(t, client.Configure())
+
+	client.Backend.Value = "brave"
+	assert.Error(t, client.Configure())
+	client.ApiKey.Value = "key"
+	require.NoError(t, client.Configure())
+	assert.True(t, client.IsConfigured())
+
+	client.Backend.Value = "http"
+	client.URL.Value = "https://search.example/api?q={query}"
+	client.ResultsPath.Value = "hits"
+	client.ResultFields.Value = "name, link, summary"
+	require.NoError(t, client.Configure())
+	backend := client.backend.(*JSONBackend)
+	assert.Equal(t, "link", backend.URLField)
+	assert.Equal(t, "Bearer key", backend.Headers["Authorization"])
+}
+
+func TestClientSearchFetchesReadablePages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			w.Write([]byte(`{"results":[
+				{"title":"Page","url":"` + server.URL + `/page","content":"snippet"},
+				{"title":"Missing","url":"` + server.URL + `/missing","content":"kept"}]}`))
+		case "/page":
+			w.Write([]byte(`<html><body><article><p>Readable page text</p></article></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.Backend.Value = BackendSearXNG
+	client.URL.Value = server.URL
+	client.FetchResults.Value = "2"
+	require.NoError(t, client.Configure())
+
+	results, err := client.Search(context.Background(), "query")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Contains(t, results[0].Content, "Readable page text")
+	assert.Empty(t, results[1].Content)
+
+	formatted := FormatContext("query", results)
+	assert.True(t, strings.HasPrefix(formatted, "# WEB SEARCH RESULTS"))
+	assert.Contains(t, formatted, "[2] Missing\nURL: "+server.URL+"/missing\nkept")
+	assert.Len(t, Citations(results), 2)
+}
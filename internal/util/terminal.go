@@ -0,0 +1,44 @@
+package util
+
+import "os"
+
+const (
+	ansiDim   = "\033[2m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+	ansiReset = "\033[0m"
+)
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Dim renders text faint when out is a terminal and NO_COLOR is not set.
+func Dim(out *os.File, text string) string {
+	return style(out, ansiDim, text)
+}
+
+// Red renders text in red when out is a terminal and NO_COLOR is not set.
+func Red(out *os.File, text string) string {
+	return style(out, ansiRed, text)
+}
+
+// Green renders text in green when out is a terminal and NO_COLOR is not set.
+func Green(out *os.File, text string) string {
+	return style(out, ansiGreen, text)
+}
+
+// Cyan renders text in cyan when out is a terminal and NO_COLOR is not set.
+func Cyan(out *os.File, text string) string {
+	return style(out, ansiCyan, text)
+}
+
+func style(out *os.File, code string, text string) string {
+	if text == "" || os.Getenv("NO_COLOR") != "" || !IsTerminal(out) {
+		return text
+	}
+	return code + text + ansiReset
+}